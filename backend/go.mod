@@ -1,6 +1,6 @@
 module montelukast
 
-go 1.19
+go 1.22
 
 require (
 	github.com/cloudinary/cloudinary-go/v2 v2.9.0
@@ -12,8 +12,8 @@ require (
 	github.com/jackc/pgx/v5 v5.5.5
 	github.com/joho/godotenv v1.5.1
 	github.com/lib/pq v1.10.9
-	github.com/resendlabs/resend-go v1.7.0
 	github.com/prometheus/client_golang v1.18.0
+	github.com/resendlabs/resend-go v1.7.0
 	github.com/sethvargo/go-password v0.3.1
 	github.com/shopspring/decimal v1.4.0
 	github.com/sirupsen/logrus v1.9.3
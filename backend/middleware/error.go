@@ -49,7 +49,7 @@ func ErrorMiddleware(c *gin.Context) {
 				Field:  er.Field,
 				Detail: er.Message,
 			}
-			c.JSON(er.Status, wrapper.ResponseData(nil, "", []apperror.Error{fieldError}))
+			c.JSON(er.Status, wrapper.ResponseData(er.Data, "", []apperror.Error{fieldError}))
 			c.Abort()
 			return
 		}
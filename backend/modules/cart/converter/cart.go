@@ -51,3 +51,72 @@ func (c GetCartItemsConverter) ToDto(cartItem entity.CartItem) dto.CartItemRespo
 		Quantity:          cartItem.Quantity,
 	}
 }
+
+type BuyAgainItemConverter struct{}
+
+func (c BuyAgainItemConverter) ToDto(item entity.BuyAgainItem) dto.BuyAgainItemResponse {
+	var suggestion *dto.PharmacyProductSuggestionResponse
+	if item.Suggestion != nil {
+		suggestion = &dto.PharmacyProductSuggestionResponse{
+			PharmacyProductID: item.Suggestion.PharmacyProductID,
+			PharmacyID:        item.Suggestion.PharmacyID,
+			PharmacyName:      item.Suggestion.PharmacyName,
+			Price:             item.Suggestion.Price.String(),
+			Distance:          item.Suggestion.Distance,
+		}
+	}
+	return dto.BuyAgainItemResponse{
+		PharmacyProductID: item.PharmacyProductID,
+		ProductID:         item.ProductID,
+		Name:              item.Name,
+		PharmacyID:        item.PharmacyID,
+		PharmacyName:      item.PharmacyName,
+		Quantity:          item.Quantity,
+		Status:            item.Status,
+		AvailableStock:    item.AvailableStock,
+		PreviousPrice:     item.PreviousPrice.String(),
+		CurrentPrice:      item.CurrentPrice.String(),
+		IsPriceChanged:    item.IsPriceChanged,
+		Suggestion:        suggestion,
+	}
+}
+
+type CartOptimizationConverter struct{}
+
+func (c CartOptimizationConverter) ToDto(optimization entity.CartOptimization) dto.CartOptimizationResponse {
+	groups := []dto.OptimizedGroupResponse{}
+	for _, group := range optimization.Groups {
+		items := []dto.OptimizedItemResponse{}
+		for _, item := range group.Items {
+			items = append(items, dto.OptimizedItemResponse{
+				ProductID:                 item.ProductID,
+				PharmacyProductID:         item.PharmacyProductID,
+				PreviousPharmacyProductID: item.PreviousPharmacyProductID,
+				Name:                      item.Name,
+				Quantity:                  item.Quantity,
+				Price:                     item.Price.String(),
+				Subtotal:                  item.Subtotal.String(),
+				IsUnavailable:             item.IsUnavailable,
+			})
+		}
+		groups = append(groups, dto.OptimizedGroupResponse{
+			PharmacyID:   group.PharmacyID,
+			PharmacyName: group.PharmacyName,
+			ShippingCost: group.ShippingCost.String(),
+			Items:        items,
+		})
+	}
+	return dto.CartOptimizationResponse{
+		ID:                     optimization.ID,
+		Groups:                 groups,
+		CurrentItemsTotal:      optimization.CurrentItemsTotal.String(),
+		CurrentShippingTotal:   optimization.CurrentShippingTotal.String(),
+		CurrentTotal:           optimization.CurrentTotal.String(),
+		ItemsTotal:             optimization.ItemsTotal.String(),
+		ShippingTotal:          optimization.ShippingTotal.String(),
+		Total:                  optimization.Total.String(),
+		Savings:                optimization.Savings.String(),
+		IsCurrentCart:          optimization.IsCurrentCart,
+		IsCurrentUndeliverable: optimization.IsCurrentUndeliverable,
+	}
+}
@@ -30,3 +30,62 @@ type ListGroupedCartItem struct {
 type CheckoutCartRequest struct {
 	IDs []int `json:"ids" binding:"required"`
 }
+
+type BuyAgainItemResponse struct {
+	PharmacyProductID int                                `json:"pharmacy_product_id"`
+	ProductID         int                                `json:"product_id"`
+	Name              string                             `json:"name"`
+	PharmacyID        int                                `json:"pharmacy_id"`
+	PharmacyName      string                             `json:"pharmacy_name"`
+	Quantity          int                                `json:"quantity"`
+	Status            string                             `json:"status"`
+	AvailableStock    int                                `json:"available_stock"`
+	PreviousPrice     string                             `json:"previous_price"`
+	CurrentPrice      string                             `json:"current_price"`
+	IsPriceChanged    bool                               `json:"is_price_changed"`
+	Suggestion        *PharmacyProductSuggestionResponse `json:"suggestion"`
+}
+
+type PharmacyProductSuggestionResponse struct {
+	PharmacyProductID int     `json:"pharmacy_product_id"`
+	PharmacyID        int     `json:"pharmacy_id"`
+	PharmacyName      string  `json:"pharmacy_name"`
+	Price             string  `json:"price"`
+	Distance          float64 `json:"distance"`
+}
+
+type CartOptimizationResponse struct {
+	ID                     string                   `json:"id"`
+	Groups                 []OptimizedGroupResponse `json:"groups"`
+	CurrentItemsTotal      string                   `json:"current_items_total"`
+	CurrentShippingTotal   string                   `json:"current_shipping_total"`
+	CurrentTotal           string                   `json:"current_total"`
+	ItemsTotal             string                   `json:"items_total"`
+	ShippingTotal          string                   `json:"shipping_total"`
+	Total                  string                   `json:"total"`
+	Savings                string                   `json:"savings"`
+	IsCurrentCart          bool                     `json:"is_current_cart"`
+	IsCurrentUndeliverable bool                     `json:"is_current_undeliverable"`
+}
+
+type OptimizedGroupResponse struct {
+	PharmacyID   int                     `json:"pharmacy_id"`
+	PharmacyName string                  `json:"pharmacy_name"`
+	ShippingCost string                  `json:"shipping_cost"`
+	Items        []OptimizedItemResponse `json:"items"`
+}
+
+type OptimizedItemResponse struct {
+	ProductID                 int    `json:"product_id"`
+	PharmacyProductID         int    `json:"pharmacy_product_id"`
+	PreviousPharmacyProductID int    `json:"previous_pharmacy_product_id"`
+	Name                      string `json:"name"`
+	Quantity                  int    `json:"quantity"`
+	Price                     string `json:"price"`
+	Subtotal                  string `json:"subtotal"`
+	IsUnavailable             bool   `json:"is_unavailable"`
+}
+
+type ApplyCartOptimizationRequest struct {
+	ID string `json:"id" binding:"required"`
+}
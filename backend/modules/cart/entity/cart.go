@@ -26,3 +26,90 @@ type ListGroupedCartItem struct {
 	ID          string
 	GroupedItem []GroupedCartItem
 }
+
+type ReorderSource struct {
+	UserID        int
+	OrderID       int
+	OrderDetailID int
+}
+
+type ReorderItem struct {
+	PharmacyProductID int
+	ProductID         int
+	Name              string
+	PharmacyID        int
+	PharmacyName      string
+	Quantity          int
+	PreviousPrice     decimal.Decimal
+	CurrentPrice      decimal.Decimal
+	IsActive          bool
+}
+
+type BuyAgainItem struct {
+	ReorderItem
+	Status         string
+	AvailableStock int
+	IsPriceChanged bool
+	Suggestion     *PharmacyProductSuggestion
+}
+
+type PharmacyProductSuggestion struct {
+	PharmacyProductID int
+	PharmacyID        int
+	PharmacyName      string
+	Price             decimal.Decimal
+	Distance          float64
+}
+
+type CartProduct struct {
+	CartItemID        int
+	PharmacyProductID int
+	ProductID         int
+	Name              string
+	PharmacyID        int
+	PharmacyName      string
+	Quantity          int
+	Price             decimal.Decimal
+}
+
+type PharmacyOffer struct {
+	PharmacyProductID int
+	ProductID         int
+	PharmacyID        int
+	PharmacyName      string
+	Price             decimal.Decimal
+	Distance          float64
+	Weight            float64
+}
+
+type CartOptimization struct {
+	ID                     string
+	Groups                 []OptimizedGroup
+	CurrentItemsTotal      decimal.Decimal
+	CurrentShippingTotal   decimal.Decimal
+	CurrentTotal           decimal.Decimal
+	ItemsTotal             decimal.Decimal
+	ShippingTotal          decimal.Decimal
+	Total                  decimal.Decimal
+	Savings                decimal.Decimal
+	IsCurrentCart          bool
+	IsCurrentUndeliverable bool
+}
+
+type OptimizedGroup struct {
+	PharmacyID   int
+	PharmacyName string
+	ShippingCost decimal.Decimal
+	Items        []OptimizedItem
+}
+
+type OptimizedItem struct {
+	ProductID                 int
+	PharmacyProductID         int
+	PreviousPharmacyProductID int
+	Name                      string
+	Quantity                  int
+	Price                     decimal.Decimal
+	Subtotal                  decimal.Decimal
+	IsUnavailable             bool
+}
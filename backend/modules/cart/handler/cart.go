@@ -200,3 +200,137 @@ func (h *CartHandler) GetSelectedCartItemsHandler(c *gin.Context) {
 	response := wrapper.ResponseData(groupedList, "get selected cart items success!", nil)
 	c.JSON(http.StatusOK, response)
 }
+
+func (h *CartHandler) AbandonCheckoutHandler(c *gin.Context) {
+	rawUserID, isExists := c.Get("user_id")
+	if !isExists {
+		err := apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, apperror.ErrTokenInvalid)
+		c.Error(err)
+		return
+	}
+	userID, err := strconv.Atoi(rawUserID.(string))
+	if err != nil {
+		err := apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, err)
+		c.Error(err)
+		return
+	}
+
+	err = h.u.AbandonCheckout(c, userID, c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(nil, "checkout abandoned, stock released!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *CartHandler) BuyAgainOrderHandler(c *gin.Context) {
+	h.buyAgain(c, false)
+}
+
+func (h *CartHandler) BuyAgainOrderDetailHandler(c *gin.Context) {
+	h.buyAgain(c, true)
+}
+
+func (h *CartHandler) buyAgain(c *gin.Context, isOrderDetail bool) {
+	rawUserID, isExists := c.Get("user_id")
+	if !isExists {
+		err := apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, apperror.ErrTokenInvalid)
+		c.Error(err)
+		return
+	}
+	userID, err := strconv.Atoi(rawUserID.(string))
+	if err != nil {
+		err := apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, err)
+		c.Error(err)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("order_id"))
+	if err != nil {
+		err := apperror.NewErrStatusBadRequest(appconstant.FieldErrBuyAgain, apperror.ErrConvertVariableType, err)
+		c.Error(err)
+		return
+	}
+
+	source := entity.ReorderSource{UserID: userID, OrderID: id}
+	if isOrderDetail {
+		source = entity.ReorderSource{UserID: userID, OrderDetailID: id}
+	}
+
+	items, err := h.u.BuyAgain(c, source)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	itemsRes := []dto.BuyAgainItemResponse{}
+	for _, item := range items {
+		itemsRes = append(itemsRes, converter.BuyAgainItemConverter{}.ToDto(item))
+	}
+	response := wrapper.ResponseData(itemsRes, "buy again success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *CartHandler) OptimizeCartHandler(c *gin.Context) {
+	rawUserID, isExists := c.Get("user_id")
+	if !isExists {
+		err := apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, apperror.ErrTokenInvalid)
+		c.Error(err)
+		return
+	}
+	userID, err := strconv.Atoi(rawUserID.(string))
+	if err != nil {
+		err := apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, err)
+		c.Error(err)
+		return
+	}
+
+	optimization, err := h.u.OptimizeCart(c, userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(converter.CartOptimizationConverter{}.ToDto(*optimization), "optimize cart success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *CartHandler) ApplyCartOptimizationHandler(c *gin.Context) {
+	rawUserID, isExists := c.Get("user_id")
+	if !isExists {
+		err := apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, apperror.ErrTokenInvalid)
+		c.Error(err)
+		return
+	}
+	userID, err := strconv.Atoi(rawUserID.(string))
+	if err != nil {
+		err := apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, err)
+		c.Error(err)
+		return
+	}
+
+	err = apperror.JsonValidator(c)
+	if err != nil {
+		err := apperror.NewErrStatusBadRequest(appconstant.FieldErrOptimizeCart, apperror.ErrInvalidJSON, err)
+		c.Error(err)
+		return
+	}
+	applyReq := dto.ApplyCartOptimizationRequest{}
+
+	err = c.ShouldBindJSON(&applyReq)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = h.u.ApplyCartOptimization(c, userID, applyReq.ID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(nil, "apply cart optimization success!", nil)
+	c.JSON(http.StatusOK, response)
+}
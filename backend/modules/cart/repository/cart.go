@@ -9,7 +9,9 @@ import (
 	"montelukast/modules/cart/entity"
 	appconstant "montelukast/pkg/constant"
 	apperror "montelukast/pkg/error"
+	"montelukast/pkg/transaction"
 	"strconv"
+	"strings"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/shopspring/decimal"
@@ -27,7 +29,18 @@ type CartRepo interface {
 	GetQuantityByProductIDAndUserID(c context.Context, cartItem entity.CartItem) (int, error)
 	AddCheckoutItem(c context.Context, carts entity.ListGroupedCartItem, userID int) (err error)
 	GetCheckoutCartRedis(c context.Context, cartID string, userID int) (result *entity.ListGroupedCartItem, err error)
+	DeleteCheckoutItem(c context.Context, cartID string, userID int) error
 	IsUserVerified(c context.Context, userID int) (bool, error)
+	GetReorderItems(c context.Context, source entity.ReorderSource) ([]entity.ReorderItem, error)
+	GetUserLocation(c context.Context, userID int) (string, error)
+	GetNearestPharmacyProduct(c context.Context, item entity.ReorderItem, location string) (*entity.PharmacyProductSuggestion, error)
+	IsActiveAddressExists(c context.Context, userID int) (bool, error)
+	GetCartProducts(c context.Context, userID int) ([]entity.CartProduct, error)
+	GetPharmacyOffers(c context.Context, products []entity.CartProduct, location string) ([]entity.PharmacyOffer, error)
+	GetCartOptimizationRedis(c context.Context, userID int, optimizationID string) (*entity.CartOptimization, error)
+	SetCartOptimizationRedis(c context.Context, userID int, optimization entity.CartOptimization) error
+	DeleteCartOptimizationRedis(c context.Context, userID int, optimizationID string) error
+	ReplaceCartProduct(c context.Context, userID int, item entity.OptimizedItem) error
 }
 
 type cartRepoImpl struct {
@@ -69,6 +82,15 @@ func (r cartRepoImpl) AddCheckoutItem(c context.Context, carts entity.ListGroupe
 	return nil
 }
 
+func (r cartRepoImpl) DeleteCheckoutItem(c context.Context, cartID string, userID int) error {
+	key := fmt.Sprintf("checkout:%d:cartIds:%s", userID, cartID)
+	_, err := r.redisDB.Del(c, key).Result()
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
 func (r cartRepoImpl) IsCartItemExistsByProductIDAndUserID(c context.Context, cartItem entity.CartItem) (bool, error) {
 	query := `SELECT EXISTS (SELECT 1 FROM carts WHERE user_id = $1 AND pharmacy_product_id = $2 AND deleted_at IS NULL)`
 
@@ -317,4 +339,242 @@ func (r cartRepoImpl) IsUserVerified(c context.Context, userID int) (bool, error
 		return isExists, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
 	}
 	return isExists, nil
-}
\ No newline at end of file
+}
+func (r cartRepoImpl) GetReorderItems(c context.Context, source entity.ReorderSource) ([]entity.ReorderItem, error) {
+	items := []entity.ReorderItem{}
+
+	query := `SELECT opd.pharmacy_product_id, pp.product_id, p.name, ph.id, ph.name, SUM(opd.quantity),
+				SUM(opd.price) / SUM(opd.quantity), pp.price,
+				pp.is_active AND pp.deleted_at IS NULL AND p.is_active AND p.deleted_at IS NULL AND ph.is_active AND ph.deleted_at IS NULL
+				FROM order_product_details opd
+				JOIN order_details od ON od.id = opd.order_detail_id
+				JOIN orders o ON o.id = od.order_id
+				JOIN pharmacy_products pp ON pp.id = opd.pharmacy_product_id
+				JOIN products p ON p.id = pp.product_id
+				JOIN pharmacies ph ON ph.id = pp.pharmacy_id
+				WHERE o.user_id = $1 AND opd.deleted_at IS NULL AND od.deleted_at IS NULL AND o.deleted_at IS NULL`
+
+	args := []any{source.UserID}
+	if source.OrderID != 0 {
+		args = append(args, source.OrderID)
+		query += fmt.Sprintf(` AND o.id = $%d`, len(args))
+	}
+	if source.OrderDetailID != 0 {
+		args = append(args, source.OrderDetailID)
+		query += fmt.Sprintf(` AND od.id = $%d`, len(args))
+	}
+	query += ` GROUP BY opd.pharmacy_product_id, pp.product_id, p.name, ph.id, ph.name, pp.price, pp.is_active, pp.deleted_at, p.is_active, p.deleted_at, ph.is_active, ph.deleted_at
+				ORDER BY ph.id, opd.pharmacy_product_id`
+
+	rows, err := r.db.QueryContext(c, query, args...)
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item entity.ReorderItem
+		err := rows.Scan(&item.PharmacyProductID, &item.ProductID, &item.Name, &item.PharmacyID, &item.PharmacyName, &item.Quantity,
+			&item.PreviousPrice, &item.CurrentPrice, &item.IsActive)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
+		item.PreviousPrice = item.PreviousPrice.Round(2)
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (r cartRepoImpl) GetUserLocation(c context.Context, userID int) (string, error) {
+	query := `SELECT location
+				FROM user_addresses
+				WHERE user_id = $1 AND is_active = true AND deleted_at IS NULL
+				LIMIT 1`
+
+	var location string
+	err := r.db.QueryRowContext(c, query, userID).Scan(&location)
+	if err == sql.ErrNoRows {
+		return appconstant.DefaultLocation, nil
+	}
+	if err != nil {
+		return "", apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return location, nil
+}
+
+func (r cartRepoImpl) GetNearestPharmacyProduct(c context.Context, item entity.ReorderItem, location string) (*entity.PharmacyProductSuggestion, error) {
+	query := fmt.Sprintf(`SELECT pp.id, ph.id, ph.name, pp.price, ST_Distance(ph.location, $4::geography)
+				FROM pharmacy_products pp
+				JOIN pharmacy_product_available_stocks pas ON pas.pharmacy_product_id = pp.id
+				JOIN pharmacies ph ON ph.id = pp.pharmacy_id
+				JOIN partners pt ON pt.id = ph.partner_id
+				WHERE pp.product_id = $1 AND pp.pharmacy_id <> $2 AND pas.available_stock >= $3 AND %s
+				AND pp.is_active = true AND pp.deleted_at IS NULL
+				AND ph.is_active = true AND ph.deleted_at IS NULL
+				AND pt.is_active = true AND pt.deleted_at IS NULL
+				ORDER BY ST_Distance(ph.location, $4::geography), pp.price
+				LIMIT 1`, fmt.Sprintf(appconstant.ServiceAreaCondition, "$4::geography"))
+
+	var suggestion entity.PharmacyProductSuggestion
+	err := r.db.QueryRowContext(c, query, item.ProductID, item.PharmacyID, item.Quantity, location).Scan(
+		&suggestion.PharmacyProductID, &suggestion.PharmacyID, &suggestion.PharmacyName, &suggestion.Price, &suggestion.Distance)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return &suggestion, nil
+}
+
+func (r cartRepoImpl) IsActiveAddressExists(c context.Context, userID int) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM user_addresses WHERE user_id = $1 AND is_active = true AND deleted_at IS NULL)`
+
+	var isExists bool
+	err := r.db.QueryRowContext(c, query, userID).Scan(&isExists)
+	if err != nil && err != sql.ErrNoRows {
+		return isExists, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return isExists, nil
+}
+
+func (r cartRepoImpl) GetCartProducts(c context.Context, userID int) ([]entity.CartProduct, error) {
+	products := []entity.CartProduct{}
+
+	query := `SELECT c.id, c.pharmacy_product_id, pp.product_id, p.name, ph.id, ph.name, c.quantity, pp.price
+				FROM carts c
+				JOIN pharmacy_products pp ON pp.id = c.pharmacy_product_id
+				JOIN products p ON p.id = pp.product_id
+				JOIN pharmacies ph ON ph.id = pp.pharmacy_id
+				WHERE c.user_id = $1 AND c.deleted_at IS NULL
+				ORDER BY pp.product_id, c.id`
+
+	rows, err := r.db.QueryContext(c, query, userID)
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var product entity.CartProduct
+		err := rows.Scan(&product.CartItemID, &product.PharmacyProductID, &product.ProductID, &product.Name,
+			&product.PharmacyID, &product.PharmacyName, &product.Quantity, &product.Price)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
+		products = append(products, product)
+	}
+	return products, nil
+}
+
+func (r cartRepoImpl) GetPharmacyOffers(c context.Context, products []entity.CartProduct, location string) ([]entity.PharmacyOffer, error) {
+	offers := []entity.PharmacyOffer{}
+	if len(products) == 0 {
+		return offers, nil
+	}
+
+	valueStrings := make([]string, 0, len(products))
+	args := []any{location}
+	for _, product := range products {
+		args = append(args, product.ProductID, product.Quantity)
+		valueStrings = append(valueStrings, fmt.Sprintf("($%d::bigint, $%d::int)", len(args)-1, len(args)))
+	}
+
+	query := fmt.Sprintf(`WITH requested (product_id, quantity) AS (VALUES %s)
+				SELECT pp.id, pp.product_id, ph.id, ph.name, pp.price, ST_Distance(ph.location, $1::geography), p.weight
+				FROM requested rq
+				JOIN pharmacy_products pp ON pp.product_id = rq.product_id
+				JOIN pharmacy_product_available_stocks pas ON pas.pharmacy_product_id = pp.id
+				JOIN pharmacies ph ON ph.id = pp.pharmacy_id
+				JOIN partners pt ON pt.id = ph.partner_id
+				JOIN products p ON p.id = pp.product_id
+				WHERE pas.available_stock >= rq.quantity AND %s
+				AND pp.is_active = true AND pp.deleted_at IS NULL
+				AND p.is_active = true AND p.deleted_at IS NULL
+				AND ph.is_active = true AND ph.deleted_at IS NULL
+				AND pt.is_active = true AND pt.deleted_at IS NULL
+				ORDER BY pp.product_id, pp.price, ST_Distance(ph.location, $1::geography)`, strings.Join(valueStrings, ","), fmt.Sprintf(appconstant.ServiceAreaCondition, "$1::geography"))
+
+	rows, err := r.db.QueryContext(c, query, args...)
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var offer entity.PharmacyOffer
+		err := rows.Scan(&offer.PharmacyProductID, &offer.ProductID, &offer.PharmacyID, &offer.PharmacyName, &offer.Price, &offer.Distance, &offer.Weight)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
+		offers = append(offers, offer)
+	}
+	return offers, nil
+}
+
+func (r cartRepoImpl) GetCartOptimizationRedis(c context.Context, userID int, optimizationID string) (*entity.CartOptimization, error) {
+	key := fmt.Sprintf(appconstant.CartOptimizationRedisKey, userID, optimizationID)
+	serialized, err := r.redisDB.Get(c, key).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	var optimization entity.CartOptimization
+	err = json.Unmarshal([]byte(serialized), &optimization)
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return &optimization, nil
+}
+
+func (r cartRepoImpl) SetCartOptimizationRedis(c context.Context, userID int, optimization entity.CartOptimization) error {
+	data, err := json.Marshal(optimization)
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	key := fmt.Sprintf(appconstant.CartOptimizationRedisKey, userID, optimization.ID)
+	_, err = r.redisDB.Set(c, key, data, appconstant.CartRedisExpiration).Result()
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+func (r cartRepoImpl) DeleteCartOptimizationRedis(c context.Context, userID int, optimizationID string) error {
+	key := fmt.Sprintf(appconstant.CartOptimizationRedisKey, userID, optimizationID)
+	_, err := r.redisDB.Del(c, key).Result()
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+func (r cartRepoImpl) ReplaceCartProduct(c context.Context, userID int, item entity.OptimizedItem) error {
+	tx := transaction.ExtractTx(c)
+
+	deleteQuery := `UPDATE carts c
+				SET deleted_at = NOW(), updated_at = NOW()
+				FROM pharmacy_products pp
+				WHERE c.pharmacy_product_id = pp.id AND c.user_id = $1 AND pp.product_id = $2 AND c.deleted_at IS NULL`
+	insertQuery := `INSERT INTO carts (user_id, pharmacy_product_id, quantity)
+				VALUES ($1, $2, $3)`
+
+	var err error
+	if tx != nil {
+		_, err = tx.ExecContext(c, deleteQuery, userID, item.ProductID)
+		if err == nil {
+			_, err = tx.ExecContext(c, insertQuery, userID, item.PharmacyProductID, item.Quantity)
+		}
+	} else {
+		_, err = r.db.ExecContext(c, deleteQuery, userID, item.ProductID)
+		if err == nil {
+			_, err = r.db.ExecContext(c, insertQuery, userID, item.PharmacyProductID, item.Quantity)
+		}
+	}
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
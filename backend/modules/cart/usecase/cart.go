@@ -4,9 +4,13 @@ import (
 	"context"
 	"montelukast/modules/cart/entity"
 	"montelukast/modules/cart/repository"
+	deliveryUsecase "montelukast/modules/delivery/usecase"
+	pharmacyProductEntity "montelukast/modules/pharmacyproduct/entity"
 	pharmacyproduct "montelukast/modules/pharmacyproduct/repository"
 	appconstant "montelukast/pkg/constant"
 	apperror "montelukast/pkg/error"
+	"montelukast/pkg/transaction"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -17,17 +21,25 @@ type CartUsecase interface {
 	GetGroupedCartItems(c context.Context, userID int) ([]entity.GroupedCartItem, error)
 	GetCartItems(c context.Context, userID int) ([]entity.CartItem, error)
 	GetSelectedCartItems(c context.Context, userID int, ids []int) (*entity.ListGroupedCartItem, error)
+	AbandonCheckout(c context.Context, userID int, cartID string) error
+	BuyAgain(c context.Context, source entity.ReorderSource) ([]entity.BuyAgainItem, error)
+	OptimizeCart(c context.Context, userID int) (*entity.CartOptimization, error)
+	ApplyCartOptimization(c context.Context, userID int, optimizationID string) error
 }
 
 type cartUsecaseImpl struct {
 	r  repository.CartRepo
 	pp pharmacyproduct.PharmacyProductRepo
+	d  deliveryUsecase.DeliveryUsecase
+	tr transaction.TransactorRepoImpl
 }
 
-func NewCartUsecase(r repository.CartRepo, pp pharmacyproduct.PharmacyProductRepo) cartUsecaseImpl {
+func NewCartUsecase(r repository.CartRepo, pp pharmacyproduct.PharmacyProductRepo, d deliveryUsecase.DeliveryUsecase, tr transaction.TransactorRepoImpl) cartUsecaseImpl {
 	return cartUsecaseImpl{
 		r:  r,
 		pp: pp,
+		d:  d,
+		tr: tr,
 	}
 }
 
@@ -56,7 +68,7 @@ func (u cartUsecaseImpl) AddToCart(c context.Context, cartItem entity.CartItem)
 		return apperror.NewErrStatusNotFound(appconstant.FieldErrAddToCart, apperror.ErrPharmacyProductNotExists, apperror.ErrPharmacyProductNotExists)
 	}
 
-	stock, err := u.pp.GetStockByID(c, cartItem.PharmacyProductID)
+	stock, err := u.pp.GetAvailableStockByID(c, cartItem.PharmacyProductID)
 	if err != nil {
 		return err
 	}
@@ -153,22 +165,119 @@ func (u cartUsecaseImpl) GetSelectedCartItems(c context.Context, userID int, ids
 	if err != nil {
 		return nil, err
 	}
-	for _, cartItem := range cartItems {
-		stock, err := u.pp.GetStockByID(c, cartItem.PharmacyProductID)
+	var listGrouped entity.ListGroupedCartItem
+	listGrouped.GroupedItem = groupByPharmacy(cartItems)
+	listGrouped.ID = uuid.New().String()
+
+	err = u.tr.WithinTransaction(c, func(txCtx context.Context) error {
+		err := u.pp.ReleaseStockHoldsByUserID(txCtx, userID)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		if cartItem.Quantity > stock {
-			return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrGetCart, apperror.ErrStockUnavailable, apperror.ErrStockUnavailable)
+
+		expiredAt := time.Now().Add(appconstant.CartRedisExpiration)
+		for _, cartItem := range cartItems {
+			stock, err := u.pp.GetAvailableStockByID(txCtx, cartItem.PharmacyProductID)
+			if err != nil {
+				return err
+			}
+			if cartItem.Quantity > stock {
+				return apperror.NewErrStatusBadRequest(appconstant.FieldErrGetCart, apperror.ErrStockUnavailable, apperror.ErrStockUnavailable)
+			}
+			err = u.pp.AddStockHold(txCtx, pharmacyProductEntity.StockHold{
+				UserID:            userID,
+				CartID:            listGrouped.ID,
+				PharmacyProductID: cartItem.PharmacyProductID,
+				Quantity:          cartItem.Quantity,
+				ExpiredAt:         expiredAt,
+			})
+			if err != nil {
+				return err
+			}
 		}
-	}
-	var listGrouped entity.ListGroupedCartItem
-	selectedCarts := groupByPharmacy(cartItems)
-	listGrouped.GroupedItem = selectedCarts
-	listGrouped.ID = uuid.New().String()
-	err = u.r.AddCheckoutItem(c, listGrouped, userID)
+
+		return u.r.AddCheckoutItem(txCtx, listGrouped, userID)
+	})
 	if err != nil {
 		return nil, err
 	}
 	return &listGrouped, nil
 }
+
+func (u cartUsecaseImpl) AbandonCheckout(c context.Context, userID int, cartID string) error {
+	err := u.pp.ReleaseStockHoldsByCartID(c, cartID, userID)
+	if err != nil {
+		return err
+	}
+	return u.r.DeleteCheckoutItem(c, cartID, userID)
+}
+
+func (u cartUsecaseImpl) BuyAgain(c context.Context, source entity.ReorderSource) ([]entity.BuyAgainItem, error) {
+	isVerified, err := u.r.IsUserVerified(c, source.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !isVerified {
+		return nil, apperror.NewErrStatusUnauthorized(appconstant.FieldErrBuyAgain, apperror.ErrUserNotVerified, apperror.ErrUserNotVerified)
+	}
+
+	reorderItems, err := u.r.GetReorderItems(c, source)
+	if err != nil {
+		return nil, err
+	}
+	if len(reorderItems) == 0 {
+		return nil, apperror.NewErrStatusNotFound(appconstant.FieldErrBuyAgain, apperror.ErrOrderNotExists, apperror.ErrOrderNotExists)
+	}
+
+	location := ""
+	items := []entity.BuyAgainItem{}
+	for _, reorderItem := range reorderItems {
+		item := entity.BuyAgainItem{
+			ReorderItem:    reorderItem,
+			IsPriceChanged: !reorderItem.PreviousPrice.Equal(reorderItem.CurrentPrice),
+		}
+
+		if !reorderItem.IsActive {
+			item.Status = appconstant.BuyAgainStatusInactive
+		} else {
+			stock, err := u.pp.GetAvailableStockByID(c, reorderItem.PharmacyProductID)
+			if err != nil {
+				return nil, err
+			}
+			cartItem := entity.CartItem{
+				UserID:            source.UserID,
+				PharmacyProductID: reorderItem.PharmacyProductID,
+				Quantity:          reorderItem.Quantity,
+			}
+			isAvailable, err := u.isStockSufficient(c, cartItem, stock)
+			if err != nil {
+				return nil, err
+			}
+			item.AvailableStock = stock
+			if isAvailable {
+				err = u.AddToCart(c, cartItem)
+				if err != nil {
+					return nil, err
+				}
+				item.Status = appconstant.BuyAgainStatusAdded
+			} else {
+				item.Status = appconstant.BuyAgainStatusOutOfStock
+			}
+		}
+
+		if item.Status != appconstant.BuyAgainStatusAdded {
+			if location == "" {
+				location, err = u.r.GetUserLocation(c, source.UserID)
+				if err != nil {
+					return nil, err
+				}
+			}
+			item.Suggestion, err = u.r.GetNearestPharmacyProduct(c, reorderItem, location)
+			if err != nil {
+				return nil, err
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
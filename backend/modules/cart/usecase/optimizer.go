@@ -0,0 +1,466 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"montelukast/modules/cart/entity"
+	deliveryEntity "montelukast/modules/delivery/entity"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type candidatePharmacy struct {
+	id       int
+	coverage int
+	distance float64
+}
+
+type estimatedAssignment struct {
+	offers   []entity.PharmacyOffer
+	estimate decimal.Decimal
+}
+
+type shippingQuote struct {
+	cost          decimal.Decimal
+	isDeliverable bool
+}
+
+type cartOptimizer struct {
+	u        cartUsecaseImpl
+	userID   int
+	shipping map[string]shippingQuote
+}
+
+func (u cartUsecaseImpl) OptimizeCart(c context.Context, userID int) (*entity.CartOptimization, error) {
+	isAddressExists, err := u.r.IsActiveAddressExists(c, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !isAddressExists {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrOptimizeCart, apperror.ErrAddressNotExists, apperror.ErrAddressNotExists)
+	}
+
+	cartProducts, err := u.r.GetCartProducts(c, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(cartProducts) == 0 {
+		return nil, apperror.NewErrStatusNotFound(appconstant.FieldErrOptimizeCart, apperror.ErrCartNotAvailable, apperror.ErrCartNotAvailable)
+	}
+	products := mergeCartProducts(cartProducts)
+
+	location, err := u.r.GetUserLocation(c, userID)
+	if err != nil {
+		return nil, err
+	}
+	offers, err := u.r.GetPharmacyOffers(c, products, location)
+	if err != nil {
+		return nil, err
+	}
+
+	o := cartOptimizer{u: u, userID: userID, shipping: map[string]shippingQuote{}}
+	optimization, err := o.optimize(c, cartProducts, products, offers)
+	if err != nil {
+		return nil, err
+	}
+
+	optimization.ID = uuid.NewString()
+	err = u.r.SetCartOptimizationRedis(c, userID, *optimization)
+	if err != nil {
+		return nil, err
+	}
+	return optimization, nil
+}
+
+func (u cartUsecaseImpl) ApplyCartOptimization(c context.Context, userID int, optimizationID string) error {
+	optimization, err := u.r.GetCartOptimizationRedis(c, userID, optimizationID)
+	if err != nil {
+		return err
+	}
+	if optimization == nil {
+		return apperror.NewErrStatusBadRequest(appconstant.FieldErrOptimizeCart, apperror.ErrCartOptimizationExpired, apperror.ErrCartOptimizationExpired)
+	}
+
+	cartProducts, err := u.r.GetCartProducts(c, userID)
+	if err != nil {
+		return err
+	}
+	if !isSameCart(mergeCartProducts(cartProducts), *optimization) {
+		return apperror.NewErrStatusBadRequest(appconstant.FieldErrOptimizeCart, apperror.ErrCartOptimizationExpired, apperror.ErrCartOptimizationExpired)
+	}
+	if optimization.IsCurrentCart {
+		return u.r.DeleteCartOptimizationRedis(c, userID, optimizationID)
+	}
+
+	err = u.tr.WithinTransaction(c, func(txCtx context.Context) error {
+		for _, group := range optimization.Groups {
+			for _, item := range group.Items {
+				if item.IsUnavailable {
+					continue
+				}
+				stock, err := u.pp.GetAvailableStockByID(txCtx, item.PharmacyProductID)
+				if err != nil {
+					return err
+				}
+				if stock < item.Quantity {
+					return apperror.NewErrStatusBadRequest(appconstant.FieldErrOptimizeCart, apperror.ErrStockUnavailable, apperror.ErrStockUnavailable)
+				}
+				err = u.r.ReplaceCartProduct(txCtx, userID, item)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return u.r.DeleteCartOptimizationRedis(c, userID, optimizationID)
+}
+
+// mergeCartProducts collapses cart rows of the same product bought from
+// different pharmacies into one entry carrying the total quantity.
+func mergeCartProducts(cartProducts []entity.CartProduct) []entity.CartProduct {
+	products := []entity.CartProduct{}
+	indexes := map[int]int{}
+	for _, cartProduct := range cartProducts {
+		if i, ok := indexes[cartProduct.ProductID]; ok {
+			products[i].Quantity += cartProduct.Quantity
+			continue
+		}
+		indexes[cartProduct.ProductID] = len(products)
+		products = append(products, cartProduct)
+	}
+	return products
+}
+
+func isSameCart(products []entity.CartProduct, optimization entity.CartOptimization) bool {
+	quantities := map[int]int{}
+	for _, group := range optimization.Groups {
+		for _, item := range group.Items {
+			quantities[item.ProductID] += item.Quantity
+		}
+	}
+	if len(quantities) != len(products) {
+		return false
+	}
+	for _, product := range products {
+		if quantities[product.ProductID] != product.Quantity {
+			return false
+		}
+	}
+	return true
+}
+
+func (o cartOptimizer) optimize(c context.Context, cartProducts []entity.CartProduct, products []entity.CartProduct, offers []entity.PharmacyOffer) (*entity.CartOptimization, error) {
+	offersByProduct := map[int][]entity.PharmacyOffer{}
+	for _, offer := range offers {
+		offersByProduct[offer.ProductID] = append(offersByProduct[offer.ProductID], offer)
+	}
+	candidates := rankCandidatePharmacies(offers)
+
+	optimization := entity.CartOptimization{}
+	currentParcels := map[int][]deliveryEntity.ParcelItem{}
+	for _, cartProduct := range cartProducts {
+		optimization.CurrentItemsTotal = optimization.CurrentItemsTotal.Add(cartProduct.Price.Mul(decimal.NewFromInt(int64(cartProduct.Quantity))))
+		currentParcels[cartProduct.PharmacyID] = append(currentParcels[cartProduct.PharmacyID], deliveryEntity.ParcelItem{
+			PharmacyProductID: cartProduct.PharmacyProductID,
+			Quantity:          cartProduct.Quantity,
+		})
+	}
+	currentCosts := map[int]decimal.Decimal{}
+	for pharmacyID, items := range currentParcels {
+		cost, isDeliverable, err := o.shippingCost(c, pharmacyID, items)
+		if err != nil {
+			return nil, err
+		}
+		if !isDeliverable {
+			optimization.IsCurrentUndeliverable = true
+			continue
+		}
+		currentCosts[pharmacyID] = cost
+		optimization.CurrentShippingTotal = optimization.CurrentShippingTotal.Add(cost)
+	}
+	optimization.CurrentTotal = optimization.CurrentItemsTotal.Add(optimization.CurrentShippingTotal)
+
+	// Subsets are ranked with a local shipping estimate, the best few
+	// assignments are then quoted with the couriers to pick the winner.
+	distances := map[int]float64{}
+	for _, candidate := range candidates {
+		distances[candidate.id] = candidate.distance
+	}
+	proposals := []estimatedAssignment{}
+	isProposed := map[string]bool{}
+	for mask := 1; mask < 1<<len(candidates); mask++ {
+		subset := map[int]bool{}
+		for i, candidate := range candidates {
+			if mask&(1<<i) != 0 {
+				subset[candidate.id] = true
+			}
+		}
+		assignment := assignOffers(products, offersByProduct, subset)
+		key := assignmentKey(assignment)
+		if isProposed[key] {
+			continue
+		}
+		isProposed[key] = true
+		proposals = append(proposals, estimatedAssignment{
+			offers:   assignment,
+			estimate: estimateAssignmentTotal(products, assignment, distances),
+		})
+	}
+	if len(proposals) == 0 {
+		proposals = append(proposals, estimatedAssignment{offers: assignOffers(products, offersByProduct, map[int]bool{})})
+	}
+	sort.SliceStable(proposals, func(i, j int) bool {
+		return proposals[i].estimate.LessThan(proposals[j].estimate)
+	})
+	if len(proposals) > appconstant.MaxCartOptimizerQuotes {
+		proposals = proposals[:appconstant.MaxCartOptimizerQuotes]
+	}
+
+	var best []entity.PharmacyOffer
+	var bestCosts map[int]decimal.Decimal
+	var bestTotal decimal.Decimal
+	for _, proposal := range proposals {
+		costs, total, isDeliverable, err := o.quoteAssignment(c, products, proposal.offers)
+		if err != nil {
+			return nil, err
+		}
+		if !isDeliverable {
+			continue
+		}
+		if best == nil || total.LessThan(bestTotal) {
+			best = proposal.offers
+			bestCosts = costs
+			bestTotal = total
+		}
+	}
+	if best == nil || (!optimization.IsCurrentUndeliverable && !bestTotal.LessThan(optimization.CurrentTotal)) {
+		return currentCartOptimization(optimization, cartProducts, currentCosts), nil
+	}
+
+	groups := map[int]*entity.OptimizedGroup{}
+	pharmacyIDs := []int{}
+	for i, product := range products {
+		offer := best[i]
+		group, ok := groups[offer.PharmacyID]
+		if !ok {
+			group = &entity.OptimizedGroup{
+				PharmacyID:   offer.PharmacyID,
+				PharmacyName: offer.PharmacyName,
+				ShippingCost: bestCosts[offer.PharmacyID],
+			}
+			groups[offer.PharmacyID] = group
+			pharmacyIDs = append(pharmacyIDs, offer.PharmacyID)
+			optimization.ShippingTotal = optimization.ShippingTotal.Add(group.ShippingCost)
+		}
+		subtotal := offer.Price.Mul(decimal.NewFromInt(int64(product.Quantity)))
+		group.Items = append(group.Items, entity.OptimizedItem{
+			ProductID:                 product.ProductID,
+			PharmacyProductID:         offer.PharmacyProductID,
+			PreviousPharmacyProductID: product.PharmacyProductID,
+			Name:                      product.Name,
+			Quantity:                  product.Quantity,
+			Price:                     offer.Price,
+			Subtotal:                  subtotal,
+			IsUnavailable:             len(offersByProduct[product.ProductID]) == 0,
+		})
+		optimization.ItemsTotal = optimization.ItemsTotal.Add(subtotal)
+	}
+	sort.Ints(pharmacyIDs)
+	for _, pharmacyID := range pharmacyIDs {
+		optimization.Groups = append(optimization.Groups, *groups[pharmacyID])
+	}
+	optimization.Total = optimization.ItemsTotal.Add(optimization.ShippingTotal)
+	if !optimization.IsCurrentUndeliverable {
+		optimization.Savings = optimization.CurrentTotal.Sub(optimization.Total)
+	}
+	return &optimization, nil
+}
+
+// currentCartOptimization proposes keeping the cart as it is, for when no
+// assignment is cheaper than what the user already picked.
+func currentCartOptimization(optimization entity.CartOptimization, cartProducts []entity.CartProduct, costs map[int]decimal.Decimal) *entity.CartOptimization {
+	optimization.IsCurrentCart = true
+	groups := map[int]*entity.OptimizedGroup{}
+	pharmacyIDs := []int{}
+	for _, cartProduct := range cartProducts {
+		group, ok := groups[cartProduct.PharmacyID]
+		if !ok {
+			group = &entity.OptimizedGroup{
+				PharmacyID:   cartProduct.PharmacyID,
+				PharmacyName: cartProduct.PharmacyName,
+				ShippingCost: costs[cartProduct.PharmacyID],
+			}
+			groups[cartProduct.PharmacyID] = group
+			pharmacyIDs = append(pharmacyIDs, cartProduct.PharmacyID)
+		}
+		group.Items = append(group.Items, entity.OptimizedItem{
+			ProductID:                 cartProduct.ProductID,
+			PharmacyProductID:         cartProduct.PharmacyProductID,
+			PreviousPharmacyProductID: cartProduct.PharmacyProductID,
+			Name:                      cartProduct.Name,
+			Quantity:                  cartProduct.Quantity,
+			Price:                     cartProduct.Price,
+			Subtotal:                  cartProduct.Price.Mul(decimal.NewFromInt(int64(cartProduct.Quantity))),
+		})
+	}
+	sort.Ints(pharmacyIDs)
+	for _, pharmacyID := range pharmacyIDs {
+		optimization.Groups = append(optimization.Groups, *groups[pharmacyID])
+	}
+	optimization.ItemsTotal = optimization.CurrentItemsTotal
+	optimization.ShippingTotal = optimization.CurrentShippingTotal
+	optimization.Total = optimization.CurrentTotal
+	return &optimization
+}
+
+// rankCandidatePharmacies keeps the pharmacies that cover the most cart
+// products, nearest first on ties, so the subset search stays bounded.
+func rankCandidatePharmacies(offers []entity.PharmacyOffer) []candidatePharmacy {
+	indexes := map[int]int{}
+	candidates := []candidatePharmacy{}
+	for _, offer := range offers {
+		i, ok := indexes[offer.PharmacyID]
+		if !ok {
+			i = len(candidates)
+			indexes[offer.PharmacyID] = i
+			candidates = append(candidates, candidatePharmacy{id: offer.PharmacyID, distance: offer.Distance})
+		}
+		candidates[i].coverage++
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].coverage != candidates[j].coverage {
+			return candidates[i].coverage > candidates[j].coverage
+		}
+		return candidates[i].distance < candidates[j].distance
+	})
+	if len(candidates) > appconstant.MaxCartOptimizerPharmacies {
+		candidates = candidates[:appconstant.MaxCartOptimizerPharmacies]
+	}
+	return candidates
+}
+
+// assignOffers picks the cheapest offer inside the subset for every product.
+// Products the subset cannot cover fall back to their cheapest offer anywhere,
+// and products nobody stocks stay with the pharmacy already in the cart.
+func assignOffers(products []entity.CartProduct, offersByProduct map[int][]entity.PharmacyOffer, subset map[int]bool) []entity.PharmacyOffer {
+	assignment := make([]entity.PharmacyOffer, len(products))
+	for i, product := range products {
+		offers := offersByProduct[product.ProductID]
+		if len(offers) == 0 {
+			assignment[i] = entity.PharmacyOffer{
+				PharmacyProductID: product.PharmacyProductID,
+				ProductID:         product.ProductID,
+				PharmacyID:        product.PharmacyID,
+				PharmacyName:      product.PharmacyName,
+				Price:             product.Price,
+			}
+			continue
+		}
+		assignment[i] = offers[0]
+		for _, offer := range offers {
+			if subset[offer.PharmacyID] {
+				assignment[i] = offer
+				break
+			}
+		}
+	}
+	return assignment
+}
+
+// estimateAssignmentTotal prices an assignment without calling the couriers:
+// every parcel costs a flat fee plus a rate per kilometre and per started
+// kilogram.
+func estimateAssignmentTotal(products []entity.CartProduct, assignment []entity.PharmacyOffer, distances map[int]float64) decimal.Decimal {
+	total := decimal.Zero
+	weights := map[int]float64{}
+	for i, offer := range assignment {
+		total = total.Add(offer.Price.Mul(decimal.NewFromInt(int64(products[i].Quantity))))
+		weights[offer.PharmacyID] += offer.Weight * float64(products[i].Quantity)
+	}
+	for pharmacyID, weight := range weights {
+		distanceInKM := distances[pharmacyID] / 1000
+		kilograms := math.Ceil(weight / 1000)
+		estimate := appconstant.CartOptimizerBaseShipping + appconstant.CartOptimizerShippingPerKm*distanceInKM + appconstant.CartOptimizerShippingPerKg*kilograms
+		total = total.Add(decimal.NewFromFloat(estimate).Round(0))
+	}
+	return total
+}
+
+// parcelsByPharmacy lists what every pharmacy of an assignment would ship, as
+// the shipping cost depends on the weight of the parcel.
+func parcelsByPharmacy(products []entity.CartProduct, assignment []entity.PharmacyOffer) map[int][]deliveryEntity.ParcelItem {
+	parcels := map[int][]deliveryEntity.ParcelItem{}
+	for i, offer := range assignment {
+		parcels[offer.PharmacyID] = append(parcels[offer.PharmacyID], deliveryEntity.ParcelItem{
+			PharmacyProductID: offer.PharmacyProductID,
+			Quantity:          products[i].Quantity,
+		})
+	}
+	return parcels
+}
+
+// assignmentKey identifies an assignment by the pharmacy products it picks,
+// as different subsets often end up with the same assignment.
+func assignmentKey(assignment []entity.PharmacyOffer) string {
+	ids := make([]int, len(assignment))
+	for i, offer := range assignment {
+		ids[i] = offer.PharmacyProductID
+	}
+	return fmt.Sprint(ids)
+}
+
+// quoteAssignment prices an assignment with the courier quotes of every
+// parcel. An assignment is undeliverable when one of its pharmacies no longer
+// delivers to the address.
+func (o cartOptimizer) quoteAssignment(c context.Context, products []entity.CartProduct, assignment []entity.PharmacyOffer) (map[int]decimal.Decimal, decimal.Decimal, bool, error) {
+	total := decimal.Zero
+	for i, offer := range assignment {
+		total = total.Add(offer.Price.Mul(decimal.NewFromInt(int64(products[i].Quantity))))
+	}
+	costs := map[int]decimal.Decimal{}
+	for pharmacyID, items := range parcelsByPharmacy(products, assignment) {
+		cost, isDeliverable, err := o.shippingCost(c, pharmacyID, items)
+		if err != nil || !isDeliverable {
+			return nil, decimal.Zero, false, err
+		}
+		costs[pharmacyID] = cost
+		total = total.Add(cost)
+	}
+	return costs, total, true, nil
+}
+
+// shippingCost quotes delivery of a parcel from a pharmacy with its cheapest
+// courier option, remembering the result for the rest of the request.
+func (o cartOptimizer) shippingCost(c context.Context, pharmacyID int, items []deliveryEntity.ParcelItem) (decimal.Decimal, bool, error) {
+	key := fmt.Sprint(pharmacyID, items)
+	if quote, ok := o.shipping[key]; ok {
+		return quote.cost, quote.isDeliverable, nil
+	}
+	ongkirList, err := o.u.d.GetAllOngkir(c, o.userID, pharmacyID, items)
+	var appErr *apperror.ErrorStruct
+	if errors.As(err, &appErr) && appErr.SpecificError == apperror.ErrAddressOutsideServiceArea {
+		o.shipping[key] = shippingQuote{}
+		return decimal.Zero, false, nil
+	}
+	if err != nil {
+		return decimal.Zero, false, err
+	}
+	cost := decimal.Zero
+	for i, ongkir := range ongkirList {
+		if i == 0 || ongkir.Cost.LessThan(cost) {
+			cost = ongkir.Cost
+		}
+	}
+	o.shipping[key] = shippingQuote{cost: cost, isDeliverable: true}
+	return cost, true, nil
+}
@@ -3,6 +3,7 @@ package converter
 import (
 	"montelukast/modules/checkout/dto"
 	"montelukast/modules/checkout/entity"
+	"time"
 )
 
 type CheckoutConverter interface {
@@ -25,12 +26,57 @@ func (c CheckoutConverterImpl) ToEntity(dto dto.CheckoutData) entity.CheckoutDat
 	return entity.CheckoutData{
 		IDCart:           dto.IDCart,
 		ListDeliveryData: entityList,
+		VoucherCode:      dto.VoucherCode,
 	}
 }
 
 func (c ListDeliveryConverterImpl) ToEntity(dto dto.DeliveryData) entity.DeliveryData {
 	return entity.DeliveryData{
-		PharmacyID: dto.PharmacyID,
-		DeliveryID: dto.DeliveryID,
+		PharmacyID:     dto.PharmacyID,
+		DeliveryID:     dto.DeliveryID,
+		PrescriptionID: dto.PrescriptionID,
+	}
+}
+
+type CheckoutOrderConverterImpl struct{}
+
+func (c CheckoutOrderConverterImpl) ToDto(order entity.CheckoutOrder) dto.CheckoutOrderResponse {
+	orderDetails := make([]dto.CheckoutOrderDetailResponse, 0, len(order.OrderDetails))
+	for _, orderDetail := range order.OrderDetails {
+		items := make([]dto.CheckoutOrderItemResponse, 0, len(orderDetail.Items))
+		for _, item := range orderDetail.Items {
+			items = append(items, dto.CheckoutOrderItemResponse{
+				PharmacyProductID: item.PharmacyProductID,
+				Name:              item.Name,
+				Quantity:          item.Quantity,
+				Subtotal:          item.Subtotal.String(),
+			})
+		}
+		orderDetails = append(orderDetails, dto.CheckoutOrderDetailResponse{
+			ID:               orderDetail.ID,
+			PharmacyID:       orderDetail.PharmacyID,
+			PharmacyName:     orderDetail.PharmacyName,
+			Items:            items,
+			ItemsSubtotal:    orderDetail.ItemsSubtotal.String(),
+			ShippingCost:     orderDetail.ShippingCost.String(),
+			Discount:         orderDetail.Discount.String(),
+			ShippingDiscount: orderDetail.ShippingDiscount.String(),
+			Total:            orderDetail.Total.String(),
+		})
+	}
+	var paymentDeadline *time.Time
+	if order.OrderID != 0 {
+		paymentDeadline = &order.PaymentDeadline
+	}
+	return dto.CheckoutOrderResponse{
+		OrderID:          order.OrderID,
+		OrderDetails:     orderDetails,
+		ItemsSubtotal:    order.ItemsSubtotal.String(),
+		ShippingCost:     order.ShippingCost.String(),
+		VoucherCode:      order.VoucherCode,
+		Discount:         order.Discount.String(),
+		ShippingDiscount: order.ShippingDiscount.String(),
+		GrandTotal:       order.GrandTotal.String(),
+		PaymentDeadline:  paymentDeadline,
 	}
 }
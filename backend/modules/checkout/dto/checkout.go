@@ -1,11 +1,50 @@
 package dto
 
+import "time"
+
 type CheckoutData struct {
 	IDCart           string         `json:"id_cart"`
 	ListDeliveryData []DeliveryData `json:"delivery_data_list"`
+	VoucherCode      *string        `json:"voucher_code"`
 }
 
 type DeliveryData struct {
-	PharmacyID int `json:"pharmacy_id"`
-	DeliveryID int `json:"delivery_id"`
+	PharmacyID     int  `json:"pharmacy_id"`
+	DeliveryID     int  `json:"delivery_id"`
+	PrescriptionID *int `json:"prescription_id"`
+}
+
+type CheckoutOrderResponse struct {
+	OrderID          int                           `json:"order_id,omitempty"`
+	OrderDetails     []CheckoutOrderDetailResponse `json:"order_details"`
+	ItemsSubtotal    string                        `json:"items_subtotal"`
+	ShippingCost     string                        `json:"shipping_cost"`
+	VoucherCode      *string                       `json:"voucher_code"`
+	Discount         string                        `json:"discount"`
+	ShippingDiscount string                        `json:"shipping_discount"`
+	GrandTotal       string                        `json:"grand_total"`
+	PaymentDeadline  *time.Time                    `json:"payment_deadline,omitempty"`
+}
+
+type CheckoutOrderDetailResponse struct {
+	ID               int                         `json:"order_detail_id,omitempty"`
+	PharmacyID       int                         `json:"pharmacy_id"`
+	PharmacyName     string                      `json:"pharmacy_name"`
+	Items            []CheckoutOrderItemResponse `json:"items"`
+	ItemsSubtotal    string                      `json:"items_subtotal"`
+	ShippingCost     string                      `json:"shipping_cost"`
+	Discount         string                      `json:"discount"`
+	ShippingDiscount string                      `json:"shipping_discount"`
+	Total            string                      `json:"total"`
+}
+
+type CheckoutOrderItemResponse struct {
+	PharmacyProductID int    `json:"pharmacy_product_id"`
+	Name              string `json:"name"`
+	Quantity          int    `json:"quantity"`
+	Subtotal          string `json:"subtotal"`
+}
+
+type CancelOrderDetailRequest struct {
+	Reason *string `json:"reason"`
 }
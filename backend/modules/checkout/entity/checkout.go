@@ -1,6 +1,10 @@
 package entity
 
-import "github.com/shopspring/decimal"
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 type GroupedCartItem struct {
 	PharmacyID   int
@@ -23,11 +27,13 @@ type GroupedCheckoutItem struct {
 type CheckoutData struct {
 	IDCart           string
 	ListDeliveryData []DeliveryData
+	VoucherCode      *string
 }
 
 type DeliveryData struct {
-	PharmacyID int
-	DeliveryID int
+	PharmacyID     int
+	DeliveryID     int
+	PrescriptionID *int
 }
 
 type LogisticPartner struct {
@@ -37,9 +43,14 @@ type LogisticPartner struct {
 }
 
 type DeliveryPriceData struct {
-	PharmacyID    int
-	LogisticPrice decimal.Decimal
-	Status        string
+	PharmacyID        int
+	LogisticPartnerID int
+	LogisticPrice     decimal.Decimal
+	Status            string
+	PrescriptionID    *int
+	VoucherID         *int
+	Discount          decimal.Decimal
+	ShippingDiscount  decimal.Decimal
 }
 
 type CartItem struct {
@@ -54,3 +65,39 @@ type CartItem struct {
 	PharmacyID        int
 	PharmacyName      string
 }
+
+type CheckoutOrder struct {
+	OrderID          int
+	OrderDetails     []CheckoutOrderDetail
+	ItemsSubtotal    decimal.Decimal
+	ShippingCost     decimal.Decimal
+	VoucherCode      *string
+	Discount         decimal.Decimal
+	ShippingDiscount decimal.Decimal
+	GrandTotal       decimal.Decimal
+	PaymentDeadline  time.Time
+	IsReplayed       bool
+}
+
+type CheckoutOrderDetail struct {
+	ID               int
+	PharmacyID       int
+	PharmacyName     string
+	Items            []CartItem
+	ItemsSubtotal    decimal.Decimal
+	ShippingCost     decimal.Decimal
+	Discount         decimal.Decimal
+	ShippingDiscount decimal.Decimal
+	Total            decimal.Decimal
+}
+
+type CheckoutResult struct {
+	Order *CheckoutOrder
+	Err   error
+}
+
+type UserOrderDetail struct {
+	ID      int
+	OrderID int
+	Status  string
+}
@@ -42,6 +42,7 @@ func (h *CheckoutHandler) CheckoutCartHandler(c *gin.Context) {
 		c.Error(err)
 		return
 	}
+	var orderConverter converter.CheckoutOrderConverterImpl
 	var converter converter.CheckoutConverterImpl
 	checkoutData := dto.CheckoutData{}
 	err = c.ShouldBindJSON(&checkoutData)
@@ -49,12 +50,16 @@ func (h *CheckoutHandler) CheckoutCartHandler(c *gin.Context) {
 		c.Error(err)
 		return
 	}
-	err = <-h.checkOutUsecase.Checkout(c, converter.ToEntity(checkoutData), userID)
-	if err != nil {
-		c.Error(err)
+	result := <-h.checkOutUsecase.Checkout(c, converter.ToEntity(checkoutData), userID, c.GetHeader(appconstant.IdempotencyKeyHeader))
+	if result.Err != nil {
+		c.Error(result.Err)
 		return
 	}
-	response := wrapper.ResponseData(nil, "checkout success,waiting for payment", nil)
+	message := "checkout success,waiting for payment"
+	if result.Order.IsReplayed {
+		message = "checkout already processed,waiting for payment"
+	}
+	response := wrapper.ResponseData(orderConverter.ToDto(*result.Order), message, nil)
 	c.JSON(http.StatusOK, response)
 }
 func (h CheckoutHandler) CancelOrder(c *gin.Context) {
@@ -87,3 +92,77 @@ func (h CheckoutHandler) CancelOrder(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 
 }
+
+func (h CheckoutHandler) CancelOrderDetailHandler(c *gin.Context) {
+	rawUserID, isExists := c.Get("user_id")
+	if !isExists {
+		err := apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, apperror.ErrTokenInvalid)
+		c.Error(err)
+		return
+	}
+	userID, err := strconv.Atoi(rawUserID.(string))
+	if err != nil {
+		err := apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, err)
+		c.Error(err)
+		return
+	}
+	orderDetailID, err := strconv.Atoi(c.Param("order_id"))
+	if err != nil {
+		err := apperror.NewErrStatusBadRequest(appconstant.FieldErrCancel, apperror.ErrConvertVariableType, err)
+		c.Error(err)
+		return
+	}
+
+	cancelReq := dto.CancelOrderDetailRequest{}
+	if c.Request.ContentLength != 0 {
+		err = c.ShouldBindJSON(&cancelReq)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+	}
+
+	err = h.checkOutUsecase.CancelOrderDetailByUser(c, userID, orderDetailID, cancelReq.Reason)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	response := wrapper.ResponseData(nil, "cancel order detail success", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h CheckoutHandler) ValidateVoucherHandler(c *gin.Context) {
+	rawUserID, isExists := c.Get("user_id")
+	if !isExists {
+		err := apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, apperror.ErrTokenInvalid)
+		c.Error(err)
+		return
+	}
+	userID, err := strconv.Atoi(rawUserID.(string))
+	if err != nil {
+		err := apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, err)
+		c.Error(err)
+		return
+	}
+	err = apperror.JsonValidator(c)
+	if err != nil {
+		err := apperror.NewErrStatusBadRequest(appconstant.FieldErrApplyVoucher, apperror.ErrInvalidJSON, err)
+		c.Error(err)
+		return
+	}
+	var orderConverter converter.CheckoutOrderConverterImpl
+	var converter converter.CheckoutConverterImpl
+	checkoutData := dto.CheckoutData{}
+	err = c.ShouldBindJSON(&checkoutData)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	order, err := h.checkOutUsecase.PreviewVoucher(c, converter.ToEntity(checkoutData), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	response := wrapper.ResponseData(orderConverter.ToDto(*order), "validate voucher success", nil)
+	c.JSON(http.StatusOK, response)
+}
@@ -10,25 +10,40 @@ import (
 	apperror "montelukast/pkg/error"
 	"montelukast/pkg/transaction"
 	"strings"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/shopspring/decimal"
 )
 
 type CheckoutRepo interface {
-	AddOrder(c context.Context, totalPrice decimal.Decimal, userID int) (int, error)
+	AddOrder(c context.Context, totalPrice decimal.Decimal, userID int, paymentWindow time.Duration, idempotencyKey string) (int, time.Time, error)
+	SetCheckoutResult(c context.Context, order entity.CheckoutOrder) error
+	GetCheckoutResult(c context.Context, userID int, idempotencyKey string) (*entity.CheckoutOrder, error)
 	GetCheckoutCartRedis(c context.Context, cartID string, userID int) (result *entity.ListGroupedCartItem, err error)
 	DeleteCartRedis(c context.Context, cartID string, userID int) error
+	LockCheckoutRedis(c context.Context, userID int, idempotencyKey string) (bool, error)
+	GetCheckoutResultRedis(c context.Context, userID int, idempotencyKey string) (*entity.CheckoutOrder, error)
+	SetCheckoutResultRedis(c context.Context, userID int, idempotencyKey string, order entity.CheckoutOrder) error
+	DeleteCheckoutLockRedis(c context.Context, userID int, idempotencyKey string) error
 	AddCheckoutOrderDetail(c context.Context, listData []entity.DeliveryPriceData, orderID int) ([]int, error)
 	AddOrderProductDetails(c context.Context, orderDetailID int, products entity.GroupedCartItem) error
-	UpdateStock(c context.Context, product entity.CartItem) error
-	GetQuantity(c context.Context, pharmacyProductIDint int) (int, error)
-	IsActive(c context.Context, pharmacyProductIDint int) (bool, error)
+	DecreaseStock(c context.Context, product entity.CartItem, orderDetailID int) (bool, error)
+	GetAvailableStock(c context.Context, pharmacyProductID int) (int, error)
 	IsCartItemExistsByIDAndUserID(c context.Context, cartItem entity.CartItem) (bool, error)
 	DeleteCartItemByID(c context.Context, id int) error
 	IsOrderFromUser(c context.Context, order_id int, user_id int) (bool, error)
 	IsOrderExistByID(c context.Context, orderID int, userID int) (bool, error)
-	CancelOrder(c context.Context, orderID int) error
+	GetUncancelledOrderDetailIDs(c context.Context, orderID int) ([]int, error)
+	GetUserOrderDetail(c context.Context, orderDetailID int, userID int) (*entity.UserOrderDetail, error)
+	RecalculateOrderTotal(c context.Context, orderID int) error
+	GetOrderDetailIDsByStatus(c context.Context, orderID int, status string) ([]int, error)
+	IsPaymentDeadlinePassed(c context.Context, orderID int) (bool, error)
+	IsPrescriptionReviewPending(c context.Context, orderID int) (bool, error)
+	ExtendPaymentDeadline(c context.Context, orderID int, paymentWindow time.Duration) error
+	GetUserEmailByOrderID(c context.Context, orderID int) (string, error)
+	IsPrescriptionFromUser(c context.Context, prescriptionID int, userID int) (bool, error)
+	ReleaseStockHolds(c context.Context, cartID string, userID int) error
 }
 
 type checkOutRepoImpl struct {
@@ -69,45 +84,54 @@ func (r *checkOutRepoImpl) DeleteCartItemByID(c context.Context, id int) error {
 	return nil
 }
 
-func (r *checkOutRepoImpl) IsActive(c context.Context, pharmacyProductIDint int) (bool, error) {
-	var is_active bool
-	var err error
+func (r *checkOutRepoImpl) DecreaseStock(c context.Context, product entity.CartItem, orderDetailID int) (bool, error) {
 	tx := transaction.ExtractTx(c)
-	query := `SELECT is_active
-				FROM pharmacy_products 
-				WHERE id = $1`
-	if tx != nil {
-		err = tx.QueryRowContext(c, query, pharmacyProductIDint).Scan(&is_active)
-	} else {
-		err = r.db.QueryRowContext(c, query, pharmacyProductIDint).Scan(&is_active)
+	query := `WITH updated AS (
+					UPDATE pharmacy_products pp
+					SET stock = pp.stock - $2, updated_at = NOW()
+					WHERE pp.id = $1 AND pp.is_active IS TRUE AND pp.deleted_at IS NULL
+					AND pp.stock - COALESCE((
+						SELECT SUM(sh.quantity)
+						FROM stock_holds sh
+						WHERE sh.pharmacy_product_id = pp.id AND sh.expired_at > NOW() AND sh.deleted_at IS NULL
+					), 0) >= $2
+					RETURNING pp.id, pp.stock
+				)
+				INSERT INTO stock_movements (pharmacy_product_id, delta, balance, reason, reference_id)
+				SELECT id, -$2::int, stock, $3, $4 FROM updated`
+	res, err := tx.ExecContext(c, query, product.PharmacyProductID, product.Quantity, appconstant.StockMovementSale, orderDetailID)
+	if err != nil {
+		return false, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
 	}
+	affected, err := res.RowsAffected()
 	if err != nil {
-		return is_active, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		return false, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
 	}
-	return is_active, nil
+	return affected > 0, nil
 }
 
-func (r *checkOutRepoImpl) UpdateStock(c context.Context, product entity.CartItem) error {
+func (r *checkOutRepoImpl) GetAvailableStock(c context.Context, pharmacyProductID int) (int, error) {
 	tx := transaction.ExtractTx(c)
-	query := `UPDATE pharmacy_products 
-				SET stock = stock - $2
-				WHERE id = $1 AND is_active IS TRUE`
-	_, err := tx.ExecContext(c, query, product.PharmacyProductID, product.Quantity)
-	if err != nil {
-		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	query := `SELECT CASE WHEN pp.is_active IS TRUE AND pp.deleted_at IS NULL THEN
+					GREATEST(pp.stock - COALESCE((
+						SELECT SUM(sh.quantity)
+						FROM stock_holds sh
+						WHERE sh.pharmacy_product_id = pp.id AND sh.expired_at > NOW() AND sh.deleted_at IS NULL
+					), 0), 0)
+				ELSE 0 END
+				FROM pharmacy_products pp
+				WHERE pp.id = $1`
+	var available int
+	var err error
+	if tx != nil {
+		err = tx.QueryRowContext(c, query, pharmacyProductID).Scan(&available)
+	} else {
+		err = r.db.QueryRowContext(c, query, pharmacyProductID).Scan(&available)
 	}
-	return nil
-}
-
-func (r *checkOutRepoImpl) GetQuantity(c context.Context, pharmacyProductIDint int) (int, error) {
-	query := `SELECT stock FROM pharmacy_products
-	WHERE id = $1`
-	var quantity int
-	err := r.db.QueryRowContext(c, query, pharmacyProductIDint).Scan(&quantity)
-	if err != nil {
-		return -1, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
 	}
-	return quantity, nil
+	return available, nil
 }
 
 func (r *checkOutRepoImpl) AddOrderProductDetails(c context.Context, orderDetailID int, products entity.GroupedCartItem) error {
@@ -133,11 +157,16 @@ func (r *checkOutRepoImpl) AddCheckoutOrderDetail(c context.Context, listData []
 	valueStrings := make([]string, 0, len(listData))
 	valueArgs := make([]interface{}, 0, len(listData))
 	for i, data := range listData {
-		valueStrings = append(valueStrings, fmt.Sprintf("($%d,$%d,$%d,$%d)", i*4+1, i*4+2, i*4+3, i*4+4))
-		valueArgs = append(valueArgs, orderID, data.PharmacyID, data.LogisticPrice, appconstant.StatusPending)
+		var prescriptionStatus *string
+		if data.PrescriptionID != nil {
+			pending := appconstant.PrescriptionStatusPending
+			prescriptionStatus = &pending
+		}
+		valueStrings = append(valueStrings, fmt.Sprintf("($%d,$%d,$%d,$%d,$%d,$%d,$%d,$%d,$%d,$%d)", i*10+1, i*10+2, i*10+3, i*10+4, i*10+5, i*10+6, i*10+7, i*10+8, i*10+9, i*10+10))
+		valueArgs = append(valueArgs, orderID, data.PharmacyID, data.LogisticPrice, appconstant.StatusPending, data.PrescriptionID, prescriptionStatus, data.VoucherID, data.Discount, data.ShippingDiscount, data.LogisticPartnerID)
 	}
 	query := fmt.Sprintf(`INSERT INTO order_details(
-	order_id,pharmacy_id, logistic_price, status)
+	order_id,pharmacy_id, logistic_price, status, prescription_id, prescription_status, voucher_id, discount, shipping_discount, logistic_partner_id)
 	VALUES %s RETURNING id`, strings.Join(valueStrings, ","))
 	rows, err := tx.QueryContext(c, query, valueArgs...)
 	if err != nil {
@@ -156,17 +185,53 @@ func (r *checkOutRepoImpl) AddCheckoutOrderDetail(c context.Context, listData []
 	return ids, nil
 }
 
-func (r *checkOutRepoImpl) AddOrder(c context.Context, totalPrice decimal.Decimal, userID int) (int, error) {
+func (r *checkOutRepoImpl) AddOrder(c context.Context, totalPrice decimal.Decimal, userID int, paymentWindow time.Duration, idempotencyKey string) (int, time.Time, error) {
 	tx := transaction.ExtractTx(c)
 	var id int
+	var paymentDeadline time.Time
 	query := `INSERT INTO orders 
-	(user_id, total_price) 
-	VALUES ($1,$2) RETURNING id`
-	err := tx.QueryRowContext(c, query, userID, totalPrice).Scan(&id)
+	(user_id, total_price, payment_deadline, idempotency_key) 
+	VALUES ($1,$2,NOW() + make_interval(secs => $3),$4) RETURNING id, payment_deadline`
+	err := tx.QueryRowContext(c, query, userID, totalPrice, paymentWindow.Seconds(), idempotencyKey).Scan(&id, &paymentDeadline)
+	if err != nil {
+		return -1, paymentDeadline, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return id, paymentDeadline, nil
+}
+
+// SetCheckoutResult stores the placed order with the order itself, so a replay
+// finds it even when the cached result in Redis is gone.
+func (r *checkOutRepoImpl) SetCheckoutResult(c context.Context, order entity.CheckoutOrder) error {
+	tx := transaction.ExtractTx(c)
+	data, err := json.Marshal(order)
 	if err != nil {
-		return -1, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	query := `UPDATE orders SET checkout_result = $2 WHERE id = $1`
+	_, err = tx.ExecContext(c, query, order.OrderID, data)
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+func (r *checkOutRepoImpl) GetCheckoutResult(c context.Context, userID int, idempotencyKey string) (*entity.CheckoutOrder, error) {
+	query := `SELECT checkout_result FROM orders
+				WHERE user_id = $1 AND idempotency_key = $2 AND checkout_result IS NOT NULL`
+	var data []byte
+	err := r.db.QueryRowContext(c, query, userID, idempotencyKey).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
 	}
-	return id, nil
+	var order entity.CheckoutOrder
+	err = json.Unmarshal(data, &order)
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return &order, nil
 }
 
 func (r *checkOutRepoImpl) GetCheckoutCartRedis(c context.Context, cartID string, userID int) (result *entity.ListGroupedCartItem, err error) {
@@ -192,6 +257,54 @@ func (r *checkOutRepoImpl) DeleteCartRedis(c context.Context, cartID string, use
 	return nil
 }
 
+func (r *checkOutRepoImpl) LockCheckoutRedis(c context.Context, userID int, idempotencyKey string) (bool, error) {
+	key := fmt.Sprintf(appconstant.CheckoutIdempotencyRedisKey, userID, idempotencyKey)
+	isLocked, err := r.redisDB.SetNX(c, key, appconstant.CheckoutProcessingMarker, appconstant.CheckoutLockExpiration).Result()
+	if err != nil {
+		return false, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return isLocked, nil
+}
+
+func (r *checkOutRepoImpl) GetCheckoutResultRedis(c context.Context, userID int, idempotencyKey string) (*entity.CheckoutOrder, error) {
+	key := fmt.Sprintf(appconstant.CheckoutIdempotencyRedisKey, userID, idempotencyKey)
+	serializedOrder, err := r.redisDB.Get(c, key).Result()
+	if err == redis.Nil || serializedOrder == appconstant.CheckoutProcessingMarker {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	var order entity.CheckoutOrder
+	err = json.Unmarshal([]byte(serializedOrder), &order)
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return &order, nil
+}
+
+func (r *checkOutRepoImpl) SetCheckoutResultRedis(c context.Context, userID int, idempotencyKey string, order entity.CheckoutOrder) error {
+	key := fmt.Sprintf(appconstant.CheckoutIdempotencyRedisKey, userID, idempotencyKey)
+	data, err := json.Marshal(order)
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	_, err = r.redisDB.Set(c, key, data, appconstant.CheckoutResultExpiration).Result()
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+func (r *checkOutRepoImpl) DeleteCheckoutLockRedis(c context.Context, userID int, idempotencyKey string) error {
+	key := fmt.Sprintf(appconstant.CheckoutIdempotencyRedisKey, userID, idempotencyKey)
+	_, err := r.redisDB.Del(c, key).Result()
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
 func (r *checkOutRepoImpl) IsOrderFromUser(c context.Context, order_id int, user_id int) (bool, error) {
 	var orderFromUser bool
 	query := `SELECT EXISTS (SELECT 1 FROM orders 
@@ -213,13 +326,198 @@ func (r *checkOutRepoImpl) IsOrderExistByID(c context.Context, orderID int, user
 	return exists, nil
 }
 
-func (r *checkOutRepoImpl) CancelOrder(c context.Context, orderID int) error {
-	query := `UPDATE order_details
-				SET 
-					status = $2,
-					updated_at = NOW()
-				WHERE order_id = $1`
-	_, err := r.db.ExecContext(c, query, orderID, appconstant.StatusCancelled)
+func (r *checkOutRepoImpl) GetUncancelledOrderDetailIDs(c context.Context, orderID int) ([]int, error) {
+	tx := transaction.ExtractTx(c)
+
+	orderDetailIDs := []int{}
+
+	query := `SELECT id FROM order_details
+				WHERE order_id = $1 AND status <> $2 AND deleted_at IS NULL
+				ORDER BY id`
+
+	var err error
+	var rows *sql.Rows
+	if tx != nil {
+		rows, err = tx.QueryContext(c, query, orderID, appconstant.StatusCancelled)
+	} else {
+		rows, err = r.db.QueryContext(c, query, orderID, appconstant.StatusCancelled)
+	}
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var orderDetailID int
+		err := rows.Scan(&orderDetailID)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
+		orderDetailIDs = append(orderDetailIDs, orderDetailID)
+	}
+	return orderDetailIDs, nil
+}
+
+func (r *checkOutRepoImpl) GetUserOrderDetail(c context.Context, orderDetailID int, userID int) (*entity.UserOrderDetail, error) {
+	query := `SELECT od.id, od.order_id, od.status
+				FROM order_details od
+				JOIN orders o ON o.id = od.order_id
+				WHERE od.id = $1 AND o.user_id = $2 AND od.deleted_at IS NULL AND o.deleted_at IS NULL`
+
+	var orderDetail entity.UserOrderDetail
+	err := r.db.QueryRowContext(c, query, orderDetailID, userID).Scan(&orderDetail.ID, &orderDetail.OrderID, &orderDetail.Status)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return &orderDetail, nil
+}
+
+func (r *checkOutRepoImpl) RecalculateOrderTotal(c context.Context, orderID int) error {
+	tx := transaction.ExtractTx(c)
+
+	query := `UPDATE orders o
+				SET total_price = COALESCE((
+					SELECT SUM(od.logistic_price - od.discount - od.shipping_discount + COALESCE((
+						SELECT SUM(opd.price)
+						FROM order_product_details opd
+						WHERE opd.order_detail_id = od.id AND opd.deleted_at IS NULL
+					), 0))
+					FROM order_details od
+					WHERE od.order_id = o.id AND od.status <> $2 AND od.deleted_at IS NULL
+				), 0),
+				updated_at = NOW()
+				WHERE o.id = $1`
+
+	var err error
+	if tx != nil {
+		_, err = tx.ExecContext(c, query, orderID, appconstant.StatusCancelled)
+	} else {
+		_, err = r.db.ExecContext(c, query, orderID, appconstant.StatusCancelled)
+	}
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+func (r *checkOutRepoImpl) GetOrderDetailIDsByStatus(c context.Context, orderID int, status string) ([]int, error) {
+	tx := transaction.ExtractTx(c)
+
+	orderDetailIDs := []int{}
+
+	query := `SELECT id FROM order_details
+				WHERE order_id = $1 AND status = $2 AND deleted_at IS NULL
+				ORDER BY id`
+
+	var err error
+	var rows *sql.Rows
+	if tx != nil {
+		rows, err = tx.QueryContext(c, query, orderID, status)
+	} else {
+		rows, err = r.db.QueryContext(c, query, orderID, status)
+	}
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var orderDetailID int
+		err := rows.Scan(&orderDetailID)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
+		orderDetailIDs = append(orderDetailIDs, orderDetailID)
+	}
+	return orderDetailIDs, nil
+}
+
+func (r *checkOutRepoImpl) IsPaymentDeadlinePassed(c context.Context, orderID int) (bool, error) {
+	tx := transaction.ExtractTx(c)
+
+	query := `SELECT EXISTS (SELECT 1 FROM orders
+				WHERE id = $1 AND payment_deadline IS NOT NULL AND payment_deadline <= NOW() AND deleted_at IS NULL)`
+
+	var isPassed bool
+	var err error
+	if tx != nil {
+		err = tx.QueryRowContext(c, query, orderID).Scan(&isPassed)
+	} else {
+		err = r.db.QueryRowContext(c, query, orderID).Scan(&isPassed)
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return isPassed, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return isPassed, nil
+}
+
+func (r *checkOutRepoImpl) IsPrescriptionReviewPending(c context.Context, orderID int) (bool, error) {
+	tx := transaction.ExtractTx(c)
+
+	query := `SELECT EXISTS (SELECT 1 FROM order_details
+				WHERE order_id = $1 AND status = $2 AND prescription_status = $3 AND deleted_at IS NULL)`
+
+	var isPending bool
+	var err error
+	if tx != nil {
+		err = tx.QueryRowContext(c, query, orderID, appconstant.StatusPending, appconstant.PrescriptionStatusPending).Scan(&isPending)
+	} else {
+		err = r.db.QueryRowContext(c, query, orderID, appconstant.StatusPending, appconstant.PrescriptionStatusPending).Scan(&isPending)
+	}
+	if err != nil {
+		return isPending, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return isPending, nil
+}
+
+func (r *checkOutRepoImpl) ExtendPaymentDeadline(c context.Context, orderID int, paymentWindow time.Duration) error {
+	tx := transaction.ExtractTx(c)
+
+	query := `UPDATE orders SET payment_deadline = NOW() + make_interval(secs => $2), updated_at = NOW()
+				WHERE id = $1`
+
+	var err error
+	if tx != nil {
+		_, err = tx.ExecContext(c, query, orderID, paymentWindow.Seconds())
+	} else {
+		_, err = r.db.ExecContext(c, query, orderID, paymentWindow.Seconds())
+	}
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+func (r *checkOutRepoImpl) GetUserEmailByOrderID(c context.Context, orderID int) (string, error) {
+	query := `SELECT u.email FROM orders o JOIN users u ON u.id = o.user_id WHERE o.id = $1`
+
+	var email string
+	err := r.db.QueryRowContext(c, query, orderID).Scan(&email)
+	if err != nil {
+		return "", apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return email, nil
+}
+
+func (r *checkOutRepoImpl) IsPrescriptionFromUser(c context.Context, prescriptionID int, userID int) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM prescriptions WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)`
+	var exists bool
+	err := r.db.QueryRowContext(c, query, prescriptionID, userID).Scan(&exists)
+	if err != nil && err != sql.ErrNoRows {
+		return exists, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return exists, nil
+}
+
+func (r *checkOutRepoImpl) ReleaseStockHolds(c context.Context, cartID string, userID int) error {
+	tx := transaction.ExtractTx(c)
+	query := `UPDATE stock_holds
+				SET deleted_at = NOW(), updated_at = NOW()
+				WHERE cart_id = $1 AND user_id = $2 AND deleted_at IS NULL`
+	_, err := tx.ExecContext(c, query, cartID, userID)
 	if err != nil {
 		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
 	}
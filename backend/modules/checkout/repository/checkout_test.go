@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"montelukast/modules/checkout/entity"
+	"montelukast/pkg/transaction"
+	"os"
+	"sync"
+	"testing"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+const (
+	concurrentBuyers = 50
+	startingStock    = 20
+)
+
+func connectTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	if os.Getenv("DB_HOST") == "" {
+		t.Skip("DB_HOST is not set, skipping database test")
+	}
+	databaseURL := fmt.Sprintf("postgres://%s:%s@%s:%s/%s",
+		os.Getenv("DB_USER_NAME"),
+		os.Getenv("DB_USER_PASSWORD"),
+		os.Getenv("DB_HOST"),
+		os.Getenv("DB_PORT"),
+		os.Getenv("DB_NAME"),
+	)
+
+	db, err := sql.Open("pgx", databaseURL)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	err = db.Ping()
+	if err != nil {
+		db.Close()
+		t.Skipf("database is unreachable, skipping database test: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func addTestPharmacyProduct(t *testing.T, db *sql.DB, stock int) int {
+	t.Helper()
+
+	var pharmacyID, productID int
+	err := db.QueryRow(`SELECT ph.id, p.id FROM pharmacies ph, products p LIMIT 1`).Scan(&pharmacyID, &productID)
+	if err == sql.ErrNoRows {
+		t.Skip("no pharmacy or product seeded, skipping database test")
+	}
+	if err != nil {
+		t.Fatalf("find pharmacy and product: %v", err)
+	}
+
+	var pharmacyProductID int
+	err = db.QueryRow(`INSERT INTO pharmacy_products (pharmacy_id, product_id, stock, price, is_active)
+		VALUES ($1, $2, $3, 1000, TRUE)
+		RETURNING id`, pharmacyID, productID, stock).Scan(&pharmacyProductID)
+	if err != nil {
+		t.Fatalf("add pharmacy product: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM stock_movements WHERE pharmacy_product_id = $1`, pharmacyProductID)
+		db.Exec(`DELETE FROM pharmacy_products WHERE id = $1`, pharmacyProductID)
+	})
+	return pharmacyProductID
+}
+
+func TestDecreaseStockConcurrently(t *testing.T) {
+	db := connectTestDB(t)
+	pharmacyProductID := addTestPharmacyProduct(t, db, startingStock)
+
+	r := NewCheckoutRepo(db, nil)
+	tr := transaction.NewTransactorRepo(db)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	successes := 0
+	for i := 0; i < concurrentBuyers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := tr.WithinTransaction(context.Background(), func(txCtx context.Context) error {
+				isDecreased, err := r.DecreaseStock(txCtx, entity.CartItem{PharmacyProductID: pharmacyProductID, Quantity: 1}, 0)
+				if err != nil || !isDecreased {
+					return err
+				}
+				mu.Lock()
+				successes++
+				mu.Unlock()
+				return nil
+			})
+			if err != nil {
+				t.Errorf("decrease stock: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if successes != startingStock {
+		t.Errorf("successful decreases = %d, want %d", successes, startingStock)
+	}
+
+	var stock int
+	err := db.QueryRow(`SELECT stock FROM pharmacy_products WHERE id = $1`, pharmacyProductID).Scan(&stock)
+	if err != nil {
+		t.Fatalf("get stock: %v", err)
+	}
+	if stock != 0 {
+		t.Errorf("stock = %d, want 0", stock)
+	}
+
+	var movements, minBalance int
+	err = db.QueryRow(`SELECT COUNT(*), COALESCE(MIN(balance), 0) FROM stock_movements WHERE pharmacy_product_id = $1`, pharmacyProductID).Scan(&movements, &minBalance)
+	if err != nil {
+		t.Fatalf("get stock movements: %v", err)
+	}
+	if movements != startingStock {
+		t.Errorf("stock movements = %d, want %d", movements, startingStock)
+	}
+	if minBalance < 0 {
+		t.Errorf("stock went negative, lowest balance = %d", minBalance)
+	}
+}
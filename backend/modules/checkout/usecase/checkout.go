@@ -2,157 +2,387 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"montelukast/modules/checkout/entity"
 	"montelukast/modules/checkout/repository"
+	deliveryEntity "montelukast/modules/delivery/entity"
 	delivery "montelukast/modules/delivery/repository"
+	orderStatusEntity "montelukast/modules/orderstatus/entity"
+	orderStatus "montelukast/modules/orderstatus/usecase"
+	stockMutation "montelukast/modules/stockmutation/usecase"
+	voucherEntity "montelukast/modules/voucher/entity"
+	voucher "montelukast/modules/voucher/usecase"
 	appconstant "montelukast/pkg/constant"
 	apperror "montelukast/pkg/error"
+	"montelukast/pkg/logger"
 	"montelukast/pkg/transaction"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
-	"github.com/shopspring/decimal"
+	"github.com/go-redis/redis/v8"
+	"github.com/resendlabs/resend-go"
+	"github.com/streadway/amqp"
 )
 
 type CheckoutUsecase interface {
-	Checkout(c context.Context, checkoutData entity.CheckoutData, userID int) <-chan error
+	Checkout(c context.Context, checkoutData entity.CheckoutData, userID int, idempotencyKey string) <-chan entity.CheckoutResult
 	CancelOrderByUser(c context.Context, userID int, orderID int) error
+	CancelOrderDetailByUser(c context.Context, userID int, orderDetailID int, reason *string) error
+	CancelUnpaidOrder(c context.Context, orderID int) error
+	PreviewVoucher(c context.Context, checkoutData entity.CheckoutData, userID int) (*entity.CheckoutOrder, error)
 }
 
 type checkoutUsecaseImpl struct {
-	c  repository.CheckoutRepo
-	d  delivery.DeliveryRepository
-	tr transaction.TransactorRepoImpl
+	c        repository.CheckoutRepo
+	d        delivery.DeliveryRepository
+	tr       transaction.TransactorRepoImpl
+	os       orderStatus.OrderStatusUsecase
+	v        voucher.VoucherUsecase
+	sm       stockMutation.StockMutationUsecase
+	rc       *resend.Client
+	rabbitMQ *amqp.Channel
 }
 
-func NewCheckoutUsecase(c repository.CheckoutRepo, d delivery.DeliveryRepository, tr transaction.TransactorRepoImpl) CheckoutUsecase {
+func NewCheckoutUsecase(rabbitMQ *amqp.Channel, c repository.CheckoutRepo, d delivery.DeliveryRepository, tr transaction.TransactorRepoImpl, os orderStatus.OrderStatusUsecase, v voucher.VoucherUsecase, sm stockMutation.StockMutationUsecase, rc *resend.Client) CheckoutUsecase {
 	return checkoutUsecaseImpl{
-		tr: tr,
-		c:  c,
-		d:  d,
+		tr:       tr,
+		c:        c,
+		d:        d,
+		os:       os,
+		v:        v,
+		sm:       sm,
+		rc:       rc,
+		rabbitMQ: rabbitMQ,
 	}
 }
 
-func (u checkoutUsecaseImpl) Checkout(c context.Context, checkoutData entity.CheckoutData, userID int) <-chan error {
-	output := make(chan error)
+func (u checkoutUsecaseImpl) Checkout(c context.Context, checkoutData entity.CheckoutData, userID int, idempotencyKey string) <-chan entity.CheckoutResult {
+	output := make(chan entity.CheckoutResult)
 	go func() {
 		defer close(output)
-		_, addressID, err := u.d.GetUserPostalCode(c, userID)
-		if err != nil {
-			output <- err
-			return
+		if idempotencyKey == "" {
+			idempotencyKey = checkoutData.IDCart
 		}
-		result, err := u.c.GetCheckoutCartRedis(c, checkoutData.IDCart, userID)
+		isLocked, err := u.c.LockCheckoutRedis(c, userID, idempotencyKey)
 		if err != nil {
-			output <- err
-			return
-		}
-		deliveryDict := make(map[int]int)
-		if len(result.GroupedItem) != len(checkoutData.ListDeliveryData) {
-			output <- apperror.NewErrStatusBadRequest(appconstant.FieldErrCheckout, apperror.ErrInvalidDeliveryData, apperror.ErrInvalidDeliveryData)
+			output <- entity.CheckoutResult{Err: err}
 			return
 		}
-		for _, delivery := range checkoutData.ListDeliveryData {
-			if _, ok := deliveryDict[delivery.PharmacyID]; !ok {
-				deliveryDict[delivery.PharmacyID] = delivery.DeliveryID
-			}
-		}
-		var listPrice []entity.DeliveryPriceData
-		for _, pharmacy := range result.GroupedItem {
-			list_ongkir, err := u.d.GetListOngkir(c, addressID, pharmacy.PharmacyID)
+		if !isLocked {
+			order, err := u.c.GetCheckoutResultRedis(c, userID, idempotencyKey)
 			if err != nil {
-				output <- err
+				output <- entity.CheckoutResult{Err: err}
 				return
 			}
-			for _, ongkir := range list_ongkir {
-				data := entity.DeliveryPriceData{
-					PharmacyID:    pharmacy.PharmacyID,
-					LogisticPrice: ongkir.Cost,
-					Status:        appconstant.StatusPending,
-				}
-				if deliveryDict[pharmacy.PharmacyID] == ongkir.Id {
-					listPrice = append(listPrice, data)
+			if order == nil {
+				order, err = u.c.GetCheckoutResult(c, userID, idempotencyKey)
+				if err != nil {
+					output <- entity.CheckoutResult{Err: err}
+					return
 				}
 			}
-		}
-		var totalPrice decimal.Decimal
-		for _, cart := range result.GroupedItem {
-			for _, cost := range listPrice {
-				if cost.PharmacyID == cart.PharmacyID {
-					totalPrice = totalPrice.Add(cost.LogisticPrice)
-				}
+			if order == nil {
+				output <- entity.CheckoutResult{Err: apperror.NewErrStatusConflict(appconstant.FieldErrCheckout, apperror.ErrCheckoutInProgress, apperror.ErrCheckoutInProgress)}
+				return
 			}
-			for _, item := range cart.Items {
-				totalPrice = totalPrice.Add(item.Subtotal)
+			order.IsReplayed = true
+			output <- entity.CheckoutResult{Order: order}
+			return
+		}
+
+		// The Redis lock and result expire, so a late retry has to find the
+		// order stored under the same key before placing a new one.
+		order, err := u.c.GetCheckoutResult(c, userID, idempotencyKey)
+		if err != nil {
+			output <- entity.CheckoutResult{Err: err}
+			return
+		}
+		if order != nil {
+			order.IsReplayed = true
+			output <- entity.CheckoutResult{Order: order}
+			return
+		}
+
+		order, err = u.placeOrder(c, checkoutData, userID, idempotencyKey)
+		if err != nil {
+			deleteErr := u.c.DeleteCheckoutLockRedis(c, userID, idempotencyKey)
+			if deleteErr != nil {
+				logger.Log.Error(deleteErr)
 			}
+			output <- entity.CheckoutResult{Err: err}
+			return
+		}
+		err = u.c.SetCheckoutResultRedis(c, userID, idempotencyKey, *order)
+		if err != nil {
+			logger.Log.Error(err)
 		}
-		err = u.tr.WithinTransaction(c, func(txCtx context.Context) error {
-			idOrder, err := u.c.AddOrder(txCtx, totalPrice, userID)
+		output <- entity.CheckoutResult{Order: order}
+	}()
+	return output
+}
+
+func (u checkoutUsecaseImpl) PreviewVoucher(c context.Context, checkoutData entity.CheckoutData, userID int) (*entity.CheckoutOrder, error) {
+	if checkoutData.VoucherCode == nil {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrApplyVoucher, apperror.ErrVoucherNotExists, apperror.ErrVoucherNotExists)
+	}
+	order, _, listPrice, err := u.buildCheckoutOrder(c, checkoutData, userID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = u.applyVoucher(c, order, listPrice, *checkoutData.VoucherCode, userID)
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (u checkoutUsecaseImpl) placeOrder(c context.Context, checkoutData entity.CheckoutData, userID int, idempotencyKey string) (*entity.CheckoutOrder, error) {
+	order, result, listPrice, err := u.buildCheckoutOrder(c, checkoutData, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	paymentWindow := getPaymentWindow()
+	err = u.tr.WithinTransaction(c, func(txCtx context.Context) error {
+		var discount *voucherEntity.VoucherDiscount
+		if checkoutData.VoucherCode != nil {
+			discount, err = u.applyVoucher(txCtx, order, listPrice, *checkoutData.VoucherCode, userID)
 			if err != nil {
 				return err
 			}
-			ids, err := u.c.AddCheckoutOrderDetail(txCtx, listPrice, idOrder)
+		}
+		order.OrderID, order.PaymentDeadline, err = u.c.AddOrder(txCtx, order.GrandTotal, userID, paymentWindow, idempotencyKey)
+		if err != nil {
+			return err
+		}
+		if discount != nil {
+			err = u.v.RecordUsage(txCtx, *discount, userID, order.OrderID)
 			if err != nil {
 				return err
 			}
-			var productUnavailable []string
-			var productInactive []string
-			for i, pharmacy := range result.GroupedItem {
-				for _, product := range pharmacy.Items {
-					isExists, err := u.c.IsCartItemExistsByIDAndUserID(c, product)
-					if err != nil {
-						return err
-					}
-					if !isExists {
-						return apperror.NewErrStatusNotFound(appconstant.FieldErrDeleteFromCart, apperror.ErrCartItemNotExists, apperror.ErrCartItemNotExists)
-					}
-					err = u.c.DeleteCartItemByID(txCtx, product.ID)
+		}
+		ids, err := u.c.AddCheckoutOrderDetail(txCtx, listPrice, order.OrderID)
+		if err != nil {
+			return err
+		}
+		for i := range order.OrderDetails {
+			order.OrderDetails[i].ID = ids[i]
+		}
+		err = u.os.RecordInitialStatus(txCtx, ids, userID)
+		if err != nil {
+			return err
+		}
+		err = u.c.ReleaseStockHolds(txCtx, checkoutData.IDCart, userID)
+		if err != nil {
+			return err
+		}
+		var conflicts []apperror.StockConflict
+		for i, pharmacy := range result.GroupedItem {
+			for _, product := range pharmacy.Items {
+				isExists, err := u.c.IsCartItemExistsByIDAndUserID(c, product)
+				if err != nil {
+					return err
+				}
+				if !isExists {
+					return apperror.NewErrStatusNotFound(appconstant.FieldErrDeleteFromCart, apperror.ErrCartItemNotExists, apperror.ErrCartItemNotExists)
+				}
+				err = u.c.DeleteCartItemByID(txCtx, product.ID)
+				if err != nil {
+					return err
+				}
+				isDecreased, err := u.c.DecreaseStock(txCtx, product, ids[i])
+				if err != nil {
+					return err
+				}
+				if !isDecreased {
+					available, err := u.c.GetAvailableStock(txCtx, product.PharmacyProductID)
 					if err != nil {
 						return err
 					}
-					err = u.c.UpdateStock(txCtx, product)
+					isCovered, err := u.sm.CoverShortage(txCtx, product.PharmacyProductID, product.Quantity-available)
 					if err != nil {
 						return err
 					}
-					quantity, err := u.c.GetQuantity(txCtx, product.PharmacyProductID)
-					if err != nil {
-						return err
+					if isCovered {
+						isDecreased, err = u.c.DecreaseStock(txCtx, product, ids[i])
+						if err != nil {
+							return err
+						}
 					}
-					is_active, err := u.c.IsActive(txCtx, product.PharmacyProductID)
+				}
+				if !isDecreased {
+					available, err := u.c.GetAvailableStock(txCtx, product.PharmacyProductID)
 					if err != nil {
 						return err
 					}
-					if !is_active {
-						productInactive = append(productInactive, product.Name)
-					}
-					if quantity < 0 {
-						productUnavailable = append(productUnavailable, product.Name)
-					}
-				}
-				err := u.c.AddOrderProductDetails(txCtx, ids[i], pharmacy)
-				if err != nil {
-					return err
+					conflicts = append(conflicts, apperror.StockConflict{
+						PharmacyProductID: product.PharmacyProductID,
+						Name:              product.Name,
+						Requested:         product.Quantity,
+						Available:         available,
+					})
 				}
 			}
-			if len(productInactive) > 0 {
-				return apperror.NewErrStatusBadRequest(fmt.Sprintf("Items not available %s", productInactive), apperror.ErrStockUnavailable, apperror.ErrStockUnavailable)
-			}
-			if len(productUnavailable) > 0 {
-				return apperror.NewErrStatusBadRequest(fmt.Sprintf("Items stock available %s", productUnavailable), apperror.ErrStockUnavailable, apperror.ErrStockUnavailable)
-			}
-			err = u.c.DeleteCartRedis(c, checkoutData.IDCart, userID)
+			err := u.c.AddOrderProductDetails(txCtx, ids[i], pharmacy)
 			if err != nil {
 				return err
 			}
-			return nil
-		})
+		}
+		if len(conflicts) > 0 {
+			return apperror.NewErrStockConflict(appconstant.FieldErrCheckout, conflicts)
+		}
+		err = u.c.SetCheckoutResult(txCtx, *order)
+		if err != nil {
+			return err
+		}
+		err = u.c.DeleteCartRedis(c, checkoutData.IDCart, userID)
+		if err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.PublishDelayedMessage(c, order.OrderID, int(paymentWindow.Milliseconds()))
+	if err != nil {
+		logger.Log.Error(err)
+	}
+	return order, nil
+}
 
+func (u checkoutUsecaseImpl) buildCheckoutOrder(c context.Context, checkoutData entity.CheckoutData, userID int) (*entity.CheckoutOrder, *entity.ListGroupedCartItem, []entity.DeliveryPriceData, error) {
+	_, addressID, err := u.d.GetUserPostalCode(c, userID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	result, err := u.c.GetCheckoutCartRedis(c, checkoutData.IDCart, userID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	deliveryDict := make(map[int]int)
+	prescriptionDict := make(map[int]*int)
+	if len(result.GroupedItem) != len(checkoutData.ListDeliveryData) {
+		return nil, nil, nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrCheckout, apperror.ErrInvalidDeliveryData, apperror.ErrInvalidDeliveryData)
+	}
+	for _, delivery := range checkoutData.ListDeliveryData {
+		if _, ok := deliveryDict[delivery.PharmacyID]; !ok {
+			isAvailable, err := u.d.IsLogisticOptionAvailable(c, delivery.PharmacyID, delivery.DeliveryID)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			if !isAvailable {
+				return nil, nil, nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrCheckout, apperror.ErrInvalidDeliveryData, apperror.ErrInvalidDeliveryData)
+			}
+			deliveryDict[delivery.PharmacyID] = delivery.DeliveryID
+		}
+		if delivery.PrescriptionID != nil {
+			isValid, err := u.c.IsPrescriptionFromUser(c, *delivery.PrescriptionID, userID)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			if !isValid {
+				return nil, nil, nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrCheckout, apperror.ErrPrescriptionNotExists, apperror.ErrPrescriptionNotExists)
+			}
+			prescriptionDict[delivery.PharmacyID] = delivery.PrescriptionID
+		}
+	}
+	var listPrice []entity.DeliveryPriceData
+	for _, pharmacy := range result.GroupedItem {
+		inArea, err := u.d.IsAddressInServiceArea(c, pharmacy.PharmacyID, addressID)
 		if err != nil {
-			output <- err
-			return
+			return nil, nil, nil, err
 		}
-		output <- nil
-	}()
-	return output
+		if !inArea {
+			return nil, nil, nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrCheckout, apperror.ErrAddressOutsideServiceArea, apperror.ErrAddressOutsideServiceArea)
+		}
+		parcel := []deliveryEntity.ParcelItem{}
+		for _, item := range pharmacy.Items {
+			parcel = append(parcel, deliveryEntity.ParcelItem{PharmacyProductID: item.PharmacyProductID, Quantity: item.Quantity})
+		}
+		list_ongkir, err := u.d.GetListOngkir(c, addressID, pharmacy.PharmacyID, parcel)
+		if err == redis.Nil {
+			return nil, nil, nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrCheckout, apperror.ErrShippingQuoteExpired, apperror.ErrShippingQuoteExpired)
+		}
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		for _, ongkir := range list_ongkir {
+			data := entity.DeliveryPriceData{
+				PharmacyID:        pharmacy.PharmacyID,
+				LogisticPartnerID: ongkir.Id,
+				LogisticPrice:     ongkir.Cost,
+				Status:            appconstant.StatusPending,
+				PrescriptionID:    prescriptionDict[pharmacy.PharmacyID],
+			}
+			if deliveryDict[pharmacy.PharmacyID] == ongkir.Id {
+				listPrice = append(listPrice, data)
+			}
+		}
+	}
+	if len(listPrice) != len(result.GroupedItem) {
+		return nil, nil, nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrCheckout, apperror.ErrInvalidDeliveryData, apperror.ErrInvalidDeliveryData)
+	}
+
+	order := entity.CheckoutOrder{}
+	for i, cart := range result.GroupedItem {
+		orderDetail := entity.CheckoutOrderDetail{
+			PharmacyID:   cart.PharmacyID,
+			PharmacyName: cart.PharmacyName,
+			Items:        cart.Items,
+			ShippingCost: listPrice[i].LogisticPrice,
+		}
+		for _, item := range cart.Items {
+			orderDetail.ItemsSubtotal = orderDetail.ItemsSubtotal.Add(item.Subtotal)
+		}
+		orderDetail.Total = orderDetail.ItemsSubtotal.Add(orderDetail.ShippingCost)
+		order.ItemsSubtotal = order.ItemsSubtotal.Add(orderDetail.ItemsSubtotal)
+		order.ShippingCost = order.ShippingCost.Add(orderDetail.ShippingCost)
+		order.OrderDetails = append(order.OrderDetails, orderDetail)
+	}
+	order.GrandTotal = order.ItemsSubtotal.Add(order.ShippingCost)
+
+	return &order, result, listPrice, nil
+}
+
+func (u checkoutUsecaseImpl) applyVoucher(c context.Context, order *entity.CheckoutOrder, listPrice []entity.DeliveryPriceData, code string, userID int) (*voucherEntity.VoucherDiscount, error) {
+	groups := make([]voucherEntity.CartGroup, 0, len(order.OrderDetails))
+	for _, orderDetail := range order.OrderDetails {
+		group := voucherEntity.CartGroup{
+			PharmacyID:   orderDetail.PharmacyID,
+			ShippingCost: orderDetail.ShippingCost,
+		}
+		for _, item := range orderDetail.Items {
+			group.Items = append(group.Items, voucherEntity.CartItem{
+				PharmacyProductID: item.PharmacyProductID,
+				Subtotal:          item.Subtotal,
+			})
+		}
+		groups = append(groups, group)
+	}
+
+	discount, err := u.v.CalculateDiscount(c, code, userID, groups)
+	if err != nil {
+		return nil, err
+	}
+
+	order.VoucherCode = &discount.Code
+	order.Discount = discount.Discount
+	order.ShippingDiscount = discount.ShippingDiscount
+	order.GrandTotal = order.ItemsSubtotal.Add(order.ShippingCost).Sub(order.Discount).Sub(order.ShippingDiscount)
+	for i, groupDiscount := range discount.Groups {
+		order.OrderDetails[i].Discount = groupDiscount.Discount
+		order.OrderDetails[i].ShippingDiscount = groupDiscount.ShippingDiscount
+		order.OrderDetails[i].Total = order.OrderDetails[i].Total.Sub(groupDiscount.Discount).Sub(groupDiscount.ShippingDiscount)
+		listPrice[i].VoucherID = &discount.VoucherID
+		listPrice[i].Discount = groupDiscount.Discount
+		listPrice[i].ShippingDiscount = groupDiscount.ShippingDiscount
+	}
+	return discount, nil
 }
 
 func (u checkoutUsecaseImpl) CancelOrderByUser(c context.Context, userID int, orderID int) error {
@@ -170,9 +400,201 @@ func (u checkoutUsecaseImpl) CancelOrderByUser(c context.Context, userID int, or
 	if !isOrderFromUser {
 		return apperror.NewErrStatusBadRequest(appconstant.FieldErrCancel, apperror.ErrInvalidOrderCancelation, apperror.ErrInvalidOrderCancelation)
 	}
-	err = u.c.CancelOrder(c, orderID)
+	return u.tr.WithinTransaction(c, func(txCtx context.Context) error {
+		orderDetailIDs, err := u.c.GetUncancelledOrderDetailIDs(txCtx, orderID)
+		if err != nil {
+			return err
+		}
+		if len(orderDetailIDs) == 0 {
+			return apperror.NewErrStatusBadRequest(appconstant.FieldErrCancel, apperror.ErrInvalidOrderCancelation, apperror.ErrInvalidOrderCancelation)
+		}
+		for _, orderDetailID := range orderDetailIDs {
+			err = u.os.Transition(txCtx, orderStatusEntity.Transition{
+				OrderDetailID: orderDetailID,
+				To:            appconstant.StatusCancelled,
+				Actor:         appconstant.ActorUser,
+				ActorID:       &userID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (u checkoutUsecaseImpl) CancelOrderDetailByUser(c context.Context, userID int, orderDetailID int, reason *string) error {
+	orderDetail, err := u.c.GetUserOrderDetail(c, orderDetailID, userID)
 	if err != nil {
 		return err
 	}
+	if orderDetail == nil {
+		return apperror.NewErrStatusNotFound(appconstant.FieldErrCancel, apperror.ErrOrderDetailNotExists, apperror.ErrOrderDetailNotExists)
+	}
+	if reason != nil {
+		trimmed := strings.TrimSpace(*reason)
+		reason = &trimmed
+		if trimmed == "" {
+			reason = nil
+		}
+	}
+	if orderDetail.Status == appconstant.StatusProcessing && reason == nil {
+		return apperror.NewErrStatusBadRequest(appconstant.FieldErrCancel, apperror.ErrCancellationReasonRequired, apperror.ErrCancellationReasonRequired)
+	}
+	return u.tr.WithinTransaction(c, func(txCtx context.Context) error {
+		err := u.os.Transition(txCtx, orderStatusEntity.Transition{
+			OrderDetailID: orderDetail.ID,
+			To:            appconstant.StatusCancelled,
+			Actor:         appconstant.ActorUser,
+			ActorID:       &userID,
+			Reason:        reason,
+		})
+		if err != nil {
+			return err
+		}
+		return u.c.RecalculateOrderTotal(txCtx, orderDetail.OrderID)
+	})
+}
+
+// CancelUnpaidOrder cancels what is still unpaid once the payment deadline
+// passes. An order with a prescription still under review cannot be paid yet,
+// so its deadline restarts instead.
+func (u checkoutUsecaseImpl) CancelUnpaidOrder(c context.Context, orderID int) error {
+	reason := appconstant.ReasonPaymentDeadlinePassed
+	paymentWindow := getPaymentWindow()
+	var cancelledIDs []int
+	isExtended := false
+	err := u.tr.WithinTransaction(c, func(txCtx context.Context) error {
+		isPassed, err := u.c.IsPaymentDeadlinePassed(txCtx, orderID)
+		if err != nil {
+			return err
+		}
+		if !isPassed {
+			return nil
+		}
+
+		isAwaitingReview, err := u.c.IsPrescriptionReviewPending(txCtx, orderID)
+		if err != nil {
+			return err
+		}
+		if isAwaitingReview {
+			isExtended = true
+			return u.c.ExtendPaymentDeadline(txCtx, orderID, paymentWindow)
+		}
+
+		orderDetailIDs, err := u.c.GetOrderDetailIDsByStatus(txCtx, orderID, appconstant.StatusPending)
+		if err != nil {
+			return err
+		}
+		for _, orderDetailID := range orderDetailIDs {
+			err = u.os.Transition(txCtx, orderStatusEntity.Transition{
+				OrderDetailID: orderDetailID,
+				To:            appconstant.StatusCancelled,
+				Actor:         appconstant.ActorSystem,
+				Reason:        &reason,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		cancelledIDs = orderDetailIDs
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if isExtended {
+		return u.PublishDelayedMessage(c, orderID, int(paymentWindow.Milliseconds()))
+	}
+	if len(cancelledIDs) == 0 {
+		return nil
+	}
+
+	email, err := u.c.GetUserEmailByOrderID(c, orderID)
+	if err != nil {
+		return err
+	}
+	return u.sendPaymentExpiredEmail(email, orderID)
+}
+
+func (u checkoutUsecaseImpl) PublishDelayedMessage(c context.Context, orderID int, delay int) error {
+	// The delayed message exchange drops delays it cannot hold.
+	if delay > int(appconstant.MaxMessageDelay.Milliseconds()) {
+		delay = int(appconstant.MaxMessageDelay.Milliseconds())
+	}
+	err := u.rabbitMQ.ExchangeDeclare(
+		"payment-deadline-exchange", //name
+		"x-delayed-message",         //type
+		true,                        // durable
+		false,                       // auto-deleted
+		false,                       // internal
+		false,                       // no-wait
+		amqp.Table{
+			"x-delayed-type": "fanout",
+		},
+	)
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrCheckout, apperror.ErrMessageQueue, err)
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"order_id": orderID,
+	})
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrCheckout, apperror.ErrMessageQueue, err)
+	}
+	err = u.rabbitMQ.Publish(
+		"payment-deadline-exchange",
+		"x-delayed-message",
+		false,
+		false,
+		amqp.Publishing{
+			DeliveryMode: amqp.Persistent,
+			ContentType:  "application/json",
+			Body:         body,
+			Headers: amqp.Table{
+				"x-delay": delay,
+			},
+		},
+	)
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrCheckout, apperror.ErrMessageQueue, err)
+	}
 	return nil
 }
+
+func (u checkoutUsecaseImpl) sendPaymentExpiredEmail(email string, orderID int) error {
+	params := &resend.SendEmailRequest{
+		From:    "mediSEAne <no-reply@mediseane.store>",
+		To:      []string{email},
+		Subject: "[mediSEAne] Order Cancelled",
+		Html: fmt.Sprintf(
+			`<p style="font-size:3rem;font-weigth:bold;margin:0px">
+				medi<span style="color:#008081">SEA</span>ne
+			</p>
+			<p style="font-weight:bold">
+				All Your <span style="color:#008081">Healthcare</span> Needs at Your Fingertips
+			</p>
+			<hr>
+			<p style="font-weight:bold">Hello, your order #%d has been cancelled.</p>
+			<p>We did not receive your payment before the payment deadline, so the unpaid items were cancelled.<p>`,
+			orderID,
+		),
+	}
+
+	_, err := u.rc.Emails.Send(params)
+	if err != nil {
+		return apperror.NewErrStatusBadRequest(appconstant.FieldErrCancel, apperror.ErrSendEmail, err)
+	}
+	return nil
+}
+
+func getPaymentWindow() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("PAYMENT_DEADLINE_MINUTES"))
+	if err != nil || minutes <= 0 {
+		return appconstant.DefaultPaymentDeadline
+	}
+	if minutes > int(appconstant.MaxMessageDelay/time.Minute) {
+		return appconstant.MaxMessageDelay
+	}
+	return time.Duration(minutes) * time.Minute
+}
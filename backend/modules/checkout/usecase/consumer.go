@@ -8,18 +8,18 @@ import (
 	"github.com/streadway/amqp"
 )
 
-type RabbitMQConsumer struct {
-	usecase  UserOrderUsecase
+type RabbitMQConsumerPaymentDeadline struct {
+	usecase  CheckoutUsecase
 	rabbitMQ *amqp.Channel
 }
 
-func NewRabbitMQConsumer(rabbitMQ *amqp.Channel, uc UserOrderUsecase) *RabbitMQConsumer {
-	return &RabbitMQConsumer{usecase: uc, rabbitMQ: rabbitMQ}
+func NewRabbitMQConsumerPaymentDeadline(rabbitMQ *amqp.Channel, u CheckoutUsecase) *RabbitMQConsumerPaymentDeadline {
+	return &RabbitMQConsumerPaymentDeadline{usecase: u, rabbitMQ: rabbitMQ}
 }
 
-func (r *RabbitMQConsumer) ConsumeDelayedMessage() {
+func (r *RabbitMQConsumerPaymentDeadline) ConsumeDelayedMessage() {
 	err := r.rabbitMQ.ExchangeDeclare(
-		"update-userorder-exchange", //name
+		"payment-deadline-exchange", //name
 		"x-delayed-message",         //type
 		true,                        // durable
 		false,                       // auto-deleted
@@ -27,13 +27,13 @@ func (r *RabbitMQConsumer) ConsumeDelayedMessage() {
 		false,                       // no-wait
 		amqp.Table{
 			"x-delayed-type": "fanout",
-		}, //arguments
+		},
 	)
 	if err != nil {
 		logger.Log.Error(err)
 	}
 	q, err := r.rabbitMQ.QueueDeclare(
-		"update-userorder-queue",
+		"payment-deadline-queue",
 		true,
 		false,
 		false,
@@ -46,7 +46,7 @@ func (r *RabbitMQConsumer) ConsumeDelayedMessage() {
 	err = r.rabbitMQ.QueueBind(
 		q.Name,                      // queue name
 		"",                          // routing key
-		"update-userorder-exchange", // exchange
+		"payment-deadline-exchange", // exchange
 		false,
 		nil,
 	)
@@ -55,7 +55,7 @@ func (r *RabbitMQConsumer) ConsumeDelayedMessage() {
 	}
 	msgs, err := r.rabbitMQ.Consume(
 		q.Name,
-		"payment_update",
+		"payment_deadline",
 		false, //auto ack
 		false, //exclusive
 		false, //no-local
@@ -64,25 +64,22 @@ func (r *RabbitMQConsumer) ConsumeDelayedMessage() {
 	)
 	if err != nil {
 		logger.Log.Error(err)
-
 	}
 	for d := range msgs {
 		var data struct {
-			OrderDetailIDs []int `json:"order_detail_ids"`
+			OrderID int `json:"order_id"`
 		}
 		err := json.Unmarshal(d.Body, &data)
 		if err != nil {
 			logger.Log.Error(err)
 		}
-		err = r.usecase.UpdatePaymentStatusFromConsumer(context.Background(), data.OrderDetailIDs)
+		err = r.usecase.CancelUnpaidOrder(context.Background(), data.OrderID)
 		if err != nil {
 			logger.Log.Error(err)
-
 		}
 		err = d.Ack(false)
 		if err != nil {
 			logger.Log.Error(err)
-
 		}
 	}
 }
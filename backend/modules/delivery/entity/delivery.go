@@ -1,6 +1,10 @@
 package entity
 
-import "github.com/shopspring/decimal"
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 type (
 	OngkirLocation struct {
@@ -10,7 +14,6 @@ type (
 	}
 
 	CalculateOngkir struct {
-		BaseUrl       string
 		SortingPrice  string
 		OriginID      int
 		DestinationID int
@@ -23,7 +26,32 @@ type (
 		OriginPostalCode      string
 		DestinationPostalCode string
 		Weight                int
-		LogisticPartnerID     int
+		Couriers              []string
+	}
+
+	ParcelItem struct {
+		PharmacyProductID int
+		Quantity          int
+	}
+
+	ParcelProduct struct {
+		PharmacyProductID int
+		Weight            float64
+		Height            float64
+		Length            float64
+		Width             float64
+	}
+
+	InternalRate struct {
+		Price      float64
+		PricePerKg float64
+	}
+
+	LogisticOption struct {
+		ID      int
+		Name    string
+		Courier string
+		Service *string
 	}
 
 	OngkirData struct {
@@ -35,15 +63,51 @@ type (
 
 	OngkirCostResponse struct {
 		Data []struct {
-			Name string          `json:"name"`
-			Cost decimal.Decimal `json:"cost"`
-			Etd  string          `json:"etd"`
+			Name    string          `json:"name"`
+			Code    string          `json:"code"`
+			Service string          `json:"service"`
+			Cost    decimal.Decimal `json:"cost"`
+			Etd     string          `json:"etd"`
 		} `json:"data"`
 	}
 
 	UserCostResponse struct {
-		Name string          `json:"name,omitempty" `
-		Cost decimal.Decimal `json:"cost,omitempty"`
-		Etd  string          `json:"etd,omitempty"`
+		Name    string          `json:"name,omitempty" `
+		Code    string          `json:"code,omitempty"`
+		Service string          `json:"service,omitempty"`
+		Cost    decimal.Decimal `json:"cost,omitempty"`
+		Etd     string          `json:"etd,omitempty"`
+	}
+
+	Shipment struct {
+		OrderDetailID  int
+		Courier        string
+		TrackingNumber string
+		ShippedAt      time.Time
+	}
+
+	TrackingEvent struct {
+		Status      string
+		Description string
+		Location    *string
+		OccurredAt  time.Time
+	}
+
+	WaybillResponse struct {
+		Data struct {
+			Delivered bool `json:"delivered"`
+			Manifest  []struct {
+				Description string `json:"manifest_description"`
+				Date        string `json:"manifest_date"`
+				Time        string `json:"manifest_time"`
+				CityName    string `json:"city_name"`
+			} `json:"manifest"`
+			DeliveryStatus struct {
+				Status      string `json:"status"`
+				PodReceiver string `json:"pod_receiver"`
+				PodDate     string `json:"pod_date"`
+				PodTime     string `json:"pod_time"`
+			} `json:"delivery_status"`
+		} `json:"data"`
 	}
 )
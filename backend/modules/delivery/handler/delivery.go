@@ -47,7 +47,7 @@ func (h *DeliveryHandler) GetOngkirCost(c *gin.Context) {
 	}
 	var dtoOngkir []dto.OngkirResponseDTO
 	var converter converter.OngkirConverterImpl
-	result, err := h.deliveryUsecase.GetAllOngkir(c, userID, pharmacy_id)
+	result, err := h.deliveryUsecase.GetCartOngkir(c, userID, pharmacy_id, c.Query("cart_id"))
 	if err != nil {
 		c.Error(err)
 		return
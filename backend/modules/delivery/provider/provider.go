@@ -0,0 +1,22 @@
+package provider
+
+import (
+	"context"
+	"montelukast/modules/delivery/entity"
+	appconstant "montelukast/pkg/constant"
+)
+
+type ShippingProvider interface {
+	Name() string
+	GetLocationID(c context.Context, postalCode string) (int, error)
+	GetCost(c context.Context, ongkir entity.CalculateOngkir) ([]entity.UserCostResponse, error)
+}
+
+// NewShippingProvider picks the provider by name, falling back to RajaOngkir
+// so a missing setting keeps production behaviour.
+func NewShippingProvider(name string, apiKey string) ShippingProvider {
+	if name == appconstant.ShippingProviderFake {
+		return NewFakeProvider()
+	}
+	return NewRajaOngkirProvider(apiKey)
+}
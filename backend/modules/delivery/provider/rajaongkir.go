@@ -0,0 +1,186 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"montelukast/modules/delivery/entity"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type rajaOngkirProvider struct {
+	apiKey  string
+	client  *http.Client
+	breaker *circuitBreaker
+}
+
+func NewRajaOngkirProvider(apiKey string) *rajaOngkirProvider {
+	return &rajaOngkirProvider{
+		apiKey:  apiKey,
+		client:  &http.Client{Timeout: appconstant.ShippingRequestTimeout},
+		breaker: newCircuitBreaker(appconstant.ShippingBreakerThreshold, appconstant.ShippingBreakerCooldown),
+	}
+}
+
+func (p *rajaOngkirProvider) Name() string {
+	return appconstant.ShippingProviderRajaOngkir
+}
+
+func (p *rajaOngkirProvider) GetLocationID(c context.Context, postalCode string) (int, error) {
+	query := url.Values{}
+	query.Set("search", postalCode)
+
+	var response entity.OngkirLocation
+	err := p.call(c, http.MethodGet, appconstant.URLOngkirLocationID, query, &response)
+	if err != nil {
+		return -1, err
+	}
+	if len(response.Data) == 0 {
+		return -1, apperror.NewErrInternalServerError(appconstant.FieldErrOngkir, apperror.ErrRajaOngkirNoResult, apperror.ErrRajaOngkirNoResult)
+	}
+	return response.Data[0].ID, nil
+}
+
+// GetCost quotes every service of the couriers in ongkir.Courier, which may
+// list several couriers separated by ":".
+func (p *rajaOngkirProvider) GetCost(c context.Context, ongkir entity.CalculateOngkir) ([]entity.UserCostResponse, error) {
+	query := url.Values{}
+	query.Set("origin", strconv.Itoa(ongkir.OriginID))
+	query.Set("destination", strconv.Itoa(ongkir.DestinationID))
+	query.Set("weight", strconv.Itoa(ongkir.Weight))
+	query.Set("price", ongkir.SortingPrice)
+	query.Set("courier", ongkir.Courier)
+
+	var response entity.OngkirCostResponse
+	err := p.call(c, http.MethodPost, appconstant.URLOngkirCost, query, &response)
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Data) == 0 {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrOngkir, apperror.ErrRajaOngkirNoResult, apperror.ErrRajaOngkirNoResult)
+	}
+	results := []entity.UserCostResponse{}
+	for _, data := range response.Data {
+		results = append(results, entity.UserCostResponse{
+			Name:    data.Name,
+			Code:    data.Code,
+			Service: data.Service,
+			Cost:    data.Cost,
+			Etd:     data.Etd,
+		})
+	}
+	return results, nil
+}
+
+// Track reads the waybill manifest. The proof of delivery becomes the
+// delivered event once the courier reports the parcel as delivered.
+func (p *rajaOngkirProvider) Track(c context.Context, shipment entity.Shipment) ([]entity.TrackingEvent, error) {
+	query := url.Values{}
+	query.Set("awb", shipment.TrackingNumber)
+	query.Set("courier", shipment.Courier)
+
+	var response entity.WaybillResponse
+	err := p.call(c, http.MethodPost, appconstant.URLTrackWaybill, query, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []entity.TrackingEvent{}
+	for _, manifest := range response.Data.Manifest {
+		occurredAt, err := time.ParseInLocation(appconstant.TrackingTimeLayout, manifest.Date+" "+manifest.Time, time.Local)
+		if err != nil {
+			continue
+		}
+		event := entity.TrackingEvent{
+			Status:      appconstant.ShipmentEventInTransit,
+			Description: manifest.Description,
+			OccurredAt:  occurredAt,
+		}
+		if manifest.CityName != "" {
+			location := manifest.CityName
+			event.Location = &location
+		}
+		events = append(events, event)
+	}
+
+	if response.Data.Delivered {
+		status := response.Data.DeliveryStatus
+		occurredAt, err := time.ParseInLocation(appconstant.TrackingTimeLayout, status.PodDate+" "+status.PodTime, time.Local)
+		if err != nil {
+			occurredAt = time.Now()
+		}
+		events = append(events, entity.TrackingEvent{
+			Status:      appconstant.ShipmentEventDelivered,
+			Description: fmt.Sprintf("Delivered to %s", status.PodReceiver),
+			OccurredAt:  occurredAt,
+		})
+	}
+	return events, nil
+}
+
+// call retries timeouts, rate limits and server errors with a doubling
+// backoff. Only those failures count towards the breaker, and the whole call
+// counts once, so a single slow request does not open it on its own.
+func (p *rajaOngkirProvider) call(c context.Context, method string, baseUrl string, query url.Values, response interface{}) error {
+	if !p.breaker.allow() {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrOngkir, apperror.ErrShippingUnavailable, apperror.ErrShippingUnavailable)
+	}
+
+	body, isRetryable, err := p.do(c, method, baseUrl+"?"+query.Encode())
+	backoff := appconstant.ShippingRetryBackoff
+	for attempt := 0; err != nil && isRetryable && attempt < appconstant.ShippingMaxRetries; attempt++ {
+		select {
+		case <-c.Done():
+			return apperror.NewErrInternalServerError(appconstant.FieldErrOngkir, apperror.ErrShippingUnavailable, c.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		body, isRetryable, err = p.do(c, method, baseUrl+"?"+query.Encode())
+	}
+	if err != nil && isRetryable {
+		p.breaker.failure()
+		return apperror.NewErrInternalServerError(appconstant.FieldErrOngkir, apperror.ErrShippingUnavailable, err)
+	}
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrOngkir, apperror.ErrInternalServer, err)
+	}
+	p.breaker.success()
+
+	err = json.Unmarshal(body, response)
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrOngkir, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+func (p *rajaOngkirProvider) do(c context.Context, method string, requestUrl string) ([]byte, bool, error) {
+	req, err := http.NewRequestWithContext(c, method, requestUrl, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Add("accept", "application/json")
+	req.Header.Add("key", p.apiKey)
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, c.Err() == nil, err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, true, err
+	}
+	if res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= http.StatusInternalServerError {
+		return nil, true, fmt.Errorf("raja ongkir responded with status %d", res.StatusCode)
+	}
+	if res.StatusCode >= http.StatusBadRequest {
+		return nil, false, fmt.Errorf("raja ongkir responded with status %d", res.StatusCode)
+	}
+	return body, false, nil
+}
@@ -0,0 +1,24 @@
+package provider
+
+import (
+	"context"
+	"montelukast/modules/delivery/entity"
+	appconstant "montelukast/pkg/constant"
+)
+
+// TrackingProvider returns the events a courier has recorded for a shipment.
+// Events may be returned again on every call, so callers must store them
+// idempotently.
+type TrackingProvider interface {
+	Name() string
+	Track(c context.Context, shipment entity.Shipment) ([]entity.TrackingEvent, error)
+}
+
+// NewTrackingProvider picks the provider by name the same way
+// NewShippingProvider does.
+func NewTrackingProvider(name string, apiKey string) TrackingProvider {
+	if name == appconstant.ShippingProviderFake {
+		return NewFakeProvider()
+	}
+	return NewRajaOngkirProvider(apiKey)
+}
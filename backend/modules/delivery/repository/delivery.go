@@ -2,16 +2,16 @@ package repository
 
 import (
 	"context"
+	"crypto/sha1"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"io"
 	"montelukast/modules/delivery/entity"
 	appconstant "montelukast/pkg/constant"
 	apperror "montelukast/pkg/error"
-	"net/http"
-	"os"
-	"strconv"
+	"sort"
+	"strings"
 
 	"github.com/go-redis/redis/v8"
 )
@@ -19,16 +19,18 @@ import (
 type DeliveryRepository interface {
 	IsPostalCodeExist(c context.Context, postalCode string) (exists bool, err error)
 	GetLocationID(c context.Context, postalCode string) (locationID int, err error)
-	GetOngkirLocationID(c context.Context, postal_code string) (locationID int, err error)
-	GetOngkirCost(c context.Context, ongkir entity.CalculateOngkir) (result entity.UserCostResponse, err error)
-	GetListOngkir(c context.Context, addressID int, pharmacyID int) (listOngkir []entity.OngkirData, err error)
+	GetListOngkir(c context.Context, addressID int, pharmacyID int, items []entity.ParcelItem) (listOngkir []entity.OngkirData, err error)
 	AddLocationID(c context.Context, postalCode string, locationID int) (err error)
-	StoreListOngkir(c context.Context, listOngkir []entity.OngkirData, userID int, pharmacyID int) (err error)
-	GetSameDayPrice(c context.Context) (price float64, err error)
-	GetInstantPrice(c context.Context) (price float64, err error)
+	StoreListOngkir(c context.Context, listOngkir []entity.OngkirData, userID int, pharmacyID int, items []entity.ParcelItem) (err error)
+	GetInternalRate(c context.Context, name string) (rate entity.InternalRate, err error)
+	GetParcelProducts(c context.Context, pharmacyID int, items []entity.ParcelItem) (products []entity.ParcelProduct, err error)
+	GetCartParcelItems(c context.Context, userID int, pharmacyID int) (items []entity.ParcelItem, err error)
 	CalculateDistance(c context.Context, pharmacyId int, addressID int) (distance float64, err error)
 	GetUserPostalCode(c context.Context, userID int) (postalCode *string, addressID int, err error)
 	GetPharmacyPostalCode(c context.Context, userID int) (postalCode *string, err error)
+	GetLogisticOptions(c context.Context, pharmacyID int) (options []entity.LogisticOption, err error)
+	IsLogisticOptionAvailable(c context.Context, pharmacyID int, logisticPartnerID int) (isAvailable bool, err error)
+	IsAddressInServiceArea(c context.Context, pharmacyID int, addressID int) (inArea bool, err error)
 }
 
 type deliveryRepository struct {
@@ -77,33 +79,73 @@ func (r *deliveryRepository) GetUserPostalCode(c context.Context, userID int) (p
 	return postalCode, addressID, nil
 }
 
-func (r *deliveryRepository) GetSameDayPrice(c context.Context) (price float64, err error) {
-	query := `SELECT price FROM logistics
+func (r *deliveryRepository) GetInternalRate(c context.Context, name string) (rate entity.InternalRate, err error) {
+	query := `SELECT price, price_per_kg FROM logistics
 		WHERE name = $1
 		AND deleted_at IS NULL`
-	err = r.db.QueryRowContext(c, query, "same day").Scan(&price)
+	err = r.db.QueryRowContext(c, query, name).Scan(&rate.Price, &rate.PricePerKg)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return -1, apperror.NewErrInternalServerError(appconstant.FieldErrAddToCart, apperror.ErrDataNotExists, err)
+			return rate, apperror.NewErrInternalServerError(appconstant.FieldErrOngkir, apperror.ErrDataNotExists, err)
 		}
-		return -1, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		return rate, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
 	}
-	return price, nil
+	return rate, nil
 }
 
-func (r *deliveryRepository) GetInstantPrice(c context.Context) (price float64, err error) {
-	query := `SELECT price FROM logistics
-		WHERE name = $1 
-		AND deleted_at IS NULL`
-	err = r.db.QueryRowContext(c, query, "instant").Scan(&price)
+func (r *deliveryRepository) GetParcelProducts(c context.Context, pharmacyID int, items []entity.ParcelItem) (products []entity.ParcelProduct, err error) {
+	if len(items) == 0 {
+		return products, nil
+	}
+	placeholders := make([]string, 0, len(items))
+	args := []interface{}{pharmacyID}
+	for i, item := range items {
+		placeholders = append(placeholders, fmt.Sprintf("$%d", i+2))
+		args = append(args, item.PharmacyProductID)
+	}
+	query := fmt.Sprintf(`SELECT pp.id, p.weight, p.height, p.length, p.width
+			FROM pharmacy_products pp
+			JOIN products p ON p.id = pp.product_id
+			WHERE pp.pharmacy_id = $1 AND pp.id IN (%s) AND pp.deleted_at IS NULL`, strings.Join(placeholders, ","))
+	rows, err := r.db.QueryContext(c, query, args...)
 	if err != nil {
-		return -1, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var product entity.ParcelProduct
+		err = rows.Scan(&product.PharmacyProductID, &product.Weight, &product.Height, &product.Length, &product.Width)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
+		products = append(products, product)
 	}
-	return price, nil
+	return products, nil
 }
 
-func (r *deliveryRepository) StoreListOngkir(c context.Context, listOngkir []entity.OngkirData, userID int, pharmacyID int) (err error) {
-	key := fmt.Sprintf(appconstant.OngkirRedisKey, userID, pharmacyID)
+func (r *deliveryRepository) GetCartParcelItems(c context.Context, userID int, pharmacyID int) (items []entity.ParcelItem, err error) {
+	query := `SELECT c.pharmacy_product_id, c.quantity
+		FROM carts c
+		JOIN pharmacy_products pp ON pp.id = c.pharmacy_product_id
+		WHERE c.user_id = $1 AND pp.pharmacy_id = $2 AND c.deleted_at IS NULL`
+	rows, err := r.db.QueryContext(c, query, userID, pharmacyID)
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var item entity.ParcelItem
+		err = rows.Scan(&item.PharmacyProductID, &item.Quantity)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (r *deliveryRepository) StoreListOngkir(c context.Context, listOngkir []entity.OngkirData, userID int, pharmacyID int, items []entity.ParcelItem) (err error) {
+	key := fmt.Sprintf(appconstant.OngkirRedisKey, userID, pharmacyID, parcelKey(items))
 	json, err := json.Marshal(listOngkir)
 	if err != nil {
 		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
@@ -115,8 +157,8 @@ func (r *deliveryRepository) StoreListOngkir(c context.Context, listOngkir []ent
 	return nil
 }
 
-func (r *deliveryRepository) GetListOngkir(c context.Context, addressID int, pharmacyID int) (listOngkir []entity.OngkirData, err error) {
-	key := fmt.Sprintf(appconstant.OngkirRedisKey, addressID, pharmacyID)
+func (r *deliveryRepository) GetListOngkir(c context.Context, addressID int, pharmacyID int, items []entity.ParcelItem) (listOngkir []entity.OngkirData, err error) {
+	key := fmt.Sprintf(appconstant.OngkirRedisKey, addressID, pharmacyID, parcelKey(items))
 	value, err := r.redisDB.Get(c, key).Result()
 	if err != nil {
 		if err == redis.Nil {
@@ -128,6 +170,27 @@ func (r *deliveryRepository) GetListOngkir(c context.Context, addressID int, pha
 	return listOngkir, nil
 }
 
+// parcelKey fingerprints the parcel contents, so a quote is only reused for
+// the exact same products and quantities.
+func parcelKey(items []entity.ParcelItem) string {
+	quantities := map[int]int{}
+	ids := []int{}
+	for _, item := range items {
+		if _, ok := quantities[item.PharmacyProductID]; !ok {
+			ids = append(ids, item.PharmacyProductID)
+		}
+		quantities[item.PharmacyProductID] += item.Quantity
+	}
+	sort.Ints(ids)
+
+	contents := make([]string, 0, len(ids))
+	for _, id := range ids {
+		contents = append(contents, fmt.Sprintf("%d:%d", id, quantities[id]))
+	}
+	hash := sha1.Sum([]byte(strings.Join(contents, ",")))
+	return hex.EncodeToString(hash[:])
+}
+
 func (r *deliveryRepository) IsPostalCodeExist(c context.Context, postalCode string) (exists bool, err error) {
 	checkQuery := `SELECT EXISTS (SELECT 1 FROM postal_ongkir WHERE postal_code = $1 AND deleted_at IS NULL) `
 	err = r.db.QueryRowContext(c, checkQuery, postalCode).Scan(&exists)
@@ -159,62 +222,50 @@ func (r *deliveryRepository) AddLocationID(c context.Context, postalCode string,
 	return nil
 }
 
-func (r *deliveryRepository) GetOngkirLocationID(c context.Context, postal_code string) (locationID int, err error) {
-	baseUrl := appconstant.URLOngkirLocationID
-	req, _ := http.NewRequest("GET", baseUrl, nil)
-	req.Header.Add("accept", "application/json")
-	req.Header.Add("key", os.Getenv("RAJA_ONGKIR_API_KEY"))
-	query := req.URL.Query()
-	query.Set("search", postal_code)
-	req.URL.RawQuery = query.Encode()
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return locationID, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
-	}
-	defer res.Body.Close()
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		return locationID, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
-	}
-	var response entity.OngkirLocation
-	err = json.Unmarshal(body, &response)
+func (r *deliveryRepository) GetLogisticOptions(c context.Context, pharmacyID int) (options []entity.LogisticOption, err error) {
+	query := `SELECT lp.id, lp.name, lp.courier, lp.service
+			FROM pharmacies_logistic_partners plp
+			JOIN logistic_partners lp ON lp.id = plp.logistic_partner_id
+			WHERE plp.pharmacy_id = $1 AND lp.is_active = TRUE
+			AND plp.deleted_at IS NULL AND lp.deleted_at IS NULL
+			ORDER BY lp.id`
+	rows, err := r.db.QueryContext(c, query, pharmacyID)
 	if err != nil {
-		return locationID, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
 	}
-	if len(response.Data) == 0 {
-		return locationID, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, apperror.ErrRajaOngkirNoResult)
+	defer rows.Close()
+	for rows.Next() {
+		var option entity.LogisticOption
+		err = rows.Scan(&option.ID, &option.Name, &option.Courier, &option.Service)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
+		options = append(options, option)
 	}
-	locationID = response.Data[0].ID
-	return locationID, nil
+	return options, nil
 }
 
-func (r *deliveryRepository) GetOngkirCost(c context.Context, ongkir entity.CalculateOngkir) (result entity.UserCostResponse, err error) {
-	baseUrl := ongkir.BaseUrl
-	req, _ := http.NewRequest("POST", baseUrl, nil)
-	req.Header.Add("accept", "application/json")
-	req.Header.Add("key", os.Getenv("RAJA_ONGKIR_API_KEY"))
-	query := req.URL.Query()
-
-	query.Set("origin", strconv.Itoa(ongkir.OriginID))
-	query.Set("destination", strconv.Itoa(ongkir.DestinationID))
-	query.Set("weight", strconv.Itoa(ongkir.Weight))
-	query.Set("price", ongkir.SortingPrice)
-	query.Set("courier", ongkir.Courier)
-
-	req.URL.RawQuery = query.Encode()
-	res, err := http.DefaultClient.Do(req)
+func (r *deliveryRepository) IsLogisticOptionAvailable(c context.Context, pharmacyID int, logisticPartnerID int) (isAvailable bool, err error) {
+	query := `SELECT EXISTS (SELECT 1
+			FROM pharmacies_logistic_partners plp
+			JOIN logistic_partners lp ON lp.id = plp.logistic_partner_id
+			WHERE plp.pharmacy_id = $1 AND lp.id = $2 AND lp.is_active = TRUE
+			AND plp.deleted_at IS NULL AND lp.deleted_at IS NULL)`
+	err = r.db.QueryRowContext(c, query, pharmacyID, logisticPartnerID).Scan(&isAvailable)
 	if err != nil {
-		return result, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		return false, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
 	}
-	defer res.Body.Close()
-	body, _ := io.ReadAll(res.Body)
-	var response entity.OngkirCostResponse
-	err = json.Unmarshal(body, &response)
+	return isAvailable, nil
+}
+
+func (r *deliveryRepository) IsAddressInServiceArea(c context.Context, pharmacyID int, addressID int) (inArea bool, err error) {
+	query := fmt.Sprintf(`SELECT EXISTS (SELECT 1
+			FROM pharmacies ph
+			JOIN user_addresses ua ON ua.id = $2 AND ua.deleted_at IS NULL
+			WHERE ph.id = $1 AND ph.deleted_at IS NULL AND %s)`, fmt.Sprintf(appconstant.ServiceAreaCondition, "ua.location"))
+	err = r.db.QueryRowContext(c, query, pharmacyID, addressID).Scan(&inArea)
 	if err != nil {
-		return result, err
+		return false, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
 	}
-	result.Name = response.Data[0].Name
-	result.Cost = response.Data[0].Cost
-	result.Etd = response.Data[0].Etd
-	return result, nil
+	return inArea, nil
 }
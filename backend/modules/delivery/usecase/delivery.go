@@ -3,44 +3,47 @@ package usecase
 import (
 	"context"
 	"fmt"
+	"math"
 	checkoutRepo "montelukast/modules/checkout/repository"
 	"montelukast/modules/delivery/entity"
+	"montelukast/modules/delivery/provider"
 	"montelukast/modules/delivery/repository"
 	appconstant "montelukast/pkg/constant"
 	apperror "montelukast/pkg/error"
+	"montelukast/pkg/logger"
+	"strings"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/shopspring/decimal"
 )
 
 type DeliveryUsecase interface {
-	GetAllOngkir(c context.Context, userID int, pharmacyID int) (ongkirList []entity.OngkirData, err error)
+	GetAllOngkir(c context.Context, userID int, pharmacyID int, items []entity.ParcelItem) (ongkirList []entity.OngkirData, err error)
+	GetCartOngkir(c context.Context, userID int, pharmacyID int, cartID string) (ongkirList []entity.OngkirData, err error)
 }
 
 type deliveryUsecaseImpl struct {
 	d repository.DeliveryRepository
 	c checkoutRepo.CheckoutRepo
+	p provider.ShippingProvider
 }
 
-func NewDeliveryUsecase(d repository.DeliveryRepository, c checkoutRepo.CheckoutRepo) deliveryUsecaseImpl {
+func NewDeliveryUsecase(d repository.DeliveryRepository, c checkoutRepo.CheckoutRepo, p provider.ShippingProvider) deliveryUsecaseImpl {
 	return deliveryUsecaseImpl{
 		d: d,
 		c: c,
+		p: p,
 	}
 }
 
-func (u *deliveryUsecaseImpl) CalculateOngkirNextDay(c context.Context, req entity.OngkirRequest, userID int) (result entity.UserCostResponse, err error) {
-	req.Weight = appconstant.MedicineWeight
-	if req.LogisticPartnerID != appconstant.IDLogisticNextDay {
-		return result, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, apperror.ErrInternalServer)
-	}
+func (u *deliveryUsecaseImpl) CalculateOngkirCouriers(c context.Context, req entity.OngkirRequest) (result []entity.UserCostResponse, err error) {
 	var calculateOngkir entity.CalculateOngkir
 	exists, err := u.d.IsPostalCodeExist(c, req.DestinationPostalCode)
 	if err != nil {
 		return result, err
 	}
 	if !exists {
-		calculateOngkir.DestinationID, err = u.d.GetOngkirLocationID(c, req.DestinationPostalCode)
+		calculateOngkir.DestinationID, err = u.p.GetLocationID(c, req.DestinationPostalCode)
 		if err != nil {
 			return result, err
 		}
@@ -59,7 +62,7 @@ func (u *deliveryUsecaseImpl) CalculateOngkirNextDay(c context.Context, req enti
 		return result, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
 	}
 	if !exists {
-		calculateOngkir.OriginID, err = u.d.GetOngkirLocationID(c, req.OriginPostalCode)
+		calculateOngkir.OriginID, err = u.p.GetLocationID(c, req.OriginPostalCode)
 		if err != nil {
 			return result, err
 
@@ -75,98 +78,247 @@ func (u *deliveryUsecaseImpl) CalculateOngkirNextDay(c context.Context, req enti
 		}
 	}
 	calculateOngkir.Weight = req.Weight
-	calculateOngkir.Courier = appconstant.DefaultCourier
-	calculateOngkir.BaseUrl = appconstant.URLOngkirCost
+	calculateOngkir.Courier = strings.Join(req.Couriers, ":")
 	calculateOngkir.SortingPrice = appconstant.LowestPrice
-	result, err = u.d.GetOngkirCost(c, calculateOngkir)
+	result, err = u.p.GetCost(c, calculateOngkir)
 	if err != nil {
 		return result, err
 	}
 	return result, err
 }
 
-func (u *deliveryUsecaseImpl) GetAllOngkirRedis(c context.Context, addressID int, pharmacyID int) (ongkirList []entity.OngkirData, err error) {
-	result, err := u.d.GetListOngkir(c, addressID, pharmacyID)
+func (u *deliveryUsecaseImpl) GetAllOngkirRedis(c context.Context, addressID int, pharmacyID int, items []entity.ParcelItem) (ongkirList []entity.OngkirData, err error) {
+	result, err := u.d.GetListOngkir(c, addressID, pharmacyID, items)
 	if err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
-func (u *deliveryUsecaseImpl) StoreListOngkir(c context.Context, addressID int, pharmacyID int, ongkirList []entity.OngkirData) (err error) {
-	err = u.d.StoreListOngkir(c, ongkirList, addressID, pharmacyID)
+func (u *deliveryUsecaseImpl) StoreListOngkir(c context.Context, addressID int, pharmacyID int, items []entity.ParcelItem, ongkirList []entity.OngkirData) (err error) {
+	err = u.d.StoreListOngkir(c, ongkirList, addressID, pharmacyID, items)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (u *deliveryUsecaseImpl) GetAllOngkir(c context.Context, userID int, pharmacyID int) (ongkirList []entity.OngkirData, err error) {
+// GetCartOngkir quotes the pharmacy group of a checkout cart. Without a cart
+// ID the pharmacy's items in the user's cart are shipped.
+func (u *deliveryUsecaseImpl) GetCartOngkir(c context.Context, userID int, pharmacyID int, cartID string) (ongkirList []entity.OngkirData, err error) {
+	if cartID == "" {
+		items, err := u.d.GetCartParcelItems(c, userID, pharmacyID)
+		if err != nil {
+			return nil, err
+		}
+		return u.GetAllOngkir(c, userID, pharmacyID, items)
+	}
+
+	cart, err := u.c.GetCheckoutCartRedis(c, cartID, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range cart.GroupedItem {
+		if group.PharmacyID != pharmacyID {
+			continue
+		}
+		items := []entity.ParcelItem{}
+		for _, item := range group.Items {
+			items = append(items, entity.ParcelItem{PharmacyProductID: item.PharmacyProductID, Quantity: item.Quantity})
+		}
+		return u.GetAllOngkir(c, userID, pharmacyID, items)
+	}
+	return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrOngkir, apperror.ErrInvalidDeliveryData, apperror.ErrInvalidDeliveryData)
+}
+
+// GetAllOngkir quotes only the logistic partners assigned to the pharmacy.
+// Instant and Same Day are priced locally by distance, while every RajaOngkir
+// courier is quoted in a single request. Quotes are cached per parcel
+// contents, so changing a quantity always asks for a fresh quote. Addresses
+// outside the pharmacy's service area are never quoted.
+func (u *deliveryUsecaseImpl) GetAllOngkir(c context.Context, userID int, pharmacyID int, items []entity.ParcelItem) (ongkirList []entity.OngkirData, err error) {
 	userPostal, addressID, err := u.d.GetUserPostalCode(c, userID)
 	if err != nil {
 		return nil, err
 	}
-	ongkirList, err = u.GetAllOngkirRedis(c, addressID, pharmacyID)
+	inArea, err := u.d.IsAddressInServiceArea(c, pharmacyID, addressID)
+	if err != nil {
+		return nil, err
+	}
+	if !inArea {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrOngkir, apperror.ErrAddressOutsideServiceArea, apperror.ErrAddressOutsideServiceArea)
+	}
+	ongkirList, err = u.GetAllOngkirRedis(c, addressID, pharmacyID, items)
 	if err != nil && err != redis.Nil {
 		return nil, err
 	}
 	if err == nil {
 		return ongkirList, nil
 	}
-	distance, err := u.d.CalculateDistance(c, pharmacyID, addressID)
+	weight, err := u.calculateParcelWeight(c, pharmacyID, items)
 	if err != nil {
 		return nil, err
 	}
-	// if distance > 25000 {
-	// 	return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrCheckout, apperror.ErrAddressTooFarr, apperror.ErrAddressTooFarr)
-	// }
-	sameDayPrice, err := u.d.GetSameDayPrice(c)
+	options, err := u.d.GetLogisticOptions(c, pharmacyID)
 	if err != nil {
 		return nil, err
 	}
-	instantPrice, err := u.d.GetInstantPrice(c)
+
+	ongkirList = []entity.OngkirData{}
+	couriers := []string{}
+	isQuoteFailed := false
+	for _, option := range options {
+		if option.Courier != appconstant.CourierInstant && option.Courier != appconstant.CourierSameDay {
+			if !containsCourier(couriers, option.Courier) {
+				couriers = append(couriers, option.Courier)
+			}
+			continue
+		}
+		cost, err := u.calculateInternalCost(c, option.Courier, pharmacyID, addressID, weight)
+		if err != nil {
+			return nil, err
+		}
+		ongkirList = append(ongkirList, entity.OngkirData{
+			Id:   option.ID,
+			Name: option.Name,
+			Cost: cost,
+			Etd:  internalEtd(option.Courier),
+		})
+	}
+
+	if len(couriers) > 0 {
+		pharmacyPostal, err := u.d.GetPharmacyPostalCode(c, pharmacyID)
+		if err != nil {
+			return nil, err
+		}
+		data := entity.OngkirRequest{
+			OriginPostalCode:      *pharmacyPostal,
+			DestinationPostalCode: *userPostal,
+			Weight:                weight,
+			Couriers:              couriers,
+		}
+		// Instant and Same Day are priced locally, so they are still offered
+		// while the courier provider is unavailable.
+		results, err := u.CalculateOngkirCouriers(c, data)
+		if err != nil {
+			logger.Log.Error(err)
+			isQuoteFailed = true
+		}
+		for _, option := range options {
+			result, ok := matchCourierService(results, option)
+			if !ok {
+				continue
+			}
+			name := option.Name
+			if option.Service == nil {
+				name = fmt.Sprintf("%s %s", option.Name, result.Service)
+			}
+			ongkirList = append(ongkirList, entity.OngkirData{
+				Id:   option.ID,
+				Name: name,
+				Cost: result.Cost.Round(1),
+				Etd:  result.Etd,
+			})
+		}
+	}
+
+	// A partial list is not cached, so the couriers reappear as soon as the
+	// provider recovers instead of after the cache expires.
+	if isQuoteFailed {
+		return ongkirList, nil
+	}
+	err = u.StoreListOngkir(c, addressID, pharmacyID, items, ongkirList)
 	if err != nil {
 		return nil, err
 	}
-	distanceInKM := distance / 1000
-	calculateSameDay := sameDayPrice * distanceInKM
-	calculateInstant := instantPrice * distanceInKM
-	instantData := entity.OngkirData{
-		Id:   appconstant.IDLogisticPartnerInstantDay,
-		Name: appconstant.Instant,
-		Cost: decimal.NewFromFloat(calculateInstant).Round(0),
-		Etd:  appconstant.EtdInstant,
-	}
-	sameDayData := entity.OngkirData{
-		Id:   appconstant.IDLogisticPartnerSameDay,
-		Name: appconstant.SameDay,
-		Cost: decimal.NewFromFloat(calculateSameDay).Round(0),
-		Etd:  appconstant.EtdSameDay,
-	}
-	ongkirList = append(ongkirList, instantData, sameDayData)
-	pharmacyPostal, err := u.d.GetPharmacyPostalCode(c, pharmacyID)
+	return ongkirList, nil
+}
+
+// calculateParcelWeight returns the billable weight in grams: the larger of
+// the real weight and the volumetric weight of every item in the parcel.
+func (u *deliveryUsecaseImpl) calculateParcelWeight(c context.Context, pharmacyID int, items []entity.ParcelItem) (int, error) {
+	if len(items) == 0 {
+		return 0, apperror.NewErrStatusBadRequest(appconstant.FieldErrOngkir, apperror.ErrCartNotAvailable, apperror.ErrCartNotAvailable)
+	}
+	products, err := u.d.GetParcelProducts(c, pharmacyID, items)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	data := entity.OngkirRequest{
-		OriginPostalCode:      *pharmacyPostal,
-		DestinationPostalCode: *userPostal,
-		Weight:                appconstant.MedicineWeight,
-		LogisticPartnerID:     appconstant.IDLogisticNextDay,
+	productsByID := map[int]entity.ParcelProduct{}
+	for _, product := range products {
+		productsByID[product.PharmacyProductID] = product
 	}
-	nextDayResult, err := u.CalculateOngkirNextDay(c, data, userID)
-	if err == nil {
-		nextDayData := entity.OngkirData{
-			Id:   appconstant.IDLogisticNextDay,
-			Name: nextDayResult.Name,
-			Cost: nextDayResult.Cost.Round(1),
-			Etd:  fmt.Sprint(nextDayResult.Etd),
+
+	var weight, volume float64
+	for _, item := range items {
+		product, ok := productsByID[item.PharmacyProductID]
+		if !ok {
+			return 0, apperror.NewErrStatusBadRequest(appconstant.FieldErrOngkir, apperror.ErrInvalidDeliveryData, apperror.ErrInvalidDeliveryData)
 		}
-		ongkirList = append(ongkirList, nextDayData)
+		weight += product.Weight * float64(item.Quantity)
+		volume += product.Length * product.Width * product.Height * float64(item.Quantity)
 	}
-	err = u.StoreListOngkir(c, addressID, pharmacyID, ongkirList)
+	volumetricWeight := volume / appconstant.VolumetricDivisor * 1000
+
+	billableWeight := int(math.Ceil(math.Max(weight, volumetricWeight)))
+	if billableWeight < appconstant.MinParcelWeight {
+		billableWeight = appconstant.MinParcelWeight
+	}
+	return billableWeight, nil
+}
+
+// calculateInternalCost prices Instant and Same Day by distance, plus a
+// surcharge for every started kilogram when the rate has one.
+func (u *deliveryUsecaseImpl) calculateInternalCost(c context.Context, courier string, pharmacyID int, addressID int, weight int) (decimal.Decimal, error) {
+	distance, err := u.d.CalculateDistance(c, pharmacyID, addressID)
 	if err != nil {
-		return nil, err
+		return decimal.Zero, err
 	}
-	return ongkirList, nil
+	name := appconstant.LogisticNameSameDay
+	if courier == appconstant.CourierInstant {
+		name = appconstant.LogisticNameInstant
+	}
+	rate, err := u.d.GetInternalRate(c, name)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	distanceInKM := distance / 1000
+	kilograms := math.Ceil(float64(weight) / 1000)
+	return decimal.NewFromFloat(rate.Price*distanceInKM + rate.PricePerKg*kilograms).Round(0), nil
+}
+
+func internalEtd(courier string) string {
+	if courier == appconstant.CourierInstant {
+		return appconstant.EtdInstant
+	}
+	return appconstant.EtdSameDay
+}
+
+// matchCourierService finds the quote for a logistic partner. Partners without
+// a service take the cheapest service their courier offers.
+func matchCourierService(results []entity.UserCostResponse, option entity.LogisticOption) (entity.UserCostResponse, bool) {
+	var match entity.UserCostResponse
+	isFound := false
+	for _, result := range results {
+		if !strings.EqualFold(result.Code, option.Courier) {
+			continue
+		}
+		if option.Service != nil && !strings.EqualFold(result.Service, *option.Service) {
+			continue
+		}
+		if !isFound || result.Cost.LessThan(match.Cost) {
+			match = result
+			isFound = true
+		}
+	}
+	return match, isFound
+}
+
+func containsCourier(couriers []string, courier string) bool {
+	for _, c := range couriers {
+		if c == courier {
+			return true
+		}
+	}
+	return false
 }
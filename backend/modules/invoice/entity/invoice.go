@@ -0,0 +1,46 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type Invoice struct {
+	Number           string
+	OrderID          int
+	UserID           int
+	UserName         string
+	UserEmail        string
+	CreatedAt        time.Time
+	Details          []InvoiceDetail
+	ItemsSubtotal    decimal.Decimal
+	ShippingCost     decimal.Decimal
+	Discount         decimal.Decimal
+	ShippingDiscount decimal.Decimal
+	GrandTotal       decimal.Decimal
+}
+
+type InvoiceDetail struct {
+	ID               int
+	PharmacyID       int
+	PharmacyName     string
+	PharmacyAddress  string
+	Status           string
+	LogisticPrice    decimal.Decimal
+	Discount         decimal.Decimal
+	ShippingDiscount decimal.Decimal
+	Items            []InvoiceItem
+}
+
+type InvoiceItem struct {
+	ProductName string
+	Quantity    int
+	UnitPrice   decimal.Decimal
+	Subtotal    decimal.Decimal
+}
+
+type InvoiceFile struct {
+	Name    string
+	Content []byte
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"fmt"
+	"montelukast/modules/invoice/entity"
+	"montelukast/modules/invoice/usecase"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InvoiceHandler struct {
+	u usecase.InvoiceUsecase
+}
+
+func NewInvoiceHandler(u usecase.InvoiceUsecase) InvoiceHandler {
+	return InvoiceHandler{
+		u: u,
+	}
+}
+
+func (h InvoiceHandler) GetUserInvoiceHandler(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	orderID, err := strconv.Atoi(c.Param("order_id"))
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrGetInvoice, apperror.ErrConvertVariableType, err))
+		return
+	}
+
+	invoice, err := h.u.GetUserInvoice(c, orderID, userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	writeInvoice(c, *invoice)
+}
+
+func (h InvoiceHandler) GetPharmacistInvoiceHandler(c *gin.Context) {
+	pharmacistID, err := getUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	orderDetailID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrGetInvoice, apperror.ErrConvertVariableType, err))
+		return
+	}
+
+	invoice, err := h.u.GetPharmacistInvoice(c, orderDetailID, pharmacistID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	writeInvoice(c, *invoice)
+}
+
+func writeInvoice(c *gin.Context, invoice entity.InvoiceFile) {
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, invoice.Name))
+	c.Data(http.StatusOK, "application/pdf", invoice.Content)
+}
+
+func getUserID(c *gin.Context) (int, error) {
+	rawUserID, isExists := c.Get("user_id")
+	if !isExists {
+		return 0, apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, apperror.ErrTokenInvalid)
+	}
+	userID, err := strconv.Atoi(rawUserID.(string))
+	if err != nil {
+		return 0, apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, err)
+	}
+	return userID, nil
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"montelukast/modules/invoice/entity"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+)
+
+type InvoiceRepo interface {
+	GetInvoiceOrder(c context.Context, orderID int) (*entity.Invoice, error)
+	GetInvoiceDetails(c context.Context, orderID int) ([]entity.InvoiceDetail, error)
+	GetInvoiceItems(c context.Context, orderDetailID int) ([]entity.InvoiceItem, error)
+	GetOrderIDByOrderDetailID(c context.Context, orderDetailID int, pharmacyID int) (int, error)
+}
+
+type invoiceRepoImpl struct {
+	db *sql.DB
+}
+
+func NewInvoiceRepo(dbConn *sql.DB) invoiceRepoImpl {
+	return invoiceRepoImpl{
+		db: dbConn,
+	}
+}
+
+func (r invoiceRepoImpl) GetInvoiceOrder(c context.Context, orderID int) (*entity.Invoice, error) {
+	query := `SELECT o.id, o.user_id, u.name, u.email, o.created_at
+				FROM orders o
+				JOIN users u ON u.id = o.user_id
+				WHERE o.id = $1 AND o.deleted_at IS NULL`
+
+	var invoice entity.Invoice
+	err := r.db.QueryRowContext(c, query, orderID).Scan(&invoice.OrderID, &invoice.UserID, &invoice.UserName, &invoice.UserEmail, &invoice.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return &invoice, nil
+}
+
+func (r invoiceRepoImpl) GetInvoiceDetails(c context.Context, orderID int) ([]entity.InvoiceDetail, error) {
+	details := []entity.InvoiceDetail{}
+
+	query := `SELECT od.id, od.pharmacy_id, ph.name, CONCAT(ph.address, ', ', ph.city, ', ', ph.province, ' ', ph.postal_code),
+				od.status, od.logistic_price, od.discount, od.shipping_discount
+				FROM order_details od
+				JOIN pharmacies ph ON ph.id = od.pharmacy_id
+				WHERE od.order_id = $1 AND od.deleted_at IS NULL
+				ORDER BY od.id`
+
+	rows, err := r.db.QueryContext(c, query, orderID)
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var detail entity.InvoiceDetail
+		err := rows.Scan(&detail.ID, &detail.PharmacyID, &detail.PharmacyName, &detail.PharmacyAddress,
+			&detail.Status, &detail.LogisticPrice, &detail.Discount, &detail.ShippingDiscount)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
+		details = append(details, detail)
+	}
+	return details, nil
+}
+
+func (r invoiceRepoImpl) GetInvoiceItems(c context.Context, orderDetailID int) ([]entity.InvoiceItem, error) {
+	items := []entity.InvoiceItem{}
+
+	query := `SELECT p.name, opd.quantity, opd.price
+				FROM order_product_details opd
+				JOIN pharmacy_products pp ON pp.id = opd.pharmacy_product_id
+				JOIN products p ON p.id = pp.product_id
+				WHERE opd.order_detail_id = $1 AND opd.deleted_at IS NULL
+				ORDER BY opd.id`
+
+	rows, err := r.db.QueryContext(c, query, orderDetailID)
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item entity.InvoiceItem
+		err := rows.Scan(&item.ProductName, &item.Quantity, &item.Subtotal)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (r invoiceRepoImpl) GetOrderIDByOrderDetailID(c context.Context, orderDetailID int, pharmacyID int) (int, error) {
+	query := `SELECT order_id FROM order_details WHERE id = $1 AND pharmacy_id = $2 AND deleted_at IS NULL`
+
+	var orderID int
+	err := r.db.QueryRowContext(c, query, orderDetailID, pharmacyID).Scan(&orderID)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return orderID, nil
+}
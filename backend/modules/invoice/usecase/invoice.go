@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"montelukast/modules/invoice/entity"
+	"montelukast/modules/invoice/repository"
+	pharmacistRepo "montelukast/modules/pharmacist/repository"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+
+	"github.com/shopspring/decimal"
+)
+
+type InvoiceUsecase interface {
+	GetUserInvoice(c context.Context, orderID int, userID int) (*entity.InvoiceFile, error)
+	GetPharmacistInvoice(c context.Context, orderDetailID int, pharmacistID int) (*entity.InvoiceFile, error)
+}
+
+type invoiceUsecaseImpl struct {
+	r   repository.InvoiceRepo
+	phr pharmacistRepo.PharmacistRepo
+}
+
+func NewInvoiceUsecase(r repository.InvoiceRepo, phr pharmacistRepo.PharmacistRepo) invoiceUsecaseImpl {
+	return invoiceUsecaseImpl{
+		r:   r,
+		phr: phr,
+	}
+}
+
+func (u invoiceUsecaseImpl) GetUserInvoice(c context.Context, orderID int, userID int) (*entity.InvoiceFile, error) {
+	invoice, err := u.r.GetInvoiceOrder(c, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if invoice == nil || invoice.UserID != userID {
+		return nil, apperror.NewErrStatusNotFound(appconstant.FieldErrGetInvoice, apperror.ErrOrderNotExists, apperror.ErrOrderNotExists)
+	}
+
+	details, err := u.r.GetInvoiceDetails(c, orderID)
+	if err != nil {
+		return nil, err
+	}
+	invoice.Number = fmt.Sprintf(appconstant.InvoiceNumberFormat, invoice.CreatedAt.Format("20060102"), invoice.OrderID)
+	err = u.fillInvoice(c, invoice, details)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.InvoiceFile{
+		Name:    fmt.Sprintf(appconstant.InvoiceFileNameFormat, invoice.OrderID),
+		Content: renderInvoice(*invoice),
+	}, nil
+}
+
+func (u invoiceUsecaseImpl) GetPharmacistInvoice(c context.Context, orderDetailID int, pharmacistID int) (*entity.InvoiceFile, error) {
+	pharmacyID, err := u.phr.GetPharmacyIDByPharmacistID(c, pharmacistID)
+	if err != nil {
+		return nil, err
+	}
+	if pharmacyID == nil {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrGetInvoice, apperror.ErrPharmacistNotHasPharmacy, apperror.ErrPharmacistNotHasPharmacy)
+	}
+
+	orderID, err := u.r.GetOrderIDByOrderDetailID(c, orderDetailID, *pharmacyID)
+	if err != nil {
+		return nil, err
+	}
+	if orderID == 0 {
+		return nil, apperror.NewErrStatusNotFound(appconstant.FieldErrGetInvoice, apperror.ErrOrderDetailNotExists, apperror.ErrOrderDetailNotExists)
+	}
+
+	invoice, err := u.r.GetInvoiceOrder(c, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if invoice == nil {
+		return nil, apperror.NewErrStatusNotFound(appconstant.FieldErrGetInvoice, apperror.ErrOrderDetailNotExists, apperror.ErrOrderDetailNotExists)
+	}
+
+	allDetails, err := u.r.GetInvoiceDetails(c, orderID)
+	if err != nil {
+		return nil, err
+	}
+	details := []entity.InvoiceDetail{}
+	for _, detail := range allDetails {
+		if detail.ID == orderDetailID {
+			details = append(details, detail)
+		}
+	}
+	invoice.Number = fmt.Sprintf(appconstant.InvoiceDetailNumberFormat, invoice.CreatedAt.Format("20060102"), invoice.OrderID, orderDetailID)
+	err = u.fillInvoice(c, invoice, details)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.InvoiceFile{
+		Name:    fmt.Sprintf(appconstant.InvoiceDetailFileNameFormat, invoice.OrderID, orderDetailID),
+		Content: renderInvoice(*invoice),
+	}, nil
+}
+
+func (u invoiceUsecaseImpl) fillInvoice(c context.Context, invoice *entity.Invoice, details []entity.InvoiceDetail) error {
+	for i := range details {
+		items, err := u.r.GetInvoiceItems(c, details[i].ID)
+		if err != nil {
+			return err
+		}
+		for j := range items {
+			if items[j].Quantity > 0 {
+				items[j].UnitPrice = items[j].Subtotal.Div(decimal.NewFromInt(int64(items[j].Quantity))).Round(2)
+			}
+		}
+		details[i].Items = items
+
+		if details[i].Status == appconstant.StatusCancelled {
+			continue
+		}
+		invoice.ItemsSubtotal = invoice.ItemsSubtotal.Add(detailSubtotal(details[i]))
+		invoice.ShippingCost = invoice.ShippingCost.Add(details[i].LogisticPrice)
+		invoice.Discount = invoice.Discount.Add(details[i].Discount)
+		invoice.ShippingDiscount = invoice.ShippingDiscount.Add(details[i].ShippingDiscount)
+	}
+	invoice.Details = details
+	invoice.GrandTotal = invoice.ItemsSubtotal.Add(invoice.ShippingCost).Sub(invoice.Discount).Sub(invoice.ShippingDiscount)
+	return nil
+}
+
+func detailSubtotal(detail entity.InvoiceDetail) decimal.Decimal {
+	subtotal := decimal.Zero
+	for _, item := range detail.Items {
+		subtotal = subtotal.Add(item.Subtotal)
+	}
+	return subtotal
+}
+
+func detailTotal(detail entity.InvoiceDetail) decimal.Decimal {
+	return detailSubtotal(detail).Add(detail.LogisticPrice).Sub(detail.Discount).Sub(detail.ShippingDiscount)
+}
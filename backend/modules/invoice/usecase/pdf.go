@@ -0,0 +1,146 @@
+package usecase
+
+import (
+	"fmt"
+	"montelukast/modules/invoice/entity"
+	appconstant "montelukast/pkg/constant"
+	"montelukast/pkg/pdfgenerator"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	marginLeft      = 40.0
+	marginRight     = pdfgenerator.PageWidth - 40
+	marginBottom    = pdfgenerator.PageHeight - 60
+	columnQuantity  = 330.0
+	columnUnitPrice = 440.0
+	lineHeight      = 16.0
+	productNameMax  = 48
+)
+
+type invoiceWriter struct {
+	doc *pdfgenerator.Document
+	y   float64
+}
+
+func renderInvoice(invoice entity.Invoice) []byte {
+	w := invoiceWriter{doc: pdfgenerator.NewDocument()}
+	w.doc.AddPage()
+	w.y = 60
+
+	w.doc.Text(marginLeft, w.y, 20, true, "INVOICE")
+	w.doc.TextRight(marginRight, w.y, 10, true, invoice.Number)
+	w.y += lineHeight
+	w.doc.TextRight(marginRight, w.y, 10, false, "Date: "+invoice.CreatedAt.Format("02 January 2006"))
+	w.y += lineHeight * 2
+
+	w.doc.Text(marginLeft, w.y, 10, true, "Billed to")
+	w.y += lineHeight
+	w.doc.Text(marginLeft, w.y, 10, false, invoice.UserName)
+	w.y += lineHeight
+	w.doc.Text(marginLeft, w.y, 10, false, invoice.UserEmail)
+	w.y += lineHeight
+	w.doc.Text(marginLeft, w.y, 10, false, "Order ID: "+strconv.Itoa(invoice.OrderID))
+	w.y += lineHeight * 2
+
+	for _, detail := range invoice.Details {
+		w.writeDetail(detail)
+	}
+
+	w.ensureSpace(lineHeight * 6)
+	w.doc.Line(marginLeft, w.y, marginRight, w.y)
+	w.y += lineHeight
+	w.writeAmount("Items subtotal", invoice.ItemsSubtotal, false)
+	w.writeAmount("Shipping", invoice.ShippingCost, false)
+	if invoice.Discount.IsPositive() {
+		w.writeAmount("Discount", invoice.Discount.Neg(), false)
+	}
+	if invoice.ShippingDiscount.IsPositive() {
+		w.writeAmount("Shipping discount", invoice.ShippingDiscount.Neg(), false)
+	}
+	w.writeAmount("Grand total", invoice.GrandTotal, true)
+
+	return w.doc.Bytes()
+}
+
+func (w *invoiceWriter) writeDetail(detail entity.InvoiceDetail) {
+	w.ensureSpace(lineHeight * 6)
+	title := detail.PharmacyName
+	if detail.Status == appconstant.StatusCancelled {
+		title += " (" + appconstant.StatusCancelled + ")"
+	}
+	w.doc.Text(marginLeft, w.y, 12, true, title)
+	w.y += lineHeight
+	w.doc.Text(marginLeft, w.y, 9, false, detail.PharmacyAddress)
+	w.y += lineHeight * 1.5
+
+	w.doc.Text(marginLeft, w.y, 10, true, "Product")
+	w.doc.TextRight(columnQuantity, w.y, 10, true, "Qty")
+	w.doc.TextRight(columnUnitPrice, w.y, 10, true, "Unit Price")
+	w.doc.TextRight(marginRight, w.y, 10, true, "Subtotal")
+	w.y += 6
+	w.doc.Line(marginLeft, w.y, marginRight, w.y)
+	w.y += lineHeight
+
+	for _, item := range detail.Items {
+		w.ensureSpace(lineHeight)
+		w.doc.Text(marginLeft, w.y, 10, false, truncate(item.ProductName, productNameMax))
+		w.doc.TextRight(columnQuantity, w.y, 10, false, strconv.Itoa(item.Quantity))
+		w.doc.TextRight(columnUnitPrice, w.y, 10, false, formatRupiah(item.UnitPrice))
+		w.doc.TextRight(marginRight, w.y, 10, false, formatRupiah(item.Subtotal))
+		w.y += lineHeight
+	}
+
+	w.ensureSpace(lineHeight * 4)
+	w.writeAmount("Shipping", detail.LogisticPrice, false)
+	if detail.Discount.IsPositive() {
+		w.writeAmount("Discount", detail.Discount.Neg(), false)
+	}
+	if detail.ShippingDiscount.IsPositive() {
+		w.writeAmount("Shipping discount", detail.ShippingDiscount.Neg(), false)
+	}
+	w.writeAmount("Pharmacy total", detailTotal(detail), true)
+	w.y += lineHeight
+}
+
+func (w *invoiceWriter) writeAmount(label string, amount decimal.Decimal, bold bool) {
+	w.ensureSpace(lineHeight)
+	w.doc.TextRight(columnUnitPrice, w.y, 10, bold, label)
+	w.doc.TextRight(marginRight, w.y, 10, bold, formatRupiah(amount))
+	w.y += lineHeight
+}
+
+func (w *invoiceWriter) ensureSpace(height float64) {
+	if w.y+height <= marginBottom {
+		return
+	}
+	w.doc.AddPage()
+	w.y = 60
+}
+
+func truncate(text string, max int) string {
+	if len(text) <= max {
+		return text
+	}
+	return text[:max-3] + "..."
+}
+
+func formatRupiah(amount decimal.Decimal) string {
+	sign := ""
+	if amount.IsNegative() {
+		sign = "-"
+		amount = amount.Abs()
+	}
+	parts := strings.Split(amount.StringFixed(2), ".")
+	integer := parts[0]
+	var grouped []string
+	for len(integer) > 3 {
+		grouped = append([]string{integer[len(integer)-3:]}, grouped...)
+		integer = integer[:len(integer)-3]
+	}
+	grouped = append([]string{integer}, grouped...)
+	return fmt.Sprintf("%sRp %s,%s", sign, strings.Join(grouped, "."), parts[1])
+}
@@ -0,0 +1,29 @@
+package converter
+
+import (
+	"montelukast/modules/logisticpartner/dto"
+	"montelukast/modules/logisticpartner/entity"
+)
+
+type LogisticPartnerRequestConverter struct{}
+
+func (c LogisticPartnerRequestConverter) ToEntity(logisticPartnerReq dto.LogisticPartnerRequest) entity.LogisticPartner {
+	return entity.LogisticPartner{
+		Name:     logisticPartnerReq.Name,
+		Courier:  logisticPartnerReq.Courier,
+		Service:  logisticPartnerReq.Service,
+		IsActive: logisticPartnerReq.IsActive,
+	}
+}
+
+type LogisticPartnerResponseConverter struct{}
+
+func (c LogisticPartnerResponseConverter) ToDto(logisticPartner entity.LogisticPartner) dto.LogisticPartnerResponse {
+	return dto.LogisticPartnerResponse{
+		ID:       logisticPartner.ID,
+		Name:     logisticPartner.Name,
+		Courier:  logisticPartner.Courier,
+		Service:  logisticPartner.Service,
+		IsActive: logisticPartner.IsActive,
+	}
+}
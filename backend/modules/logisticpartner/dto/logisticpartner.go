@@ -0,0 +1,20 @@
+package dto
+
+type LogisticPartnerRequest struct {
+	Name     string  `json:"name" binding:"required"`
+	Courier  string  `json:"courier" binding:"required,oneof=instant same_day jne pos tiki"`
+	Service  *string `json:"service"`
+	IsActive bool    `json:"is_active"`
+}
+
+type LogisticPartnerResponse struct {
+	ID       int     `json:"id"`
+	Name     string  `json:"name"`
+	Courier  string  `json:"courier"`
+	Service  *string `json:"service"`
+	IsActive bool    `json:"is_active"`
+}
+
+type PharmacyLogisticPartnersRequest struct {
+	LogisticPartnerIDs []int `json:"logistic_partner_ids" binding:"required,dive,gte=1"`
+}
@@ -0,0 +1,14 @@
+package entity
+
+type LogisticPartner struct {
+	ID       int
+	Name     string
+	Courier  string
+	Service  *string
+	IsActive bool
+}
+
+type PharmacyLogisticPartners struct {
+	PharmacyID         int
+	LogisticPartnerIDs []int
+}
@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"montelukast/modules/logisticpartner/converter"
+	"montelukast/modules/logisticpartner/dto"
+	"montelukast/modules/logisticpartner/entity"
+	"montelukast/modules/logisticpartner/usecase"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"montelukast/pkg/wrapper"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LogisticPartnerHandler struct {
+	u usecase.LogisticPartnerUsecase
+}
+
+func NewLogisticPartnerHandler(u usecase.LogisticPartnerUsecase) LogisticPartnerHandler {
+	return LogisticPartnerHandler{
+		u: u,
+	}
+}
+
+func (h LogisticPartnerHandler) AddLogisticPartnerHandler(c *gin.Context) {
+	err := apperror.JsonValidator(c)
+	if err != nil {
+		err := apperror.NewErrStatusBadRequest(appconstant.FieldErrAddLogisticPartner, apperror.ErrInvalidJSON, err)
+		c.Error(err)
+		return
+	}
+	logisticPartnerReq := dto.LogisticPartnerRequest{}
+
+	err = c.ShouldBindJSON(&logisticPartnerReq)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = h.u.AddLogisticPartner(c, converter.LogisticPartnerRequestConverter{}.ToEntity(logisticPartnerReq))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(nil, "add logistic partner success!", nil)
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h LogisticPartnerHandler) UpdateLogisticPartnerHandler(c *gin.Context) {
+	logisticPartnerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		err = apperror.NewErrStatusBadRequest(appconstant.FieldErrUpdateLogisticPartner, apperror.ErrConvertVariableType, err)
+		c.Error(err)
+		return
+	}
+
+	err = apperror.JsonValidator(c)
+	if err != nil {
+		err := apperror.NewErrStatusBadRequest(appconstant.FieldErrUpdateLogisticPartner, apperror.ErrInvalidJSON, err)
+		c.Error(err)
+		return
+	}
+	logisticPartnerReq := dto.LogisticPartnerRequest{}
+
+	err = c.ShouldBindJSON(&logisticPartnerReq)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	logisticPartner := converter.LogisticPartnerRequestConverter{}.ToEntity(logisticPartnerReq)
+	logisticPartner.ID = logisticPartnerID
+	err = h.u.UpdateLogisticPartner(c, logisticPartner)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(nil, "update logistic partner success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h LogisticPartnerHandler) DeleteLogisticPartnerHandler(c *gin.Context) {
+	logisticPartnerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		err = apperror.NewErrStatusBadRequest(appconstant.FieldErrDeleteLogisticPartner, apperror.ErrConvertVariableType, err)
+		c.Error(err)
+		return
+	}
+
+	err = h.u.DeleteLogisticPartner(c, logisticPartnerID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(nil, "delete logistic partner success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h LogisticPartnerHandler) GetLogisticPartnerHandler(c *gin.Context) {
+	logisticPartnerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		err = apperror.NewErrStatusBadRequest(appconstant.FieldErrGetLogisticPartners, apperror.ErrConvertVariableType, err)
+		c.Error(err)
+		return
+	}
+
+	logisticPartner, err := h.u.GetLogisticPartner(c, logisticPartnerID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(converter.LogisticPartnerResponseConverter{}.ToDto(*logisticPartner), "get logistic partner success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h LogisticPartnerHandler) GetLogisticPartnersHandler(c *gin.Context) {
+	logisticPartners, err := h.u.GetLogisticPartners(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(toLogisticPartnerResponses(logisticPartners), "get logistic partners success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h LogisticPartnerHandler) GetPharmacyLogisticPartnersHandler(c *gin.Context) {
+	pharmacyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		err = apperror.NewErrStatusBadRequest(appconstant.FieldErrGetLogisticPartners, apperror.ErrConvertVariableType, err)
+		c.Error(err)
+		return
+	}
+
+	logisticPartners, err := h.u.GetPharmacyLogisticPartners(c, pharmacyID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(toLogisticPartnerResponses(logisticPartners), "get pharmacy logistic partners success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h LogisticPartnerHandler) UpdatePharmacyLogisticPartnersHandler(c *gin.Context) {
+	pharmacyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		err = apperror.NewErrStatusBadRequest(appconstant.FieldErrSetPharmacyLogistics, apperror.ErrConvertVariableType, err)
+		c.Error(err)
+		return
+	}
+
+	err = apperror.JsonValidator(c)
+	if err != nil {
+		err := apperror.NewErrStatusBadRequest(appconstant.FieldErrSetPharmacyLogistics, apperror.ErrInvalidJSON, err)
+		c.Error(err)
+		return
+	}
+	pharmacyLogisticsReq := dto.PharmacyLogisticPartnersRequest{}
+
+	err = c.ShouldBindJSON(&pharmacyLogisticsReq)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = h.u.SetPharmacyLogisticPartners(c, entity.PharmacyLogisticPartners{
+		PharmacyID:         pharmacyID,
+		LogisticPartnerIDs: pharmacyLogisticsReq.LogisticPartnerIDs,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(nil, "update pharmacy logistic partners success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func toLogisticPartnerResponses(logisticPartners []entity.LogisticPartner) []dto.LogisticPartnerResponse {
+	responses := []dto.LogisticPartnerResponse{}
+	for _, logisticPartner := range logisticPartners {
+		responses = append(responses, converter.LogisticPartnerResponseConverter{}.ToDto(logisticPartner))
+	}
+	return responses
+}
@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"montelukast/modules/logisticpartner/entity"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"montelukast/pkg/transaction"
+	"strings"
+)
+
+type LogisticPartnerRepo interface {
+	AddLogisticPartner(c context.Context, logisticPartner entity.LogisticPartner) error
+	UpdateLogisticPartner(c context.Context, logisticPartner entity.LogisticPartner) error
+	DeleteLogisticPartnerByID(c context.Context, id int) error
+	IsLogisticPartnerExistsByID(c context.Context, id int) (bool, error)
+	IsCourierServiceExists(c context.Context, courier string, service *string, excludeID int) (bool, error)
+	GetLogisticPartnerByID(c context.Context, id int) (*entity.LogisticPartner, error)
+	GetLogisticPartners(c context.Context) ([]entity.LogisticPartner, error)
+	CountLogisticPartners(c context.Context, ids []int) (int, error)
+	IsPharmacyExistsByID(c context.Context, id int) (bool, error)
+	GetPharmacyLogisticPartners(c context.Context, pharmacyID int) ([]entity.LogisticPartner, error)
+	DeletePharmacyLogisticPartners(c context.Context, pharmacyID int) error
+	AddPharmacyLogisticPartners(c context.Context, pharmacyLogistics entity.PharmacyLogisticPartners) error
+}
+
+type logisticPartnerRepoImpl struct {
+	db *sql.DB
+}
+
+func NewLogisticPartnerRepo(dbConn *sql.DB) logisticPartnerRepoImpl {
+	return logisticPartnerRepoImpl{
+		db: dbConn,
+	}
+}
+
+func (r logisticPartnerRepoImpl) AddLogisticPartner(c context.Context, logisticPartner entity.LogisticPartner) error {
+	query := `INSERT INTO logistic_partners (name, courier, service, is_active)
+			VALUES ($1, $2, $3, $4)`
+
+	_, err := r.db.ExecContext(c, query, logisticPartner.Name, logisticPartner.Courier, logisticPartner.Service, logisticPartner.IsActive)
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+func (r logisticPartnerRepoImpl) UpdateLogisticPartner(c context.Context, logisticPartner entity.LogisticPartner) error {
+	query := `UPDATE logistic_partners
+			SET name = $2, courier = $3, service = $4, is_active = $5, updated_at = NOW()
+			WHERE id = $1 AND deleted_at IS NULL`
+
+	_, err := r.db.ExecContext(c, query, logisticPartner.ID, logisticPartner.Name, logisticPartner.Courier, logisticPartner.Service, logisticPartner.IsActive)
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+// DeleteLogisticPartnerByID also detaches the partner from every pharmacy so
+// it stops showing up in their shipping options.
+func (r logisticPartnerRepoImpl) DeleteLogisticPartnerByID(c context.Context, id int) error {
+	tx := transaction.ExtractTx(c)
+
+	query := `UPDATE pharmacies_logistic_partners SET deleted_at = NOW() WHERE logistic_partner_id = $1 AND deleted_at IS NULL`
+	_, err := tx.ExecContext(c, query, id)
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+
+	query = `UPDATE logistic_partners SET deleted_at = NOW() WHERE id = $1`
+	_, err = tx.ExecContext(c, query, id)
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+func (r logisticPartnerRepoImpl) IsLogisticPartnerExistsByID(c context.Context, id int) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM logistic_partners WHERE id = $1 AND deleted_at IS NULL)`
+
+	var isExists bool
+	err := r.db.QueryRowContext(c, query, id).Scan(&isExists)
+	if err != nil {
+		return isExists, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return isExists, nil
+}
+
+func (r logisticPartnerRepoImpl) IsCourierServiceExists(c context.Context, courier string, service *string, excludeID int) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM logistic_partners
+				WHERE courier = $1 AND COALESCE(service, '') = COALESCE($2, '') AND id <> $3 AND deleted_at IS NULL)`
+
+	var isExists bool
+	err := r.db.QueryRowContext(c, query, courier, service, excludeID).Scan(&isExists)
+	if err != nil {
+		return isExists, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return isExists, nil
+}
+
+func (r logisticPartnerRepoImpl) GetLogisticPartnerByID(c context.Context, id int) (*entity.LogisticPartner, error) {
+	query := `SELECT id, name, courier, service, is_active
+			FROM logistic_partners
+			WHERE id = $1 AND deleted_at IS NULL`
+
+	var logisticPartner entity.LogisticPartner
+	err := r.db.QueryRowContext(c, query, id).Scan(&logisticPartner.ID, &logisticPartner.Name, &logisticPartner.Courier,
+		&logisticPartner.Service, &logisticPartner.IsActive)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return &logisticPartner, nil
+}
+
+func (r logisticPartnerRepoImpl) GetLogisticPartners(c context.Context) ([]entity.LogisticPartner, error) {
+	query := `SELECT id, name, courier, service, is_active
+			FROM logistic_partners
+			WHERE deleted_at IS NULL
+			ORDER BY id`
+
+	return r.queryLogisticPartners(c, query)
+}
+
+func (r logisticPartnerRepoImpl) CountLogisticPartners(c context.Context, ids []int) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	placeholders := make([]string, 0, len(ids))
+	args := make([]interface{}, 0, len(ids))
+	for i, id := range ids {
+		placeholders = append(placeholders, fmt.Sprintf("$%d", i+1))
+		args = append(args, id)
+	}
+	query := fmt.Sprintf(`SELECT COUNT(*) FROM logistic_partners WHERE id IN (%s) AND deleted_at IS NULL`, strings.Join(placeholders, ","))
+
+	var total int
+	err := r.db.QueryRowContext(c, query, args...).Scan(&total)
+	if err != nil {
+		return 0, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return total, nil
+}
+
+func (r logisticPartnerRepoImpl) IsPharmacyExistsByID(c context.Context, id int) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM pharmacies WHERE id = $1 AND deleted_at IS NULL)`
+
+	var isExists bool
+	err := r.db.QueryRowContext(c, query, id).Scan(&isExists)
+	if err != nil {
+		return isExists, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return isExists, nil
+}
+
+func (r logisticPartnerRepoImpl) GetPharmacyLogisticPartners(c context.Context, pharmacyID int) ([]entity.LogisticPartner, error) {
+	query := `SELECT lp.id, lp.name, lp.courier, lp.service, lp.is_active
+			FROM pharmacies_logistic_partners plp
+			JOIN logistic_partners lp ON lp.id = plp.logistic_partner_id
+			WHERE plp.pharmacy_id = $1 AND plp.deleted_at IS NULL AND lp.deleted_at IS NULL
+			ORDER BY lp.id`
+
+	return r.queryLogisticPartners(c, query, pharmacyID)
+}
+
+func (r logisticPartnerRepoImpl) queryLogisticPartners(c context.Context, query string, args ...interface{}) ([]entity.LogisticPartner, error) {
+	logisticPartners := []entity.LogisticPartner{}
+
+	rows, err := r.db.QueryContext(c, query, args...)
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var logisticPartner entity.LogisticPartner
+		err := rows.Scan(&logisticPartner.ID, &logisticPartner.Name, &logisticPartner.Courier,
+			&logisticPartner.Service, &logisticPartner.IsActive)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
+		logisticPartners = append(logisticPartners, logisticPartner)
+	}
+	return logisticPartners, nil
+}
+
+func (r logisticPartnerRepoImpl) DeletePharmacyLogisticPartners(c context.Context, pharmacyID int) error {
+	tx := transaction.ExtractTx(c)
+
+	query := `UPDATE pharmacies_logistic_partners SET deleted_at = NOW() WHERE pharmacy_id = $1 AND deleted_at IS NULL`
+	_, err := tx.ExecContext(c, query, pharmacyID)
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+func (r logisticPartnerRepoImpl) AddPharmacyLogisticPartners(c context.Context, pharmacyLogistics entity.PharmacyLogisticPartners) error {
+	if len(pharmacyLogistics.LogisticPartnerIDs) == 0 {
+		return nil
+	}
+	tx := transaction.ExtractTx(c)
+
+	valueStrings := make([]string, 0, len(pharmacyLogistics.LogisticPartnerIDs))
+	valueArgs := []interface{}{pharmacyLogistics.PharmacyID}
+	for i, logisticPartnerID := range pharmacyLogistics.LogisticPartnerIDs {
+		valueStrings = append(valueStrings, fmt.Sprintf("($1,$%d)", i+2))
+		valueArgs = append(valueArgs, logisticPartnerID)
+	}
+	query := fmt.Sprintf(`INSERT INTO pharmacies_logistic_partners (pharmacy_id, logistic_partner_id) VALUES %s`, strings.Join(valueStrings, ","))
+
+	_, err := tx.ExecContext(c, query, valueArgs...)
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
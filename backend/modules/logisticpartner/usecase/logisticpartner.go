@@ -0,0 +1,165 @@
+package usecase
+
+import (
+	"context"
+	"montelukast/modules/logisticpartner/entity"
+	"montelukast/modules/logisticpartner/repository"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"montelukast/pkg/transaction"
+	"strings"
+)
+
+type LogisticPartnerUsecase interface {
+	AddLogisticPartner(c context.Context, logisticPartner entity.LogisticPartner) error
+	UpdateLogisticPartner(c context.Context, logisticPartner entity.LogisticPartner) error
+	DeleteLogisticPartner(c context.Context, id int) error
+	GetLogisticPartner(c context.Context, id int) (*entity.LogisticPartner, error)
+	GetLogisticPartners(c context.Context) ([]entity.LogisticPartner, error)
+	GetPharmacyLogisticPartners(c context.Context, pharmacyID int) ([]entity.LogisticPartner, error)
+	SetPharmacyLogisticPartners(c context.Context, pharmacyLogistics entity.PharmacyLogisticPartners) error
+}
+
+type logisticPartnerUsecaseImpl struct {
+	r  repository.LogisticPartnerRepo
+	tr transaction.TransactorRepoImpl
+}
+
+func NewLogisticPartnerUsecase(r repository.LogisticPartnerRepo, tr transaction.TransactorRepoImpl) logisticPartnerUsecaseImpl {
+	return logisticPartnerUsecaseImpl{
+		r:  r,
+		tr: tr,
+	}
+}
+
+func (u logisticPartnerUsecaseImpl) AddLogisticPartner(c context.Context, logisticPartner entity.LogisticPartner) error {
+	err := u.validateLogisticPartner(c, &logisticPartner, appconstant.FieldErrAddLogisticPartner)
+	if err != nil {
+		return err
+	}
+	return u.r.AddLogisticPartner(c, logisticPartner)
+}
+
+func (u logisticPartnerUsecaseImpl) UpdateLogisticPartner(c context.Context, logisticPartner entity.LogisticPartner) error {
+	isExists, err := u.r.IsLogisticPartnerExistsByID(c, logisticPartner.ID)
+	if err != nil {
+		return err
+	}
+	if !isExists {
+		return apperror.NewErrStatusNotFound(appconstant.FieldErrUpdateLogisticPartner, apperror.ErrLogisticPartnerNotExists, apperror.ErrLogisticPartnerNotExists)
+	}
+
+	err = u.validateLogisticPartner(c, &logisticPartner, appconstant.FieldErrUpdateLogisticPartner)
+	if err != nil {
+		return err
+	}
+	return u.r.UpdateLogisticPartner(c, logisticPartner)
+}
+
+func (u logisticPartnerUsecaseImpl) DeleteLogisticPartner(c context.Context, id int) error {
+	isExists, err := u.r.IsLogisticPartnerExistsByID(c, id)
+	if err != nil {
+		return err
+	}
+	if !isExists {
+		return apperror.NewErrStatusNotFound(appconstant.FieldErrDeleteLogisticPartner, apperror.ErrLogisticPartnerNotExists, apperror.ErrLogisticPartnerNotExists)
+	}
+
+	return u.tr.WithinTransaction(c, func(txCtx context.Context) error {
+		return u.r.DeleteLogisticPartnerByID(txCtx, id)
+	})
+}
+
+func (u logisticPartnerUsecaseImpl) GetLogisticPartner(c context.Context, id int) (*entity.LogisticPartner, error) {
+	logisticPartner, err := u.r.GetLogisticPartnerByID(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if logisticPartner == nil {
+		return nil, apperror.NewErrStatusNotFound(appconstant.FieldErrGetLogisticPartners, apperror.ErrLogisticPartnerNotExists, apperror.ErrLogisticPartnerNotExists)
+	}
+	return logisticPartner, nil
+}
+
+func (u logisticPartnerUsecaseImpl) GetLogisticPartners(c context.Context) ([]entity.LogisticPartner, error) {
+	return u.r.GetLogisticPartners(c)
+}
+
+func (u logisticPartnerUsecaseImpl) GetPharmacyLogisticPartners(c context.Context, pharmacyID int) ([]entity.LogisticPartner, error) {
+	err := u.checkPharmacy(c, pharmacyID, appconstant.FieldErrGetLogisticPartners)
+	if err != nil {
+		return nil, err
+	}
+	return u.r.GetPharmacyLogisticPartners(c, pharmacyID)
+}
+
+// SetPharmacyLogisticPartners replaces the couriers a pharmacy ships with.
+// An empty list leaves the pharmacy without any shipping option.
+func (u logisticPartnerUsecaseImpl) SetPharmacyLogisticPartners(c context.Context, pharmacyLogistics entity.PharmacyLogisticPartners) error {
+	err := u.checkPharmacy(c, pharmacyLogistics.PharmacyID, appconstant.FieldErrSetPharmacyLogistics)
+	if err != nil {
+		return err
+	}
+
+	logisticPartnerIDs := []int{}
+	isSeen := map[int]bool{}
+	for _, id := range pharmacyLogistics.LogisticPartnerIDs {
+		if !isSeen[id] {
+			isSeen[id] = true
+			logisticPartnerIDs = append(logisticPartnerIDs, id)
+		}
+	}
+	pharmacyLogistics.LogisticPartnerIDs = logisticPartnerIDs
+
+	total, err := u.r.CountLogisticPartners(c, logisticPartnerIDs)
+	if err != nil {
+		return err
+	}
+	if total != len(logisticPartnerIDs) {
+		return apperror.NewErrStatusNotFound(appconstant.FieldErrSetPharmacyLogistics, apperror.ErrLogisticPartnerNotExists, apperror.ErrLogisticPartnerNotExists)
+	}
+
+	return u.tr.WithinTransaction(c, func(txCtx context.Context) error {
+		err := u.r.DeletePharmacyLogisticPartners(txCtx, pharmacyLogistics.PharmacyID)
+		if err != nil {
+			return err
+		}
+		return u.r.AddPharmacyLogisticPartners(txCtx, pharmacyLogistics)
+	})
+}
+
+// validateLogisticPartner keeps services to RajaOngkir couriers, where they
+// pick one service such as REG instead of the cheapest one.
+func (u logisticPartnerUsecaseImpl) validateLogisticPartner(c context.Context, logisticPartner *entity.LogisticPartner, field string) error {
+	if logisticPartner.Service != nil {
+		service := strings.ToUpper(strings.TrimSpace(*logisticPartner.Service))
+		logisticPartner.Service = &service
+		if service == "" {
+			logisticPartner.Service = nil
+		}
+	}
+	isInternal := logisticPartner.Courier == appconstant.CourierInstant || logisticPartner.Courier == appconstant.CourierSameDay
+	if isInternal && logisticPartner.Service != nil {
+		return apperror.NewErrStatusBadRequest(field, apperror.ErrInvalidCourierService, apperror.ErrInvalidCourierService)
+	}
+
+	isExists, err := u.r.IsCourierServiceExists(c, logisticPartner.Courier, logisticPartner.Service, logisticPartner.ID)
+	if err != nil {
+		return err
+	}
+	if isExists {
+		return apperror.NewErrStatusBadRequest(field, apperror.ErrLogisticPartnerExists, apperror.ErrLogisticPartnerExists)
+	}
+	return nil
+}
+
+func (u logisticPartnerUsecaseImpl) checkPharmacy(c context.Context, pharmacyID int, field string) error {
+	isExists, err := u.r.IsPharmacyExistsByID(c, pharmacyID)
+	if err != nil {
+		return err
+	}
+	if !isExists {
+		return apperror.NewErrStatusNotFound(field, apperror.ErrPharmacyNotExists, apperror.ErrPharmacyNotExists)
+	}
+	return nil
+}
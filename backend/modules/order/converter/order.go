@@ -1,10 +1,12 @@
 package converter
 
 import (
+	deliveryEntity "montelukast/modules/delivery/entity"
 	"montelukast/modules/order/dto"
 	"montelukast/modules/order/entity"
 	queryparams "montelukast/modules/order/query_params"
 	productEntity "montelukast/modules/product/entity"
+	"strings"
 )
 
 type GetUserOrdersConverter struct{}
@@ -14,6 +16,7 @@ func (c GetUserOrdersConverter) ToDto(order entity.OrderDetail) dto.GetUserOrder
 		OrderID: order.ID,
 		Status: order.Status,
 		CreatedAt: order.CreatedAt,
+		CancellationReason: order.CancellationReason,
 	}
 }
 
@@ -23,7 +26,8 @@ func (c GetUserProductOrdersConverter) ToDto(order entity.OrderProductDetail) dt
 	return dto.GetUserOrderDetailsResponse{
 		OrderID: order.ID,
 		Status: order.Status,
-		CreatedAt: order.CreatedAt,	
+		CreatedAt: order.CreatedAt,
+		CancellationReason: order.CancellationReason,
 	}
 }
 
@@ -61,3 +65,13 @@ func (c QueryParamsConverter) ToEntity(queryParams queryparams.QueryParamsDto) q
 		Page:   queryParams.Page,
 	}
 }
+
+type ShipOrderConverter struct{}
+
+func (c ShipOrderConverter) ToEntity(request dto.ShipOrderRequest, orderDetailID int) deliveryEntity.Shipment {
+	return deliveryEntity.Shipment{
+		OrderDetailID:  orderDetailID,
+		Courier:        request.Courier,
+		TrackingNumber: strings.TrimSpace(request.TrackingNumber),
+	}
+}
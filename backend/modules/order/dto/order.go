@@ -5,16 +5,18 @@ import (
 )
 
 type GetUserOrdersResponse struct {
-	OrderID   int       `json:"order_id"`
-	Status    string    `json:"status"`
-	CreatedAt time.Time `json:"created_at"`
+	OrderID            int       `json:"order_id"`
+	Status             string    `json:"status"`
+	CreatedAt          time.Time `json:"created_at"`
+	CancellationReason *string   `json:"cancellation_reason"`
 }
 
 type GetUserOrderDetailsResponse struct {
-	OrderID        int                           `json:"order_id"`
-	Status         string                        `json:"status"`
-	CreatedAt      time.Time                     `json:"created_at"`
-	ProductDetails []GetUserProductOrdersResponse `json:"product_list"`
+	OrderID            int                            `json:"order_id"`
+	Status             string                         `json:"status"`
+	CreatedAt          time.Time                      `json:"created_at"`
+	CancellationReason *string                        `json:"cancellation_reason"`
+	ProductDetails     []GetUserProductOrdersResponse `json:"product_list"`
 }
 
 type GetUserProductOrdersResponse struct {
@@ -24,6 +26,11 @@ type GetUserProductOrdersResponse struct {
 	Image    string `json:"image"`
 }
 
+type ShipOrderRequest struct {
+	Courier        string `json:"courier" binding:"required,oneof=instant same_day jne pos tiki"`
+	TrackingNumber string `json:"tracking_number" binding:"required,max=50"`
+}
+
 type Pagination struct {
 	CurrentPage int `json:"current_page"`
 	TotalPage   int `json:"total_page"`
@@ -17,8 +17,9 @@ type OrderDetail struct {
 	Order
 	PharmacyID    int
 	LogisticPrice decimal.Decimal
-	Status        string
-	CreatedAt     time.Time
+	Status             string
+	CreatedAt          time.Time
+	CancellationReason *string
 }
 
 type OrderProductDetail struct {
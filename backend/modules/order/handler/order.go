@@ -161,7 +161,21 @@ func (h OrderHandler) UpdateOrderStatusHandler(c *gin.Context) {
 		return 
 	}
 
-	err = h.u.UpdateOrderStatus(c, orderDetailID, userID)
+	err = apperror.JsonValidator(c)
+	if err != nil {
+		err := apperror.NewErrStatusBadRequest(appconstant.FieldErrJSON, apperror.ErrInvalidJSON, err)
+		c.Error(err)
+		return
+	}
+	var shipOrderReq dto.ShipOrderRequest
+	err = c.ShouldBindJSON(&shipOrderReq)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var shipOrderConverter converter.ShipOrderConverter
+	err = h.u.UpdateOrderStatus(c, shipOrderConverter.ToEntity(shipOrderReq, orderDetailID), userID)
 	if err != nil {
 		c.Error(err)
 		return
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	deliveryEntity "montelukast/modules/delivery/entity"
 	"montelukast/modules/order/entity"
 	queryparams "montelukast/modules/order/query_params"
 	productEntity "montelukast/modules/product/entity"
@@ -21,13 +22,11 @@ type OrderRepo interface {
 	GetOrderDetailByID(c context.Context, orderDetailID int) (*entity.OrderDetail, error)
 	GetOrderedProduct(c context.Context, orderDetail int, pharmacyID int) ([]productEntity.ProductDetail, error)
 	GetPharmacyIDByOrderID(c context.Context, orderDetailID int) (int, error)
-	DeleteOrderDetails(c context.Context, orderDetailID int) error
-	DeleteOrderProductDetails(c context.Context, orderDetailID int) error
-	GetOrderStatusByID(c context.Context, orderDetailID int) (string, error)
-	UpdateOrderStatus(c context.Context, orderDetailID int) error
-	GetOrderedProductsQuantity(c context.Context, orderDetailID int) (map[int]int, error)
-	UpdateProductQuantity(c context.Context, pharmacyProductID, quantity int) error
-	UpdateOrderStatusDelivered(c context.Context, orderDetailID int) error
+	GetUserEmailByOrderDetailID(c context.Context, orderDetailID int) (string, error)
+	GetLogisticCourierByOrderDetailID(c context.Context, orderDetailID int) (*string, error)
+	UpdateShipment(c context.Context, shipment deliveryEntity.Shipment) error
+	GetShipmentByOrderDetailID(c context.Context, orderDetailID int) (*deliveryEntity.Shipment, error)
+	AddShipmentEvents(c context.Context, orderDetailID int, events []deliveryEntity.TrackingEvent) error
 }
 
 type orderRepoImpl struct {
@@ -40,10 +39,16 @@ func NewOrderRepo(dbConn *sql.DB) orderRepoImpl {
 	}
 }
 
+const cancellationReasonQuery = `(SELECT osh.reason
+				FROM order_status_histories osh
+				WHERE osh.order_detail_id = od.id AND osh.to_status = '` + appconstant.StatusCancelled + `' AND osh.deleted_at IS NULL
+				ORDER BY osh.created_at DESC, osh.id DESC
+				LIMIT 1)`
+
 func (r orderRepoImpl) GetOrders(c context.Context, queryParams queryparams.QueryParams, pharmacyID int) ([]entity.OrderDetail, error) {
 	orders := []entity.OrderDetail{}
 
-	query := `SELECT od.id, od.status, o.created_at 
+	query := `SELECT od.id, od.status, o.created_at, ` + cancellationReasonQuery + `
 				FROM orders o
 				JOIN order_details od ON od.order_id = o.id
 				WHERE pharmacy_id = $1 AND o.deleted_at IS NULL`
@@ -64,6 +69,7 @@ func (r orderRepoImpl) GetOrders(c context.Context, queryParams queryparams.Quer
 			&order.ID,
 			&order.Status,
 			&order.CreatedAt,
+			&order.CancellationReason,
 		)
 		if err != nil {
 			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
@@ -133,7 +139,7 @@ func (r orderRepoImpl) IsOrderDetailExistsByID(c context.Context, orderDetailID
 }
 
 func (r orderRepoImpl) GetOrderDetailByID(c context.Context, orderDetailID int) (*entity.OrderDetail, error) {
-	query := `select id, status, created_at
+	query := `select id, status, created_at, ` + cancellationReasonQuery + `
 				from order_details od 
 				where id = $1 AND deleted_at IS NULL`
 
@@ -142,6 +148,7 @@ func (r orderRepoImpl) GetOrderDetailByID(c context.Context, orderDetailID int)
 		&orderDetail.ID,
 		&orderDetail.Status,
 		&orderDetail.CreatedAt,
+		&orderDetail.CancellationReason,
 	)
 	if err != nil {
 		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
@@ -207,131 +214,94 @@ func (r orderRepoImpl) GetOrderedProduct(c context.Context, orderDetailID int, p
 	return productOrders, nil
 }
 
-func (r orderRepoImpl) GetOrderStatusByID(c context.Context, orderDetailID int) (string, error) {
-	query := `select status
-				from order_details pd 
-				where id = $1 AND deleted_at IS NULL`
+func (r orderRepoImpl) GetUserEmailByOrderDetailID(c context.Context, orderDetailID int) (string, error) {
+	query := `SELECT u.email
+				FROM order_details od
+				JOIN orders o ON o.id = od.order_id
+				JOIN users u ON u.id = o.user_id
+				WHERE od.id = $1 AND od.deleted_at IS NULL`
 
-	var orderStatus string
-	err := r.db.QueryRow(query, orderDetailID).Scan(&orderStatus)
+	var email string
+	err := r.db.QueryRowContext(c, query, orderDetailID).Scan(&email)
 	if err != nil {
 		return "", apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
 	}
-
-	return orderStatus, nil
+	return email, nil
 }
 
-func (r orderRepoImpl) DeleteOrderDetails(c context.Context, orderDetailID int) error {
-	tx := transaction.ExtractTx(c)
-
-	query := `UPDATE order_details
-				SET status = $2, deleted_at = NOW()
-				WHERE id = $1 AND deleted_at IS NULL`
+func (r orderRepoImpl) GetLogisticCourierByOrderDetailID(c context.Context, orderDetailID int) (*string, error) {
+	query := `SELECT lp.courier
+				FROM order_details od
+				LEFT JOIN logistic_partners lp ON lp.id = od.logistic_partner_id
+				WHERE od.id = $1 AND od.deleted_at IS NULL`
 
-	var err error
-	if tx != nil {
-		_, err = tx.ExecContext(c, query, orderDetailID, appconstant.StatusCancelled)
-	} else {
-		_, err = r.db.ExecContext(c, query, orderDetailID, appconstant.StatusCancelled)
-	}
+	var courier *string
+	err := r.db.QueryRowContext(c, query, orderDetailID).Scan(&courier)
 	if err != nil {
-		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
 	}
-
-	return nil
+	return courier, nil
 }
 
-func (r orderRepoImpl) DeleteOrderProductDetails(c context.Context, orderDetailID int) error {
+func (r orderRepoImpl) UpdateShipment(c context.Context, shipment deliveryEntity.Shipment) error {
 	tx := transaction.ExtractTx(c)
 
-	query := `UPDATE order_product_details
-				SET deleted_at = NOW()
-				WHERE order_detail_id = $1 AND deleted_at IS NULL`
+	query := `UPDATE order_details
+				SET courier = $2, tracking_number = $3, shipped_at = $4, updated_at = NOW()
+				WHERE id = $1 AND deleted_at IS NULL`
 
 	var err error
 	if tx != nil {
-		_, err = tx.ExecContext(c, query, orderDetailID)
+		_, err = tx.ExecContext(c, query, shipment.OrderDetailID, shipment.Courier, shipment.TrackingNumber, shipment.ShippedAt)
 	} else {
-		_, err = r.db.ExecContext(c, query, orderDetailID)
+		_, err = r.db.ExecContext(c, query, shipment.OrderDetailID, shipment.Courier, shipment.TrackingNumber, shipment.ShippedAt)
 	}
 	if err != nil {
 		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
 	}
-
-	return nil
-}
-
-func (r orderRepoImpl) UpdateOrderStatus(c context.Context, orderDetailID int) error {
-	query := `UPDATE order_details
-				SET status = $2, updated_at = NOW()
-				WHERE id = $1`
-
-	_, err := r.db.Exec(query, orderDetailID, appconstant.StatusShipped)
-	if err != nil {
-		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
-	}
-
 	return nil
 }
 
-func (r orderRepoImpl) UpdateOrderStatusDelivered(c context.Context, orderDetailID int) error {
-	query := `UPDATE order_details
-				SET status = $2, updated_at = NOW()
-				WHERE id = $1`
-
-	_, err := r.db.Exec(query, orderDetailID, appconstant.StatusDelivered)
-	if err != nil {
-		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+func (r orderRepoImpl) GetShipmentByOrderDetailID(c context.Context, orderDetailID int) (*deliveryEntity.Shipment, error) {
+	query := `SELECT id, courier, tracking_number, shipped_at
+				FROM order_details
+				WHERE id = $1 AND tracking_number IS NOT NULL AND deleted_at IS NULL`
+
+	var shipment deliveryEntity.Shipment
+	err := r.db.QueryRowContext(c, query, orderDetailID).Scan(
+		&shipment.OrderDetailID,
+		&shipment.Courier,
+		&shipment.TrackingNumber,
+		&shipment.ShippedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
-
-	return nil
-}
-
-func (r orderRepoImpl) GetOrderedProductsQuantity(c context.Context, orderDetailID int) (map[int]int, error) {
-	orderedProducts := make(map[int]int)
-
-	query := `SELECT opd.pharmacy_product_id, opd.quantity 
-				FROM order_details od 
-				JOIN order_product_details opd ON opd.order_detail_id = od.id AND opd.deleted_at IS NULL
-				WHERE od.id = $1 AND od.deleted_at IS NULL`
-
-	rows, err := r.db.Query(query, orderDetailID)
 	if err != nil {
 		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var pharmacyProductID int
-		var quantity int
-		err := rows.Scan(
-			&pharmacyProductID,
-			&quantity,
-		)
-		if err != nil {
-			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
-		}
-		orderedProducts[pharmacyProductID] = quantity
-	}
-	return orderedProducts, nil
+	return &shipment, nil
 }
 
-func (r orderRepoImpl) UpdateProductQuantity(c context.Context, pharmacyProductID, quantity int) error {
+// AddShipmentEvents skips events that are already stored, since tracking
+// providers return the whole timeline on every poll.
+func (r orderRepoImpl) AddShipmentEvents(c context.Context, orderDetailID int, events []deliveryEntity.TrackingEvent) error {
 	tx := transaction.ExtractTx(c)
 
-	query := `UPDATE pharmacy_products
-				SET stock = stock + $2, updated_at = NOW()
-				WHERE id = $1 AND deleted_at IS NULL`
+	query := `INSERT INTO shipment_events (order_detail_id, status, description, location, occurred_at)
+				VALUES ($1, $2, $3, $4, $5)
+				ON CONFLICT DO NOTHING`
 
-	var err error
-	if tx != nil {
-		_, err = tx.ExecContext(c, query, pharmacyProductID, quantity)
-	} else {
-		_, err = r.db.ExecContext(c, query, pharmacyProductID, quantity)
-	}
-	if err != nil {
-		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	for _, event := range events {
+		var err error
+		if tx != nil {
+			_, err = tx.ExecContext(c, query, orderDetailID, event.Status, event.Description, event.Location, event.OccurredAt)
+		} else {
+			_, err = r.db.ExecContext(c, query, orderDetailID, event.Status, event.Description, event.Location, event.OccurredAt)
+		}
+		if err != nil {
+			return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
 	}
-
 	return nil
 }
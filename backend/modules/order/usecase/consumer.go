@@ -3,6 +3,7 @@ package usecase
 import (
 	"context"
 	"encoding/json"
+	appconstant "montelukast/pkg/constant"
 	"montelukast/pkg/logger"
 
 	"github.com/streadway/amqp"
@@ -71,13 +72,21 @@ func (r *RabbitMQConsumerStatus) ConsumeDelayedMessage() {
 	}
 	for d := range msgs {
 		var data struct {
-			ID int `json:"status_order"`
+			ID     int    `json:"status_order"`
+			Action string `json:"action"`
 		}
 		err := json.Unmarshal(d.Body, &data)
 		if err != nil {
 			logger.Log.Error(err)
 		}
-		err = r.usecase.UpdateOrderStatusFromConsumer(context.Background(), data.ID)
+		switch data.Action {
+		case appconstant.DeliveryActionReminder:
+			err = r.usecase.SendDeliveryReminder(context.Background(), data.ID)
+		case appconstant.DeliveryActionTrack:
+			err = r.usecase.TrackShipment(context.Background(), data.ID)
+		default:
+			err = r.usecase.UpdateOrderStatusFromConsumer(context.Background(), data.ID)
+		}
 		if err != nil {
 			logger.Log.Error(err)
 		}
@@ -3,14 +3,25 @@ package usecase
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"math"
+	deliveryEntity "montelukast/modules/delivery/entity"
+	deliveryProvider "montelukast/modules/delivery/provider"
 	"montelukast/modules/order/entity"
 	queryparams "montelukast/modules/order/query_params"
 	"montelukast/modules/order/repository"
+	orderStatusEntity "montelukast/modules/orderstatus/entity"
+	orderStatus "montelukast/modules/orderstatus/usecase"
 	appconstant "montelukast/pkg/constant"
 	apperror "montelukast/pkg/error"
+	"montelukast/pkg/logger"
 	"montelukast/pkg/transaction"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/resendlabs/resend-go"
 	"github.com/streadway/amqp"
 )
 
@@ -18,21 +29,29 @@ type OrderUsecase interface {
 	GetOrders(c context.Context, queryParams queryparams.QueryParams, pharmacistID int) (*entity.OrdersList, error)
 	GetOrderedProducts(c context.Context, orderDetailID int, pharmacistID int) (*entity.OrderProductDetail, error)
 	DeleteOrder(c context.Context, orderDetailID int, pharmacistID int) error
-	UpdateOrderStatus(c context.Context, orderDetailID int, pharmacistID int) error
+	UpdateOrderStatus(c context.Context, shipment deliveryEntity.Shipment, pharmacistID int) error
 	UpdateOrderStatusFromConsumer(c context.Context, orderDetailID int) error
+	SendDeliveryReminder(c context.Context, orderDetailID int) error
+	TrackShipment(c context.Context, orderDetailID int) error
 }
 
 type orderUsecaseImpl struct {
 	r        repository.OrderRepo
 	tr       transaction.TransactorRepoImpl
+	os       orderStatus.OrderStatusUsecase
+	rc       *resend.Client
 	rabbitMQ *amqp.Channel
+	tp       deliveryProvider.TrackingProvider
 }
 
-func NewOrderUsecase(rabbitMQ *amqp.Channel, r repository.OrderRepo, tr transaction.TransactorRepoImpl) orderUsecaseImpl {
+func NewOrderUsecase(rabbitMQ *amqp.Channel, r repository.OrderRepo, tr transaction.TransactorRepoImpl, os orderStatus.OrderStatusUsecase, rc *resend.Client, tp deliveryProvider.TrackingProvider) orderUsecaseImpl {
 	return orderUsecaseImpl{
 		r:        r,
 		tr:       tr,
+		os:       os,
+		rc:       rc,
 		rabbitMQ: rabbitMQ,
+		tp:       tp,
 	}
 }
 
@@ -137,86 +156,208 @@ func (u orderUsecaseImpl) GetOrderedProducts(c context.Context, orderDetailID in
 	orderProductDetail.ID = orderDetail.ID
 	orderProductDetail.Status = orderDetail.Status
 	orderProductDetail.CreatedAt = orderDetail.CreatedAt
+	orderProductDetail.CancellationReason = orderDetail.CancellationReason
 	orderProductDetail.ProductDetails = productOrders
 
 	return &orderProductDetail, nil
 }
 
 func (u orderUsecaseImpl) DeleteOrder(c context.Context, orderDetailID int, pharmacistID int) error {
-	err := u.tr.WithinTransaction(c, func(txCtx context.Context) error {
-		isExists, err := u.r.IsOrderDetailExistsByID(txCtx, orderDetailID)
+	err := u.checkPharmacistOrder(c, orderDetailID, pharmacistID, appconstant.FieldErrDeleteOrder)
+	if err != nil {
+		return err
+	}
+
+	reason := appconstant.ReasonCancelledByPharmacist
+	return u.os.Transition(c, orderStatusEntity.Transition{
+		OrderDetailID: orderDetailID,
+		To:            appconstant.StatusCancelled,
+		Actor:         appconstant.ActorPharmacist,
+		ActorID:       &pharmacistID,
+		Reason:        &reason,
+	})
+}
+
+// UpdateOrderStatus ships an order detail with the courier and tracking number
+// the pharmacist handed the parcel to. Couriers with a waybill are then polled
+// until the provider reports the parcel as delivered.
+func (u orderUsecaseImpl) UpdateOrderStatus(c context.Context, shipment deliveryEntity.Shipment, pharmacistID int) error {
+	orderDetailID := shipment.OrderDetailID
+	err := u.checkPharmacistOrder(c, orderDetailID, pharmacistID, appconstant.FieldErrUpdateOrderStatus)
+	if err != nil {
+		return err
+	}
+
+	courier, err := u.r.GetLogisticCourierByOrderDetailID(c, orderDetailID)
+	if err != nil {
+		return err
+	}
+	if courier != nil && *courier != shipment.Courier {
+		return apperror.NewErrStatusBadRequest(appconstant.FieldErrUpdateOrderStatus, apperror.ErrCourierMismatch, apperror.ErrCourierMismatch)
+	}
+
+	shipment.ShippedAt = time.Now()
+	err = u.tr.WithinTransaction(c, func(txCtx context.Context) error {
+		err := u.os.Transition(txCtx, orderStatusEntity.Transition{
+			OrderDetailID: orderDetailID,
+			To:            appconstant.StatusShipped,
+			Actor:         appconstant.ActorPharmacist,
+			ActorID:       &pharmacistID,
+		})
 		if err != nil {
 			return err
 		}
-		if !isExists {
-			return apperror.NewErrStatusNotFound(appconstant.FieldErrDeleteOrder, apperror.ErrOrderDetailNotExists, apperror.ErrOrderDetailNotExists)
-		}
-
-		isExists, err = u.r.IsPharmacistExistsByID(txCtx, pharmacistID)
+		err = u.r.UpdateShipment(txCtx, shipment)
 		if err != nil {
 			return err
 		}
-		if !isExists {
-			apperror.NewErrStatusNotFound(appconstant.FieldErrDeleteOrder, apperror.ErrOrderDetailNotExists, apperror.ErrOrderDetailNotExists)
-		}
+		return u.r.AddShipmentEvents(txCtx, orderDetailID, []deliveryEntity.TrackingEvent{{
+			Status:      appconstant.ShipmentEventShipped,
+			Description: fmt.Sprintf("Shipped with %s, tracking number %s", strings.ToUpper(shipment.Courier), shipment.TrackingNumber),
+			OccurredAt:  shipment.ShippedAt,
+		}})
+	})
+	if err != nil {
+		return err
+	}
 
-		isAuthorized, err := u.IsPharmacistAuthorized(c, orderDetailID, pharmacistID)
+	// The order is already shipped, so a broker outage is only logged like at
+	// checkout. The user can still confirm the delivery manually.
+	autoConfirmWindow := getAutoConfirmWindow()
+	if autoConfirmWindow > appconstant.AutoConfirmReminderTime {
+		err = u.PublishDelayedMessage(c, orderDetailID, appconstant.DeliveryActionReminder, int((autoConfirmWindow - appconstant.AutoConfirmReminderTime).Milliseconds()))
 		if err != nil {
-			return err
-		}
-		if !isAuthorized {
-			return apperror.NewErrStatusUnauthorized(appconstant.FieldErrGetUserOrders, apperror.ErrUserUnauthorized, apperror.ErrUserUnauthorized)
+			logger.Log.Error(err)
 		}
-
-		orderStatus, err := u.r.GetOrderStatusByID(c, orderDetailID)
+	}
+	err = u.PublishDelayedMessage(c, orderDetailID, appconstant.DeliveryActionAutoConfirm, int(autoConfirmWindow.Milliseconds()))
+	if err != nil {
+		logger.Log.Error(err)
+	}
+	if !isInternalCourier(shipment.Courier) {
+		err = u.scheduleTracking(c, orderDetailID)
 		if err != nil {
-			return err
+			logger.Log.Error(err)
 		}
+	}
+	return nil
+}
 
-		CanBeCanceled := apperror.IsOrderCanBeCanceled(orderStatus)
-		if !CanBeCanceled {
-			return apperror.NewErrStatusBadRequest(appconstant.FieldErrDeleteOrder, apperror.ErrOrderCannotCanceled, apperror.ErrOrderCannotCanceled)
-		}
+// TrackShipment stores the latest tracking events of a shipped order detail.
+// A delivered event completes the order, otherwise the next poll is scheduled.
+// Provider outages only delay the next poll, the auto-confirm still applies.
+func (u orderUsecaseImpl) TrackShipment(c context.Context, orderDetailID int) error {
+	isShipped, err := u.isStillShipped(c, orderDetailID)
+	if err != nil || !isShipped {
+		return err
+	}
 
-		err = u.r.DeleteOrderDetails(txCtx, orderDetailID)
-		if err != nil {
-			return err
-		}
+	shipment, err := u.r.GetShipmentByOrderDetailID(c, orderDetailID)
+	if err != nil || shipment == nil {
+		return err
+	}
 
-		err = u.r.DeleteOrderProductDetails(txCtx, orderDetailID)
-		if err != nil {
-			return err
-		}
+	events, err := u.tp.Track(c, *shipment)
+	if err != nil {
+		logger.Log.Error(err)
+		return u.scheduleTracking(c, orderDetailID)
+	}
 
-		orderedProducts, err := u.r.GetOrderedProductsQuantity(txCtx, orderDetailID)
-		if err != nil {
-			return err
+	err = u.r.AddShipmentEvents(c, orderDetailID, events)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		if event.Status != appconstant.ShipmentEventDelivered {
+			continue
 		}
+		reason := appconstant.ReasonDeliveredByCourier
+		return u.os.Transition(c, orderStatusEntity.Transition{
+			OrderDetailID: orderDetailID,
+			To:            appconstant.StatusDelivered,
+			Actor:         appconstant.ActorSystem,
+			Reason:        &reason,
+		})
+	}
+	return u.scheduleTracking(c, orderDetailID)
+}
 
-		for pharmacyProductID, quantity := range orderedProducts {
-			err := u.r.UpdateProductQuantity(txCtx, pharmacyProductID, quantity)
-			if err != nil {
-				return err
-			}
-		}
+func (u orderUsecaseImpl) scheduleTracking(c context.Context, orderDetailID int) error {
+	return u.PublishDelayedMessage(c, orderDetailID, appconstant.DeliveryActionTrack, int(appconstant.TrackingPollInterval.Milliseconds()))
+}
 
-		return nil
+func (u orderUsecaseImpl) UpdateOrderStatusFromConsumer(c context.Context, orderDetailID int) error {
+	isShipped, err := u.isStillShipped(c, orderDetailID)
+	if err != nil || !isShipped {
+		return err
+	}
+
+	reason := appconstant.ReasonDeliveryAutoConfirmed
+	return u.os.Transition(c, orderStatusEntity.Transition{
+		OrderDetailID: orderDetailID,
+		To:            appconstant.StatusDelivered,
+		Actor:         appconstant.ActorSystem,
+		Reason:        &reason,
 	})
+}
+
+func (u orderUsecaseImpl) SendDeliveryReminder(c context.Context, orderDetailID int) error {
+	isShipped, err := u.isStillShipped(c, orderDetailID)
+	if err != nil || !isShipped {
+		return err
+	}
+
+	email, err := u.r.GetUserEmailByOrderDetailID(c, orderDetailID)
 	if err != nil {
 		return err
 	}
 
+	params := &resend.SendEmailRequest{
+		From:    "mediSEAne <no-reply@mediseane.store>",
+		To:      []string{email},
+		Subject: "[mediSEAne] Confirm Your Delivery",
+		Html: fmt.Sprintf(
+			`<p style="font-size:3rem;font-weigth:bold;margin:0px">
+				medi<span style="color:#008081">SEA</span>ne
+			</p>
+			<p style="font-weight:bold">
+				All Your <span style="color:#008081">Healthcare</span> Needs at Your Fingertips
+			</p>
+			<hr>
+			<p style="font-weight:bold">Hello, has your order #%d arrived?</p>
+			<p>Please confirm the delivery in your order list. If we do not hear from you, the order will be marked as delivered automatically in %d hours.<p>`,
+			orderDetailID,
+			int(appconstant.AutoConfirmReminderTime.Hours()),
+		),
+	}
+
+	_, err = u.rc.Emails.Send(params)
+	if err != nil {
+		return apperror.NewErrStatusBadRequest(appconstant.FieldErrChangeStatus, apperror.ErrSendEmail, err)
+	}
 	return nil
 }
 
-func (u orderUsecaseImpl) UpdateOrderStatus(c context.Context, orderDetailID int, pharmacistID int) error {
+func (u orderUsecaseImpl) isStillShipped(c context.Context, orderDetailID int) (bool, error) {
+	isExists, err := u.r.IsOrderDetailExistsByID(c, orderDetailID)
+	if err != nil || !isExists {
+		return false, err
+	}
+
+	orderDetail, err := u.r.GetOrderDetailByID(c, orderDetailID)
+	if err != nil {
+		return false, err
+	}
+	return orderDetail.Status == appconstant.StatusShipped, nil
+}
 
+func (u orderUsecaseImpl) checkPharmacistOrder(c context.Context, orderDetailID int, pharmacistID int, field string) error {
 	isExists, err := u.r.IsOrderDetailExistsByID(c, orderDetailID)
 	if err != nil {
 		return err
 	}
 	if !isExists {
-		return apperror.NewErrStatusNotFound(appconstant.FieldErrDeleteOrder, apperror.ErrOrderDetailNotExists, apperror.ErrOrderDetailNotExists)
+		return apperror.NewErrStatusNotFound(field, apperror.ErrOrderDetailNotExists, apperror.ErrOrderDetailNotExists)
 	}
 
 	isExists, err = u.r.IsPharmacistExistsByID(c, pharmacistID)
@@ -224,7 +365,7 @@ func (u orderUsecaseImpl) UpdateOrderStatus(c context.Context, orderDetailID int
 		return err
 	}
 	if !isExists {
-		apperror.NewErrStatusNotFound(appconstant.FieldErrDeleteOrder, apperror.ErrOrderDetailNotExists, apperror.ErrOrderDetailNotExists)
+		return apperror.NewErrStatusNotFound(field, apperror.ErrPharmacistNotExists, apperror.ErrPharmacistNotExists)
 	}
 
 	isAuthorized, err := u.IsPharmacistAuthorized(c, orderDetailID, pharmacistID)
@@ -232,37 +373,16 @@ func (u orderUsecaseImpl) UpdateOrderStatus(c context.Context, orderDetailID int
 		return err
 	}
 	if !isAuthorized {
-		return apperror.NewErrStatusUnauthorized(appconstant.FieldErrGetUserOrders, apperror.ErrUserUnauthorized, apperror.ErrUserUnauthorized)
-	}
-
-	orderStatus, err := u.r.GetOrderStatusByID(c, orderDetailID)
-	if err != nil {
-		return err
-	}
-
-	if orderStatus != appconstant.StatusProcessing {
-		return apperror.NewErrStatusBadRequest(appconstant.FieldErrUpdateOrderStatus, apperror.ErrCannotUpdateOrderStatus, apperror.ErrCannotUpdateOrderStatus)
-	}
-	err = u.r.UpdateOrderStatus(c, orderDetailID)
-	if err != nil {
-		return err
-	}
-	err = u.PublishDelayedMessage(c, orderDetailID, appconstant.OrderCompleteTime)
-	if err != nil {
-		return err
+		return apperror.NewErrStatusUnauthorized(field, apperror.ErrUserUnauthorized, apperror.ErrUserUnauthorized)
 	}
 	return nil
 }
 
-func (u orderUsecaseImpl) UpdateOrderStatusFromConsumer(c context.Context, orderDetailID int) error {
-	err := u.r.UpdateOrderStatusDelivered(c, orderDetailID)
-	if err != nil {
-		return err
+func (u orderUsecaseImpl) PublishDelayedMessage(c context.Context, id int, action string, delay int) error {
+	// The delayed message exchange drops delays it cannot hold.
+	if delay > int(appconstant.MaxMessageDelay.Milliseconds()) {
+		delay = int(appconstant.MaxMessageDelay.Milliseconds())
 	}
-	return nil
-}
-
-func (u orderUsecaseImpl) PublishDelayedMessage(c context.Context, id int, delay int) error {
 	err := u.rabbitMQ.ExchangeDeclare(
 		"update-status-exchange", //name
 		"x-delayed-message",      //type
@@ -279,6 +399,7 @@ func (u orderUsecaseImpl) PublishDelayedMessage(c context.Context, id int, delay
 	}
 	body, err := json.Marshal(map[string]interface{}{
 		"status_order": id,
+		"action":       action,
 	})
 
 	if err != nil {
@@ -315,3 +436,20 @@ func (u orderUsecaseImpl) IsPharmacistAuthorized(c context.Context, orderDetailI
 	}
 	return pharmacyID1 == pharmacistID2, nil
 }
+
+// isInternalCourier reports couriers run by the pharmacy itself, which have no
+// waybill to track.
+func isInternalCourier(courier string) bool {
+	return courier == appconstant.CourierInstant || courier == appconstant.CourierSameDay
+}
+
+func getAutoConfirmWindow() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("AUTO_CONFIRM_DAYS"))
+	if err != nil || days <= 0 {
+		days = appconstant.DefaultAutoConfirmDays
+	}
+	if days > int(appconstant.MaxMessageDelay/(24*time.Hour)) {
+		return appconstant.MaxMessageDelay
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
@@ -0,0 +1,83 @@
+package converter
+
+import (
+	"montelukast/modules/orderreturn/dto"
+	"montelukast/modules/orderreturn/entity"
+)
+
+type OrderReturnConverter struct{}
+
+func (c OrderReturnConverter) ToDto(orderReturn entity.OrderReturn) dto.OrderReturnResponse {
+	var items []dto.OrderReturnItemResponse
+	for _, item := range orderReturn.Items {
+		items = append(items, dto.OrderReturnItemResponse{
+			ID:                   item.ID,
+			OrderProductDetailID: item.OrderProductDetailID,
+			PharmacyProductID:    item.PharmacyProductID,
+			ProductName:          item.ProductName,
+			Quantity:             item.Quantity,
+			Amount:               item.Amount.String(),
+		})
+	}
+	var refund *dto.RefundResponse
+	if orderReturn.Refund != nil {
+		refund = &dto.RefundResponse{
+			ID:        orderReturn.Refund.ID,
+			Amount:    orderReturn.Refund.Amount.String(),
+			Status:    orderReturn.Refund.Status,
+			CreatedAt: orderReturn.Refund.CreatedAt,
+		}
+	}
+	var histories []dto.ReturnHistoryResponse
+	for _, history := range orderReturn.Histories {
+		histories = append(histories, dto.ReturnHistoryResponse{
+			FromStatus: history.FromStatus,
+			ToStatus:   history.ToStatus,
+			Actor:      history.Actor,
+			ActorID:    history.ActorID,
+			Reason:     history.Reason,
+			CreatedAt:  history.CreatedAt,
+		})
+	}
+	return dto.OrderReturnResponse{
+		ID:              orderReturn.ID,
+		OrderDetailID:   orderReturn.OrderDetailID,
+		OrderID:         orderReturn.OrderID,
+		PharmacyID:      orderReturn.PharmacyID,
+		PharmacyName:    orderReturn.PharmacyName,
+		UserID:          orderReturn.UserID,
+		UserName:        orderReturn.UserName,
+		Reason:          orderReturn.Reason,
+		Status:          orderReturn.Status,
+		ReviewerID:      orderReturn.ReviewerID,
+		RejectionReason: orderReturn.RejectionReason,
+		IsRestocked:     orderReturn.IsRestocked,
+		ReviewedAt:      orderReturn.ReviewedAt,
+		CreatedAt:       orderReturn.CreatedAt,
+		Items:           items,
+		Images:          orderReturn.Images,
+		Refund:          refund,
+		Histories:       histories,
+	}
+}
+
+type ReturnItemConverter struct{}
+
+func (c ReturnItemConverter) ToEntity(items []dto.ReturnItemRequest) []entity.ReturnItemRequest {
+	entities := []entity.ReturnItemRequest{}
+	for _, item := range items {
+		entities = append(entities, entity.ReturnItemRequest{
+			OrderProductDetailID: item.OrderProductDetailID,
+			Quantity:             item.Quantity,
+		})
+	}
+	return entities
+}
+
+type FileConverter struct{}
+
+func (c FileConverter) ToEntity(file dto.FileRequest) entity.File {
+	return entity.File{
+		File: file.File,
+	}
+}
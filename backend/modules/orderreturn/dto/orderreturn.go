@@ -0,0 +1,73 @@
+package dto
+
+import (
+	"mime/multipart"
+	"time"
+)
+
+type ReturnItemRequest struct {
+	OrderProductDetailID int `json:"order_product_detail_id"`
+	Quantity             int `json:"quantity"`
+}
+
+type ReturnFilterRequest struct {
+	Status string `form:"status"`
+}
+
+type ApproveReturnRequest struct {
+	Restock bool `json:"restock"`
+}
+
+type RejectReturnRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+type FileRequest struct {
+	File multipart.File `json:"file,omitempty"`
+}
+
+type OrderReturnResponse struct {
+	ID              int                       `json:"id"`
+	OrderDetailID   int                       `json:"order_detail_id"`
+	OrderID         int                       `json:"order_id"`
+	PharmacyID      int                       `json:"pharmacy_id"`
+	PharmacyName    string                    `json:"pharmacy_name"`
+	UserID          int                       `json:"user_id"`
+	UserName        string                    `json:"user_name"`
+	Reason          string                    `json:"reason"`
+	Status          string                    `json:"status"`
+	ReviewerID      *int                      `json:"reviewer_id"`
+	RejectionReason *string                   `json:"rejection_reason"`
+	IsRestocked     bool                      `json:"is_restocked"`
+	ReviewedAt      *time.Time                `json:"reviewed_at"`
+	CreatedAt       time.Time                 `json:"created_at"`
+	Items           []OrderReturnItemResponse `json:"items,omitempty"`
+	Images          []string                  `json:"images,omitempty"`
+	Refund          *RefundResponse           `json:"refund,omitempty"`
+	Histories       []ReturnHistoryResponse   `json:"histories,omitempty"`
+}
+
+type OrderReturnItemResponse struct {
+	ID                   int    `json:"id"`
+	OrderProductDetailID int    `json:"order_product_detail_id"`
+	PharmacyProductID    int    `json:"pharmacy_product_id"`
+	ProductName          string `json:"product_name"`
+	Quantity             int    `json:"quantity"`
+	Amount               string `json:"amount"`
+}
+
+type RefundResponse struct {
+	ID        int       `json:"id"`
+	Amount    string    `json:"amount"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ReturnHistoryResponse struct {
+	FromStatus *string   `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Actor      string    `json:"actor"`
+	ActorID    *int      `json:"actor_id"`
+	Reason     *string   `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,101 @@
+package entity
+
+import (
+	"mime/multipart"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type OrderReturn struct {
+	ID              int
+	OrderDetailID   int
+	OrderID         int
+	PharmacyID      int
+	PharmacyName    string
+	UserID          int
+	UserName        string
+	Reason          string
+	Status          string
+	ReviewerID      *int
+	RejectionReason *string
+	IsRestocked     bool
+	ReviewedAt      *time.Time
+	CreatedAt       time.Time
+	Items           []OrderReturnItem
+	Images          []string
+	Refund          *Refund
+	Histories       []ReturnHistory
+}
+
+type OrderReturnItem struct {
+	ID                   int
+	OrderProductDetailID int
+	PharmacyProductID    int
+	ProductName          string
+	Quantity             int
+	Amount               decimal.Decimal
+}
+
+type ReturnRequest struct {
+	OrderDetailID int
+	UserID        int
+	Reason        string
+	Items         []ReturnItemRequest
+	Files         []File
+}
+
+type ReturnItemRequest struct {
+	OrderProductDetailID int
+	Quantity             int
+}
+
+type ReturnableOrderDetail struct {
+	ID          int
+	OrderID     int
+	Status      string
+	DeliveredAt time.Time
+	Subtotal    decimal.Decimal
+	Discount    decimal.Decimal
+}
+
+type ReturnableItem struct {
+	OrderProductDetailID int
+	Quantity             int
+	ReturnedQuantity     int
+	Price                decimal.Decimal
+}
+
+type Refund struct {
+	ID            int
+	OrderReturnID int
+	OrderID       int
+	Amount        decimal.Decimal
+	Status        string
+	CreatedAt     time.Time
+}
+
+type ReturnHistory struct {
+	OrderReturnID int
+	FromStatus    *string
+	ToStatus      string
+	Actor         string
+	ActorID       *int
+	Reason        *string
+	CreatedAt     time.Time
+}
+
+type ReturnFilter struct {
+	UserID     *int
+	PharmacyID *int
+	Status     string
+}
+
+type Reviewer struct {
+	ID   int
+	Role string
+}
+
+type File struct {
+	File multipart.File `validate:"required"`
+}
@@ -0,0 +1,294 @@
+package handler
+
+import (
+	"encoding/json"
+	"mime/multipart"
+	"montelukast/modules/orderreturn/converter"
+	"montelukast/modules/orderreturn/dto"
+	"montelukast/modules/orderreturn/entity"
+	"montelukast/modules/orderreturn/usecase"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"montelukast/pkg/wrapper"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OrderReturnHandler struct {
+	u usecase.OrderReturnUsecase
+}
+
+func NewOrderReturnHandler(u usecase.OrderReturnUsecase) OrderReturnHandler {
+	return OrderReturnHandler{
+		u: u,
+	}
+}
+
+func (h OrderReturnHandler) RequestReturnHandler(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	orderDetailID, err := strconv.Atoi(c.Param("order_id"))
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrRequestReturn, apperror.ErrConvertVariableType, err))
+		return
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrRequestReturn, apperror.ErrReturnImagesRequired, err))
+		return
+	}
+
+	itemsReq := []dto.ReturnItemRequest{}
+	err = json.Unmarshal([]byte(c.PostForm("items")), &itemsReq)
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrRequestReturn, apperror.ErrInvalidJSON, err))
+		return
+	}
+
+	files := []entity.File{}
+	for _, fileHeader := range form.File["images"] {
+		formFile, err := openImage(fileHeader)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		defer formFile.Close()
+		files = append(files, converter.FileConverter{}.ToEntity(dto.FileRequest{File: formFile}))
+	}
+
+	orderReturn, err := h.u.RequestReturn(c, entity.ReturnRequest{
+		OrderDetailID: orderDetailID,
+		UserID:        userID,
+		Reason:        c.PostForm("reason"),
+		Items:         converter.ReturnItemConverter{}.ToEntity(itemsReq),
+		Files:         files,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(converter.OrderReturnConverter{}.ToDto(*orderReturn), "request return success!", nil)
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h OrderReturnHandler) GetUserReturnsHandler(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	filterReq := dto.ReturnFilterRequest{}
+	err = c.ShouldBindQuery(&filterReq)
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrGetReturns, apperror.ErrInvalidJSON, err))
+		return
+	}
+
+	orderReturns, err := h.u.GetUserReturns(c, userID, filterReq.Status)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(toDtos(orderReturns), "get returns success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h OrderReturnHandler) GetUserReturnHandler(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	orderReturnID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrGetReturns, apperror.ErrConvertVariableType, err))
+		return
+	}
+
+	orderReturn, err := h.u.GetUserReturn(c, orderReturnID, userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(converter.OrderReturnConverter{}.ToDto(*orderReturn), "get return success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h OrderReturnHandler) GetReturnsHandler(c *gin.Context) {
+	reviewer, err := getReviewer(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	filterReq := dto.ReturnFilterRequest{}
+	err = c.ShouldBindQuery(&filterReq)
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrGetReturns, apperror.ErrInvalidJSON, err))
+		return
+	}
+	if filterReq.Status == "" && reviewer.Role == appconstant.ROLE_PHARMACY {
+		filterReq.Status = appconstant.ReturnStatusPending
+	}
+
+	orderReturns, err := h.u.GetReturns(c, filterReq.Status, *reviewer)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(toDtos(orderReturns), "get returns success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h OrderReturnHandler) GetReturnHandler(c *gin.Context) {
+	reviewer, err := getReviewer(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	orderReturnID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrGetReturns, apperror.ErrConvertVariableType, err))
+		return
+	}
+
+	orderReturn, err := h.u.GetReturn(c, orderReturnID, *reviewer)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(converter.OrderReturnConverter{}.ToDto(*orderReturn), "get return success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h OrderReturnHandler) ApproveReturnHandler(c *gin.Context) {
+	reviewer, err := getReviewer(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	orderReturnID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrReviewReturn, apperror.ErrConvertVariableType, err))
+		return
+	}
+
+	approveReq := dto.ApproveReturnRequest{}
+	if c.Request.ContentLength != 0 {
+		err = c.ShouldBindJSON(&approveReq)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+	}
+
+	err = h.u.ApproveReturn(c, orderReturnID, approveReq.Restock, *reviewer)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(nil, "approve return success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h OrderReturnHandler) RejectReturnHandler(c *gin.Context) {
+	reviewer, err := getReviewer(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	orderReturnID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrReviewReturn, apperror.ErrConvertVariableType, err))
+		return
+	}
+
+	err = apperror.JsonValidator(c)
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrReviewReturn, apperror.ErrInvalidJSON, err))
+		return
+	}
+
+	rejectReq := dto.RejectReturnRequest{}
+	err = c.ShouldBindJSON(&rejectReq)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = h.u.RejectReturn(c, orderReturnID, rejectReq.Reason, *reviewer)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(nil, "reject return success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func openImage(fileHeader *multipart.FileHeader) (multipart.File, error) {
+	fileName := fileHeader.Filename
+	extension := fileName[strings.Index(fileName, ".")+1:]
+	if extension != "png" && extension != "jpg" && extension != "jpeg" {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrImageType, apperror.ErrUploadImage, apperror.ErrUploadImage)
+	}
+	if fileHeader.Size > appconstant.IMAGESIZEMAX {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrImageSize, apperror.ErrUploadImageSize, apperror.ErrUploadImageSize)
+	}
+	formFile, err := fileHeader.Open()
+	if err != nil {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrImageType, apperror.ErrUploadImage, err)
+	}
+	return formFile, nil
+}
+
+func toDtos(orderReturns []entity.OrderReturn) []dto.OrderReturnResponse {
+	orderReturnsDto := []dto.OrderReturnResponse{}
+	for _, orderReturn := range orderReturns {
+		orderReturnsDto = append(orderReturnsDto, converter.OrderReturnConverter{}.ToDto(orderReturn))
+	}
+	return orderReturnsDto
+}
+
+func getUserID(c *gin.Context) (int, error) {
+	rawUserID, isExists := c.Get("user_id")
+	if !isExists {
+		return 0, apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, apperror.ErrTokenInvalid)
+	}
+	userID, err := strconv.Atoi(rawUserID.(string))
+	if err != nil {
+		return 0, apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, err)
+	}
+	return userID, nil
+}
+
+func getReviewer(c *gin.Context) (*entity.Reviewer, error) {
+	userID, err := getUserID(c)
+	if err != nil {
+		return nil, err
+	}
+	role, isExists := c.Get("role")
+	if !isExists {
+		return nil, apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrUserUnauthorized, apperror.ErrUserUnauthorized)
+	}
+	return &entity.Reviewer{ID: userID, Role: role.(string)}, nil
+}
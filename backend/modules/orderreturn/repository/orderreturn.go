@@ -0,0 +1,498 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"montelukast/modules/orderreturn/entity"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"montelukast/pkg/transaction"
+	"strings"
+)
+
+type OrderReturnRepo interface {
+	GetReturnableOrderDetail(c context.Context, orderDetailID int, userID int) (*entity.ReturnableOrderDetail, error)
+	GetReturnableItems(c context.Context, orderDetailID int) ([]entity.ReturnableItem, error)
+	AddOrderReturn(c context.Context, orderReturn entity.OrderReturn) (*entity.OrderReturn, error)
+	AddReturnItems(c context.Context, orderReturnID int, items []entity.OrderReturnItem) error
+	AddReturnImages(c context.Context, orderReturnID int, images []string) error
+	AddReturnHistory(c context.Context, history entity.ReturnHistory) error
+	GetReturns(c context.Context, filter entity.ReturnFilter) ([]entity.OrderReturn, error)
+	GetReturnByID(c context.Context, orderReturnID int) (*entity.OrderReturn, error)
+	GetReturnItems(c context.Context, orderReturnID int) ([]entity.OrderReturnItem, error)
+	GetReturnImages(c context.Context, orderReturnID int) ([]string, error)
+	GetReturnHistories(c context.Context, orderReturnID int) ([]entity.ReturnHistory, error)
+	GetRefundByReturnID(c context.Context, orderReturnID int) (*entity.Refund, error)
+	UpdateReturnStatus(c context.Context, orderReturn entity.OrderReturn) error
+	UpsertRefund(c context.Context, refund entity.Refund) error
+	UpdateRefundStatus(c context.Context, orderReturnID int, status string) error
+	RestockReturnItems(c context.Context, orderReturnID int) error
+	UnstockReturnItems(c context.Context, orderReturnID int) (int, error)
+}
+
+type orderReturnRepoImpl struct {
+	db *sql.DB
+}
+
+func NewOrderReturnRepo(dbConn *sql.DB) orderReturnRepoImpl {
+	return orderReturnRepoImpl{
+		db: dbConn,
+	}
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func (r orderReturnRepoImpl) GetReturnableOrderDetail(c context.Context, orderDetailID int, userID int) (*entity.ReturnableOrderDetail, error) {
+	tx := transaction.ExtractTx(c)
+
+	query := `SELECT od.id, od.order_id, od.status,
+				COALESCE((
+					SELECT MAX(h.created_at)
+					FROM order_status_histories h
+					WHERE h.order_detail_id = od.id AND h.to_status = $3 AND h.deleted_at IS NULL
+				), od.updated_at),
+				COALESCE((
+					SELECT SUM(opd.price)
+					FROM order_product_details opd
+					WHERE opd.order_detail_id = od.id AND opd.deleted_at IS NULL
+				), 0),
+				od.discount
+				FROM order_details od
+				JOIN orders o ON o.id = od.order_id
+				WHERE od.id = $1 AND o.user_id = $2 AND od.deleted_at IS NULL AND o.deleted_at IS NULL`
+
+	var orderDetail entity.ReturnableOrderDetail
+	var row *sql.Row
+	if tx != nil {
+		row = tx.QueryRowContext(c, query+` FOR UPDATE OF od`, orderDetailID, userID, appconstant.StatusDelivered)
+	} else {
+		row = r.db.QueryRowContext(c, query, orderDetailID, userID, appconstant.StatusDelivered)
+	}
+	err := row.Scan(&orderDetail.ID, &orderDetail.OrderID, &orderDetail.Status, &orderDetail.DeliveredAt, &orderDetail.Subtotal, &orderDetail.Discount)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return &orderDetail, nil
+}
+
+func (r orderReturnRepoImpl) GetReturnableItems(c context.Context, orderDetailID int) ([]entity.ReturnableItem, error) {
+	tx := transaction.ExtractTx(c)
+	items := []entity.ReturnableItem{}
+
+	query := `SELECT opd.id, opd.quantity, opd.price,
+				COALESCE((
+					SELECT SUM(ori.quantity)
+					FROM order_return_items ori
+					JOIN order_returns orr ON orr.id = ori.order_return_id
+					WHERE ori.order_product_detail_id = opd.id AND orr.status <> $2
+					AND ori.deleted_at IS NULL AND orr.deleted_at IS NULL
+				), 0)
+				FROM order_product_details opd
+				WHERE opd.order_detail_id = $1 AND opd.deleted_at IS NULL`
+
+	var rows *sql.Rows
+	var err error
+	if tx != nil {
+		rows, err = tx.QueryContext(c, query+` FOR UPDATE OF opd`, orderDetailID, appconstant.ReturnStatusRejected)
+	} else {
+		rows, err = r.db.QueryContext(c, query, orderDetailID, appconstant.ReturnStatusRejected)
+	}
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item entity.ReturnableItem
+		err := rows.Scan(&item.OrderProductDetailID, &item.Quantity, &item.Price, &item.ReturnedQuantity)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (r orderReturnRepoImpl) AddOrderReturn(c context.Context, orderReturn entity.OrderReturn) (*entity.OrderReturn, error) {
+	tx := transaction.ExtractTx(c)
+
+	query := `INSERT INTO order_returns (order_detail_id, user_id, reason, status)
+				VALUES ($1, $2, $3, $4)
+				RETURNING id, created_at`
+
+	var err error
+	if tx != nil {
+		err = tx.QueryRowContext(c, query, orderReturn.OrderDetailID, orderReturn.UserID, orderReturn.Reason, appconstant.ReturnStatusPending).Scan(&orderReturn.ID, &orderReturn.CreatedAt)
+	} else {
+		err = r.db.QueryRowContext(c, query, orderReturn.OrderDetailID, orderReturn.UserID, orderReturn.Reason, appconstant.ReturnStatusPending).Scan(&orderReturn.ID, &orderReturn.CreatedAt)
+	}
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	orderReturn.Status = appconstant.ReturnStatusPending
+	return &orderReturn, nil
+}
+
+func (r orderReturnRepoImpl) AddReturnItems(c context.Context, orderReturnID int, items []entity.OrderReturnItem) error {
+	tx := transaction.ExtractTx(c)
+	valueStrings := make([]string, 0, len(items))
+	valueArgs := make([]interface{}, 0, len(items)*4)
+	for i, item := range items {
+		valueStrings = append(valueStrings, fmt.Sprintf("($%d,$%d,$%d,$%d)", i*4+1, i*4+2, i*4+3, i*4+4))
+		valueArgs = append(valueArgs, orderReturnID, item.OrderProductDetailID, item.Quantity, item.Amount)
+	}
+	query := fmt.Sprintf(`INSERT INTO order_return_items (order_return_id, order_product_detail_id, quantity, amount)
+				VALUES %s`, strings.Join(valueStrings, ","))
+
+	var err error
+	if tx != nil {
+		_, err = tx.ExecContext(c, query, valueArgs...)
+	} else {
+		_, err = r.db.ExecContext(c, query, valueArgs...)
+	}
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+func (r orderReturnRepoImpl) AddReturnImages(c context.Context, orderReturnID int, images []string) error {
+	tx := transaction.ExtractTx(c)
+	valueStrings := make([]string, 0, len(images))
+	valueArgs := make([]interface{}, 0, len(images)*2)
+	for i, image := range images {
+		valueStrings = append(valueStrings, fmt.Sprintf("($%d,$%d)", i*2+1, i*2+2))
+		valueArgs = append(valueArgs, orderReturnID, image)
+	}
+	query := fmt.Sprintf(`INSERT INTO order_return_images (order_return_id, image)
+				VALUES %s`, strings.Join(valueStrings, ","))
+
+	var err error
+	if tx != nil {
+		_, err = tx.ExecContext(c, query, valueArgs...)
+	} else {
+		_, err = r.db.ExecContext(c, query, valueArgs...)
+	}
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+func (r orderReturnRepoImpl) AddReturnHistory(c context.Context, history entity.ReturnHistory) error {
+	tx := transaction.ExtractTx(c)
+
+	query := `INSERT INTO order_return_histories (order_return_id, from_status, to_status, actor, actor_id, reason)
+				VALUES ($1, $2, $3, $4, $5, $6)`
+
+	var err error
+	if tx != nil {
+		_, err = tx.ExecContext(c, query, history.OrderReturnID, history.FromStatus, history.ToStatus, history.Actor, history.ActorID, history.Reason)
+	} else {
+		_, err = r.db.ExecContext(c, query, history.OrderReturnID, history.FromStatus, history.ToStatus, history.Actor, history.ActorID, history.Reason)
+	}
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+const orderReturnColumns = `orr.id, orr.order_detail_id, od.order_id, od.pharmacy_id, ph.name, orr.user_id, u.name,
+				orr.reason, orr.status, orr.reviewer_id, orr.rejection_reason, orr.is_restocked, orr.reviewed_at, orr.created_at`
+
+const orderReturnJoins = `FROM order_returns orr
+				JOIN order_details od ON od.id = orr.order_detail_id
+				JOIN pharmacies ph ON ph.id = od.pharmacy_id
+				JOIN users u ON u.id = orr.user_id`
+
+func scanOrderReturn(row rowScanner) (*entity.OrderReturn, error) {
+	var orderReturn entity.OrderReturn
+	err := row.Scan(
+		&orderReturn.ID,
+		&orderReturn.OrderDetailID,
+		&orderReturn.OrderID,
+		&orderReturn.PharmacyID,
+		&orderReturn.PharmacyName,
+		&orderReturn.UserID,
+		&orderReturn.UserName,
+		&orderReturn.Reason,
+		&orderReturn.Status,
+		&orderReturn.ReviewerID,
+		&orderReturn.RejectionReason,
+		&orderReturn.IsRestocked,
+		&orderReturn.ReviewedAt,
+		&orderReturn.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return &orderReturn, nil
+}
+
+func (r orderReturnRepoImpl) GetReturns(c context.Context, filter entity.ReturnFilter) ([]entity.OrderReturn, error) {
+	orderReturns := []entity.OrderReturn{}
+
+	query := `SELECT ` + orderReturnColumns + `
+				` + orderReturnJoins + `
+				WHERE orr.deleted_at IS NULL`
+
+	args := []any{}
+	if filter.UserID != nil {
+		args = append(args, *filter.UserID)
+		query += fmt.Sprintf(` AND orr.user_id = $%d`, len(args))
+	}
+	if filter.PharmacyID != nil {
+		args = append(args, *filter.PharmacyID)
+		query += fmt.Sprintf(` AND od.pharmacy_id = $%d`, len(args))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		query += fmt.Sprintf(` AND orr.status = $%d`, len(args))
+	}
+	query += ` ORDER BY orr.created_at, orr.id`
+
+	rows, err := r.db.QueryContext(c, query, args...)
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		orderReturn, err := scanOrderReturn(rows)
+		if err != nil {
+			return nil, err
+		}
+		orderReturns = append(orderReturns, *orderReturn)
+	}
+	return orderReturns, nil
+}
+
+func (r orderReturnRepoImpl) GetReturnByID(c context.Context, orderReturnID int) (*entity.OrderReturn, error) {
+	tx := transaction.ExtractTx(c)
+
+	query := `SELECT ` + orderReturnColumns + `
+				` + orderReturnJoins + `
+				WHERE orr.id = $1 AND orr.deleted_at IS NULL`
+
+	if tx != nil {
+		return scanOrderReturn(tx.QueryRowContext(c, query+` FOR UPDATE OF orr`, orderReturnID))
+	}
+	return scanOrderReturn(r.db.QueryRowContext(c, query, orderReturnID))
+}
+
+func (r orderReturnRepoImpl) GetReturnItems(c context.Context, orderReturnID int) ([]entity.OrderReturnItem, error) {
+	items := []entity.OrderReturnItem{}
+
+	query := `SELECT ori.id, ori.order_product_detail_id, opd.pharmacy_product_id, p.name, ori.quantity, ori.amount
+				FROM order_return_items ori
+				JOIN order_product_details opd ON opd.id = ori.order_product_detail_id
+				JOIN pharmacy_products pp ON pp.id = opd.pharmacy_product_id
+				JOIN products p ON p.id = pp.product_id
+				WHERE ori.order_return_id = $1 AND ori.deleted_at IS NULL
+				ORDER BY ori.id`
+
+	rows, err := r.db.QueryContext(c, query, orderReturnID)
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item entity.OrderReturnItem
+		err := rows.Scan(&item.ID, &item.OrderProductDetailID, &item.PharmacyProductID, &item.ProductName, &item.Quantity, &item.Amount)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (r orderReturnRepoImpl) GetReturnImages(c context.Context, orderReturnID int) ([]string, error) {
+	images := []string{}
+
+	query := `SELECT image FROM order_return_images WHERE order_return_id = $1 AND deleted_at IS NULL ORDER BY id`
+
+	rows, err := r.db.QueryContext(c, query, orderReturnID)
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var image string
+		err := rows.Scan(&image)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
+		images = append(images, image)
+	}
+	return images, nil
+}
+
+func (r orderReturnRepoImpl) GetReturnHistories(c context.Context, orderReturnID int) ([]entity.ReturnHistory, error) {
+	histories := []entity.ReturnHistory{}
+
+	query := `SELECT order_return_id, from_status, to_status, actor, actor_id, reason, created_at
+				FROM order_return_histories
+				WHERE order_return_id = $1 AND deleted_at IS NULL
+				ORDER BY created_at, id`
+
+	rows, err := r.db.QueryContext(c, query, orderReturnID)
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var history entity.ReturnHistory
+		err := rows.Scan(&history.OrderReturnID, &history.FromStatus, &history.ToStatus, &history.Actor, &history.ActorID, &history.Reason, &history.CreatedAt)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
+		histories = append(histories, history)
+	}
+	return histories, nil
+}
+
+func (r orderReturnRepoImpl) GetRefundByReturnID(c context.Context, orderReturnID int) (*entity.Refund, error) {
+	query := `SELECT id, order_return_id, order_id, amount, status, created_at
+				FROM refunds
+				WHERE order_return_id = $1 AND deleted_at IS NULL`
+
+	var refund entity.Refund
+	err := r.db.QueryRowContext(c, query, orderReturnID).Scan(&refund.ID, &refund.OrderReturnID, &refund.OrderID, &refund.Amount, &refund.Status, &refund.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return &refund, nil
+}
+
+func (r orderReturnRepoImpl) UpdateReturnStatus(c context.Context, orderReturn entity.OrderReturn) error {
+	tx := transaction.ExtractTx(c)
+
+	query := `UPDATE order_returns
+				SET status = $2, reviewer_id = $3, rejection_reason = $4, is_restocked = $5, reviewed_at = NOW(), updated_at = NOW()
+				WHERE id = $1 AND deleted_at IS NULL`
+
+	var err error
+	if tx != nil {
+		_, err = tx.ExecContext(c, query, orderReturn.ID, orderReturn.Status, orderReturn.ReviewerID, orderReturn.RejectionReason, orderReturn.IsRestocked)
+	} else {
+		_, err = r.db.ExecContext(c, query, orderReturn.ID, orderReturn.Status, orderReturn.ReviewerID, orderReturn.RejectionReason, orderReturn.IsRestocked)
+	}
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+func (r orderReturnRepoImpl) UpsertRefund(c context.Context, refund entity.Refund) error {
+	tx := transaction.ExtractTx(c)
+
+	query := `INSERT INTO refunds (order_return_id, order_id, amount, status)
+				VALUES ($1, $2, $3, $4)
+				ON CONFLICT (order_return_id) DO UPDATE
+				SET amount = EXCLUDED.amount, status = EXCLUDED.status, updated_at = NOW()`
+
+	var err error
+	if tx != nil {
+		_, err = tx.ExecContext(c, query, refund.OrderReturnID, refund.OrderID, refund.Amount, refund.Status)
+	} else {
+		_, err = r.db.ExecContext(c, query, refund.OrderReturnID, refund.OrderID, refund.Amount, refund.Status)
+	}
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+func (r orderReturnRepoImpl) UpdateRefundStatus(c context.Context, orderReturnID int, status string) error {
+	tx := transaction.ExtractTx(c)
+
+	query := `UPDATE refunds SET status = $2, updated_at = NOW() WHERE order_return_id = $1 AND deleted_at IS NULL`
+
+	var err error
+	if tx != nil {
+		_, err = tx.ExecContext(c, query, orderReturnID, status)
+	} else {
+		_, err = r.db.ExecContext(c, query, orderReturnID, status)
+	}
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+func (r orderReturnRepoImpl) RestockReturnItems(c context.Context, orderReturnID int) error {
+	tx := transaction.ExtractTx(c)
+
+	query := `WITH updated AS (
+					UPDATE pharmacy_products pp
+					SET stock = pp.stock + ori.quantity, updated_at = NOW()
+					FROM order_return_items ori
+					JOIN order_product_details opd ON opd.id = ori.order_product_detail_id
+					WHERE ori.order_return_id = $1 AND opd.pharmacy_product_id = pp.id AND ori.deleted_at IS NULL
+					RETURNING pp.id, pp.stock, ori.quantity
+				)
+				INSERT INTO stock_movements (pharmacy_product_id, delta, balance, reason, reference_id)
+				SELECT id, quantity, stock, $2, $1 FROM updated`
+
+	var err error
+	if tx != nil {
+		_, err = tx.ExecContext(c, query, orderReturnID, appconstant.StockMovementReturn)
+	} else {
+		_, err = r.db.ExecContext(c, query, orderReturnID, appconstant.StockMovementReturn)
+	}
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+// UnstockReturnItems takes restocked return items back out of stock. Items
+// already sold again are left untouched, so the caller compares the returned
+// count with the number of return items.
+func (r orderReturnRepoImpl) UnstockReturnItems(c context.Context, orderReturnID int) (int, error) {
+	tx := transaction.ExtractTx(c)
+
+	query := `WITH updated AS (
+					UPDATE pharmacy_products pp
+					SET stock = pp.stock - ori.quantity, updated_at = NOW()
+					FROM order_return_items ori
+					JOIN order_product_details opd ON opd.id = ori.order_product_detail_id
+					WHERE ori.order_return_id = $1 AND opd.pharmacy_product_id = pp.id AND ori.deleted_at IS NULL
+					AND pp.stock >= ori.quantity
+					RETURNING pp.id, pp.stock, ori.quantity
+				)
+				INSERT INTO stock_movements (pharmacy_product_id, delta, balance, reason, reference_id)
+				SELECT id, -quantity, stock, $2, $1 FROM updated`
+
+	var res sql.Result
+	var err error
+	if tx != nil {
+		res, err = tx.ExecContext(c, query, orderReturnID, appconstant.StockMovementReturnReversal)
+	} else {
+		res, err = r.db.ExecContext(c, query, orderReturnID, appconstant.StockMovementReturnReversal)
+	}
+	if err != nil {
+		return 0, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return int(affected), nil
+}
@@ -0,0 +1,445 @@
+package usecase
+
+import (
+	"context"
+	"montelukast/modules/orderreturn/entity"
+	"montelukast/modules/orderreturn/repository"
+	pharmacistRepo "montelukast/modules/pharmacist/repository"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"montelukast/pkg/imageuploader"
+	"montelukast/pkg/transaction"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+)
+
+type OrderReturnUsecase interface {
+	RequestReturn(c context.Context, request entity.ReturnRequest) (*entity.OrderReturn, error)
+	GetUserReturns(c context.Context, userID int, status string) ([]entity.OrderReturn, error)
+	GetUserReturn(c context.Context, orderReturnID int, userID int) (*entity.OrderReturn, error)
+	GetReturns(c context.Context, status string, reviewer entity.Reviewer) ([]entity.OrderReturn, error)
+	GetReturn(c context.Context, orderReturnID int, reviewer entity.Reviewer) (*entity.OrderReturn, error)
+	ApproveReturn(c context.Context, orderReturnID int, restock bool, reviewer entity.Reviewer) error
+	RejectReturn(c context.Context, orderReturnID int, reason string, reviewer entity.Reviewer) error
+}
+
+type orderReturnUsecaseImpl struct {
+	r   repository.OrderReturnRepo
+	tr  transaction.TransactorRepoImpl
+	phr pharmacistRepo.PharmacistRepo
+}
+
+func NewOrderReturnUsecase(r repository.OrderReturnRepo, tr transaction.TransactorRepoImpl, phr pharmacistRepo.PharmacistRepo) orderReturnUsecaseImpl {
+	return orderReturnUsecaseImpl{
+		r:   r,
+		tr:  tr,
+		phr: phr,
+	}
+}
+
+func (u orderReturnUsecaseImpl) RequestReturn(c context.Context, request entity.ReturnRequest) (*entity.OrderReturn, error) {
+	request.Reason = strings.TrimSpace(request.Reason)
+	if request.Reason == "" {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrRequestReturn, apperror.ErrReturnReasonRequired, apperror.ErrReturnReasonRequired)
+	}
+	if len(request.Items) == 0 {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrRequestReturn, apperror.ErrInvalidReturnItem, apperror.ErrInvalidReturnItem)
+	}
+	if len(request.Files) == 0 {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrRequestReturn, apperror.ErrReturnImagesRequired, apperror.ErrReturnImagesRequired)
+	}
+	if len(request.Files) > appconstant.MaxReturnImages {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrRequestReturn, apperror.ErrTooManyReturnImages, apperror.ErrTooManyReturnImages)
+	}
+	validate := validator.New()
+	for _, file := range request.Files {
+		err := validate.Struct(file)
+		if err != nil {
+			return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrRequestReturn, apperror.ErrFileEmpty, err)
+		}
+	}
+
+	_, err := u.getReturnableOrderDetail(c, request.OrderDetailID, request.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	images := []string{}
+	for _, file := range request.Files {
+		uploadUrl, err := imageuploader.ImageUploadHelper(file.File)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrRequestReturn, apperror.ErrUploadImage, err)
+		}
+		images = append(images, uploadUrl)
+	}
+
+	var orderReturn *entity.OrderReturn
+	err = u.tr.WithinTransaction(c, func(txCtx context.Context) error {
+		orderDetail, err := u.getReturnableOrderDetail(txCtx, request.OrderDetailID, request.UserID)
+		if err != nil {
+			return err
+		}
+		returnableItems, err := u.r.GetReturnableItems(txCtx, orderDetail.ID)
+		if err != nil {
+			return err
+		}
+		items, err := buildReturnItems(*orderDetail, returnableItems, request.Items)
+		if err != nil {
+			return err
+		}
+
+		orderReturn, err = u.r.AddOrderReturn(txCtx, entity.OrderReturn{
+			OrderDetailID: orderDetail.ID,
+			OrderID:       orderDetail.OrderID,
+			UserID:        request.UserID,
+			Reason:        request.Reason,
+		})
+		if err != nil {
+			return err
+		}
+		err = u.r.AddReturnItems(txCtx, orderReturn.ID, items)
+		if err != nil {
+			return err
+		}
+		err = u.r.AddReturnImages(txCtx, orderReturn.ID, images)
+		if err != nil {
+			return err
+		}
+		orderReturn.Items = items
+		orderReturn.Images = images
+
+		return u.r.AddReturnHistory(txCtx, entity.ReturnHistory{
+			OrderReturnID: orderReturn.ID,
+			ToStatus:      appconstant.ReturnStatusPending,
+			Actor:         appconstant.ActorUser,
+			ActorID:       &request.UserID,
+			Reason:        &request.Reason,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orderReturn, nil
+}
+
+func (u orderReturnUsecaseImpl) GetUserReturns(c context.Context, userID int, status string) ([]entity.OrderReturn, error) {
+	return u.r.GetReturns(c, entity.ReturnFilter{UserID: &userID, Status: status})
+}
+
+func (u orderReturnUsecaseImpl) GetUserReturn(c context.Context, orderReturnID int, userID int) (*entity.OrderReturn, error) {
+	orderReturn, err := u.r.GetReturnByID(c, orderReturnID)
+	if err != nil {
+		return nil, err
+	}
+	if orderReturn == nil || orderReturn.UserID != userID {
+		return nil, apperror.NewErrStatusNotFound(appconstant.FieldErrGetReturns, apperror.ErrReturnNotExists, apperror.ErrReturnNotExists)
+	}
+	return u.withDetails(c, orderReturn)
+}
+
+func (u orderReturnUsecaseImpl) GetReturns(c context.Context, status string, reviewer entity.Reviewer) ([]entity.OrderReturn, error) {
+	filter := entity.ReturnFilter{Status: status}
+	if reviewer.Role == appconstant.ROLE_PHARMACY {
+		pharmacyID, err := u.getPharmacyID(c, reviewer.ID, appconstant.FieldErrGetReturns)
+		if err != nil {
+			return nil, err
+		}
+		filter.PharmacyID = &pharmacyID
+	}
+	return u.r.GetReturns(c, filter)
+}
+
+func (u orderReturnUsecaseImpl) GetReturn(c context.Context, orderReturnID int, reviewer entity.Reviewer) (*entity.OrderReturn, error) {
+	orderReturn, err := u.getReviewableReturn(c, orderReturnID, reviewer, appconstant.FieldErrGetReturns)
+	if err != nil {
+		return nil, err
+	}
+	return u.withDetails(c, orderReturn)
+}
+
+func (u orderReturnUsecaseImpl) ApproveReturn(c context.Context, orderReturnID int, restock bool, reviewer entity.Reviewer) error {
+	_, err := u.getReviewableReturn(c, orderReturnID, reviewer, appconstant.FieldErrReviewReturn)
+	if err != nil {
+		return err
+	}
+
+	return u.tr.WithinTransaction(c, func(txCtx context.Context) error {
+		orderReturn, err := u.lockDecidableReturn(txCtx, orderReturnID, appconstant.ReturnStatusApproved, reviewer)
+		if err != nil {
+			return err
+		}
+		items, err := u.r.GetReturnItems(txCtx, orderReturn.ID)
+		if err != nil {
+			return err
+		}
+		if orderReturn.Status == appconstant.ReturnStatusRejected {
+			err = u.checkReturnableAgain(txCtx, orderReturn.OrderDetailID, items)
+			if err != nil {
+				return err
+			}
+		}
+		amount := decimal.Zero
+		for _, item := range items {
+			amount = amount.Add(item.Amount)
+		}
+
+		err = u.r.UpsertRefund(txCtx, entity.Refund{
+			OrderReturnID: orderReturn.ID,
+			OrderID:       orderReturn.OrderID,
+			Amount:        amount,
+			Status:        appconstant.RefundStatusPending,
+		})
+		if err != nil {
+			return err
+		}
+		if restock && !orderReturn.IsRestocked {
+			err = u.r.RestockReturnItems(txCtx, orderReturn.ID)
+			if err != nil {
+				return err
+			}
+			orderReturn.IsRestocked = true
+		}
+
+		from := orderReturn.Status
+		orderReturn.Status = appconstant.ReturnStatusApproved
+		orderReturn.ReviewerID = &reviewer.ID
+		orderReturn.RejectionReason = nil
+		err = u.r.UpdateReturnStatus(txCtx, *orderReturn)
+		if err != nil {
+			return err
+		}
+
+		return u.r.AddReturnHistory(txCtx, entity.ReturnHistory{
+			OrderReturnID: orderReturn.ID,
+			FromStatus:    &from,
+			ToStatus:      appconstant.ReturnStatusApproved,
+			Actor:         reviewer.Role,
+			ActorID:       &reviewer.ID,
+		})
+	})
+}
+
+func (u orderReturnUsecaseImpl) RejectReturn(c context.Context, orderReturnID int, reason string, reviewer entity.Reviewer) error {
+	_, err := u.getReviewableReturn(c, orderReturnID, reviewer, appconstant.FieldErrReviewReturn)
+	if err != nil {
+		return err
+	}
+
+	return u.tr.WithinTransaction(c, func(txCtx context.Context) error {
+		orderReturn, err := u.lockDecidableReturn(txCtx, orderReturnID, appconstant.ReturnStatusRejected, reviewer)
+		if err != nil {
+			return err
+		}
+		if orderReturn.Status == appconstant.ReturnStatusApproved {
+			err = u.r.UpdateRefundStatus(txCtx, orderReturn.ID, appconstant.RefundStatusCancelled)
+			if err != nil {
+				return err
+			}
+		}
+		if orderReturn.IsRestocked {
+			err = u.reverseRestock(txCtx, orderReturn.ID)
+			if err != nil {
+				return err
+			}
+			orderReturn.IsRestocked = false
+		}
+
+		from := orderReturn.Status
+		orderReturn.Status = appconstant.ReturnStatusRejected
+		orderReturn.ReviewerID = &reviewer.ID
+		orderReturn.RejectionReason = &reason
+		err = u.r.UpdateReturnStatus(txCtx, *orderReturn)
+		if err != nil {
+			return err
+		}
+
+		return u.r.AddReturnHistory(txCtx, entity.ReturnHistory{
+			OrderReturnID: orderReturn.ID,
+			FromStatus:    &from,
+			ToStatus:      appconstant.ReturnStatusRejected,
+			Actor:         reviewer.Role,
+			ActorID:       &reviewer.ID,
+			Reason:        &reason,
+		})
+	})
+}
+
+// checkReturnableAgain guards an override of a rejected return. Its items no
+// longer count as returned, so the user may have returned them again since.
+func (u orderReturnUsecaseImpl) checkReturnableAgain(c context.Context, orderDetailID int, items []entity.OrderReturnItem) error {
+	returnableItems, err := u.r.GetReturnableItems(c, orderDetailID)
+	if err != nil {
+		return err
+	}
+	returnable := make(map[int]entity.ReturnableItem)
+	for _, item := range returnableItems {
+		returnable[item.OrderProductDetailID] = item
+	}
+	for _, item := range items {
+		returnableItem := returnable[item.OrderProductDetailID]
+		if item.Quantity > returnableItem.Quantity-returnableItem.ReturnedQuantity {
+			return apperror.NewErrStatusBadRequest(appconstant.FieldErrReviewReturn, apperror.ErrReturnQuantityExceeded, apperror.ErrReturnQuantityExceeded)
+		}
+	}
+	return nil
+}
+
+// reverseRestock undoes the restock of an approved return that is overridden
+// to rejected. It fails once any restocked item has been sold again.
+func (u orderReturnUsecaseImpl) reverseRestock(c context.Context, orderReturnID int) error {
+	items, err := u.r.GetReturnItems(c, orderReturnID)
+	if err != nil {
+		return err
+	}
+	unstocked, err := u.r.UnstockReturnItems(c, orderReturnID)
+	if err != nil {
+		return err
+	}
+	if unstocked != len(items) {
+		return apperror.NewErrStatusBadRequest(appconstant.FieldErrReviewReturn, apperror.ErrReturnRestockSold, apperror.ErrReturnRestockSold)
+	}
+	return nil
+}
+
+func (u orderReturnUsecaseImpl) getReturnableOrderDetail(c context.Context, orderDetailID int, userID int) (*entity.ReturnableOrderDetail, error) {
+	orderDetail, err := u.r.GetReturnableOrderDetail(c, orderDetailID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if orderDetail == nil {
+		return nil, apperror.NewErrStatusNotFound(appconstant.FieldErrRequestReturn, apperror.ErrOrderDetailNotExists, apperror.ErrOrderDetailNotExists)
+	}
+	if orderDetail.Status != appconstant.StatusDelivered {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrRequestReturn, apperror.ErrOrderNotDelivered, apperror.ErrOrderNotDelivered)
+	}
+	if time.Now().After(orderDetail.DeliveredAt.Add(getReturnWindow())) {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrRequestReturn, apperror.ErrReturnWindowPassed, apperror.ErrReturnWindowPassed)
+	}
+	return orderDetail, nil
+}
+
+func buildReturnItems(orderDetail entity.ReturnableOrderDetail, returnableItems []entity.ReturnableItem, requested []entity.ReturnItemRequest) ([]entity.OrderReturnItem, error) {
+	returnable := make(map[int]entity.ReturnableItem)
+	for _, item := range returnableItems {
+		returnable[item.OrderProductDetailID] = item
+	}
+
+	items := []entity.OrderReturnItem{}
+	seen := make(map[int]bool)
+	for _, request := range requested {
+		item, ok := returnable[request.OrderProductDetailID]
+		if !ok || seen[request.OrderProductDetailID] || request.Quantity <= 0 {
+			return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrRequestReturn, apperror.ErrInvalidReturnItem, apperror.ErrInvalidReturnItem)
+		}
+		seen[request.OrderProductDetailID] = true
+		if request.Quantity > item.Quantity-item.ReturnedQuantity {
+			return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrRequestReturn, apperror.ErrReturnQuantityExceeded, apperror.ErrReturnQuantityExceeded)
+		}
+
+		amount := item.Price.Mul(decimal.NewFromInt(int64(request.Quantity))).Div(decimal.NewFromInt(int64(item.Quantity)))
+		if orderDetail.Discount.IsPositive() && orderDetail.Subtotal.IsPositive() {
+			amount = amount.Sub(orderDetail.Discount.Mul(amount).Div(orderDetail.Subtotal))
+		}
+		items = append(items, entity.OrderReturnItem{
+			OrderProductDetailID: request.OrderProductDetailID,
+			Quantity:             request.Quantity,
+			Amount:               amount.Round(2),
+		})
+	}
+	return items, nil
+}
+
+func (u orderReturnUsecaseImpl) withDetails(c context.Context, orderReturn *entity.OrderReturn) (*entity.OrderReturn, error) {
+	items, err := u.r.GetReturnItems(c, orderReturn.ID)
+	if err != nil {
+		return nil, err
+	}
+	images, err := u.r.GetReturnImages(c, orderReturn.ID)
+	if err != nil {
+		return nil, err
+	}
+	refund, err := u.r.GetRefundByReturnID(c, orderReturn.ID)
+	if err != nil {
+		return nil, err
+	}
+	histories, err := u.r.GetReturnHistories(c, orderReturn.ID)
+	if err != nil {
+		return nil, err
+	}
+	orderReturn.Items = items
+	orderReturn.Images = images
+	orderReturn.Refund = refund
+	orderReturn.Histories = histories
+	return orderReturn, nil
+}
+
+func (u orderReturnUsecaseImpl) lockDecidableReturn(c context.Context, orderReturnID int, to string, reviewer entity.Reviewer) (*entity.OrderReturn, error) {
+	orderReturn, err := u.r.GetReturnByID(c, orderReturnID)
+	if err != nil {
+		return nil, err
+	}
+	if orderReturn == nil {
+		return nil, apperror.NewErrStatusNotFound(appconstant.FieldErrReviewReturn, apperror.ErrReturnNotExists, apperror.ErrReturnNotExists)
+	}
+	if orderReturn.Status == to {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrReviewReturn, apperror.ErrReturnAlreadyReviewed, apperror.ErrReturnAlreadyReviewed)
+	}
+	if orderReturn.Status != appconstant.ReturnStatusPending && reviewer.Role != appconstant.ROLE_ADMIN {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrReviewReturn, apperror.ErrReturnAlreadyReviewed, apperror.ErrReturnAlreadyReviewed)
+	}
+	return orderReturn, nil
+}
+
+func (u orderReturnUsecaseImpl) getReviewableReturn(c context.Context, orderReturnID int, reviewer entity.Reviewer, field string) (*entity.OrderReturn, error) {
+	orderReturn, err := u.r.GetReturnByID(c, orderReturnID)
+	if err != nil {
+		return nil, err
+	}
+	if orderReturn == nil {
+		return nil, apperror.NewErrStatusNotFound(field, apperror.ErrReturnNotExists, apperror.ErrReturnNotExists)
+	}
+	if reviewer.Role != appconstant.ROLE_PHARMACY {
+		return orderReturn, nil
+	}
+
+	pharmacyID, err := u.getPharmacyID(c, reviewer.ID, field)
+	if err != nil {
+		return nil, err
+	}
+	if orderReturn.PharmacyID != pharmacyID {
+		return nil, apperror.NewErrStatusNotFound(field, apperror.ErrReturnNotExists, apperror.ErrReturnNotExists)
+	}
+	return orderReturn, nil
+}
+
+func (u orderReturnUsecaseImpl) getPharmacyID(c context.Context, pharmacistID int, field string) (int, error) {
+	isExists, err := u.phr.IsPharmacistExistsByID(c, pharmacistID)
+	if err != nil {
+		return 0, err
+	}
+	if !isExists {
+		return 0, apperror.NewErrStatusNotFound(field, apperror.ErrPharmacistNotExists, apperror.ErrPharmacistNotExists)
+	}
+
+	pharmacyID, err := u.phr.GetPharmacyIDByPharmacistID(c, pharmacistID)
+	if err != nil {
+		return 0, err
+	}
+	if pharmacyID == nil {
+		return 0, apperror.NewErrStatusBadRequest(field, apperror.ErrPharmacistNotHasPharmacy, apperror.ErrPharmacistNotHasPharmacy)
+	}
+	return *pharmacyID, nil
+}
+
+func getReturnWindow() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("RETURN_WINDOW_DAYS"))
+	if err != nil || days <= 0 {
+		days = appconstant.DefaultReturnWindowDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+type Transition struct {
+	OrderDetailID int
+	To            string
+	Actor         string
+	ActorID       *int
+	Reason        *string
+}
+
+type StatusHistory struct {
+	ID            int
+	OrderDetailID int
+	FromStatus    *string
+	ToStatus      string
+	Actor         string
+	ActorID       *int
+	Reason        *string
+	CreatedAt     time.Time
+}
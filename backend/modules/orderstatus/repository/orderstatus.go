@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"montelukast/modules/orderstatus/entity"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"montelukast/pkg/transaction"
+)
+
+type OrderStatusRepo interface {
+	GetStatusForUpdate(c context.Context, orderDetailID int) (string, error)
+	UpdateStatus(c context.Context, orderDetailID int, status string) error
+	RestoreStockByOrderDetailID(c context.Context, orderDetailID int) error
+	AddStatusHistory(c context.Context, history entity.StatusHistory) error
+	IsPrescriptionApprovalPending(c context.Context, orderDetailID int) (bool, error)
+}
+
+type orderStatusRepoImpl struct {
+	db *sql.DB
+}
+
+func NewOrderStatusRepo(dbConn *sql.DB) orderStatusRepoImpl {
+	return orderStatusRepoImpl{
+		db: dbConn,
+	}
+}
+
+func (r orderStatusRepoImpl) GetStatusForUpdate(c context.Context, orderDetailID int) (string, error) {
+	tx := transaction.ExtractTx(c)
+
+	query := `SELECT status FROM order_details WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+
+	var status string
+	var err error
+	if tx != nil {
+		err = tx.QueryRowContext(c, query, orderDetailID).Scan(&status)
+	} else {
+		err = r.db.QueryRowContext(c, query, orderDetailID).Scan(&status)
+	}
+	if err == sql.ErrNoRows {
+		return "", apperror.NewErrStatusNotFound(appconstant.FieldErrOrderStatus, apperror.ErrOrderDetailNotExists, err)
+	}
+	if err != nil {
+		return "", apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return status, nil
+}
+
+func (r orderStatusRepoImpl) UpdateStatus(c context.Context, orderDetailID int, status string) error {
+	tx := transaction.ExtractTx(c)
+
+	query := `UPDATE order_details
+				SET status = $2, updated_at = NOW()
+				WHERE id = $1 AND deleted_at IS NULL`
+
+	var err error
+	if tx != nil {
+		_, err = tx.ExecContext(c, query, orderDetailID, status)
+	} else {
+		_, err = r.db.ExecContext(c, query, orderDetailID, status)
+	}
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+func (r orderStatusRepoImpl) RestoreStockByOrderDetailID(c context.Context, orderDetailID int) error {
+	tx := transaction.ExtractTx(c)
+
+	query := `WITH updated AS (
+					UPDATE pharmacy_products pp
+					SET stock = pp.stock + opd.quantity, updated_at = NOW()
+					FROM order_product_details opd
+					WHERE opd.order_detail_id = $1 AND opd.pharmacy_product_id = pp.id AND opd.deleted_at IS NULL
+					RETURNING pp.id, pp.stock, opd.quantity
+				)
+				INSERT INTO stock_movements (pharmacy_product_id, delta, balance, reason, reference_id)
+				SELECT id, quantity, stock, $2, $1 FROM updated`
+
+	var err error
+	if tx != nil {
+		_, err = tx.ExecContext(c, query, orderDetailID, appconstant.StockMovementCancel)
+	} else {
+		_, err = r.db.ExecContext(c, query, orderDetailID, appconstant.StockMovementCancel)
+	}
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+func (r orderStatusRepoImpl) AddStatusHistory(c context.Context, history entity.StatusHistory) error {
+	tx := transaction.ExtractTx(c)
+
+	query := `INSERT INTO order_status_histories (order_detail_id, from_status, to_status, actor, actor_id, reason)
+				VALUES ($1, $2, $3, $4, $5, $6)`
+
+	var err error
+	if tx != nil {
+		_, err = tx.ExecContext(c, query, history.OrderDetailID, history.FromStatus, history.ToStatus, history.Actor, history.ActorID, history.Reason)
+	} else {
+		_, err = r.db.ExecContext(c, query, history.OrderDetailID, history.FromStatus, history.ToStatus, history.Actor, history.ActorID, history.Reason)
+	}
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+func (r orderStatusRepoImpl) IsPrescriptionApprovalPending(c context.Context, orderDetailID int) (bool, error) {
+	tx := transaction.ExtractTx(c)
+
+	query := `SELECT EXISTS (
+				SELECT 1
+				FROM order_details od
+				JOIN order_product_details opd ON opd.order_detail_id = od.id AND opd.deleted_at IS NULL
+				JOIN pharmacy_products pp ON pp.id = opd.pharmacy_product_id
+				JOIN products p ON p.id = pp.product_id
+				JOIN product_classifications pc ON pc.id = p.product_classification_id
+				WHERE od.id = $1 AND od.deleted_at IS NULL
+				AND pc.name = $2 AND od.prescription_status IS DISTINCT FROM $3
+			)`
+
+	var exists bool
+	var err error
+	if tx != nil {
+		err = tx.QueryRowContext(c, query, orderDetailID, appconstant.PrescriptionClassification, appconstant.PrescriptionStatusApproved).Scan(&exists)
+	} else {
+		err = r.db.QueryRowContext(c, query, orderDetailID, appconstant.PrescriptionClassification, appconstant.PrescriptionStatusApproved).Scan(&exists)
+	}
+	if err != nil {
+		return false, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return exists, nil
+}
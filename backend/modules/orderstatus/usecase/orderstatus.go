@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"context"
+	"montelukast/modules/orderstatus/entity"
+	"montelukast/modules/orderstatus/repository"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"montelukast/pkg/transaction"
+)
+
+var transitions = map[string]map[string][]string{
+	appconstant.StatusPending: {
+		appconstant.StatusProcessing:    {appconstant.ActorAdmin, appconstant.ActorSystem},
+		appconstant.StatusPaymentReview: {appconstant.ActorUser},
+		appconstant.StatusCancelled:     {appconstant.ActorUser, appconstant.ActorPharmacist, appconstant.ActorAdmin, appconstant.ActorSystem},
+	},
+	appconstant.StatusPaymentReview: {
+		appconstant.StatusProcessing: {appconstant.ActorPharmacist, appconstant.ActorAdmin},
+		appconstant.StatusPending:    {appconstant.ActorPharmacist, appconstant.ActorAdmin},
+		appconstant.StatusCancelled:  {appconstant.ActorAdmin},
+	},
+	appconstant.StatusProcessing: {
+		appconstant.StatusShipped:   {appconstant.ActorPharmacist},
+		appconstant.StatusCancelled: {appconstant.ActorUser, appconstant.ActorPharmacist, appconstant.ActorAdmin},
+	},
+	appconstant.StatusShipped: {
+		appconstant.StatusDelivered: {appconstant.ActorUser, appconstant.ActorSystem},
+	},
+}
+
+type OrderStatusUsecase interface {
+	Transition(c context.Context, transition entity.Transition) error
+	RecordInitialStatus(c context.Context, orderDetailIDs []int, userID int) error
+}
+
+type orderStatusUsecaseImpl struct {
+	r  repository.OrderStatusRepo
+	tr transaction.TransactorRepoImpl
+}
+
+func NewOrderStatusUsecase(r repository.OrderStatusRepo, tr transaction.TransactorRepoImpl) orderStatusUsecaseImpl {
+	return orderStatusUsecaseImpl{
+		r:  r,
+		tr: tr,
+	}
+}
+
+func CanTransition(from string, to string, actor string) error {
+	actors, ok := transitions[from][to]
+	if !ok {
+		return apperror.NewErrStatusBadRequest(appconstant.FieldErrOrderStatus, apperror.ErrInvalidStatusTransition, apperror.ErrInvalidStatusTransition)
+	}
+	for _, allowed := range actors {
+		if allowed == actor {
+			return nil
+		}
+	}
+	return apperror.NewErrStatusUnauthorized(appconstant.FieldErrOrderStatus, apperror.ErrActorNotAllowed, apperror.ErrActorNotAllowed)
+}
+
+func (u orderStatusUsecaseImpl) Transition(c context.Context, transition entity.Transition) error {
+	if transaction.ExtractTx(c) != nil {
+		return u.transition(c, transition)
+	}
+	return u.tr.WithinTransaction(c, func(txCtx context.Context) error {
+		return u.transition(txCtx, transition)
+	})
+}
+
+func (u orderStatusUsecaseImpl) transition(c context.Context, transition entity.Transition) error {
+	from, err := u.r.GetStatusForUpdate(c, transition.OrderDetailID)
+	if err != nil {
+		return err
+	}
+
+	err = CanTransition(from, transition.To, transition.Actor)
+	if err != nil {
+		return err
+	}
+
+	// Prescription drugs may only be processed once the pharmacy has approved
+	// the prescription, whichever path moves the order forward.
+	if transition.To == appconstant.StatusProcessing {
+		isPending, err := u.r.IsPrescriptionApprovalPending(c, transition.OrderDetailID)
+		if err != nil {
+			return err
+		}
+		if isPending {
+			return apperror.NewErrStatusBadRequest(appconstant.FieldErrOrderStatus, apperror.ErrPrescriptionNotApproved, apperror.ErrPrescriptionNotApproved)
+		}
+	}
+
+	err = u.r.UpdateStatus(c, transition.OrderDetailID, transition.To)
+	if err != nil {
+		return err
+	}
+
+	if transition.To == appconstant.StatusCancelled {
+		err = u.r.RestoreStockByOrderDetailID(c, transition.OrderDetailID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return u.r.AddStatusHistory(c, entity.StatusHistory{
+		OrderDetailID: transition.OrderDetailID,
+		FromStatus:    &from,
+		ToStatus:      transition.To,
+		Actor:         transition.Actor,
+		ActorID:       transition.ActorID,
+		Reason:        transition.Reason,
+	})
+}
+
+func (u orderStatusUsecaseImpl) RecordInitialStatus(c context.Context, orderDetailIDs []int, userID int) error {
+	for _, orderDetailID := range orderDetailIDs {
+		err := u.r.AddStatusHistory(c, entity.StatusHistory{
+			OrderDetailID: orderDetailID,
+			ToStatus:      appconstant.StatusPending,
+			Actor:         appconstant.ActorUser,
+			ActorID:       &userID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
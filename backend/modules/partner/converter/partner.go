@@ -16,6 +16,9 @@ func (c AddPartnerConverter) ToEntity(partnerReq dto.AddPartnerRequest) entity.P
 		StartHour: partnerReq.StartHour,
 		EndHour: partnerReq.EndHour,
 		IsActive: partnerReq.IsActive,
+		StockUpdateScope: partnerReq.StockUpdateScope,
+		StockUpdateLimit: partnerReq.StockUpdateLimit,
+		StockUpdateWindowHours: partnerReq.StockUpdateWindowHours,
 	}
 }
 
@@ -27,7 +30,9 @@ func (c UpdatePartnerConverter) ToEntity(partnerReq dto.UpdatePartnerRequest) en
 		StartHour: partnerReq.StartHour,
 		EndHour: partnerReq.EndHour,
 		IsActive: partnerReq.IsActive,
-
+		StockUpdateScope: partnerReq.StockUpdateScope,
+		StockUpdateLimit: partnerReq.StockUpdateLimit,
+		StockUpdateWindowHours: partnerReq.StockUpdateWindowHours,
 	}
 }
 
@@ -52,6 +57,9 @@ func (c GetPartnersConverter) ToDto(partner entity.Partner) dto.GetPartnersRespo
 		StartHour: partner.StartHour,
 		EndHour: partner.EndHour,
 		IsActive: partner.IsActive,
+		StockUpdateScope: partner.StockUpdateScope,
+		StockUpdateLimit: partner.StockUpdateLimit,
+		StockUpdateWindowHours: partner.StockUpdateWindowHours,
 	}
 }
 
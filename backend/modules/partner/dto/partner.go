@@ -1,19 +1,25 @@
 package dto
 
 type AddPartnerRequest struct {
-	Name        string `json:"name" binding:"required,min=3"`
-	YearFounded string `json:"year_founded" binding:"required,gte=0"`
-	ActiveDays  string `json:"active_days" binding:"required"`
-	StartHour   string `json:"start_hour" binding:"required"`
-	EndHour     string `json:"end_hour" binding:"required"`
-	IsActive    bool   `json:"is_active"`
+	Name                   string `json:"name" binding:"required,min=3"`
+	YearFounded            string `json:"year_founded" binding:"required,gte=0"`
+	ActiveDays             string `json:"active_days" binding:"required"`
+	StartHour              string `json:"start_hour" binding:"required"`
+	EndHour                string `json:"end_hour" binding:"required"`
+	IsActive               bool   `json:"is_active"`
+	StockUpdateScope       string `json:"stock_update_scope" binding:"required,oneof=pharmacy_product pharmacy"`
+	StockUpdateLimit       int    `json:"stock_update_limit" binding:"required,gte=1"`
+	StockUpdateWindowHours int    `json:"stock_update_window_hours" binding:"required,gte=1"`
 }
 
 type UpdatePartnerRequest struct {
-	ActiveDays string `json:"active_days" binding:"required"`
-	StartHour  string `json:"start_hour" binding:"required"`
-	EndHour    string `json:"end_hour" binding:"required"`
-	IsActive   bool   `json:"is_active"`
+	ActiveDays             string `json:"active_days" binding:"required"`
+	StartHour              string `json:"start_hour" binding:"required"`
+	EndHour                string `json:"end_hour" binding:"required"`
+	IsActive               bool   `json:"is_active"`
+	StockUpdateScope       string `json:"stock_update_scope" binding:"required,oneof=pharmacy_product pharmacy"`
+	StockUpdateLimit       int    `json:"stock_update_limit" binding:"required,gte=1"`
+	StockUpdateWindowHours int    `json:"stock_update_window_hours" binding:"required,gte=1"`
 }
 
 type DeletePartnerRequest struct {
@@ -21,13 +27,16 @@ type DeletePartnerRequest struct {
 }
 
 type GetPartnersResponse struct {
-	ID          int    `json:"id" binding:"required"`
-	Name        string `json:"name" binding:"required"`
-	YearFounded string `json:"year_founded" binding:"required"`
-	ActiveDays  string `json:"active_days" binding:"required"`
-	StartHour   string `json:"start_hour" binding:"required"`
-	EndHour     string `json:"end_hour" binding:"required"`
-	IsActive    bool   `json:"is_active"`
+	ID                     int    `json:"id" binding:"required"`
+	Name                   string `json:"name" binding:"required"`
+	YearFounded            string `json:"year_founded" binding:"required"`
+	ActiveDays             string `json:"active_days" binding:"required"`
+	StartHour              string `json:"start_hour" binding:"required"`
+	EndHour                string `json:"end_hour" binding:"required"`
+	IsActive               bool   `json:"is_active"`
+	StockUpdateScope       string `json:"stock_update_scope"`
+	StockUpdateLimit       int    `json:"stock_update_limit"`
+	StockUpdateWindowHours int    `json:"stock_update_window_hours"`
 }
 
 type Pagination struct {
@@ -1,13 +1,16 @@
 package entity
 
 type Partner struct {
-	ID          int    `json:"id"`
-	Name        string `json:"name"`
-	YearFounded string `json:"year_founded"`
-	ActiveDays  string `json:"active_days"`
-	StartHour   string `json:"start_hour"`
-	EndHour     string `json:"end_hour"`
-	IsActive    bool   `json:"is_active"`
+	ID                     int    `json:"id"`
+	Name                   string `json:"name"`
+	YearFounded            string `json:"year_founded"`
+	ActiveDays             string `json:"active_days"`
+	StartHour              string `json:"start_hour"`
+	EndHour                string `json:"end_hour"`
+	IsActive               bool   `json:"is_active"`
+	StockUpdateScope       string `json:"stock_update_scope"`
+	StockUpdateLimit       int    `json:"stock_update_limit"`
+	StockUpdateWindowHours int    `json:"stock_update_window_hours"`
 }
 
 type Pagination struct {
@@ -55,10 +55,11 @@ func (r partnerRepoImpl) IsPartnerExistsByID(c context.Context, partnerID int) (
 }
 
 func (r partnerRepoImpl) AddPartner(c context.Context, partner entity.Partner) error {
-	query := `INSERT INTO partners (name, year_founded, active_days, start_hour, end_hour, is_active)
-			  VALUES ($1, $2, $3, $4, $5, $6)`
+	query := `INSERT INTO partners (name, year_founded, active_days, start_hour, end_hour, is_active, stock_update_scope, stock_update_limit, stock_update_window_hours)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
 
-	_, err := r.db.Exec(query, partner.Name, partner.YearFounded, partner.ActiveDays, partner.StartHour, partner.EndHour, partner.IsActive)
+	_, err := r.db.Exec(query, partner.Name, partner.YearFounded, partner.ActiveDays, partner.StartHour, partner.EndHour, partner.IsActive,
+		partner.StockUpdateScope, partner.StockUpdateLimit, partner.StockUpdateWindowHours)
 	if err != nil {
 		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
 	}
@@ -80,14 +81,18 @@ func (r partnerRepoImpl) UpdatePartner(c context.Context, partner entity.Partner
 	tx := transaction.ExtractTx(c)
 
 	query := `UPDATE partners
-				SET active_days = $2, start_hour = $3, end_hour = $4, is_active = $5, updated_at = NOW()
+				SET active_days = $2, start_hour = $3, end_hour = $4, is_active = $5,
+				stock_update_scope = $6, stock_update_limit = $7, stock_update_window_hours = $8, updated_at = NOW()
 				WHERE id = $1`
 
+	args := []any{partner.ID, partner.ActiveDays, partner.StartHour, partner.EndHour, partner.IsActive,
+		partner.StockUpdateScope, partner.StockUpdateLimit, partner.StockUpdateWindowHours}
+
 	var err error
 	if tx != nil {
-		_, err = tx.ExecContext(c, query, partner.ID, partner.ActiveDays, partner.StartHour, partner.EndHour, partner.IsActive)
+		_, err = tx.ExecContext(c, query, args...)
 	} else {
-		_, err = r.db.ExecContext(c, query, partner.ID, partner.ActiveDays, partner.StartHour, partner.EndHour, partner.IsActive)
+		_, err = r.db.ExecContext(c, query, args...)
 	}
 	if err != nil {
 		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
@@ -117,7 +122,8 @@ func (r partnerRepoImpl) DeletePartner(c context.Context, partnerID int) error {
 func (r partnerRepoImpl) GetPartners(c context.Context, queryParams queryparams.QueryParams, totalItems int) ([]entity.Partner, error) {
 	partners := []entity.Partner{}
 
-	query := `SELECT id, name, year_founded, active_days, start_hour, end_hour, is_active 
+	query := `SELECT id, name, year_founded, active_days, start_hour, end_hour, is_active,
+				stock_update_scope, stock_update_limit, stock_update_window_hours
 				FROM partners p 
 				WHERE deleted_at IS NULL`
 
@@ -140,6 +146,9 @@ func (r partnerRepoImpl) GetPartners(c context.Context, queryParams queryparams.
 			&partner.StartHour,
 			&partner.EndHour,
 			&partner.IsActive,
+			&partner.StockUpdateScope,
+			&partner.StockUpdateLimit,
+			&partner.StockUpdateWindowHours,
 		)
 		if err != nil {
 			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
@@ -173,7 +182,8 @@ func (r partnerRepoImpl) GetTotalPartners(c context.Context, queryParams querypa
 }
 
 func (r partnerRepoImpl) GetPartner(c context.Context, partnerID int) (*entity.Partner, error) {
-	query := `select id, name, year_founded, active_days, start_hour, end_hour, is_active
+	query := `select id, name, year_founded, active_days, start_hour, end_hour, is_active,
+				stock_update_scope, stock_update_limit, stock_update_window_hours
 				from partners p 
 				where id = $1`
 
@@ -186,6 +196,9 @@ func (r partnerRepoImpl) GetPartner(c context.Context, partnerID int) (*entity.P
 		&partner.StartHour,
 		&partner.EndHour,
 		&partner.IsActive,
+		&partner.StockUpdateScope,
+		&partner.StockUpdateLimit,
+		&partner.StockUpdateWindowHours,
 	)
 	if err != nil {
 		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
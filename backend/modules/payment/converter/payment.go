@@ -0,0 +1,48 @@
+package converter
+
+import (
+	"montelukast/modules/payment/dto"
+	"montelukast/modules/payment/entity"
+)
+
+type PaymentConverter struct{}
+
+func (c PaymentConverter) ToDto(payment entity.Payment) dto.PaymentResponse {
+	var proof *dto.PaymentProofResponse
+	if payment.Proof != nil {
+		proofDto := PaymentProofConverter{}.ToDto(*payment.Proof)
+		proof = &proofDto
+	}
+	return dto.PaymentResponse{
+		ID:           payment.ID,
+		OrderID:      payment.OrderID,
+		Provider:     payment.Provider,
+		Reference:    payment.Reference,
+		Amount:       payment.Amount.String(),
+		Status:       payment.Status,
+		Instructions: payment.Instructions,
+		PaidAt:       payment.PaidAt,
+		CreatedAt:    payment.CreatedAt,
+		Proof:        proof,
+	}
+}
+
+type PaymentProofConverter struct{}
+
+func (c PaymentProofConverter) ToDto(proof entity.PaymentProof) dto.PaymentProofResponse {
+	return dto.PaymentProofResponse{
+		ID:              proof.ID,
+		PaymentID:       proof.PaymentID,
+		OrderID:         proof.OrderID,
+		UserID:          proof.UserID,
+		UserName:        proof.UserName,
+		Reference:       proof.Reference,
+		Amount:          proof.Amount.String(),
+		Image:           proof.Image,
+		Status:          proof.Status,
+		ReviewerID:      proof.ReviewerID,
+		RejectionReason: proof.RejectionReason,
+		ReviewedAt:      proof.ReviewedAt,
+		CreatedAt:       proof.CreatedAt,
+	}
+}
@@ -0,0 +1,44 @@
+package dto
+
+import "time"
+
+type CreatePaymentRequest struct {
+	Provider string `json:"provider" binding:"required"`
+}
+
+type PaymentResponse struct {
+	ID           int                   `json:"id"`
+	OrderID      int                   `json:"order_id"`
+	Provider     string                `json:"provider"`
+	Reference    string                `json:"reference"`
+	Amount       string                `json:"amount"`
+	Status       string                `json:"status"`
+	Instructions *string               `json:"instructions"`
+	PaidAt       *time.Time            `json:"paid_at"`
+	CreatedAt    time.Time             `json:"created_at"`
+	Proof        *PaymentProofResponse `json:"proof,omitempty"`
+}
+
+type PaymentProofFilterRequest struct {
+	Status string `form:"status"`
+}
+
+type RejectPaymentProofRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+type PaymentProofResponse struct {
+	ID              int        `json:"id"`
+	PaymentID       int        `json:"payment_id"`
+	OrderID         int        `json:"order_id"`
+	UserID          int        `json:"user_id"`
+	UserName        string     `json:"user_name"`
+	Reference       string     `json:"reference"`
+	Amount          string     `json:"amount"`
+	Image           string     `json:"image"`
+	Status          string     `json:"status"`
+	ReviewerID      *int       `json:"reviewer_id"`
+	RejectionReason *string    `json:"rejection_reason"`
+	ReviewedAt      *time.Time `json:"reviewed_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
@@ -0,0 +1,68 @@
+package entity
+
+import (
+	"mime/multipart"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type Payment struct {
+	ID           int
+	OrderID      int
+	Provider     string
+	Reference    string
+	Amount       decimal.Decimal
+	Status       string
+	Instructions *string
+	PaidAt       *time.Time
+	CreatedAt    time.Time
+	Proof        *PaymentProof
+}
+
+type Charge struct {
+	OrderID   int
+	Reference string
+	Amount    decimal.Decimal
+}
+
+type ChargeResult struct {
+	Reference    string
+	Status       string
+	Instructions string
+}
+
+type WebhookEvent struct {
+	Reference string
+	Status    string
+}
+
+type PaymentProof struct {
+	ID              int
+	PaymentID       int
+	OrderID         int
+	UserID          int
+	UserName        string
+	Reference       string
+	Amount          decimal.Decimal
+	Image           string
+	Status          string
+	ReviewerID      *int
+	RejectionReason *string
+	ReviewedAt      *time.Time
+	CreatedAt       time.Time
+}
+
+type PaymentProofFilter struct {
+	PharmacyID *int
+	Status     string
+}
+
+type Reviewer struct {
+	ID   int
+	Role string
+}
+
+type File struct {
+	File multipart.File `validate:"required"`
+}
@@ -0,0 +1,244 @@
+package handler
+
+import (
+	"io"
+	"montelukast/modules/payment/converter"
+	"montelukast/modules/payment/dto"
+	"montelukast/modules/payment/entity"
+	"montelukast/modules/payment/usecase"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"montelukast/pkg/wrapper"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PaymentHandler struct {
+	u usecase.PaymentUsecase
+}
+
+func NewPaymentHandler(u usecase.PaymentUsecase) PaymentHandler {
+	return PaymentHandler{
+		u: u,
+	}
+}
+
+func (h PaymentHandler) CreatePaymentHandler(c *gin.Context) {
+	rawUserID, isExists := c.Get("user_id")
+	if !isExists {
+		c.Error(apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, apperror.ErrInternalServer))
+		return
+	}
+	userID, err := strconv.Atoi(rawUserID.(string))
+	if err != nil {
+		c.Error(apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrUserUnauthorized, err))
+		return
+	}
+
+	rawOrderID := c.Param("order_id")
+	orderID, err := strconv.Atoi(rawOrderID)
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrCreatePayment, apperror.ErrConvertVariableType, err))
+		return
+	}
+
+	err = apperror.JsonValidator(c)
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrCreatePayment, apperror.ErrInvalidJSON, err))
+		return
+	}
+
+	paymentReq := dto.CreatePaymentRequest{}
+	err = c.ShouldBindJSON(&paymentReq)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	payment, err := h.u.CreatePayment(c, orderID, userID, paymentReq.Provider)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(converter.PaymentConverter{}.ToDto(*payment), "create payment success!", nil)
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h PaymentHandler) GetPaymentHandler(c *gin.Context) {
+	rawUserID, isExists := c.Get("user_id")
+	if !isExists {
+		c.Error(apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, apperror.ErrInternalServer))
+		return
+	}
+	userID, err := strconv.Atoi(rawUserID.(string))
+	if err != nil {
+		c.Error(apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrUserUnauthorized, err))
+		return
+	}
+
+	rawOrderID := c.Param("order_id")
+	orderID, err := strconv.Atoi(rawOrderID)
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrGetPayment, apperror.ErrConvertVariableType, err))
+		return
+	}
+
+	payment, err := h.u.GetPayment(c, orderID, userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(converter.PaymentConverter{}.ToDto(*payment), "get payment success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h PaymentHandler) WebhookHandler(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrPaymentWebhook, apperror.ErrInvalidWebhookPayload, err))
+		return
+	}
+
+	err = h.u.HandleWebhook(c, c.Param("provider"), c.Request.Header, body)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(nil, "payment webhook success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h PaymentHandler) GetPaymentProofsHandler(c *gin.Context) {
+	reviewer, err := getReviewer(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	filterReq := dto.PaymentProofFilterRequest{}
+	err = c.ShouldBindQuery(&filterReq)
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrGetPaymentProofs, apperror.ErrInvalidJSON, err))
+		return
+	}
+	if filterReq.Status == "" {
+		filterReq.Status = appconstant.PaymentProofStatusPending
+	}
+
+	proofs, err := h.u.GetPaymentProofs(c, filterReq.Status, *reviewer)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	proofsDto := []dto.PaymentProofResponse{}
+	for _, proof := range proofs {
+		proofsDto = append(proofsDto, converter.PaymentProofConverter{}.ToDto(proof))
+	}
+
+	response := wrapper.ResponseData(proofsDto, "get payment proofs success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h PaymentHandler) GetPaymentProofHandler(c *gin.Context) {
+	reviewer, err := getReviewer(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	proofID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrGetPaymentProofs, apperror.ErrConvertVariableType, err))
+		return
+	}
+
+	proof, err := h.u.GetPaymentProof(c, proofID, *reviewer)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(converter.PaymentProofConverter{}.ToDto(*proof), "get payment proof success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h PaymentHandler) ApprovePaymentProofHandler(c *gin.Context) {
+	reviewer, err := getReviewer(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	proofID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrReviewPaymentProof, apperror.ErrConvertVariableType, err))
+		return
+	}
+
+	err = h.u.ApprovePaymentProof(c, proofID, *reviewer)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(nil, "approve payment proof success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h PaymentHandler) RejectPaymentProofHandler(c *gin.Context) {
+	reviewer, err := getReviewer(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	proofID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrReviewPaymentProof, apperror.ErrConvertVariableType, err))
+		return
+	}
+
+	err = apperror.JsonValidator(c)
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrReviewPaymentProof, apperror.ErrInvalidJSON, err))
+		return
+	}
+
+	rejectReq := dto.RejectPaymentProofRequest{}
+	err = c.ShouldBindJSON(&rejectReq)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = h.u.RejectPaymentProof(c, proofID, rejectReq.Reason, *reviewer)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(nil, "reject payment proof success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func getReviewer(c *gin.Context) (*entity.Reviewer, error) {
+	rawUserID, isExists := c.Get("user_id")
+	if !isExists {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, apperror.ErrInternalServer)
+	}
+	userID, err := strconv.Atoi(rawUserID.(string))
+	if err != nil {
+		return nil, apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrUserUnauthorized, err)
+	}
+	role, isExists := c.Get("role")
+	if !isExists {
+		return nil, apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrUserUnauthorized, apperror.ErrUserUnauthorized)
+	}
+	return &entity.Reviewer{ID: userID, Role: role.(string)}, nil
+}
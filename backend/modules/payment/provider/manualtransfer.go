@@ -0,0 +1,45 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"montelukast/modules/payment/entity"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"net/http"
+)
+
+type manualTransferProvider struct {
+	bank          string
+	accountNumber string
+	accountName   string
+}
+
+func NewManualTransferProvider(bank string, accountNumber string, accountName string) manualTransferProvider {
+	return manualTransferProvider{
+		bank:          bank,
+		accountNumber: accountNumber,
+		accountName:   accountName,
+	}
+}
+
+func (p manualTransferProvider) Name() string {
+	return appconstant.PaymentProviderManualTransfer
+}
+
+func (p manualTransferProvider) CreateCharge(c context.Context, charge entity.Charge) (*entity.ChargeResult, error) {
+	return &entity.ChargeResult{
+		Reference: charge.Reference,
+		Status:    appconstant.PaymentStatusPending,
+		Instructions: fmt.Sprintf("transfer Rp%s to %s %s a.n. %s with reference %s, then upload the transfer proof",
+			charge.Amount.StringFixed(2), p.bank, p.accountNumber, p.accountName, charge.Reference),
+	}, nil
+}
+
+func (p manualTransferProvider) QueryStatus(c context.Context, reference string) (string, error) {
+	return appconstant.PaymentStatusPending, nil
+}
+
+func (p manualTransferProvider) VerifyWebhook(c context.Context, header http.Header, body []byte) (*entity.WebhookEvent, error) {
+	return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrPaymentWebhook, apperror.ErrWebhookNotSupported, apperror.ErrWebhookNotSupported)
+}
@@ -0,0 +1,14 @@
+package provider
+
+import (
+	"context"
+	"montelukast/modules/payment/entity"
+	"net/http"
+)
+
+type PaymentProvider interface {
+	Name() string
+	CreateCharge(c context.Context, charge entity.Charge) (*entity.ChargeResult, error)
+	QueryStatus(c context.Context, reference string) (string, error)
+	VerifyWebhook(c context.Context, header http.Header, body []byte) (*entity.WebhookEvent, error)
+}
@@ -0,0 +1,94 @@
+package provider
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"montelukast/modules/payment/entity"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"net/http"
+	"sync"
+)
+
+type simulatedWebhookPayload struct {
+	Reference string `json:"reference"`
+	Status    string `json:"status"`
+}
+
+type simulatedProvider struct {
+	secret   string
+	mu       sync.Mutex
+	statuses map[string]string
+}
+
+func NewSimulatedProvider(secret string) *simulatedProvider {
+	return &simulatedProvider{
+		secret:   secret,
+		statuses: make(map[string]string),
+	}
+}
+
+func (p *simulatedProvider) Name() string {
+	return appconstant.PaymentProviderSimulated
+}
+
+func (p *simulatedProvider) CreateCharge(c context.Context, charge entity.Charge) (*entity.ChargeResult, error) {
+	p.mu.Lock()
+	p.statuses[charge.Reference] = appconstant.PaymentStatusPending
+	p.mu.Unlock()
+
+	// The signature is never handed out: only a developer holding the secret
+	// can produce a valid webhook for this reference.
+	return &entity.ChargeResult{
+		Reference: charge.Reference,
+		Status:    appconstant.PaymentStatusPending,
+		Instructions: fmt.Sprintf("simulated charge %s is pending, confirm it with a webhook signed with the simulator secret",
+			charge.Reference),
+	}, nil
+}
+
+func (p *simulatedProvider) QueryStatus(c context.Context, reference string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status, ok := p.statuses[reference]
+	if !ok {
+		return appconstant.PaymentStatusPending, nil
+	}
+	return status, nil
+}
+
+func (p *simulatedProvider) VerifyWebhook(c context.Context, header http.Header, body []byte) (*entity.WebhookEvent, error) {
+	signature, err := hex.DecodeString(header.Get(appconstant.SimulatorSignatureHeader))
+	if err != nil || p.secret == "" || !hmac.Equal(signature, p.mac(body)) {
+		return nil, apperror.NewErrStatusUnauthorized(appconstant.FieldErrPaymentWebhook, apperror.ErrInvalidWebhookSignature, apperror.ErrInvalidWebhookSignature)
+	}
+
+	var payload simulatedWebhookPayload
+	err = json.Unmarshal(body, &payload)
+	if err != nil {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrPaymentWebhook, apperror.ErrInvalidWebhookPayload, err)
+	}
+	if payload.Reference == "" || (payload.Status != appconstant.PaymentStatusPaid && payload.Status != appconstant.PaymentStatusFailed) {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrPaymentWebhook, apperror.ErrInvalidWebhookPayload, apperror.ErrInvalidWebhookPayload)
+	}
+
+	p.mu.Lock()
+	p.statuses[payload.Reference] = payload.Status
+	p.mu.Unlock()
+
+	return &entity.WebhookEvent{
+		Reference: payload.Reference,
+		Status:    payload.Status,
+	}, nil
+}
+
+func (p *simulatedProvider) mac(body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(p.secret))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
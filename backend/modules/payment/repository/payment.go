@@ -0,0 +1,394 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"montelukast/modules/payment/entity"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"montelukast/pkg/transaction"
+
+	"github.com/shopspring/decimal"
+)
+
+type PaymentRepo interface {
+	IsOrderExistsByID(c context.Context, orderID int, userID int) (bool, error)
+	GetOrderStatusesByID(c context.Context, orderID int) ([]string, error)
+	IsPrescriptionApprovalPending(c context.Context, orderID int) (bool, error)
+	IsPaymentDeadlinePassed(c context.Context, orderID int) (bool, error)
+	GetOrderTotalPrice(c context.Context, orderID int) (decimal.Decimal, error)
+	AddPayment(c context.Context, payment entity.Payment) (*entity.Payment, error)
+	GetPendingPayment(c context.Context, orderID int, provider string) (*entity.Payment, error)
+	GetLatestPayment(c context.Context, orderID int) (*entity.Payment, error)
+	GetPaymentByReference(c context.Context, provider string, reference string) (*entity.Payment, error)
+	UpdatePaymentStatus(c context.Context, paymentID int, status string) error
+	GetOrderDetailIDsByStatus(c context.Context, orderID int, status string) ([]int, error)
+	AddPaymentProof(c context.Context, proof entity.PaymentProof) (*entity.PaymentProof, error)
+	GetLatestPaymentProof(c context.Context, paymentID int) (*entity.PaymentProof, error)
+	GetPaymentProofs(c context.Context, filter entity.PaymentProofFilter) ([]entity.PaymentProof, error)
+	GetPaymentProofByID(c context.Context, proofID int) (*entity.PaymentProof, error)
+	IsOrderInPharmacy(c context.Context, orderID int, pharmacyID int) (bool, error)
+	IsOrderFromOtherPharmacies(c context.Context, orderID int, pharmacyID int) (bool, error)
+	UpdatePaymentProofStatus(c context.Context, proof entity.PaymentProof) error
+}
+
+type paymentRepoImpl struct {
+	db *sql.DB
+}
+
+func NewPaymentRepo(dbConn *sql.DB) paymentRepoImpl {
+	return paymentRepoImpl{
+		db: dbConn,
+	}
+}
+
+const paymentColumns = `id, order_id, provider, reference, amount, status, instructions, paid_at, created_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPayment(row rowScanner) (*entity.Payment, error) {
+	var payment entity.Payment
+	err := row.Scan(
+		&payment.ID,
+		&payment.OrderID,
+		&payment.Provider,
+		&payment.Reference,
+		&payment.Amount,
+		&payment.Status,
+		&payment.Instructions,
+		&payment.PaidAt,
+		&payment.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return &payment, nil
+}
+
+func (r paymentRepoImpl) IsOrderExistsByID(c context.Context, orderID int, userID int) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM orders WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)`
+
+	var exists bool
+	err := r.db.QueryRowContext(c, query, orderID, userID).Scan(&exists)
+	if err != nil && err != sql.ErrNoRows {
+		return exists, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return exists, nil
+}
+
+func (r paymentRepoImpl) GetOrderStatusesByID(c context.Context, orderID int) ([]string, error) {
+	allStatus := []string{}
+
+	query := `SELECT status FROM order_details WHERE order_id = $1 AND deleted_at IS NULL`
+
+	rows, err := r.db.QueryContext(c, query, orderID)
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var status string
+		err := rows.Scan(&status)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
+		allStatus = append(allStatus, status)
+	}
+	return allStatus, nil
+}
+
+func (r paymentRepoImpl) IsPrescriptionApprovalPending(c context.Context, orderID int) (bool, error) {
+	query := `SELECT EXISTS (
+				SELECT 1
+				FROM order_details od
+				JOIN order_product_details opd ON opd.order_detail_id = od.id AND opd.deleted_at IS NULL
+				JOIN pharmacy_products pp ON pp.id = opd.pharmacy_product_id
+				JOIN products p ON p.id = pp.product_id
+				JOIN product_classifications pc ON pc.id = p.product_classification_id
+				WHERE od.order_id = $1 AND od.status = $2 AND od.deleted_at IS NULL
+				AND pc.name = $3 AND od.prescription_status IS DISTINCT FROM $4
+			)`
+
+	var exists bool
+	err := r.db.QueryRowContext(c, query, orderID, appconstant.StatusPending, appconstant.PrescriptionClassification, appconstant.PrescriptionStatusApproved).Scan(&exists)
+	if err != nil && err != sql.ErrNoRows {
+		return exists, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return exists, nil
+}
+
+func (r paymentRepoImpl) IsPaymentDeadlinePassed(c context.Context, orderID int) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM orders
+				WHERE id = $1 AND payment_deadline IS NOT NULL AND payment_deadline <= NOW() AND deleted_at IS NULL)`
+
+	var isPassed bool
+	err := r.db.QueryRowContext(c, query, orderID).Scan(&isPassed)
+	if err != nil && err != sql.ErrNoRows {
+		return isPassed, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return isPassed, nil
+}
+
+func (r paymentRepoImpl) GetOrderTotalPrice(c context.Context, orderID int) (decimal.Decimal, error) {
+	query := `SELECT total_price FROM orders WHERE id = $1 AND deleted_at IS NULL`
+
+	var totalPrice decimal.Decimal
+	err := r.db.QueryRowContext(c, query, orderID).Scan(&totalPrice)
+	if err != nil {
+		return totalPrice, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return totalPrice, nil
+}
+
+func (r paymentRepoImpl) AddPayment(c context.Context, payment entity.Payment) (*entity.Payment, error) {
+	query := `INSERT INTO payments (order_id, provider, reference, amount, status, instructions)
+				VALUES ($1, $2, $3, $4, $5, $6)
+				RETURNING ` + paymentColumns
+
+	return scanPayment(r.db.QueryRowContext(c, query, payment.OrderID, payment.Provider, payment.Reference, payment.Amount, payment.Status, payment.Instructions))
+}
+
+func (r paymentRepoImpl) GetPendingPayment(c context.Context, orderID int, provider string) (*entity.Payment, error) {
+	query := `SELECT ` + paymentColumns + `
+				FROM payments
+				WHERE order_id = $1 AND provider = $2 AND status = $3 AND deleted_at IS NULL
+				ORDER BY created_at DESC, id DESC
+				LIMIT 1`
+
+	return scanPayment(r.db.QueryRowContext(c, query, orderID, provider, appconstant.PaymentStatusPending))
+}
+
+func (r paymentRepoImpl) GetLatestPayment(c context.Context, orderID int) (*entity.Payment, error) {
+	query := `SELECT ` + paymentColumns + `
+				FROM payments
+				WHERE order_id = $1 AND deleted_at IS NULL
+				ORDER BY created_at DESC, id DESC
+				LIMIT 1`
+
+	return scanPayment(r.db.QueryRowContext(c, query, orderID))
+}
+
+func (r paymentRepoImpl) GetPaymentByReference(c context.Context, provider string, reference string) (*entity.Payment, error) {
+	tx := transaction.ExtractTx(c)
+
+	query := `SELECT ` + paymentColumns + `
+				FROM payments
+				WHERE provider = $1 AND reference = $2 AND deleted_at IS NULL`
+
+	if tx != nil {
+		return scanPayment(tx.QueryRowContext(c, query+` FOR UPDATE`, provider, reference))
+	}
+	return scanPayment(r.db.QueryRowContext(c, query, provider, reference))
+}
+
+func (r paymentRepoImpl) UpdatePaymentStatus(c context.Context, paymentID int, status string) error {
+	tx := transaction.ExtractTx(c)
+
+	query := `UPDATE payments
+				SET status = $2,
+					paid_at = CASE WHEN $2 IN ($3, $4) THEN NOW() ELSE paid_at END,
+					updated_at = NOW()
+				WHERE id = $1 AND deleted_at IS NULL`
+
+	var err error
+	if tx != nil {
+		_, err = tx.ExecContext(c, query, paymentID, status, appconstant.PaymentStatusPaid, appconstant.PaymentStatusRefundRequired)
+	} else {
+		_, err = r.db.ExecContext(c, query, paymentID, status, appconstant.PaymentStatusPaid, appconstant.PaymentStatusRefundRequired)
+	}
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+func (r paymentRepoImpl) GetOrderDetailIDsByStatus(c context.Context, orderID int, status string) ([]int, error) {
+	tx := transaction.ExtractTx(c)
+
+	orderDetailIDs := []int{}
+
+	query := `SELECT id FROM order_details
+				WHERE order_id = $1 AND status = $2 AND deleted_at IS NULL
+				ORDER BY id`
+
+	var err error
+	var rows *sql.Rows
+	if tx != nil {
+		rows, err = tx.QueryContext(c, query, orderID, status)
+	} else {
+		rows, err = r.db.QueryContext(c, query, orderID, status)
+	}
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var orderDetailID int
+		err := rows.Scan(&orderDetailID)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
+		orderDetailIDs = append(orderDetailIDs, orderDetailID)
+	}
+	return orderDetailIDs, nil
+}
+
+const paymentProofColumns = `pf.id, pf.payment_id, pf.order_id, o.user_id, u.name, p.reference, p.amount,
+				pf.image, pf.status, pf.reviewer_id, pf.rejection_reason, pf.reviewed_at, pf.created_at`
+
+const paymentProofJoins = `FROM payment_proofs pf
+				JOIN payments p ON p.id = pf.payment_id
+				JOIN orders o ON o.id = pf.order_id
+				JOIN users u ON u.id = o.user_id`
+
+func scanPaymentProof(row rowScanner) (*entity.PaymentProof, error) {
+	var proof entity.PaymentProof
+	err := row.Scan(
+		&proof.ID,
+		&proof.PaymentID,
+		&proof.OrderID,
+		&proof.UserID,
+		&proof.UserName,
+		&proof.Reference,
+		&proof.Amount,
+		&proof.Image,
+		&proof.Status,
+		&proof.ReviewerID,
+		&proof.RejectionReason,
+		&proof.ReviewedAt,
+		&proof.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return &proof, nil
+}
+
+func (r paymentRepoImpl) AddPaymentProof(c context.Context, proof entity.PaymentProof) (*entity.PaymentProof, error) {
+	tx := transaction.ExtractTx(c)
+
+	query := `INSERT INTO payment_proofs (payment_id, order_id, image, status)
+				VALUES ($1, $2, $3, $4)
+				RETURNING id, created_at`
+
+	var err error
+	if tx != nil {
+		err = tx.QueryRowContext(c, query, proof.PaymentID, proof.OrderID, proof.Image, appconstant.PaymentProofStatusPending).Scan(&proof.ID, &proof.CreatedAt)
+	} else {
+		err = r.db.QueryRowContext(c, query, proof.PaymentID, proof.OrderID, proof.Image, appconstant.PaymentProofStatusPending).Scan(&proof.ID, &proof.CreatedAt)
+	}
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	proof.Status = appconstant.PaymentProofStatusPending
+	return &proof, nil
+}
+
+func (r paymentRepoImpl) GetLatestPaymentProof(c context.Context, paymentID int) (*entity.PaymentProof, error) {
+	query := `SELECT ` + paymentProofColumns + `
+				` + paymentProofJoins + `
+				WHERE pf.payment_id = $1 AND pf.deleted_at IS NULL
+				ORDER BY pf.created_at DESC, pf.id DESC
+				LIMIT 1`
+
+	return scanPaymentProof(r.db.QueryRowContext(c, query, paymentID))
+}
+
+func (r paymentRepoImpl) GetPaymentProofs(c context.Context, filter entity.PaymentProofFilter) ([]entity.PaymentProof, error) {
+	proofs := []entity.PaymentProof{}
+
+	query := `SELECT ` + paymentProofColumns + `
+				` + paymentProofJoins + `
+				WHERE pf.deleted_at IS NULL`
+
+	args := []any{}
+	if filter.PharmacyID != nil {
+		args = append(args, *filter.PharmacyID)
+		query += fmt.Sprintf(` AND EXISTS (SELECT 1 FROM order_details od WHERE od.order_id = pf.order_id AND od.pharmacy_id = $%d AND od.deleted_at IS NULL)`, len(args))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		query += fmt.Sprintf(` AND pf.status = $%d`, len(args))
+	}
+	query += ` ORDER BY pf.created_at, pf.id`
+
+	rows, err := r.db.QueryContext(c, query, args...)
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		proof, err := scanPaymentProof(rows)
+		if err != nil {
+			return nil, err
+		}
+		proofs = append(proofs, *proof)
+	}
+	return proofs, nil
+}
+
+func (r paymentRepoImpl) GetPaymentProofByID(c context.Context, proofID int) (*entity.PaymentProof, error) {
+	tx := transaction.ExtractTx(c)
+
+	query := `SELECT ` + paymentProofColumns + `
+				` + paymentProofJoins + `
+				WHERE pf.id = $1 AND pf.deleted_at IS NULL`
+
+	if tx != nil {
+		return scanPaymentProof(tx.QueryRowContext(c, query+` FOR UPDATE OF pf`, proofID))
+	}
+	return scanPaymentProof(r.db.QueryRowContext(c, query, proofID))
+}
+
+func (r paymentRepoImpl) IsOrderInPharmacy(c context.Context, orderID int, pharmacyID int) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM order_details WHERE order_id = $1 AND pharmacy_id = $2 AND deleted_at IS NULL)`
+
+	var exists bool
+	err := r.db.QueryRowContext(c, query, orderID, pharmacyID).Scan(&exists)
+	if err != nil && err != sql.ErrNoRows {
+		return exists, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return exists, nil
+}
+
+func (r paymentRepoImpl) IsOrderFromOtherPharmacies(c context.Context, orderID int, pharmacyID int) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM order_details WHERE order_id = $1 AND pharmacy_id <> $2 AND status <> $3 AND deleted_at IS NULL)`
+
+	var exists bool
+	err := r.db.QueryRowContext(c, query, orderID, pharmacyID, appconstant.StatusCancelled).Scan(&exists)
+	if err != nil && err != sql.ErrNoRows {
+		return exists, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return exists, nil
+}
+
+func (r paymentRepoImpl) UpdatePaymentProofStatus(c context.Context, proof entity.PaymentProof) error {
+	tx := transaction.ExtractTx(c)
+
+	query := `UPDATE payment_proofs
+				SET status = $2, reviewer_id = $3, rejection_reason = $4, reviewed_at = NOW(), updated_at = NOW()
+				WHERE id = $1 AND deleted_at IS NULL`
+
+	var err error
+	if tx != nil {
+		_, err = tx.ExecContext(c, query, proof.ID, proof.Status, proof.ReviewerID, proof.RejectionReason)
+	} else {
+		_, err = r.db.ExecContext(c, query, proof.ID, proof.Status, proof.ReviewerID, proof.RejectionReason)
+	}
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
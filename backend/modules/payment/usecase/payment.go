@@ -0,0 +1,470 @@
+package usecase
+
+import (
+	"context"
+	orderStatusEntity "montelukast/modules/orderstatus/entity"
+	orderStatus "montelukast/modules/orderstatus/usecase"
+	"montelukast/modules/payment/entity"
+	"montelukast/modules/payment/provider"
+	"montelukast/modules/payment/repository"
+	pharmacistRepo "montelukast/modules/pharmacist/repository"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"montelukast/pkg/imageuploader"
+	"montelukast/pkg/transaction"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type PaymentUsecase interface {
+	CreatePayment(c context.Context, orderID int, userID int, providerName string) (*entity.Payment, error)
+	GetPayment(c context.Context, orderID int, userID int) (*entity.Payment, error)
+	HandleWebhook(c context.Context, providerName string, header http.Header, body []byte) error
+	UploadPaymentProof(c context.Context, file entity.File, orderID int, userID int) (*entity.PaymentProof, error)
+	GetPaymentProofs(c context.Context, status string, reviewer entity.Reviewer) ([]entity.PaymentProof, error)
+	GetPaymentProof(c context.Context, proofID int, reviewer entity.Reviewer) (*entity.PaymentProof, error)
+	ApprovePaymentProof(c context.Context, proofID int, reviewer entity.Reviewer) error
+	RejectPaymentProof(c context.Context, proofID int, reason string, reviewer entity.Reviewer) error
+}
+
+type paymentUsecaseImpl struct {
+	r         repository.PaymentRepo
+	tr        transaction.TransactorRepoImpl
+	os        orderStatus.OrderStatusUsecase
+	phr       pharmacistRepo.PharmacistRepo
+	providers map[string]provider.PaymentProvider
+}
+
+func NewPaymentUsecase(r repository.PaymentRepo, tr transaction.TransactorRepoImpl, os orderStatus.OrderStatusUsecase, phr pharmacistRepo.PharmacistRepo, providers ...provider.PaymentProvider) paymentUsecaseImpl {
+	registered := make(map[string]provider.PaymentProvider)
+	for _, p := range providers {
+		registered[p.Name()] = p
+	}
+	return paymentUsecaseImpl{
+		r:         r,
+		tr:        tr,
+		os:        os,
+		phr:       phr,
+		providers: registered,
+	}
+}
+
+func (u paymentUsecaseImpl) CreatePayment(c context.Context, orderID int, userID int, providerName string) (*entity.Payment, error) {
+	paymentProvider, ok := u.providers[providerName]
+	if !ok {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrCreatePayment, apperror.ErrPaymentProviderNotExists, apperror.ErrPaymentProviderNotExists)
+	}
+
+	exists, err := u.r.IsOrderExistsByID(c, orderID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrCreatePayment, apperror.ErrOrderNotExists, apperror.ErrOrderNotExists)
+	}
+
+	allStatus, err := u.r.GetOrderStatusesByID(c, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if len(allStatus) == 0 {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrCreatePayment, apperror.ErrOrderNotExists, apperror.ErrOrderNotExists)
+	}
+	pendingCount := 0
+	for _, status := range allStatus {
+		if status == appconstant.StatusCancelled {
+			continue
+		}
+		if status != appconstant.StatusPending {
+			return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrCreatePayment, apperror.ErrPaymentAlreadyDone, apperror.ErrPaymentAlreadyDone)
+		}
+		pendingCount++
+	}
+	if pendingCount == 0 {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrCreatePayment, apperror.ErrOrderCancelled, apperror.ErrOrderCancelled)
+	}
+
+	isPassed, err := u.r.IsPaymentDeadlinePassed(c, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if isPassed {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrCreatePayment, apperror.ErrPaymentDeadlinePassed, apperror.ErrPaymentDeadlinePassed)
+	}
+
+	isApprovalPending, err := u.r.IsPrescriptionApprovalPending(c, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if isApprovalPending {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrCreatePayment, apperror.ErrPrescriptionNotApproved, apperror.ErrPrescriptionNotApproved)
+	}
+
+	amount, err := u.r.GetOrderTotalPrice(c, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	payment, err := u.r.GetPendingPayment(c, orderID, providerName)
+	if err != nil {
+		return nil, err
+	}
+	if payment != nil {
+		if payment.Amount.Equal(amount) {
+			return payment, nil
+		}
+		err = u.r.UpdatePaymentStatus(c, payment.ID, appconstant.PaymentStatusFailed)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := paymentProvider.CreateCharge(c, entity.Charge{
+		OrderID:   orderID,
+		Reference: appconstant.PaymentReferencePrefix + uuid.NewString(),
+		Amount:    amount,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return u.r.AddPayment(c, entity.Payment{
+		OrderID:      orderID,
+		Provider:     providerName,
+		Reference:    result.Reference,
+		Amount:       amount,
+		Status:       result.Status,
+		Instructions: &result.Instructions,
+	})
+}
+
+func (u paymentUsecaseImpl) GetPayment(c context.Context, orderID int, userID int) (*entity.Payment, error) {
+	exists, err := u.r.IsOrderExistsByID(c, orderID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, apperror.NewErrStatusNotFound(appconstant.FieldErrGetPayment, apperror.ErrOrderNotExists, apperror.ErrOrderNotExists)
+	}
+
+	payment, err := u.r.GetLatestPayment(c, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if payment == nil {
+		return nil, apperror.NewErrStatusNotFound(appconstant.FieldErrGetPayment, apperror.ErrPaymentNotExists, apperror.ErrPaymentNotExists)
+	}
+	payment.Proof, err = u.r.GetLatestPaymentProof(c, payment.ID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != appconstant.PaymentStatusPending {
+		return payment, nil
+	}
+
+	paymentProvider, ok := u.providers[payment.Provider]
+	if !ok {
+		return payment, nil
+	}
+	status, err := paymentProvider.QueryStatus(c, payment.Reference)
+	if err != nil {
+		return nil, err
+	}
+	if status == appconstant.PaymentStatusPending {
+		return payment, nil
+	}
+
+	err = u.applyStatus(c, payment.Provider, payment.Reference, status)
+	if err != nil {
+		return nil, err
+	}
+	proof := payment.Proof
+	payment, err = u.r.GetPaymentByReference(c, payment.Provider, payment.Reference)
+	if err != nil {
+		return nil, err
+	}
+	payment.Proof = proof
+	return payment, nil
+}
+
+func (u paymentUsecaseImpl) HandleWebhook(c context.Context, providerName string, header http.Header, body []byte) error {
+	paymentProvider, ok := u.providers[providerName]
+	if !ok {
+		return apperror.NewErrStatusNotFound(appconstant.FieldErrPaymentWebhook, apperror.ErrPaymentProviderNotExists, apperror.ErrPaymentProviderNotExists)
+	}
+
+	event, err := paymentProvider.VerifyWebhook(c, header, body)
+	if err != nil {
+		return err
+	}
+
+	return u.applyStatus(c, providerName, event.Reference, event.Status)
+}
+
+func (u paymentUsecaseImpl) applyStatus(c context.Context, providerName string, reference string, status string) error {
+	return u.tr.WithinTransaction(c, func(txCtx context.Context) error {
+		payment, err := u.r.GetPaymentByReference(txCtx, providerName, reference)
+		if err != nil {
+			return err
+		}
+		if payment == nil {
+			return apperror.NewErrStatusNotFound(appconstant.FieldErrPaymentWebhook, apperror.ErrPaymentNotExists, apperror.ErrPaymentNotExists)
+		}
+		if payment.Status != appconstant.PaymentStatusPending {
+			return nil
+		}
+		if status != appconstant.PaymentStatusPaid {
+			return u.r.UpdatePaymentStatus(txCtx, payment.ID, status)
+		}
+
+		// A payment settled after the order was cancelled has nothing left to
+		// pay for, the money has to go back to the user.
+		orderDetailIDs, err := u.r.GetOrderDetailIDsByStatus(txCtx, payment.OrderID, appconstant.StatusPending)
+		if err != nil {
+			return err
+		}
+		if len(orderDetailIDs) == 0 {
+			return u.r.UpdatePaymentStatus(txCtx, payment.ID, appconstant.PaymentStatusRefundRequired)
+		}
+
+		err = u.r.UpdatePaymentStatus(txCtx, payment.ID, status)
+		if err != nil {
+			return err
+		}
+		return u.transitionOrder(txCtx, payment.OrderID, appconstant.StatusPending, orderStatusEntity.Transition{
+			To:    appconstant.StatusProcessing,
+			Actor: appconstant.ActorSystem,
+		})
+	})
+}
+
+func (u paymentUsecaseImpl) UploadPaymentProof(c context.Context, file entity.File, orderID int, userID int) (*entity.PaymentProof, error) {
+	validate := validator.New()
+	err := validate.Struct(file)
+	if err != nil {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrUploadPayment, apperror.ErrFileEmpty, err)
+	}
+
+	payment, err := u.CreatePayment(c, orderID, userID, appconstant.PaymentProviderManualTransfer)
+	if err != nil {
+		return nil, err
+	}
+
+	uploadUrl, err := imageuploader.ImageUploadHelper(file.File)
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrUploadPayment, apperror.ErrUploadImage, err)
+	}
+
+	var proof *entity.PaymentProof
+	err = u.tr.WithinTransaction(c, func(txCtx context.Context) error {
+		proof, err = u.r.AddPaymentProof(txCtx, entity.PaymentProof{
+			PaymentID: payment.ID,
+			OrderID:   orderID,
+			Image:     uploadUrl,
+		})
+		if err != nil {
+			return err
+		}
+		return u.transitionOrder(txCtx, orderID, appconstant.StatusPending, orderStatusEntity.Transition{
+			To:      appconstant.StatusPaymentReview,
+			Actor:   appconstant.ActorUser,
+			ActorID: &userID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+func (u paymentUsecaseImpl) GetPaymentProofs(c context.Context, status string, reviewer entity.Reviewer) ([]entity.PaymentProof, error) {
+	filter := entity.PaymentProofFilter{Status: status}
+	if reviewer.Role == appconstant.ROLE_PHARMACY {
+		pharmacyID, err := u.getPharmacyID(c, reviewer.ID, appconstant.FieldErrGetPaymentProofs)
+		if err != nil {
+			return nil, err
+		}
+		filter.PharmacyID = &pharmacyID
+	}
+	return u.r.GetPaymentProofs(c, filter)
+}
+
+func (u paymentUsecaseImpl) GetPaymentProof(c context.Context, proofID int, reviewer entity.Reviewer) (*entity.PaymentProof, error) {
+	return u.getReviewableProof(c, proofID, reviewer, appconstant.FieldErrGetPaymentProofs)
+}
+
+func (u paymentUsecaseImpl) ApprovePaymentProof(c context.Context, proofID int, reviewer entity.Reviewer) error {
+	err := u.checkProofReviewer(c, proofID, reviewer)
+	if err != nil {
+		return err
+	}
+
+	return u.tr.WithinTransaction(c, func(txCtx context.Context) error {
+		proof, err := u.lockPendingProof(txCtx, proofID)
+		if err != nil {
+			return err
+		}
+
+		proof.Status = appconstant.PaymentProofStatusApproved
+		proof.ReviewerID = &reviewer.ID
+		err = u.r.UpdatePaymentProofStatus(txCtx, *proof)
+		if err != nil {
+			return err
+		}
+
+		err = u.r.UpdatePaymentStatus(txCtx, proof.PaymentID, appconstant.PaymentStatusPaid)
+		if err != nil {
+			return err
+		}
+
+		return u.transitionOrder(txCtx, proof.OrderID, appconstant.StatusPaymentReview, orderStatusEntity.Transition{
+			To:      appconstant.StatusProcessing,
+			Actor:   reviewer.Role,
+			ActorID: &reviewer.ID,
+		})
+	})
+}
+
+func (u paymentUsecaseImpl) RejectPaymentProof(c context.Context, proofID int, reason string, reviewer entity.Reviewer) error {
+	err := u.checkProofReviewer(c, proofID, reviewer)
+	if err != nil {
+		return err
+	}
+
+	return u.tr.WithinTransaction(c, func(txCtx context.Context) error {
+		proof, err := u.lockPendingProof(txCtx, proofID)
+		if err != nil {
+			return err
+		}
+
+		proof.Status = appconstant.PaymentProofStatusRejected
+		proof.ReviewerID = &reviewer.ID
+		proof.RejectionReason = &reason
+		err = u.r.UpdatePaymentProofStatus(txCtx, *proof)
+		if err != nil {
+			return err
+		}
+
+		err = u.transitionOrder(txCtx, proof.OrderID, appconstant.StatusPaymentReview, orderStatusEntity.Transition{
+			To:      appconstant.StatusPending,
+			Actor:   reviewer.Role,
+			ActorID: &reviewer.ID,
+			Reason:  &reason,
+		})
+		if err != nil {
+			return err
+		}
+
+		// The deadline message has already been consumed while the proof was in
+		// review, so an order past its deadline is cancelled here instead.
+		isPassed, err := u.r.IsPaymentDeadlinePassed(txCtx, proof.OrderID)
+		if err != nil || !isPassed {
+			return err
+		}
+		deadlineReason := appconstant.ReasonPaymentDeadlinePassed
+		return u.transitionOrder(txCtx, proof.OrderID, appconstant.StatusPending, orderStatusEntity.Transition{
+			To:     appconstant.StatusCancelled,
+			Actor:  appconstant.ActorSystem,
+			Reason: &deadlineReason,
+		})
+	})
+}
+
+func (u paymentUsecaseImpl) transitionOrder(c context.Context, orderID int, from string, transition orderStatusEntity.Transition) error {
+	orderDetailIDs, err := u.r.GetOrderDetailIDsByStatus(c, orderID, from)
+	if err != nil {
+		return err
+	}
+	for _, orderDetailID := range orderDetailIDs {
+		transition.OrderDetailID = orderDetailID
+		err = u.os.Transition(c, transition)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u paymentUsecaseImpl) lockPendingProof(c context.Context, proofID int) (*entity.PaymentProof, error) {
+	proof, err := u.r.GetPaymentProofByID(c, proofID)
+	if err != nil {
+		return nil, err
+	}
+	if proof == nil {
+		return nil, apperror.NewErrStatusNotFound(appconstant.FieldErrReviewPaymentProof, apperror.ErrPaymentProofNotExists, apperror.ErrPaymentProofNotExists)
+	}
+	if proof.Status != appconstant.PaymentProofStatusPending {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrReviewPaymentProof, apperror.ErrPaymentProofAlreadyReviewed, apperror.ErrPaymentProofAlreadyReviewed)
+	}
+	return proof, nil
+}
+
+// checkProofReviewer lets a pharmacist review only orders served by their
+// pharmacy alone. A payment covers the whole order, so approving or rejecting
+// it moves every detail and an order spanning pharmacies is left to admins.
+func (u paymentUsecaseImpl) checkProofReviewer(c context.Context, proofID int, reviewer entity.Reviewer) error {
+	proof, err := u.getReviewableProof(c, proofID, reviewer, appconstant.FieldErrReviewPaymentProof)
+	if err != nil || reviewer.Role != appconstant.ROLE_PHARMACY {
+		return err
+	}
+
+	pharmacyID, err := u.getPharmacyID(c, reviewer.ID, appconstant.FieldErrReviewPaymentProof)
+	if err != nil {
+		return err
+	}
+	isShared, err := u.r.IsOrderFromOtherPharmacies(c, proof.OrderID, pharmacyID)
+	if err != nil {
+		return err
+	}
+	if isShared {
+		return apperror.NewErrStatusUnauthorized(appconstant.FieldErrReviewPaymentProof, apperror.ErrProofNeedsAdminReview, apperror.ErrProofNeedsAdminReview)
+	}
+	return nil
+}
+
+func (u paymentUsecaseImpl) getReviewableProof(c context.Context, proofID int, reviewer entity.Reviewer, field string) (*entity.PaymentProof, error) {
+	proof, err := u.r.GetPaymentProofByID(c, proofID)
+	if err != nil {
+		return nil, err
+	}
+	if proof == nil {
+		return nil, apperror.NewErrStatusNotFound(field, apperror.ErrPaymentProofNotExists, apperror.ErrPaymentProofNotExists)
+	}
+	if reviewer.Role != appconstant.ROLE_PHARMACY {
+		return proof, nil
+	}
+
+	pharmacyID, err := u.getPharmacyID(c, reviewer.ID, field)
+	if err != nil {
+		return nil, err
+	}
+	isInPharmacy, err := u.r.IsOrderInPharmacy(c, proof.OrderID, pharmacyID)
+	if err != nil {
+		return nil, err
+	}
+	if !isInPharmacy {
+		return nil, apperror.NewErrStatusNotFound(field, apperror.ErrPaymentProofNotExists, apperror.ErrPaymentProofNotExists)
+	}
+	return proof, nil
+}
+
+func (u paymentUsecaseImpl) getPharmacyID(c context.Context, pharmacistID int, field string) (int, error) {
+	isExists, err := u.phr.IsPharmacistExistsByID(c, pharmacistID)
+	if err != nil {
+		return 0, err
+	}
+	if !isExists {
+		return 0, apperror.NewErrStatusNotFound(field, apperror.ErrPharmacistNotExists, apperror.ErrPharmacistNotExists)
+	}
+
+	pharmacyID, err := u.phr.GetPharmacyIDByPharmacistID(c, pharmacistID)
+	if err != nil {
+		return 0, err
+	}
+	if pharmacyID == nil {
+		return 0, apperror.NewErrStatusBadRequest(field, apperror.ErrPharmacistNotHasPharmacy, apperror.ErrPharmacistNotHasPharmacy)
+	}
+	return *pharmacyID, nil
+}
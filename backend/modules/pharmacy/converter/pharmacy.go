@@ -80,3 +80,20 @@ func (c *PharmacyConverterImpl) ToDTO(pharmacy entity.Pharmacy) (pharmacyDTO dto
 		UpdatedAt:     pharmacy.UpdatedAt,
 	}
 }
+
+type ServiceAreaConverterImpl struct{}
+
+func (c ServiceAreaConverterImpl) ToEntity(serviceAreaDTO dto.ServiceAreaRequest) entity.ServiceArea {
+	return entity.ServiceArea{
+		Radius: serviceAreaDTO.ServiceRadius,
+		Zone:   serviceAreaDTO.ServiceZone,
+	}
+}
+
+func (c ServiceAreaConverterImpl) ToDto(serviceArea entity.ServiceArea) dto.ServiceAreaResponse {
+	return dto.ServiceAreaResponse{
+		PharmacyID:    serviceArea.PharmacyID,
+		ServiceRadius: serviceArea.Radius,
+		ServiceZone:   serviceArea.Zone,
+	}
+}
@@ -46,6 +46,17 @@ type PharmacyResponse struct {
 	UpdatedAt     string `json:"updated_at"`
 }
 
+type ServiceAreaRequest struct {
+	ServiceRadius int         `json:"service_radius" binding:"required,gte=1"`
+	ServiceZone   [][]float64 `json:"service_zone" binding:"omitempty,dive,len=2"`
+}
+
+type ServiceAreaResponse struct {
+	PharmacyID    int         `json:"pharmacy_id"`
+	ServiceRadius int         `json:"service_radius"`
+	ServiceZone   [][]float64 `json:"service_zone"`
+}
+
 type PaginatedPharmaciesResponse struct {
 	Pharmacies []PharmacyResponse            `json:"pharmacies"`
 	Pagination pagination.PaginationResponse `json:"pagination"`
@@ -34,6 +34,14 @@ type Pharmacy struct {
 	UpdatedAt     string
 }
 
+// ServiceArea is the region a pharmacy delivers to. A zone, given as a ring
+// of [longitude, latitude] points, takes precedence over the radius in meters.
+type ServiceArea struct {
+	PharmacyID int
+	Radius     int
+	Zone       [][]float64
+}
+
 type PharmacyLogisticPartners struct {
 	ID                int
 	PharmacyID        int
@@ -171,3 +171,48 @@ func (h PharmacyHandler) AddLogoHandler(c *gin.Context) {
 	response := wrapper.ResponseData(url, "success uploaded file", err)
 	c.JSON(http.StatusOK, response)
 }
+
+func (h PharmacyHandler) GetServiceAreaHandler(c *gin.Context) {
+	pharmacyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrPharmacy, apperror.ErrConvertVariableType, err))
+		return
+	}
+	serviceArea, err := h.pharmacyUsecase.GetServiceArea(c, pharmacyID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var serviceAreaConverter converter.ServiceAreaConverterImpl
+	response := wrapper.ResponseData(serviceAreaConverter.ToDto(serviceArea), "get service area success", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h PharmacyHandler) UpdateServiceAreaHandler(c *gin.Context) {
+	pharmacyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrUpdateServiceArea, apperror.ErrConvertVariableType, err))
+		return
+	}
+	err = apperror.JsonValidator(c)
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrJSON, apperror.ErrInvalidJSON, err))
+		return
+	}
+	var serviceAreaReq dto.ServiceAreaRequest
+	err = c.ShouldBindJSON(&serviceAreaReq)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var serviceAreaConverter converter.ServiceAreaConverterImpl
+	serviceArea := serviceAreaConverter.ToEntity(serviceAreaReq)
+	serviceArea.PharmacyID = pharmacyID
+	err = h.pharmacyUsecase.UpdateServiceArea(c, serviceArea)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	response := wrapper.ResponseData(nil, "update service area success", nil)
+	c.JSON(http.StatusOK, response)
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"log"
 	"montelukast/modules/pharmacy/entity"
 	appconstant "montelukast/pkg/constant"
@@ -19,6 +20,8 @@ type PharmacyRepository interface {
 	GetTotalItem(c context.Context, filter entity.PharmacyFilterCount) (int, error)
 	AddLogo(c context.Context, url string, id int) (err error)
 	GetPharmacyByID(c context.Context, id int) (pharmacy entity.Pharmacy, err error)
+	GetServiceArea(c context.Context, id int) (serviceArea entity.ServiceArea, err error)
+	UpdateServiceArea(c context.Context, id int, radius int, zone *string) (err error)
 }
 
 type pharmacyRepository struct {
@@ -246,3 +249,39 @@ func (r *pharmacyRepository) AddLogo(c context.Context, url string, id int) (err
 	}
 	return nil
 }
+
+func (r *pharmacyRepository) GetServiceArea(c context.Context, id int) (serviceArea entity.ServiceArea, err error) {
+	query := `SELECT id, service_radius, ST_AsGeoJSON(service_zone)
+			FROM pharmacies
+			WHERE id = $1 AND deleted_at IS NULL`
+	var zone sql.NullString
+	err = r.db.QueryRowContext(c, query, id).Scan(&serviceArea.PharmacyID, &serviceArea.Radius, &zone)
+	if err != nil {
+		return serviceArea, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	if !zone.Valid {
+		return serviceArea, nil
+	}
+	var polygon struct {
+		Coordinates [][][]float64 `json:"coordinates"`
+	}
+	err = json.Unmarshal([]byte(zone.String), &polygon)
+	if err != nil {
+		return serviceArea, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	if len(polygon.Coordinates) > 0 {
+		serviceArea.Zone = polygon.Coordinates[0]
+	}
+	return serviceArea, nil
+}
+
+func (r *pharmacyRepository) UpdateServiceArea(c context.Context, id int, radius int, zone *string) (err error) {
+	query := `UPDATE pharmacies
+			SET service_radius = $2, service_zone = $3::geography, updated_at = NOW()
+			WHERE id = $1 AND deleted_at IS NULL`
+	_, err = r.db.ExecContext(c, query, id, radius, zone)
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
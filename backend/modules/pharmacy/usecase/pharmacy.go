@@ -2,12 +2,15 @@ package usecase
 
 import (
 	"context"
+	"fmt"
 	"math"
 	"montelukast/modules/pharmacy/entity"
 	"montelukast/modules/pharmacy/repository"
 	appconstant "montelukast/pkg/constant"
 	apperror "montelukast/pkg/error"
 	"montelukast/pkg/imageuploader"
+	"strconv"
+	"strings"
 
 	"github.com/go-playground/validator"
 )
@@ -19,6 +22,8 @@ type PharmacyUsecase interface {
 	GetAllPharmacies(c context.Context, filter entity.PharmacyFilter) (pharmacies *entity.PaginatedPharmacies, err error)
 	AddLogo(c context.Context, file entity.File) (string, error)
 	GetPharmacyByID(c context.Context, id int) (pharmacy entity.Pharmacy, err error)
+	GetServiceArea(c context.Context, id int) (serviceArea entity.ServiceArea, err error)
+	UpdateServiceArea(c context.Context, serviceArea entity.ServiceArea) (err error)
 }
 
 type pharmacyUsecaseImpl struct {
@@ -147,3 +152,57 @@ func (u pharmacyUsecaseImpl) AddLogo(c context.Context, file entity.File) (strin
 	}
 	return uploadUrl, nil
 }
+
+func (u pharmacyUsecaseImpl) GetServiceArea(c context.Context, id int) (serviceArea entity.ServiceArea, err error) {
+	exists, err := u.pharmacyRepo.IsPharmacyExists(c, id)
+	if err != nil {
+		return serviceArea, err
+	}
+	if !exists {
+		return serviceArea, apperror.NewErrStatusNotFound(appconstant.FieldErrPharmacy, apperror.ErrPharmacyNotExists, apperror.ErrPharmacyNotExists)
+	}
+	return u.pharmacyRepo.GetServiceArea(c, id)
+}
+
+// UpdateServiceArea sets the delivery radius of a pharmacy and, when a zone is
+// given, the polygon that replaces it. An empty zone falls back to the radius.
+func (u pharmacyUsecaseImpl) UpdateServiceArea(c context.Context, serviceArea entity.ServiceArea) (err error) {
+	exists, err := u.pharmacyRepo.IsPharmacyExists(c, serviceArea.PharmacyID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return apperror.NewErrStatusNotFound(appconstant.FieldErrUpdateServiceArea, apperror.ErrPharmacyNotExists, apperror.ErrPharmacyNotExists)
+	}
+	var zone *string
+	if len(serviceArea.Zone) > 0 {
+		wkt, err := serviceZoneWKT(serviceArea.Zone)
+		if err != nil {
+			return err
+		}
+		zone = &wkt
+	}
+	return u.pharmacyRepo.UpdateServiceArea(c, serviceArea.PharmacyID, serviceArea.Radius, zone)
+}
+
+// serviceZoneWKT validates the ring and closes it when the last point does
+// not repeat the first one.
+func serviceZoneWKT(ring [][]float64) (string, error) {
+	invalidZone := apperror.NewErrStatusBadRequest(appconstant.FieldErrUpdateServiceArea, apperror.ErrInvalidServiceZone, apperror.ErrInvalidServiceZone)
+	first, last := ring[0], ring[len(ring)-1]
+	if first[0] != last[0] || first[1] != last[1] {
+		ring = append(ring, first)
+	}
+	if len(ring)-1 < appconstant.MinServiceZonePoints {
+		return "", invalidZone
+	}
+	points := make([]string, 0, len(ring))
+	for _, point := range ring {
+		lng, lat := point[0], point[1]
+		if lng < -180 || lng > 180 || lat < -90 || lat > 90 {
+			return "", invalidZone
+		}
+		points = append(points, strconv.FormatFloat(lng, 'f', -1, 64)+" "+strconv.FormatFloat(lat, 'f', -1, 64))
+	}
+	return fmt.Sprintf(appconstant.ServiceZoneWKTFormat, strings.Join(points, ",")), nil
+}
@@ -60,3 +60,35 @@ func (c QueryParamsConverter) ToEntity(queryParams queryparams.QueryParamsDto) q
 		Page:                  queryParams.Page,
 	}
 }
+
+type InventoryImportConverter struct{}
+
+func (c InventoryImportConverter) ToDto(report entity.InventoryImport) dto.InventoryImportResponse {
+	rows := []dto.InventoryRowResponse{}
+	for _, row := range report.Rows {
+		rowErrors := row.Errors
+		if rowErrors == nil {
+			rowErrors = []string{}
+		}
+		rows = append(rows, dto.InventoryRowResponse{
+			Row:               row.Row,
+			PharmacyProductID: row.PharmacyProductID,
+			ProductID:         row.ProductID,
+			Name:              row.Name,
+			Stock:             row.Stock,
+			Price:             row.Price.String(),
+			IsActive:          row.IsActive,
+			Action:            row.Action,
+			Errors:            rowErrors,
+		})
+	}
+	return dto.InventoryImportResponse{
+		IsDryRun:  report.IsDryRun,
+		IsValid:   report.IsValid,
+		Created:   report.Created,
+		Updated:   report.Updated,
+		Unchanged: report.Unchanged,
+		Invalid:   report.Invalid,
+		Rows:      rows,
+	}
+}
@@ -35,3 +35,33 @@ type ProductsList struct {
 	Pagination productDto.Pagination        `json:"pagination"`
 	Products   []GetPharmacyProductResponse `json:"pharmacy_products"`
 }
+
+type InventoryImportRequest struct {
+	IsDryRun bool `form:"dry_run"`
+}
+
+type InventoryExportRequest struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv xlsx"`
+}
+
+type InventoryRowResponse struct {
+	Row               int      `json:"row"`
+	PharmacyProductID int      `json:"pharmacy_product_id,omitempty"`
+	ProductID         int      `json:"product_id"`
+	Name              string   `json:"name"`
+	Stock             int      `json:"stock"`
+	Price             string   `json:"price"`
+	IsActive          bool     `json:"is_active"`
+	Action            string   `json:"action,omitempty"`
+	Errors            []string `json:"errors"`
+}
+
+type InventoryImportResponse struct {
+	IsDryRun  bool                   `json:"is_dry_run"`
+	IsValid   bool                   `json:"is_valid"`
+	Created   int                    `json:"created"`
+	Updated   int                    `json:"updated"`
+	Unchanged int                    `json:"unchanged"`
+	Invalid   int                    `json:"invalid"`
+	Rows      []InventoryRowResponse `json:"rows"`
+}
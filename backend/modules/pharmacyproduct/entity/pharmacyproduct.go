@@ -1,6 +1,11 @@
 package entity
 
-import "github.com/shopspring/decimal"
+import (
+	"mime/multipart"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 type PharmacyProduct struct {
 	ID int
@@ -14,3 +19,65 @@ type PharmacyProduct struct {
 }
 
 
+
+type StockUpdatePolicy struct {
+	PharmacyID  int
+	Scope       string
+	Limit       int
+	WindowHours int
+}
+
+type StockHold struct {
+	ID                int
+	UserID            int
+	CartID            string
+	PharmacyProductID int
+	Quantity          int
+	ExpiredAt         time.Time
+}
+
+type InventoryItem struct {
+	PharmacyProductID int
+	ProductID         int
+	Name              string
+	Stock             int
+	Price             decimal.Decimal
+	IsActive          bool
+}
+
+type InventoryRow struct {
+	Row               int
+	PharmacyProductID int
+	ProductID         int
+	Name              string
+	Stock             int
+	Price             decimal.Decimal
+	IsActive          bool
+	IsPriceChanged    bool
+	IsStockChanged    bool
+	Action            string
+	Errors            []string
+}
+
+type InventoryImport struct {
+	IsDryRun  bool
+	IsValid   bool
+	Created   int
+	Updated   int
+	Unchanged int
+	Invalid   int
+	Rows      []InventoryRow
+}
+
+type InventoryUpload struct {
+	Format   string
+	File     multipart.File
+	Size     int64
+	IsDryRun bool
+}
+
+type InventoryFile struct {
+	Name        string
+	ContentType string
+	Content     []byte
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"fmt"
 	"montelukast/modules/pharmacyproduct/converter"
 	"montelukast/modules/pharmacyproduct/dto"
 	"montelukast/modules/pharmacyproduct/entity"
@@ -9,9 +10,12 @@ import (
 	productConverter "montelukast/modules/product/converter"
 	appconstant "montelukast/pkg/constant"
 	apperror "montelukast/pkg/error"
+	"montelukast/pkg/spreadsheet"
 	"montelukast/pkg/wrapper"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -209,3 +213,114 @@ func (h *PharmacyProductHandler) GetPharmacyProductsHandler(c *gin.Context) {
 	response := wrapper.ResponseData(productsList, "get products success", nil)
 	c.JSON(http.StatusOK, response)
 }
+
+func (h PharmacyProductHandler) ResetStockUpdateLimitHandler(c *gin.Context) {
+	pharmacyProductID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrResetStockUpdateLimit, apperror.ErrConvertVariableType, err))
+		return
+	}
+
+	err = h.u.ResetStockUpdateLimit(c, pharmacyProductID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(nil, "reset stock update limit success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h PharmacyProductHandler) ImportInventoryHandler(c *gin.Context) {
+	rawPharmacistID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, apperror.ErrInternalServer))
+		return
+	}
+	pharmacistID, err := strconv.Atoi(rawPharmacistID.(string))
+	if err != nil {
+		c.Error(apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrUserUnauthorized, err))
+		return
+	}
+
+	importReq := dto.InventoryImportRequest{}
+	err = c.ShouldBindQuery(&importReq)
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrImportInventory, apperror.ErrInvalidQuery, err))
+		return
+	}
+
+	_, fileHeader, err := c.Request.FormFile("file")
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrImportInventory, apperror.ErrFileEmpty, err))
+		return
+	}
+	format := strings.ToLower(strings.TrimPrefix(filepath.Ext(fileHeader.Filename), "."))
+	if format != spreadsheet.FormatCSV && format != spreadsheet.FormatXLSX {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrImportInventory, apperror.ErrInvalidImportFile, apperror.ErrInvalidImportFile))
+		return
+	}
+	if fileHeader.Size > appconstant.MaxInventoryImportSize {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrImportInventory, apperror.ErrImportFileTooLarge, apperror.ErrImportFileTooLarge))
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrImportInventory, apperror.ErrInvalidImportFile, err))
+		return
+	}
+	defer file.Close()
+
+	report, err := h.u.ImportInventory(c, pharmacistID, entity.InventoryUpload{
+		Format:   format,
+		File:     file,
+		Size:     fileHeader.Size,
+		IsDryRun: importReq.IsDryRun,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	reportDto := converter.InventoryImportConverter{}.ToDto(*report)
+	if !report.IsDryRun && !report.IsValid {
+		c.Error(apperror.NewErrInvalidImport(appconstant.FieldErrImportInventory, reportDto))
+		return
+	}
+
+	message := "import inventory success!"
+	if report.IsDryRun {
+		message = "validate inventory import success!"
+	}
+	response := wrapper.ResponseData(reportDto, message, nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h PharmacyProductHandler) ExportInventoryHandler(c *gin.Context) {
+	rawPharmacistID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, apperror.ErrInternalServer))
+		return
+	}
+	pharmacistID, err := strconv.Atoi(rawPharmacistID.(string))
+	if err != nil {
+		c.Error(apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrUserUnauthorized, err))
+		return
+	}
+
+	exportReq := dto.InventoryExportRequest{}
+	err = c.ShouldBindQuery(&exportReq)
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrExportInventory, apperror.ErrInvalidQuery, err))
+		return
+	}
+
+	file, err := h.u.ExportInventory(c, pharmacistID, exportReq.Format)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, file.Name))
+	c.Data(http.StatusOK, file.ContentType, file.Content)
+}
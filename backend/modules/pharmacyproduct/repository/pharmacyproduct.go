@@ -3,15 +3,17 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"montelukast/modules/pharmacyproduct/entity"
 	"montelukast/modules/pharmacyproduct/queryparams"
 	productEntity "montelukast/modules/product/entity"
 	appconstant "montelukast/pkg/constant"
-	"montelukast/pkg/dateconverter"
 	apperror "montelukast/pkg/error"
+	"montelukast/pkg/transaction"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/shopspring/decimal"
 )
 
 type PharmacyProductRepo interface {
@@ -20,16 +22,26 @@ type PharmacyProductRepo interface {
 	IsPharmacyProductExists(c context.Context, pharmacyID, productID int) (bool, error)
 	IsProductExistsByID(c context.Context, id int) (bool, error)
 	GetStockByID(c context.Context, id int) (int, error)
+	GetAvailableStockByID(c context.Context, id int) (int, error)
+	AddStockHold(c context.Context, stockHold entity.StockHold) error
+	ReleaseStockHoldsByUserID(c context.Context, userID int) error
+	ReleaseStockHoldsByCartID(c context.Context, cartID string, userID int) error
 	AddPharmacyProduct(c context.Context, pharmacyProduct entity.PharmacyProduct) error
 	GetPharmacyIDbyPharmacistID(c context.Context, pharmacistID int) (int, error)
 	UpdatePharmacyProduct(c context.Context, pharmacistProduct entity.PharmacyProduct) error
+	UpdatePharmacyProductPrice(c context.Context, pharmacyProductID int, price decimal.Decimal) error
 	GetPharmacyIDbyPharmacyProductID(c context.Context, pharmacyProductID int) (int, error)
-	GetStockUpdatedDate(c context.Context) (string, error)
-	SetStockUpdatedDate(c context.Context) error
+	GetStockUpdatePolicy(c context.Context, pharmacyProductID int) (*entity.StockUpdatePolicy, error)
+	GetStockUpdateCount(c context.Context, scope string, id int) (int, time.Duration, error)
+	IncreaseStockUpdateCount(c context.Context, scope string, id int, window time.Duration) (int, time.Duration, error)
+	DecreaseStockUpdateCount(c context.Context, scope string, id int) error
+	DeleteStockUpdateCount(c context.Context, scope string, id int) error
 	DeletePharmacyProduct(c context.Context, pharmacyProductID int) error
 	GetPharmacyProduct(c context.Context, productPharmacyID int, pharmacyID int) (*productEntity.ProductDetail, error)
 	GetPharmacyProducts(c context.Context, queryParams queryparams.QueryParams, pharmacyID int) ([]productEntity.ProductDetail, error)
 	GetTotalProduct(c context.Context, queryParams queryparams.QueryParams, pharmacyID int) (int, error)
+	GetInventory(c context.Context, pharmacyID int) ([]entity.InventoryItem, error)
+	GetImportProducts(c context.Context, productID int, name string) ([]entity.InventoryItem, error)
 }
 
 type pharmacyProductRepoImpl struct {
@@ -66,6 +78,86 @@ func (r pharmacyProductRepoImpl) GetStockByID(c context.Context, id int) (int, e
 	return stock, nil
 }
 
+func (r pharmacyProductRepoImpl) GetAvailableStockByID(c context.Context, id int) (int, error) {
+	tx := transaction.ExtractTx(c)
+
+	query := `SELECT pp.stock - COALESCE((
+					SELECT SUM(sh.quantity)
+					FROM stock_holds sh
+					WHERE sh.pharmacy_product_id = pp.id AND sh.expired_at > NOW() AND sh.deleted_at IS NULL
+				), 0)
+				FROM pharmacy_products pp
+				WHERE pp.id = $1 AND pp.deleted_at IS NULL`
+
+	var stock int
+	var err error
+	if tx != nil {
+		err = tx.QueryRowContext(c, query+` FOR UPDATE OF pp`, id).Scan(&stock)
+	} else {
+		err = r.db.QueryRowContext(c, query, id).Scan(&stock)
+	}
+	if err != nil {
+		return -1, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return stock, nil
+}
+
+func (r pharmacyProductRepoImpl) AddStockHold(c context.Context, stockHold entity.StockHold) error {
+	tx := transaction.ExtractTx(c)
+
+	query := `INSERT INTO stock_holds (user_id, cart_id, pharmacy_product_id, quantity, expired_at)
+				VALUES ($1, $2, $3, $4, $5)`
+
+	var err error
+	if tx != nil {
+		_, err = tx.ExecContext(c, query, stockHold.UserID, stockHold.CartID, stockHold.PharmacyProductID, stockHold.Quantity, stockHold.ExpiredAt)
+	} else {
+		_, err = r.db.ExecContext(c, query, stockHold.UserID, stockHold.CartID, stockHold.PharmacyProductID, stockHold.Quantity, stockHold.ExpiredAt)
+	}
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+func (r pharmacyProductRepoImpl) ReleaseStockHoldsByUserID(c context.Context, userID int) error {
+	tx := transaction.ExtractTx(c)
+
+	query := `UPDATE stock_holds
+				SET deleted_at = NOW(), updated_at = NOW()
+				WHERE user_id = $1 AND deleted_at IS NULL`
+
+	var err error
+	if tx != nil {
+		_, err = tx.ExecContext(c, query, userID)
+	} else {
+		_, err = r.db.ExecContext(c, query, userID)
+	}
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+func (r pharmacyProductRepoImpl) ReleaseStockHoldsByCartID(c context.Context, cartID string, userID int) error {
+	tx := transaction.ExtractTx(c)
+
+	query := `UPDATE stock_holds
+				SET deleted_at = NOW(), updated_at = NOW()
+				WHERE cart_id = $1 AND user_id = $2 AND deleted_at IS NULL`
+
+	var err error
+	if tx != nil {
+		_, err = tx.ExecContext(c, query, cartID, userID)
+	} else {
+		_, err = r.db.ExecContext(c, query, cartID, userID)
+	}
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
 func (r pharmacyProductRepoImpl) IsPharmacyProductExistsByIDAndPharmacy(c context.Context, pharmacyProductID int, pharmacyID int) (bool, error) {
 	query := `SELECT EXISTS (SELECT 1 FROM pharmacy_products WHERE id = $1 AND pharmacy_id = $2 AND deleted_at IS NULL)`
 
@@ -100,17 +192,31 @@ func (r pharmacyProductRepoImpl) IsProductExistsByID(c context.Context, id int)
 }
 
 func (r pharmacyProductRepoImpl) AddPharmacyProduct(c context.Context, pharmacyProduct entity.PharmacyProduct) error {
+	tx := transaction.ExtractTx(c)
 
-	query := `INSERT INTO pharmacy_products (pharmacy_id, product_id, stock, price, is_active)
-			  VALUES ($1, $2, $3, $4, $5)`
+	query := `WITH inserted AS (
+				INSERT INTO pharmacy_products (pharmacy_id, product_id, stock, price, is_active)
+				VALUES ($1, $2, $3, $4, $5)
+				RETURNING id, stock
+			  )
+			  INSERT INTO stock_movements (pharmacy_product_id, delta, balance, reason)
+			  SELECT id, stock, stock, $6 FROM inserted WHERE stock <> 0`
 
-	_, err := r.db.Exec(query,
+	args := []any{
 		pharmacyProduct.PharmacyID,
 		pharmacyProduct.ProductID,
 		pharmacyProduct.Stock,
 		pharmacyProduct.Price,
 		pharmacyProduct.IsActive,
-	)
+		appconstant.StockMovementManualAdjustment,
+	}
+
+	var err error
+	if tx != nil {
+		_, err = tx.ExecContext(c, query, args...)
+	} else {
+		_, err = r.db.ExecContext(c, query, args...)
+	}
 	if err != nil {
 		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
 	}
@@ -146,43 +252,137 @@ func (r pharmacyProductRepoImpl) GetPharmacyIDbyPharmacyProductID(c context.Cont
 }
 
 func (r pharmacyProductRepoImpl) UpdatePharmacyProduct(c context.Context, pharmacistProduct entity.PharmacyProduct) error {
+	tx := transaction.ExtractTx(c)
+
+	query := `WITH previous AS (
+					SELECT id, stock FROM pharmacy_products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE
+				),
+				updated AS (
+					UPDATE pharmacy_products pp
+					SET stock = $2, is_active = $3, updated_at = NOW()
+					FROM previous
+					WHERE pp.id = previous.id
+					RETURNING pp.id, pp.stock, previous.stock AS previous_stock
+				)
+				INSERT INTO stock_movements (pharmacy_product_id, delta, balance, reason)
+				SELECT id, stock - previous_stock, stock, $4 FROM updated WHERE stock <> previous_stock`
+
+	var err error
+	if tx != nil {
+		_, err = tx.ExecContext(c, query, pharmacistProduct.ID, pharmacistProduct.Stock, pharmacistProduct.IsActive, appconstant.StockMovementManualAdjustment)
+	} else {
+		_, err = r.db.ExecContext(c, query, pharmacistProduct.ID, pharmacistProduct.Stock, pharmacistProduct.IsActive, appconstant.StockMovementManualAdjustment)
+	}
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+
+	return nil
+}
+
+func (r pharmacyProductRepoImpl) UpdatePharmacyProductPrice(c context.Context, pharmacyProductID int, price decimal.Decimal) error {
+	tx := transaction.ExtractTx(c)
+
 	query := `UPDATE pharmacy_products
-				SET stock = $2, is_active = $3, updated_at = NOW()
+				SET price = $2, updated_at = NOW()
 				WHERE id = $1 AND deleted_at IS NULL`
 
-	_, err := r.db.Exec(query, pharmacistProduct.ID, pharmacistProduct.Stock, pharmacistProduct.IsActive)
+	var err error
+	if tx != nil {
+		_, err = tx.ExecContext(c, query, pharmacyProductID, price)
+	} else {
+		_, err = r.db.ExecContext(c, query, pharmacyProductID, price)
+	}
 	if err != nil {
 		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
 	}
-
 	return nil
 }
 
-func (r pharmacyProductRepoImpl) GetStockUpdatedDate(c context.Context) (string, error) {
-	res := r.rdb.Get(c, "stock-update-time")
-	err := res.Err()
+func (r pharmacyProductRepoImpl) GetStockUpdatePolicy(c context.Context, pharmacyProductID int) (*entity.StockUpdatePolicy, error) {
+	query := `SELECT ph.id, pa.stock_update_scope, pa.stock_update_limit, pa.stock_update_window_hours
+				FROM pharmacy_products pp
+				JOIN pharmacies ph ON ph.id = pp.pharmacy_id
+				JOIN partners pa ON pa.id = ph.partner_id
+				WHERE pp.id = $1`
+
+	var policy entity.StockUpdatePolicy
+	err := r.db.QueryRowContext(c, query, pharmacyProductID).Scan(&policy.PharmacyID, &policy.Scope, &policy.Limit, &policy.WindowHours)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
-		return "", apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
 	}
+	return &policy, nil
+}
+
+// GetStockUpdateCount returns how many stock updates were made in the current
+// window and how long until that window closes.
+func (r pharmacyProductRepoImpl) GetStockUpdateCount(c context.Context, scope string, id int) (int, time.Duration, error) {
+	key := fmt.Sprintf(appconstant.StockUpdateRedisKey, scope, id)
 
-	stockUpdatedTime, err := res.Result()
+	count, err := r.rdb.Get(c, key).Int()
+	if err == redis.Nil {
+		return 0, 0, nil
+	}
 	if err != nil {
-		return "", apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		return 0, 0, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
 	}
 
-	return stockUpdatedTime, nil
+	ttl, err := r.rdb.TTL(c, key).Result()
+	if err != nil {
+		return 0, 0, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return count, ttl, nil
 }
 
-func (r pharmacyProductRepoImpl) SetStockUpdatedDate(c context.Context) error {
-	currentDate := dateconverter.GetCurrentDate()
-	ttl := time.Duration(10) * time.Second
+// IncreaseStockUpdateCount counts an update and returns the new count and how
+// long until the window closes. The window is created with its expiry in the
+// same transaction on the first update, so later updates do not push it back
+// and a key can never be left without one.
+func (r pharmacyProductRepoImpl) IncreaseStockUpdateCount(c context.Context, scope string, id int, window time.Duration) (int, time.Duration, error) {
+	key := fmt.Sprintf(appconstant.StockUpdateRedisKey, scope, id)
+
+	var incr *redis.IntCmd
+	var ttl *redis.DurationCmd
+	_, err := r.rdb.TxPipelined(c, func(pipe redis.Pipeliner) error {
+		pipe.SetNX(c, key, 0, window)
+		incr = pipe.Incr(c, key)
+		ttl = pipe.TTL(c, key)
+		return nil
+	})
+	if err != nil {
+		return 0, 0, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return int(incr.Val()), ttl.Val(), nil
+}
 
-	opt := r.rdb.Set(c, "stock-update-time", currentDate, ttl)
-	err := opt.Err()
+// decreaseIfExistsScript only decrements a live window. A plain DECR on a key
+// that has just expired would recreate it without an expiry.
+var decreaseIfExistsScript = redis.NewScript(`
+	if redis.call("EXISTS", KEYS[1]) == 1 then
+		return redis.call("DECR", KEYS[1])
+	end
+	return 0
+`)
+
+// DecreaseStockUpdateCount gives back an update that was counted but not made.
+func (r pharmacyProductRepoImpl) DecreaseStockUpdateCount(c context.Context, scope string, id int) error {
+	key := fmt.Sprintf(appconstant.StockUpdateRedisKey, scope, id)
+
+	err := decreaseIfExistsScript.Run(c, r.rdb, []string{key}).Err()
 	if err != nil {
 		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
 	}
+	return nil
+}
 
+func (r pharmacyProductRepoImpl) DeleteStockUpdateCount(c context.Context, scope string, id int) error {
+	err := r.rdb.Del(c, fmt.Sprintf(appconstant.StockUpdateRedisKey, scope, id)).Err()
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
 	return nil
 }
 
@@ -306,3 +506,64 @@ func (r pharmacyProductRepoImpl) GetTotalProduct(c context.Context, queryParams
 
 	return totalProduct, nil
 }
+
+func (r pharmacyProductRepoImpl) GetInventory(c context.Context, pharmacyID int) ([]entity.InventoryItem, error) {
+	tx := transaction.ExtractTx(c)
+	items := []entity.InventoryItem{}
+
+	query := `SELECT pp.id, p.id, p.name, pp.stock, pp.price, pp.is_active
+				FROM pharmacy_products pp
+				JOIN products p ON p.id = pp.product_id
+				WHERE pp.pharmacy_id = $1 AND pp.deleted_at IS NULL AND p.deleted_at IS NULL
+				ORDER BY p.name, p.id`
+
+	var rows *sql.Rows
+	var err error
+	if tx != nil {
+		rows, err = tx.QueryContext(c, query+` FOR UPDATE OF pp`, pharmacyID)
+	} else {
+		rows, err = r.db.QueryContext(c, query, pharmacyID)
+	}
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item entity.InventoryItem
+		err := rows.Scan(&item.PharmacyProductID, &item.ProductID, &item.Name, &item.Stock, &item.Price, &item.IsActive)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// GetImportProducts looks a product up by ID, or by name when no ID is given.
+// It returns at most two matches so callers can tell an ambiguous name apart.
+func (r pharmacyProductRepoImpl) GetImportProducts(c context.Context, productID int, name string) ([]entity.InventoryItem, error) {
+	products := []entity.InventoryItem{}
+
+	query := `SELECT id, name
+				FROM products
+				WHERE deleted_at IS NULL AND (id = $1 OR ($1 = 0 AND LOWER(name) = LOWER($2)))
+				ORDER BY id
+				LIMIT 2`
+
+	rows, err := r.db.QueryContext(c, query, productID, name)
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var product entity.InventoryItem
+		err := rows.Scan(&product.ProductID, &product.Name)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
+		products = append(products, product)
+	}
+	return products, nil
+}
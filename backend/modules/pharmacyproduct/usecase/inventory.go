@@ -0,0 +1,470 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"montelukast/modules/pharmacyproduct/entity"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"montelukast/pkg/logger"
+	"montelukast/pkg/spreadsheet"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	inventoryColumnProductID = "product_id"
+	inventoryColumnName      = "name"
+	inventoryColumnStock     = "stock"
+	inventoryColumnPrice     = "price"
+	inventoryColumnIsActive  = "is_active"
+)
+
+var inventoryColumns = []string{
+	inventoryColumnProductID,
+	inventoryColumnName,
+	inventoryColumnStock,
+	inventoryColumnPrice,
+	inventoryColumnIsActive,
+}
+
+// inventoryImporter validates import rows against the pharmacy's inventory.
+// The stock update policy is shared by every product of a pharmacy, so it is
+// looked up once and the limit is checked once per throttled scope. A real
+// import counts its update per scope up front and gives it back on failure.
+type inventoryImporter struct {
+	u          pharmacyProductUsecaseImpl
+	pharmacyID int
+	isDryRun   bool
+	policy     *entity.StockUpdatePolicy
+	limits     map[int]string
+	counted    []int
+}
+
+func (u pharmacyProductUsecaseImpl) ImportInventory(c context.Context, pharmacistID int, upload entity.InventoryUpload) (*entity.InventoryImport, error) {
+	pharmacyID, err := u.getPharmacyID(c, pharmacistID, appconstant.FieldErrImportInventory)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := spreadsheet.Read(upload.Format, upload.File, upload.Size)
+	if err != nil {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrImportInventory, apperror.ErrInvalidImportFile, err)
+	}
+	columns, err := inventoryHeader(rows)
+	if err != nil {
+		return nil, err
+	}
+	importRows, err := parseInventoryRows(rows, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	importer := inventoryImporter{u: u, pharmacyID: pharmacyID, isDryRun: upload.IsDryRun, limits: map[int]string{}}
+	if upload.IsDryRun {
+		inventory, err := u.r.GetInventory(c, pharmacyID)
+		if err != nil {
+			return nil, err
+		}
+		return importer.validate(c, inventory, importRows, columns, true)
+	}
+
+	var report *entity.InventoryImport
+	err = u.tr.WithinTransaction(c, func(txCtx context.Context) error {
+		inventory, err := u.r.GetInventory(txCtx, pharmacyID)
+		if err != nil {
+			return err
+		}
+		report, err = importer.validate(txCtx, inventory, importRows, columns, false)
+		if err != nil || !report.IsValid {
+			return err
+		}
+		return importer.apply(txCtx, report.Rows)
+	})
+	if err != nil || !report.IsValid {
+		importer.releaseCounted(c)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (u pharmacyProductUsecaseImpl) ExportInventory(c context.Context, pharmacistID int, format string) (*entity.InventoryFile, error) {
+	pharmacyID, err := u.getPharmacyID(c, pharmacistID, appconstant.FieldErrExportInventory)
+	if err != nil {
+		return nil, err
+	}
+
+	inventory, err := u.r.GetInventory(c, pharmacyID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := [][]string{inventoryColumns}
+	for _, item := range inventory {
+		rows = append(rows, []string{
+			strconv.Itoa(item.ProductID),
+			item.Name,
+			strconv.Itoa(item.Stock),
+			item.Price.String(),
+			strconv.FormatBool(item.IsActive),
+		})
+	}
+
+	if format != spreadsheet.FormatXLSX {
+		format = spreadsheet.FormatCSV
+	}
+	content, err := spreadsheet.Write(format, rows)
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrExportInventory, apperror.ErrInternalServer, err)
+	}
+
+	contentType := spreadsheet.ContentTypeCSV
+	if format == spreadsheet.FormatXLSX {
+		contentType = spreadsheet.ContentTypeXLSX
+	}
+	return &entity.InventoryFile{
+		Name:        fmt.Sprintf("inventory-%d-%s.%s", pharmacyID, time.Now().Format("20060102"), format),
+		ContentType: contentType,
+		Content:     content,
+	}, nil
+}
+
+func inventoryHeader(rows [][]string) (map[string]int, error) {
+	if len(rows) == 0 {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrImportInventory, apperror.ErrInvalidImportHeader, apperror.ErrInvalidImportHeader)
+	}
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	_, hasProductID := columns[inventoryColumnProductID]
+	_, hasName := columns[inventoryColumnName]
+	_, hasStock := columns[inventoryColumnStock]
+	_, hasPrice := columns[inventoryColumnPrice]
+	if (!hasProductID && !hasName) || !hasStock || !hasPrice {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrImportInventory, apperror.ErrInvalidImportHeader, apperror.ErrInvalidImportHeader)
+	}
+	return columns, nil
+}
+
+// parseInventoryRows reads the rows below the header, skipping blank lines.
+// Row numbers follow the file so pharmacists can find the row to fix.
+func parseInventoryRows(rows [][]string, columns map[string]int) ([]entity.InventoryRow, error) {
+	importRows := []entity.InventoryRow{}
+	for i := 1; i < len(rows); i++ {
+		cell := func(column string) string {
+			index, ok := columns[column]
+			if !ok || index >= len(rows[i]) {
+				return ""
+			}
+			return strings.TrimSpace(rows[i][index])
+		}
+		if strings.TrimSpace(strings.Join(rows[i], "")) == "" {
+			continue
+		}
+		if len(importRows) == appconstant.MaxInventoryImportRows {
+			return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrImportInventory, apperror.ErrTooManyImportRows, apperror.ErrTooManyImportRows)
+		}
+
+		row := entity.InventoryRow{Row: i + 1, Name: cell(inventoryColumnName)}
+		if value := cell(inventoryColumnProductID); value != "" {
+			productID, err := strconv.Atoi(value)
+			if err != nil || productID <= 0 {
+				row.Errors = append(row.Errors, inventoryRowError(inventoryColumnProductID, apperror.ErrInvalidImportValue))
+			}
+			row.ProductID = productID
+		} else if row.Name == "" {
+			row.Errors = append(row.Errors, inventoryRowError(inventoryColumnProductID, apperror.ErrInvalidImportValue))
+		}
+
+		stock, err := strconv.Atoi(cell(inventoryColumnStock))
+		if err != nil {
+			row.Errors = append(row.Errors, inventoryRowError(inventoryColumnStock, apperror.ErrInvalidImportValue))
+		} else if stock < 0 {
+			row.Errors = append(row.Errors, inventoryRowError(inventoryColumnStock, apperror.ErrPriceOrStockLessThanZero))
+		}
+		row.Stock = stock
+
+		price, err := decimal.NewFromString(cell(inventoryColumnPrice))
+		if err != nil {
+			row.Errors = append(row.Errors, inventoryRowError(inventoryColumnPrice, apperror.ErrInvalidImportValue))
+		} else if price.IsNegative() {
+			row.Errors = append(row.Errors, inventoryRowError(inventoryColumnPrice, apperror.ErrPriceOrStockLessThanZero))
+		}
+		row.Price = price
+
+		row.IsActive = true
+		if value := cell(inventoryColumnIsActive); value != "" {
+			isActive, err := strconv.ParseBool(strings.ToLower(value))
+			if err != nil {
+				row.Errors = append(row.Errors, inventoryRowError(inventoryColumnIsActive, apperror.ErrInvalidImportValue))
+			}
+			row.IsActive = isActive
+		}
+		importRows = append(importRows, row)
+	}
+	return importRows, nil
+}
+
+func (i *inventoryImporter) validate(c context.Context, inventory []entity.InventoryItem, rows []entity.InventoryRow, columns map[string]int, isDryRun bool) (*entity.InventoryImport, error) {
+	byProductID := map[int]entity.InventoryItem{}
+	byName := map[string][]entity.InventoryItem{}
+	for _, item := range inventory {
+		byProductID[item.ProductID] = item
+		name := strings.ToLower(item.Name)
+		byName[name] = append(byName[name], item)
+	}
+	_, hasIsActive := columns[inventoryColumnIsActive]
+
+	report := entity.InventoryImport{IsDryRun: isDryRun}
+	seen := map[int]bool{}
+	for _, row := range rows {
+		if len(row.Errors) == 0 {
+			err := i.validateRow(c, &row, byProductID, byName, seen, hasIsActive)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		switch {
+		case len(row.Errors) > 0:
+			row.Action = ""
+			report.Invalid++
+		case row.Action == appconstant.InventoryActionCreate:
+			report.Created++
+		case row.Action == appconstant.InventoryActionUpdate:
+			report.Updated++
+		default:
+			report.Unchanged++
+		}
+		report.Rows = append(report.Rows, row)
+	}
+	report.IsValid = report.Invalid == 0
+	return &report, nil
+}
+
+func (i *inventoryImporter) validateRow(c context.Context, row *entity.InventoryRow, byProductID map[int]entity.InventoryItem, byName map[string][]entity.InventoryItem, seen map[int]bool, hasIsActive bool) error {
+	item, isExists := byProductID[row.ProductID]
+	if row.ProductID == 0 {
+		matches := byName[strings.ToLower(row.Name)]
+		if len(matches) > 1 {
+			row.Errors = append(row.Errors, inventoryRowError(inventoryColumnName, apperror.ErrAmbiguousImportProduct))
+			return nil
+		}
+		if len(matches) == 1 {
+			item, isExists = matches[0], true
+		}
+	}
+
+	if !isExists {
+		products, err := i.u.r.GetImportProducts(c, row.ProductID, row.Name)
+		if err != nil {
+			return err
+		}
+		if len(products) == 0 {
+			row.Errors = append(row.Errors, inventoryRowError(inventoryColumnProductID, apperror.ErrProductNotExists))
+			return nil
+		}
+		if len(products) > 1 {
+			row.Errors = append(row.Errors, inventoryRowError(inventoryColumnName, apperror.ErrAmbiguousImportProduct))
+			return nil
+		}
+		row.ProductID = products[0].ProductID
+		row.Name = products[0].Name
+		row.Action = appconstant.InventoryActionCreate
+		row.IsStockChanged = true
+		row.IsPriceChanged = true
+	} else {
+		row.PharmacyProductID = item.PharmacyProductID
+		row.ProductID = item.ProductID
+		row.Name = item.Name
+		if !hasIsActive {
+			row.IsActive = item.IsActive
+		}
+		row.IsStockChanged = row.Stock != item.Stock
+		row.IsPriceChanged = !row.Price.Equal(item.Price)
+		row.Action = appconstant.InventoryActionUnchanged
+		if row.IsStockChanged || row.IsPriceChanged || row.IsActive != item.IsActive {
+			row.Action = appconstant.InventoryActionUpdate
+		}
+	}
+
+	if seen[row.ProductID] {
+		row.Errors = append(row.Errors, inventoryRowError(inventoryColumnProductID, apperror.ErrDuplicateImportRow))
+		return nil
+	}
+	seen[row.ProductID] = true
+
+	pharmacyProduct := entity.PharmacyProduct{
+		ID:         row.PharmacyProductID,
+		PharmacyID: i.pharmacyID,
+		ProductID:  row.ProductID,
+		Stock:      row.Stock,
+		Price:      row.Price,
+		IsActive:   row.IsActive,
+	}
+	var err error
+	switch row.Action {
+	case appconstant.InventoryActionCreate:
+		err = i.u.checkAddablePharmacyProduct(c, pharmacyProduct, appconstant.FieldErrImportInventory)
+	case appconstant.InventoryActionUpdate:
+		err = i.u.checkUpdatablePharmacyProduct(c, pharmacyProduct, appconstant.FieldErrImportInventory)
+	}
+	message, err := inventoryRuleError(inventoryColumnProductID, err)
+	if err != nil {
+		return err
+	}
+	if message != "" {
+		row.Errors = append(row.Errors, message)
+		return nil
+	}
+
+	if row.Action == appconstant.InventoryActionUpdate && row.IsStockChanged {
+		message, err := i.checkStockUpdateLimit(c, row.PharmacyProductID)
+		if err != nil {
+			return err
+		}
+		if message != "" {
+			row.Errors = append(row.Errors, message)
+		}
+	}
+	return nil
+}
+
+// checkStockUpdateLimit treats the whole import as a single stock update for
+// every scope it touches, so a pharmacy-wide limit is not used up row by row.
+func (i *inventoryImporter) checkStockUpdateLimit(c context.Context, pharmacyProductID int) (string, error) {
+	if i.policy == nil {
+		policy, err := i.u.r.GetStockUpdatePolicy(c, pharmacyProductID)
+		if err != nil {
+			return "", err
+		}
+		if policy == nil {
+			return inventoryRowError(inventoryColumnProductID, apperror.ErrPharmacyProductNotExists), nil
+		}
+		i.policy = policy
+	}
+
+	scopeID := stockUpdateScopeID(*i.policy, pharmacyProductID)
+	if message, ok := i.limits[scopeID]; ok {
+		return message, nil
+	}
+	isLimited, ttl, err := i.countStockUpdate(c, scopeID)
+	if err != nil {
+		return "", err
+	}
+	message := ""
+	if isLimited {
+		nextUpdateAt := time.Now().Add(ttl).Format(time.RFC3339)
+		message = inventoryRowError(inventoryColumnStock, fmt.Errorf("%w, next update allowed at %s", apperror.ErrStockAlreadyUpdated, nextUpdateAt))
+	}
+	i.limits[scopeID] = message
+	return message, nil
+}
+
+// countStockUpdate only peeks at the count on a dry run. A real import counts
+// the update first, like a single stock edit, and gives it back when the
+// limit was already reached.
+func (i *inventoryImporter) countStockUpdate(c context.Context, scopeID int) (bool, time.Duration, error) {
+	if i.isDryRun {
+		count, ttl, err := i.u.r.GetStockUpdateCount(c, i.policy.Scope, scopeID)
+		return count >= i.policy.Limit, ttl, err
+	}
+
+	count, ttl, err := i.u.r.IncreaseStockUpdateCount(c, i.policy.Scope, scopeID, time.Duration(i.policy.WindowHours)*time.Hour)
+	if err != nil {
+		return false, 0, err
+	}
+	if count > i.policy.Limit {
+		return true, ttl, i.u.r.DecreaseStockUpdateCount(c, i.policy.Scope, scopeID)
+	}
+	i.counted = append(i.counted, scopeID)
+	return false, ttl, nil
+}
+
+// releaseCounted gives back the updates counted by an import that was not
+// applied.
+func (i *inventoryImporter) releaseCounted(c context.Context) {
+	for _, scopeID := range i.counted {
+		err := i.u.r.DecreaseStockUpdateCount(c, i.policy.Scope, scopeID)
+		if err != nil {
+			logger.Log.Error(err)
+		}
+	}
+	i.counted = nil
+}
+
+func (i *inventoryImporter) apply(c context.Context, rows []entity.InventoryRow) error {
+	for _, row := range rows {
+		switch row.Action {
+		case appconstant.InventoryActionCreate:
+			err := i.u.r.AddPharmacyProduct(c, entity.PharmacyProduct{
+				PharmacyID: i.pharmacyID,
+				ProductID:  row.ProductID,
+				Stock:      row.Stock,
+				Price:      row.Price,
+				IsActive:   row.IsActive,
+			})
+			if err != nil {
+				return err
+			}
+		case appconstant.InventoryActionUpdate:
+			err := i.u.r.UpdatePharmacyProduct(c, entity.PharmacyProduct{
+				ID:       row.PharmacyProductID,
+				Stock:    row.Stock,
+				IsActive: row.IsActive,
+			})
+			if err != nil {
+				return err
+			}
+			if row.IsPriceChanged {
+				err = i.u.r.UpdatePharmacyProductPrice(c, row.PharmacyProductID, row.Price)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func inventoryRowError(column string, err error) string {
+	return column + ": " + err.Error()
+}
+
+// inventoryRuleError reports a broken inventory rule as a row error, while
+// any other failure still aborts the import.
+func inventoryRuleError(column string, err error) (string, error) {
+	var appErr *apperror.ErrorStruct
+	if errors.As(err, &appErr) && appErr.Status != http.StatusInternalServerError {
+		return inventoryRowError(column, errors.New(appErr.Message)), nil
+	}
+	return "", err
+}
+
+func (u pharmacyProductUsecaseImpl) getPharmacyID(c context.Context, pharmacistID int, field string) (int, error) {
+	isExists, err := u.phsr.IsPharmacistExistsByID(c, pharmacistID)
+	if err != nil {
+		return 0, err
+	}
+	if !isExists {
+		return 0, apperror.NewErrStatusNotFound(field, apperror.ErrPharmacistNotExists, apperror.ErrPharmacistNotExists)
+	}
+
+	pharmacyID, err := u.r.GetPharmacyIDbyPharmacistID(c, pharmacistID)
+	if err != nil {
+		return 0, err
+	}
+	if pharmacyID == 0 {
+		return 0, apperror.NewErrStatusBadRequest(field, apperror.ErrPharmacistNotHasPharmacy, apperror.ErrPharmacistNotHasPharmacy)
+	}
+	return pharmacyID, nil
+}
@@ -11,12 +11,10 @@ import (
 	productEntity "montelukast/modules/product/entity"
 	productRepo "montelukast/modules/product/repository"
 	appconstant "montelukast/pkg/constant"
-	"montelukast/pkg/dateconverter"
 	apperror "montelukast/pkg/error"
+	"montelukast/pkg/logger"
 	"montelukast/pkg/transaction"
-
-	"github.com/go-redis/redis/v8"
-	"github.com/shopspring/decimal"
+	"time"
 )
 
 type PharmacyProductUsecase interface {
@@ -25,6 +23,9 @@ type PharmacyProductUsecase interface {
 	DeletePharmacyProduct(c context.Context, pharmacyProduct entity.PharmacyProduct, pharmacistID int) error
 	GetPharmacyProductDetail(c context.Context, pharmacyProductID int, pharmacistID int) (*productEntity.ProductDetail, error)
 	GetPharmacyProducts(c context.Context, queryParams queryparams.QueryParams, pharmacistID int) (*productEntity.ProductsList, error)
+	ResetStockUpdateLimit(c context.Context, pharmacyProductID int) error
+	ImportInventory(c context.Context, pharmacistID int, upload entity.InventoryUpload) (*entity.InventoryImport, error)
+	ExportInventory(c context.Context, pharmacistID int, format string) (*entity.InventoryFile, error)
 }
 
 type pharmacyProductUsecaseImpl struct {
@@ -46,8 +47,9 @@ func NewPharmacyProductUsecase(r repository.PharmacyProductRepo, tr transaction.
 }
 
 func (u pharmacyProductUsecaseImpl) AddPharmacyProduct(c context.Context, pharmacyProduct entity.PharmacyProduct, pharmacistID int) error {
-	if pharmacyProduct.Stock < 0 || decimal.NewFromInt(0).GreaterThan(pharmacyProduct.Price) {
-		return apperror.NewErrStatusBadRequest(appconstant.FieldErrAddPharmacyProduct, apperror.ErrPriceOrStockLessThanZero, apperror.ErrPriceOrStockLessThanZero)
+	err := checkStockAndPrice(pharmacyProduct, appconstant.FieldErrAddPharmacyProduct)
+	if err != nil {
+		return err
 	}
 
 	isExists, err := u.phsr.IsPharmacistExistsByID(c, pharmacistID)
@@ -67,29 +69,10 @@ func (u pharmacyProductUsecaseImpl) AddPharmacyProduct(c context.Context, pharma
 	}
 	pharmacyProduct.PharmacyID = pharmacyID
 
-	isExists, err = u.r.IsPharmacyProductExists(c, pharmacyID, pharmacyProduct.ProductID)
-	if err != nil {
-		return err
-	}
-	if isExists {
-		return apperror.NewErrStatusBadRequest(appconstant.FieldErrAddPharmacyProduct, apperror.ErrProductAlreadyExists, apperror.ErrProductAlreadyExists)
-	}
-
-	isExists, err = u.phr.IsPharmacyExists(c, pharmacyID)
-	if err != nil {
-		return err
-	}
-	if !isExists {
-		return apperror.NewErrStatusNotFound(appconstant.FieldErrAddPharmacyProduct, apperror.ErrPharmacyNotExists, apperror.ErrPharmacyNotExists)
-	}
-
-	isExists, err = u.r.IsProductExistsByID(c, pharmacyProduct.ProductID)
+	err = u.checkAddablePharmacyProduct(c, pharmacyProduct, appconstant.FieldErrAddPharmacyProduct)
 	if err != nil {
 		return err
 	}
-	if !isExists {
-		return apperror.NewErrStatusNotFound(appconstant.FieldErrAddPharmacyProduct, apperror.ErrProductNotExists, apperror.ErrProductNotExists)
-	}
 
 	err = u.r.AddPharmacyProduct(c, pharmacyProduct)
 	if err != nil {
@@ -108,13 +91,10 @@ func (u pharmacyProductUsecaseImpl) UpdatePharmacyProduct(c context.Context, pha
 		return apperror.NewErrStatusBadRequest(appconstant.FieldErrAddPharmacyProduct, apperror.ErrPharmacistNotExists, apperror.ErrPharmacistNotExists)
 	}
 
-	isExists, err = u.r.IsPharmacyProductExistsByID(c, pharmacyProduct.ID)
+	err = u.checkUpdatablePharmacyProduct(c, pharmacyProduct, appconstant.FieldErrUpdatePharmacyProduct)
 	if err != nil {
 		return err
 	}
-	if !isExists {
-		return apperror.NewErrStatusNotFound(appconstant.FieldErrUpdatePharmacyProduct, apperror.ErrPharmacyProductNotExists, apperror.ErrPharmacyProductNotExists)
-	}
 
 	err = u.CheckPharmacistAuthorization(c, pharmacistID, pharmacyProduct.ID)
 	if err != nil {
@@ -126,18 +106,21 @@ func (u pharmacyProductUsecaseImpl) UpdatePharmacyProduct(c context.Context, pha
 		return err
 	}
 
-	err = u.CheckStockUpdatedDate(c, currStock, pharmacyProduct.Stock)
-	if err != nil {
-		return err
+	if currStock == pharmacyProduct.Stock {
+		return u.r.UpdatePharmacyProduct(c, pharmacyProduct)
 	}
 
-	err = u.r.UpdatePharmacyProduct(c, pharmacyProduct)
+	policy, err := u.CheckStockUpdateLimit(c, pharmacyProduct.ID)
 	if err != nil {
 		return err
 	}
 
-	err = u.r.SetStockUpdatedDate(c)
+	err = u.r.UpdatePharmacyProduct(c, pharmacyProduct)
 	if err != nil {
+		errRelease := u.r.DecreaseStockUpdateCount(c, policy.Scope, stockUpdateScopeID(*policy, pharmacyProduct.ID))
+		if errRelease != nil {
+			logger.Log.Error(errRelease)
+		}
 		return err
 	}
 
@@ -261,23 +244,111 @@ func (u pharmacyProductUsecaseImpl) GetPharmacyProducts(c context.Context, query
 	return &productsList, nil
 }
 
-func (u pharmacyProductUsecaseImpl) CheckStockUpdatedDate(c context.Context, currStock, incomingStock int) error {
-	if currStock != incomingStock {
-		stockUpdatedDate, err := u.r.GetStockUpdatedDate(c)
-		if err != nil && err.Error() != redis.Nil.Error() {
-			return err
-		}
-		if err != redis.Nil {
-			currentDate := dateconverter.GetCurrentDate()
-			if stockUpdatedDate == currentDate {
-				return apperror.NewErrStatusBadRequest(appconstant.FieldErrUpdatePharmacyProduct, apperror.ErrStockAlreadyUpdated, apperror.ErrStockAlreadyUpdated)
-			}
+// CheckStockUpdateLimit applies the partner's stock update policy to either
+// the pharmacy product or its whole pharmacy, depending on the partner scope.
+// The update is counted before it is made, so concurrent updates cannot all
+// pass the check. A caller whose update then fails gives the count back.
+func (u pharmacyProductUsecaseImpl) CheckStockUpdateLimit(c context.Context, pharmacyProductID int) (*entity.StockUpdatePolicy, error) {
+	policy, err := u.r.GetStockUpdatePolicy(c, pharmacyProductID)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return nil, apperror.NewErrStatusNotFound(appconstant.FieldErrUpdatePharmacyProduct, apperror.ErrPharmacyProductNotExists, apperror.ErrPharmacyProductNotExists)
+	}
+
+	scopeID := stockUpdateScopeID(*policy, pharmacyProductID)
+	count, ttl, err := u.r.IncreaseStockUpdateCount(c, policy.Scope, scopeID, time.Duration(policy.WindowHours)*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	if count > policy.Limit {
+		err = u.r.DecreaseStockUpdateCount(c, policy.Scope, scopeID)
+		if err != nil {
+			return nil, err
 		}
+		return nil, apperror.NewErrStockUpdateLimited(appconstant.FieldErrUpdatePharmacyProduct, time.Now().Add(ttl))
+	}
+
+	return policy, nil
+}
+
+func (u pharmacyProductUsecaseImpl) ResetStockUpdateLimit(c context.Context, pharmacyProductID int) error {
+	policy, err := u.r.GetStockUpdatePolicy(c, pharmacyProductID)
+	if err != nil {
+		return err
+	}
+	if policy == nil {
+		return apperror.NewErrStatusNotFound(appconstant.FieldErrResetStockUpdateLimit, apperror.ErrPharmacyProductNotExists, apperror.ErrPharmacyProductNotExists)
 	}
 
+	err = u.r.DeleteStockUpdateCount(c, appconstant.StockUpdateScopePharmacyProduct, pharmacyProductID)
+	if err != nil {
+		return err
+	}
+	return u.r.DeleteStockUpdateCount(c, appconstant.StockUpdateScopePharmacy, policy.PharmacyID)
+}
+
+// checkStockAndPrice, checkAddablePharmacyProduct and checkUpdatablePharmacyProduct
+// hold the inventory rules shared by single product changes and inventory
+// imports, which report a broken rule as an error of the offending row.
+func checkStockAndPrice(pharmacyProduct entity.PharmacyProduct, field string) error {
+	if pharmacyProduct.Stock < 0 || pharmacyProduct.Price.IsNegative() {
+		return apperror.NewErrStatusBadRequest(field, apperror.ErrPriceOrStockLessThanZero, apperror.ErrPriceOrStockLessThanZero)
+	}
+	return nil
+}
+
+func (u pharmacyProductUsecaseImpl) checkAddablePharmacyProduct(c context.Context, pharmacyProduct entity.PharmacyProduct, field string) error {
+	isExists, err := u.r.IsPharmacyProductExists(c, pharmacyProduct.PharmacyID, pharmacyProduct.ProductID)
+	if err != nil {
+		return err
+	}
+	if isExists {
+		return apperror.NewErrStatusBadRequest(field, apperror.ErrProductAlreadyExists, apperror.ErrProductAlreadyExists)
+	}
+
+	isExists, err = u.phr.IsPharmacyExists(c, pharmacyProduct.PharmacyID)
+	if err != nil {
+		return err
+	}
+	if !isExists {
+		return apperror.NewErrStatusNotFound(field, apperror.ErrPharmacyNotExists, apperror.ErrPharmacyNotExists)
+	}
+
+	isExists, err = u.r.IsProductExistsByID(c, pharmacyProduct.ProductID)
+	if err != nil {
+		return err
+	}
+	if !isExists {
+		return apperror.NewErrStatusNotFound(field, apperror.ErrProductNotExists, apperror.ErrProductNotExists)
+	}
 	return nil
 }
 
+func (u pharmacyProductUsecaseImpl) checkUpdatablePharmacyProduct(c context.Context, pharmacyProduct entity.PharmacyProduct, field string) error {
+	err := checkStockAndPrice(pharmacyProduct, field)
+	if err != nil {
+		return err
+	}
+
+	isExists, err := u.r.IsPharmacyProductExistsByID(c, pharmacyProduct.ID)
+	if err != nil {
+		return err
+	}
+	if !isExists {
+		return apperror.NewErrStatusNotFound(field, apperror.ErrPharmacyProductNotExists, apperror.ErrPharmacyProductNotExists)
+	}
+	return nil
+}
+
+func stockUpdateScopeID(policy entity.StockUpdatePolicy, pharmacyProductID int) int {
+	if policy.Scope == appconstant.StockUpdateScopePharmacy {
+		return policy.PharmacyID
+	}
+	return pharmacyProductID
+}
+
 func (u pharmacyProductUsecaseImpl) CheckPharmacistAuthorization(c context.Context, pharmacistID, pharmacyProductID int) error {
 	pharmacyID1, err := u.r.GetPharmacyIDbyPharmacistID(c, pharmacistID)
 	if err != nil {
@@ -0,0 +1,30 @@
+package converter
+
+import (
+	"montelukast/modules/prescription/dto"
+	"montelukast/modules/prescription/entity"
+)
+
+type FileConverter struct{}
+
+func (c FileConverter) ToEntity(file dto.FileRequest) entity.File {
+	return entity.File{
+		File: file.File,
+	}
+}
+
+type PrescriptionConverter struct{}
+
+func (c PrescriptionConverter) ToDto(prescription entity.Prescription) dto.PrescriptionResponse {
+	return dto.PrescriptionResponse{
+		ID:              prescription.ID,
+		UserID:          prescription.UserID,
+		UserName:        prescription.UserName,
+		Image:           prescription.Image,
+		Status:          prescription.Status,
+		RejectionReason: prescription.RejectionReason,
+		ReviewedAt:      prescription.ReviewedAt,
+		CreatedAt:       prescription.CreatedAt,
+		OrderDetailIDs:  prescription.OrderDetailIDs,
+	}
+}
@@ -0,0 +1,34 @@
+package dto
+
+import (
+	"mime/multipart"
+	"time"
+)
+
+type FileRequest struct {
+	File multipart.File `json:"file,omitempty"`
+}
+
+type AttachPrescriptionRequest struct {
+	PrescriptionID int `json:"prescription_id" binding:"required,gte=1"`
+}
+
+type RejectPrescriptionRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+type PrescriptionFilterRequest struct {
+	Status string `form:"status"`
+}
+
+type PrescriptionResponse struct {
+	ID              int        `json:"id"`
+	UserID          int        `json:"user_id"`
+	UserName        string     `json:"user_name,omitempty"`
+	Image           string     `json:"image"`
+	Status          string     `json:"status,omitempty"`
+	RejectionReason *string    `json:"rejection_reason"`
+	ReviewedAt      *time.Time `json:"reviewed_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	OrderDetailIDs  []int      `json:"order_detail_ids,omitempty"`
+}
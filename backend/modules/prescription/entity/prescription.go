@@ -0,0 +1,43 @@
+package entity
+
+import (
+	"mime/multipart"
+	"time"
+)
+
+type Prescription struct {
+	ID              int
+	UserID          int
+	UserName        string
+	Image           string
+	Status          string
+	PharmacistID    *int
+	RejectionReason *string
+	ReviewedAt      *time.Time
+	CreatedAt       time.Time
+	OrderDetailIDs  []int
+}
+
+// PrescriptionReview is one pharmacy's verdict on a prescription. It only
+// applies to that pharmacy's order details, other pharmacies review their own.
+type PrescriptionReview struct {
+	PrescriptionID  int
+	PharmacyID      int
+	Status          string
+	PharmacistID    int
+	RejectionReason *string
+}
+
+type ReviewedOrderDetail struct {
+	ID     int
+	Status string
+}
+
+type PrescriptionFilter struct {
+	PharmacyID int
+	Status     string
+}
+
+type File struct {
+	File multipart.File `validate:"required"`
+}
@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"montelukast/modules/prescription/converter"
+	"montelukast/modules/prescription/dto"
+	"montelukast/modules/prescription/entity"
+	"montelukast/modules/prescription/usecase"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"montelukast/pkg/wrapper"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PrescriptionHandler struct {
+	u usecase.PrescriptionUsecase
+}
+
+func NewPrescriptionHandler(u usecase.PrescriptionUsecase) PrescriptionHandler {
+	return PrescriptionHandler{
+		u: u,
+	}
+}
+
+func (h PrescriptionHandler) UploadPrescriptionHandler(c *gin.Context) {
+	rawUserID, isExists := c.Get("user_id")
+	if !isExists {
+		err := apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, apperror.ErrTokenInvalid)
+		c.Error(err)
+		return
+	}
+	userID, err := strconv.Atoi(rawUserID.(string))
+	if err != nil {
+		err := apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, err)
+		c.Error(err)
+		return
+	}
+
+	_, fileHeader, err := c.Request.FormFile("file")
+	if err != nil {
+		err := apperror.NewErrStatusBadRequest(appconstant.FieldErrUploadPrescription, apperror.ErrFileEmpty, err)
+		c.Error(err)
+		return
+	}
+	fileName := fileHeader.Filename
+	extension := fileName[strings.Index(fileName, ".")+1:]
+	if extension != "pdf" && extension != "png" && extension != "jpg" {
+		err := apperror.NewErrStatusBadRequest(appconstant.FieldErrImageType, apperror.ErrUploadImage, err)
+		c.Error(err)
+		return
+	}
+	if fileHeader.Size > appconstant.IMAGESIZEMAX {
+		err := apperror.NewErrStatusBadRequest(appconstant.FieldErrImageSize, apperror.ErrUploadImageSize, err)
+		c.Error(err)
+		return
+	}
+	formFile, err := fileHeader.Open()
+	if err != nil {
+		err := apperror.NewErrStatusBadRequest(appconstant.FieldErrImageType, apperror.ErrUploadImage, err)
+		c.Error(err)
+		return
+	}
+	defer formFile.Close()
+
+	fileRequest := dto.FileRequest{}
+	fileRequest.File = formFile
+
+	prescription, err := h.u.UploadPrescription(c, converter.FileConverter{}.ToEntity(fileRequest), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(converter.PrescriptionConverter{}.ToDto(*prescription), "upload prescription success!", nil)
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h PrescriptionHandler) AttachPrescriptionHandler(c *gin.Context) {
+	rawUserID, isExists := c.Get("user_id")
+	if !isExists {
+		err := apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, apperror.ErrTokenInvalid)
+		c.Error(err)
+		return
+	}
+	userID, err := strconv.Atoi(rawUserID.(string))
+	if err != nil {
+		err := apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, err)
+		c.Error(err)
+		return
+	}
+
+	rawOrderDetailID := c.Param("order_id")
+	orderDetailID, err := strconv.Atoi(rawOrderDetailID)
+	if err != nil {
+		err := apperror.NewErrStatusBadRequest(appconstant.FieldErrAttachPrescription, apperror.ErrConvertVariableType, err)
+		c.Error(err)
+		return
+	}
+
+	err = apperror.JsonValidator(c)
+	if err != nil {
+		err := apperror.NewErrStatusBadRequest(appconstant.FieldErrAttachPrescription, apperror.ErrInvalidJSON, err)
+		c.Error(err)
+		return
+	}
+
+	attachReq := dto.AttachPrescriptionRequest{}
+	err = c.ShouldBindJSON(&attachReq)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = h.u.AttachPrescription(c, orderDetailID, attachReq.PrescriptionID, userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(nil, "attach prescription success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h PrescriptionHandler) GetPrescriptionsHandler(c *gin.Context) {
+	rawPharmacistID, isExists := c.Get("user_id")
+	if !isExists {
+		err := apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, apperror.ErrTokenInvalid)
+		c.Error(err)
+		return
+	}
+	pharmacistID, err := strconv.Atoi(rawPharmacistID.(string))
+	if err != nil {
+		err := apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, err)
+		c.Error(err)
+		return
+	}
+
+	filterReq := dto.PrescriptionFilterRequest{}
+	if err := c.ShouldBindQuery(&filterReq); err != nil {
+		err := apperror.NewErrStatusBadRequest(appconstant.FieldErrGetPrescriptions, apperror.ErrQueryParams, err)
+		c.Error(err)
+		return
+	}
+
+	prescriptions, err := h.u.GetPrescriptions(c, entity.PrescriptionFilter{Status: filterReq.Status}, pharmacistID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	prescriptionsDto := []dto.PrescriptionResponse{}
+	for _, prescription := range prescriptions {
+		prescriptionsDto = append(prescriptionsDto, converter.PrescriptionConverter{}.ToDto(prescription))
+	}
+
+	response := wrapper.ResponseData(prescriptionsDto, "get prescriptions success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h PrescriptionHandler) ApprovePrescriptionHandler(c *gin.Context) {
+	rawPharmacistID, isExists := c.Get("user_id")
+	if !isExists {
+		err := apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, apperror.ErrTokenInvalid)
+		c.Error(err)
+		return
+	}
+	pharmacistID, err := strconv.Atoi(rawPharmacistID.(string))
+	if err != nil {
+		err := apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, err)
+		c.Error(err)
+		return
+	}
+
+	rawPrescriptionID := c.Param("id")
+	prescriptionID, err := strconv.Atoi(rawPrescriptionID)
+	if err != nil {
+		err := apperror.NewErrStatusBadRequest(appconstant.FieldErrReviewPrescription, apperror.ErrConvertVariableType, err)
+		c.Error(err)
+		return
+	}
+
+	err = h.u.ApprovePrescription(c, prescriptionID, pharmacistID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(nil, "approve prescription success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h PrescriptionHandler) RejectPrescriptionHandler(c *gin.Context) {
+	rawPharmacistID, isExists := c.Get("user_id")
+	if !isExists {
+		err := apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, apperror.ErrTokenInvalid)
+		c.Error(err)
+		return
+	}
+	pharmacistID, err := strconv.Atoi(rawPharmacistID.(string))
+	if err != nil {
+		err := apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, err)
+		c.Error(err)
+		return
+	}
+
+	rawPrescriptionID := c.Param("id")
+	prescriptionID, err := strconv.Atoi(rawPrescriptionID)
+	if err != nil {
+		err := apperror.NewErrStatusBadRequest(appconstant.FieldErrReviewPrescription, apperror.ErrConvertVariableType, err)
+		c.Error(err)
+		return
+	}
+
+	err = apperror.JsonValidator(c)
+	if err != nil {
+		err := apperror.NewErrStatusBadRequest(appconstant.FieldErrReviewPrescription, apperror.ErrInvalidJSON, err)
+		c.Error(err)
+		return
+	}
+
+	rejectReq := dto.RejectPrescriptionRequest{}
+	err = c.ShouldBindJSON(&rejectReq)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = h.u.RejectPrescription(c, prescriptionID, rejectReq.Reason, pharmacistID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(nil, "reject prescription success!", nil)
+	c.JSON(http.StatusOK, response)
+}
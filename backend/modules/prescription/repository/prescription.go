@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"montelukast/modules/prescription/entity"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"montelukast/pkg/transaction"
+)
+
+type PrescriptionRepo interface {
+	AddPrescription(c context.Context, prescription entity.Prescription) (*entity.Prescription, error)
+	IsPrescriptionExistsByID(c context.Context, prescriptionID int) (bool, error)
+	GetPrescriptionByID(c context.Context, prescriptionID int) (*entity.Prescription, error)
+	IsOrderDetailFromUser(c context.Context, orderDetailID int, userID int) (bool, error)
+	GetOrderDetailStatusByID(c context.Context, orderDetailID int) (string, error)
+	UpdateOrderDetailPrescription(c context.Context, orderDetailID int, prescriptionID int) error
+	GetPrescriptionsByPharmacyID(c context.Context, filter entity.PrescriptionFilter) ([]entity.Prescription, error)
+	IsPrescriptionInPharmacy(c context.Context, prescriptionID int, pharmacyID int) (bool, error)
+	GetOrderDetailsAwaitingReview(c context.Context, prescriptionID int, pharmacyID int) ([]entity.ReviewedOrderDetail, error)
+	UpdateOrderDetailPrescriptionReview(c context.Context, review entity.PrescriptionReview) error
+}
+
+type prescriptionRepoImpl struct {
+	db *sql.DB
+}
+
+func NewPrescriptionRepo(dbConn *sql.DB) prescriptionRepoImpl {
+	return prescriptionRepoImpl{
+		db: dbConn,
+	}
+}
+
+func (r prescriptionRepoImpl) AddPrescription(c context.Context, prescription entity.Prescription) (*entity.Prescription, error) {
+	query := `INSERT INTO prescriptions (user_id, image)
+				VALUES ($1, $2)
+				RETURNING id, user_id, image, created_at`
+
+	var result entity.Prescription
+	err := r.db.QueryRowContext(c, query, prescription.UserID, prescription.Image).Scan(
+		&result.ID,
+		&result.UserID,
+		&result.Image,
+		&result.CreatedAt,
+	)
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return &result, nil
+}
+
+func (r prescriptionRepoImpl) IsPrescriptionExistsByID(c context.Context, prescriptionID int) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM prescriptions WHERE id = $1 AND deleted_at IS NULL)`
+
+	var exists bool
+	err := r.db.QueryRowContext(c, query, prescriptionID).Scan(&exists)
+	if err != nil && err != sql.ErrNoRows {
+		return exists, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return exists, nil
+}
+
+func (r prescriptionRepoImpl) GetPrescriptionByID(c context.Context, prescriptionID int) (*entity.Prescription, error) {
+	query := `SELECT id, user_id, image, created_at
+				FROM prescriptions
+				WHERE id = $1 AND deleted_at IS NULL`
+
+	var prescription entity.Prescription
+	err := r.db.QueryRowContext(c, query, prescriptionID).Scan(
+		&prescription.ID,
+		&prescription.UserID,
+		&prescription.Image,
+		&prescription.CreatedAt,
+	)
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return &prescription, nil
+}
+
+func (r prescriptionRepoImpl) IsOrderDetailFromUser(c context.Context, orderDetailID int, userID int) (bool, error) {
+	query := `SELECT EXISTS (
+				SELECT 1
+				FROM order_details od
+				JOIN orders o ON o.id = od.order_id
+				WHERE od.id = $1 AND o.user_id = $2 AND od.deleted_at IS NULL AND o.deleted_at IS NULL
+			)`
+
+	var exists bool
+	err := r.db.QueryRowContext(c, query, orderDetailID, userID).Scan(&exists)
+	if err != nil && err != sql.ErrNoRows {
+		return exists, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return exists, nil
+}
+
+func (r prescriptionRepoImpl) GetOrderDetailStatusByID(c context.Context, orderDetailID int) (string, error) {
+	query := `SELECT status FROM order_details WHERE id = $1 AND deleted_at IS NULL`
+
+	var status string
+	err := r.db.QueryRowContext(c, query, orderDetailID).Scan(&status)
+	if err != nil {
+		return "", apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return status, nil
+}
+
+func (r prescriptionRepoImpl) UpdateOrderDetailPrescription(c context.Context, orderDetailID int, prescriptionID int) error {
+	query := `UPDATE order_details
+				SET prescription_id = $2, prescription_status = $3, prescription_reviewed_by = NULL,
+				prescription_rejection_reason = NULL, prescription_reviewed_at = NULL, updated_at = NOW()
+				WHERE id = $1 AND deleted_at IS NULL`
+
+	_, err := r.db.ExecContext(c, query, orderDetailID, prescriptionID, appconstant.PrescriptionStatusPending)
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+func (r prescriptionRepoImpl) GetPrescriptionsByPharmacyID(c context.Context, filter entity.PrescriptionFilter) ([]entity.Prescription, error) {
+	query := `SELECT pr.id, pr.user_id, u.name, pr.image, od.prescription_status, od.prescription_reviewed_by,
+				od.prescription_rejection_reason, od.prescription_reviewed_at, pr.created_at, od.id
+				FROM prescriptions pr
+				JOIN order_details od ON od.prescription_id = pr.id
+				JOIN users u ON u.id = pr.user_id
+				WHERE od.pharmacy_id = $1 AND od.deleted_at IS NULL AND pr.deleted_at IS NULL`
+
+	args := []any{filter.PharmacyID}
+	if filter.Status != "" {
+		query += ` AND od.prescription_status = $2`
+		args = append(args, filter.Status)
+	}
+	query += ` ORDER BY pr.created_at DESC, od.id`
+
+	rows, err := r.db.QueryContext(c, query, args...)
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	// A prescription reused on a later order of the same pharmacy is reviewed
+	// again, so order details are grouped per prescription and review status.
+	type groupKey struct {
+		id     int
+		status string
+	}
+	prescriptions := []entity.Prescription{}
+	indexByKey := make(map[groupKey]int)
+	for rows.Next() {
+		var prescription entity.Prescription
+		var orderDetailID int
+		err := rows.Scan(
+			&prescription.ID,
+			&prescription.UserID,
+			&prescription.UserName,
+			&prescription.Image,
+			&prescription.Status,
+			&prescription.PharmacistID,
+			&prescription.RejectionReason,
+			&prescription.ReviewedAt,
+			&prescription.CreatedAt,
+			&orderDetailID,
+		)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
+		key := groupKey{id: prescription.ID, status: prescription.Status}
+		if index, ok := indexByKey[key]; ok {
+			prescriptions[index].OrderDetailIDs = append(prescriptions[index].OrderDetailIDs, orderDetailID)
+			continue
+		}
+		prescription.OrderDetailIDs = []int{orderDetailID}
+		indexByKey[key] = len(prescriptions)
+		prescriptions = append(prescriptions, prescription)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return prescriptions, nil
+}
+
+func (r prescriptionRepoImpl) IsPrescriptionInPharmacy(c context.Context, prescriptionID int, pharmacyID int) (bool, error) {
+	query := `SELECT EXISTS (
+				SELECT 1 FROM order_details
+				WHERE prescription_id = $1 AND pharmacy_id = $2 AND deleted_at IS NULL
+			)`
+
+	var exists bool
+	err := r.db.QueryRowContext(c, query, prescriptionID, pharmacyID).Scan(&exists)
+	if err != nil && err != sql.ErrNoRows {
+		return exists, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return exists, nil
+}
+
+func (r prescriptionRepoImpl) GetOrderDetailsAwaitingReview(c context.Context, prescriptionID int, pharmacyID int) ([]entity.ReviewedOrderDetail, error) {
+	tx := transaction.ExtractTx(c)
+
+	query := `SELECT id, status
+				FROM order_details
+				WHERE prescription_id = $1 AND pharmacy_id = $2 AND prescription_status = $3 AND deleted_at IS NULL
+				FOR UPDATE`
+
+	var err error
+	var rows *sql.Rows
+	if tx != nil {
+		rows, err = tx.QueryContext(c, query, prescriptionID, pharmacyID, appconstant.PrescriptionStatusPending)
+	} else {
+		rows, err = r.db.QueryContext(c, query, prescriptionID, pharmacyID, appconstant.PrescriptionStatusPending)
+	}
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	orderDetails := []entity.ReviewedOrderDetail{}
+	for rows.Next() {
+		var orderDetail entity.ReviewedOrderDetail
+		err := rows.Scan(&orderDetail.ID, &orderDetail.Status)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
+		orderDetails = append(orderDetails, orderDetail)
+	}
+	return orderDetails, nil
+}
+
+func (r prescriptionRepoImpl) UpdateOrderDetailPrescriptionReview(c context.Context, review entity.PrescriptionReview) error {
+	tx := transaction.ExtractTx(c)
+
+	query := `UPDATE order_details
+				SET prescription_status = $4, prescription_reviewed_by = $5, prescription_rejection_reason = $6,
+				prescription_reviewed_at = NOW(), updated_at = NOW()
+				WHERE prescription_id = $1 AND pharmacy_id = $2 AND prescription_status = $3 AND deleted_at IS NULL`
+
+	args := []any{review.PrescriptionID, review.PharmacyID, appconstant.PrescriptionStatusPending, review.Status, review.PharmacistID, review.RejectionReason}
+	var err error
+	if tx != nil {
+		_, err = tx.ExecContext(c, query, args...)
+	} else {
+		_, err = r.db.ExecContext(c, query, args...)
+	}
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
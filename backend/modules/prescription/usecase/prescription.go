@@ -0,0 +1,203 @@
+package usecase
+
+import (
+	"context"
+	orderStatusEntity "montelukast/modules/orderstatus/entity"
+	orderStatus "montelukast/modules/orderstatus/usecase"
+	pharmacistRepo "montelukast/modules/pharmacist/repository"
+	"montelukast/modules/prescription/entity"
+	"montelukast/modules/prescription/repository"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"montelukast/pkg/imageuploader"
+	"montelukast/pkg/transaction"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type PrescriptionUsecase interface {
+	UploadPrescription(c context.Context, file entity.File, userID int) (*entity.Prescription, error)
+	AttachPrescription(c context.Context, orderDetailID int, prescriptionID int, userID int) error
+	GetPrescriptions(c context.Context, filter entity.PrescriptionFilter, pharmacistID int) ([]entity.Prescription, error)
+	ApprovePrescription(c context.Context, prescriptionID int, pharmacistID int) error
+	RejectPrescription(c context.Context, prescriptionID int, reason string, pharmacistID int) error
+}
+
+type prescriptionUsecaseImpl struct {
+	r   repository.PrescriptionRepo
+	tr  transaction.TransactorRepoImpl
+	phr pharmacistRepo.PharmacistRepo
+	os  orderStatus.OrderStatusUsecase
+}
+
+func NewPrescriptionUsecase(r repository.PrescriptionRepo, tr transaction.TransactorRepoImpl, phr pharmacistRepo.PharmacistRepo, os orderStatus.OrderStatusUsecase) prescriptionUsecaseImpl {
+	return prescriptionUsecaseImpl{
+		r:   r,
+		tr:  tr,
+		phr: phr,
+		os:  os,
+	}
+}
+
+func (u prescriptionUsecaseImpl) UploadPrescription(c context.Context, file entity.File, userID int) (*entity.Prescription, error) {
+	validate := validator.New()
+	err := validate.Struct(file)
+	if err != nil {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrUploadPrescription, apperror.ErrFileEmpty, err)
+	}
+
+	uploadUrl, err := imageuploader.ImageUploadHelper(file.File)
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrUploadPrescription, apperror.ErrUploadImage, err)
+	}
+
+	prescription, err := u.r.AddPrescription(c, entity.Prescription{UserID: userID, Image: uploadUrl})
+	if err != nil {
+		return nil, err
+	}
+	return prescription, nil
+}
+
+func (u prescriptionUsecaseImpl) AttachPrescription(c context.Context, orderDetailID int, prescriptionID int, userID int) error {
+	isExists, err := u.r.IsOrderDetailFromUser(c, orderDetailID, userID)
+	if err != nil {
+		return err
+	}
+	if !isExists {
+		return apperror.NewErrStatusNotFound(appconstant.FieldErrAttachPrescription, apperror.ErrOrderDetailNotExists, apperror.ErrOrderDetailNotExists)
+	}
+
+	isExists, err = u.r.IsPrescriptionExistsByID(c, prescriptionID)
+	if err != nil {
+		return err
+	}
+	if !isExists {
+		return apperror.NewErrStatusNotFound(appconstant.FieldErrAttachPrescription, apperror.ErrPrescriptionNotExists, apperror.ErrPrescriptionNotExists)
+	}
+
+	prescription, err := u.r.GetPrescriptionByID(c, prescriptionID)
+	if err != nil {
+		return err
+	}
+	if prescription.UserID != userID {
+		return apperror.NewErrStatusNotFound(appconstant.FieldErrAttachPrescription, apperror.ErrPrescriptionNotExists, apperror.ErrPrescriptionNotExists)
+	}
+
+	status, err := u.r.GetOrderDetailStatusByID(c, orderDetailID)
+	if err != nil {
+		return err
+	}
+	if status != appconstant.StatusPending {
+		return apperror.NewErrStatusBadRequest(appconstant.FieldErrAttachPrescription, apperror.ErrPrescriptionAttachClosed, apperror.ErrPrescriptionAttachClosed)
+	}
+
+	return u.r.UpdateOrderDetailPrescription(c, orderDetailID, prescriptionID)
+}
+
+func (u prescriptionUsecaseImpl) GetPrescriptions(c context.Context, filter entity.PrescriptionFilter, pharmacistID int) ([]entity.Prescription, error) {
+	pharmacyID, err := u.getPharmacyID(c, pharmacistID, appconstant.FieldErrGetPrescriptions)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.PharmacyID = pharmacyID
+	return u.r.GetPrescriptionsByPharmacyID(c, filter)
+}
+
+func (u prescriptionUsecaseImpl) ApprovePrescription(c context.Context, prescriptionID int, pharmacistID int) error {
+	return u.reviewPrescription(c, prescriptionID, pharmacistID, appconstant.PrescriptionStatusApproved, nil)
+}
+
+func (u prescriptionUsecaseImpl) RejectPrescription(c context.Context, prescriptionID int, reason string, pharmacistID int) error {
+	return u.reviewPrescription(c, prescriptionID, pharmacistID, appconstant.PrescriptionStatusRejected, &reason)
+}
+
+// reviewPrescription records the verdict on the pharmacist's own order details
+// only. A rejection cancels those details that are still awaiting payment.
+func (u prescriptionUsecaseImpl) reviewPrescription(c context.Context, prescriptionID int, pharmacistID int, status string, reason *string) error {
+	pharmacyID, err := u.getPharmacyID(c, pharmacistID, appconstant.FieldErrReviewPrescription)
+	if err != nil {
+		return err
+	}
+
+	err = u.checkReviewable(c, prescriptionID, pharmacyID)
+	if err != nil {
+		return err
+	}
+
+	return u.tr.WithinTransaction(c, func(txCtx context.Context) error {
+		orderDetails, err := u.r.GetOrderDetailsAwaitingReview(txCtx, prescriptionID, pharmacyID)
+		if err != nil {
+			return err
+		}
+		if len(orderDetails) == 0 {
+			return apperror.NewErrStatusBadRequest(appconstant.FieldErrReviewPrescription, apperror.ErrPrescriptionAlreadyReviewed, apperror.ErrPrescriptionAlreadyReviewed)
+		}
+
+		err = u.r.UpdateOrderDetailPrescriptionReview(txCtx, entity.PrescriptionReview{
+			PrescriptionID:  prescriptionID,
+			PharmacyID:      pharmacyID,
+			Status:          status,
+			PharmacistID:    pharmacistID,
+			RejectionReason: reason,
+		})
+		if err != nil || status != appconstant.PrescriptionStatusRejected {
+			return err
+		}
+
+		for _, orderDetail := range orderDetails {
+			if orderDetail.Status != appconstant.StatusPending {
+				continue
+			}
+			err = u.os.Transition(txCtx, orderStatusEntity.Transition{
+				OrderDetailID: orderDetail.ID,
+				To:            appconstant.StatusCancelled,
+				Actor:         appconstant.ActorPharmacist,
+				ActorID:       &pharmacistID,
+				Reason:        reason,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (u prescriptionUsecaseImpl) getPharmacyID(c context.Context, pharmacistID int, field string) (int, error) {
+	isExists, err := u.phr.IsPharmacistExistsByID(c, pharmacistID)
+	if err != nil {
+		return 0, err
+	}
+	if !isExists {
+		return 0, apperror.NewErrStatusNotFound(field, apperror.ErrPharmacistNotExists, apperror.ErrPharmacistNotExists)
+	}
+
+	pharmacyID, err := u.phr.GetPharmacyIDByPharmacistID(c, pharmacistID)
+	if err != nil {
+		return 0, err
+	}
+	if pharmacyID == nil {
+		return 0, apperror.NewErrStatusBadRequest(field, apperror.ErrPharmacistNotHasPharmacy, apperror.ErrPharmacistNotHasPharmacy)
+	}
+	return *pharmacyID, nil
+}
+
+func (u prescriptionUsecaseImpl) checkReviewable(c context.Context, prescriptionID int, pharmacyID int) error {
+	isExists, err := u.r.IsPrescriptionExistsByID(c, prescriptionID)
+	if err != nil {
+		return err
+	}
+	if !isExists {
+		return apperror.NewErrStatusNotFound(appconstant.FieldErrReviewPrescription, apperror.ErrPrescriptionNotExists, apperror.ErrPrescriptionNotExists)
+	}
+
+	isInPharmacy, err := u.r.IsPrescriptionInPharmacy(c, prescriptionID, pharmacyID)
+	if err != nil {
+		return err
+	}
+	if !isInPharmacy {
+		return apperror.NewErrStatusUnauthorized(appconstant.FieldErrReviewPrescription, apperror.ErrPharmacistUnauthorized, apperror.ErrPharmacistUnauthorized)
+	}
+	return nil
+}
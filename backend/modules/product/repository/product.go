@@ -61,9 +61,10 @@ func (r ProductRepoImpl) GetUserProducts(c context.Context, queryParams querypar
 	query += fmt.Sprintf(` GetDistance AS (
 					SELECT p.product_id, pp.id as pharmacy_product_id, p.image, p.product_name, p.manufacture, ph.name as pharmacy_product_name, pp.price as product_price, st_distance(ph.location, $%d::geography) as distance
 					FROM ProductCategory p
-					JOIN pharmacy_products pp ON pp.product_id = p.product_id AND pp.stock > 0 AND pp.deleted_at IS NULL
+					JOIN pharmacy_products pp ON pp.product_id = p.product_id AND pp.deleted_at IS NULL
+					JOIN pharmacy_product_available_stocks pas ON pas.pharmacy_product_id = pp.id AND pas.available_stock > 0
 					JOIN pharmacies ph ON ph.id = pp.pharmacy_id AND pp.is_active = true AND ph.deleted_at IS NULL
-					WHERE ST_DWithin(ph.location, $%d::geography, 25000)
+					WHERE %s
 				), DetermineProductRank AS (
 					SELECT product_id, pharmacy_product_id, image, product_name, manufacture, pharmacy_product_name, product_price, distance, rank() over (order by product_price desc) * 0.7 as price_score, rank() over (order by distance desc) * 0.3 as distance_score
 					FROM GetDistance
@@ -74,7 +75,7 @@ func (r ProductRepoImpl) GetUserProducts(c context.Context, queryParams querypar
 				join pharmacies ph on ph.id = pp.pharmacy_id AND ph.is_active = true AND ph.deleted_at IS NULL
 				join products p on p.id = dpr.product_id AND p.is_active = true AND p.deleted_at IS NULL
 				join partners pt on pt.id = ph.partner_id AND pt.is_active = true AND pt.deleted_at IS NULL
-				where 1=1`, querIndex, querIndex)
+				where 1=1`, querIndex, fmt.Sprintf(appconstant.ServiceAreaCondition, fmt.Sprintf("$%d::geography", querIndex)))
 
 	params = append(params, location)
 	querIndex++
@@ -123,9 +124,10 @@ func (r ProductRepoImpl) GetTotalProduct(c context.Context, queryParams querypar
 	query += fmt.Sprintf(` GetDistance AS (
 					SELECT p.product_id, pp.id as pharmacy_product_id, p.image, p.product_name, p.manufacture, ph.name as pharmacy_product_name, pp.price as product_price, st_distance(ph.location, $%d::geography) as distance
 					FROM ProductCategory p
-					JOIN pharmacy_products pp ON pp.product_id = p.product_id AND pp.stock > 0 AND pp.deleted_at IS NULL
+					JOIN pharmacy_products pp ON pp.product_id = p.product_id AND pp.deleted_at IS NULL
+					JOIN pharmacy_product_available_stocks pas ON pas.pharmacy_product_id = pp.id AND pas.available_stock > 0
 					JOIN pharmacies ph ON ph.id = pp.pharmacy_id AND pp.is_active = true AND ph.deleted_at IS NULL
-					WHERE ST_DWithin(ph.location, $%d::geography, 25000)
+					WHERE %s
 				), DetermineProductRank AS (
 					SELECT product_id, pharmacy_product_id, image, product_name, manufacture, pharmacy_product_name, product_price, distance, rank() over (order by product_price desc) * 0.7 as price_score, rank() over (order by distance desc) * 0.3 as distance_score
 					FROM GetDistance
@@ -136,7 +138,7 @@ func (r ProductRepoImpl) GetTotalProduct(c context.Context, queryParams querypar
 				join pharmacies ph on ph.id = pp.pharmacy_id AND ph.is_active = true AND ph.deleted_at IS NULL
 				join products p on p.id = dpr.product_id AND p.is_active = true AND p.deleted_at IS NULL
 				join partners pt on pt.id = ph.partner_id AND pt.is_active = true AND pt.deleted_at IS NULL
-				where 1=1`, querIndex, querIndex)
+				where 1=1`, querIndex, fmt.Sprintf(appconstant.ServiceAreaCondition, fmt.Sprintf("$%d::geography", querIndex)))
 
 	params = append(params, location)
 	querIndex++
@@ -161,12 +163,13 @@ func (r ProductRepoImpl) GetTotalProductHomePage(c context.Context, queryParams
 	var params []any
 	querIndex := 1
 
-	query := `with GetDistance as (
+	query := fmt.Sprintf(`with GetDistance as (
 					select p.id as product_id, pp.id as pharmacy_product_id, p.image[1] as image, p.name as product_name, p.manufacture as manufacture, ph.name as pharmacy_product_name, pp.price as product_price, st_distance(ph.location, $1::geography) as distance
 					FROM products p
-					JOIN pharmacy_products pp ON pp.product_id = p.id AND pp.stock > 0 AND pp.deleted_at IS NULL
+					JOIN pharmacy_products pp ON pp.product_id = p.id AND pp.deleted_at IS NULL
+					JOIN pharmacy_product_available_stocks pas ON pas.pharmacy_product_id = pp.id AND pas.available_stock > 0
 					JOIN pharmacies ph ON ph.id = pp.pharmacy_id AND pp.is_active = true AND ph.deleted_at IS NULL
-					WHERE ST_DWithin(ph.location, $1::geography, 25000) AND p.deleted_at IS NULL
+					WHERE %s AND p.deleted_at IS NULL
 				), DetermineProductRank as (
 					select product_id, pharmacy_product_id, image, product_name, manufacture, pharmacy_product_name, product_price, distance, rank() over (order by product_price desc) * 0.7 as price_score, rank() over (order by distance desc) * 0.3 as distance_score
 					from GetDistance
@@ -189,7 +192,7 @@ func (r ProductRepoImpl) GetTotalProductHomePage(c context.Context, queryParams
 				join pharmacies ph on ph.id = pp.pharmacy_id AND ph.is_active = true AND ph.deleted_at IS NULL
 				join products p on p.id = ncp.product_id AND p.is_active = true AND p.deleted_at IS NULL
 				join partners pt on pt.id = ph.partner_id AND pt.is_active = true AND pt.deleted_at IS NULL
-				WHERE 1=1`
+				WHERE 1=1`, fmt.Sprintf(appconstant.ServiceAreaCondition, "$1::geography"))
 
 	querIndex++
 
@@ -209,12 +212,13 @@ func (r ProductRepoImpl) GetTotalProductHomePage(c context.Context, queryParams
 func (r ProductRepoImpl) GetUserProductsHomePage(c context.Context, queryParams queryparams.QueryParams, location string) ([]entity.ProductDetail, error) {
 	products := []entity.ProductDetail{}
 
-	query := `with GetDistance as (
+	query := fmt.Sprintf(`with GetDistance as (
 					select p.id as product_id, pp.id as pharmacy_product_id, p.image[1] as image, p.name as product_name, p.manufacture as manufacture, ph.name as pharmacy_product_name, pp.price as product_price, st_distance(ph.location, $1::geography) as distance
 					FROM products p
-					JOIN pharmacy_products pp ON pp.product_id = p.id AND pp.stock > 0 AND pp.deleted_at IS NULL
+					JOIN pharmacy_products pp ON pp.product_id = p.id AND pp.deleted_at IS NULL
+					JOIN pharmacy_product_available_stocks pas ON pas.pharmacy_product_id = pp.id AND pas.available_stock > 0
 					JOIN pharmacies ph ON ph.id = pp.pharmacy_id AND pp.is_active = true AND ph.deleted_at IS NULL
-					WHERE ST_DWithin(ph.location, $1::geography, 25000) AND p.deleted_at IS NULL  
+					WHERE %s AND p.deleted_at IS NULL
 				), DetermineProductRank as (
 					select product_id, pharmacy_product_id, image, product_name, manufacture, pharmacy_product_name, product_price, distance, rank() over (order by product_price desc) * 0.7 as price_score, rank() over (order by distance desc) * 0.3 as distance_score
 					from GetDistance
@@ -237,7 +241,7 @@ func (r ProductRepoImpl) GetUserProductsHomePage(c context.Context, queryParams
 				join pharmacies ph on ph.id = pp.pharmacy_id AND ph.is_active = true AND pp.deleted_at IS NULL
 				join products p on p.id = ncp.product_id AND p.is_active = true AND pp.deleted_at IS NULL
 				join partners pt on pt.id = ph.partner_id AND pt.is_active = true AND pt.deleted_at IS NULL
-				WHERE 1=1`
+				WHERE 1=1`, fmt.Sprintf(appconstant.ServiceAreaCondition, "$1::geography"))
 
 	var params []any
 	querIndex := 1
@@ -331,8 +335,9 @@ func (r ProductRepoImpl) GetProductCategories(c context.Context, productID int)
 }
 
 func (r ProductRepoImpl) GetProductDetail(c context.Context, pharcistsProductID int) (*entity.ProductDetail, error) {
-	query := `select p.id, pp.id, p.name, p.image[1], p.generic_name, p.manufacture, p.description, p.unit_in_pack, ph.name, ph.address, pp.stock, pp.price 
+	query := `select p.id, pp.id, p.name, p.image[1], p.generic_name, p.manufacture, p.description, p.unit_in_pack, ph.name, ph.address, pas.available_stock, pp.price 
 				from pharmacy_products pp 
+				join pharmacy_product_available_stocks pas on pas.pharmacy_product_id = pp.id
 				join products p on p.id = pp.product_id and p.deleted_at is null
 				join pharmacies ph on ph.id = pp.pharmacy_id and ph.deleted_at is null
 				where pp.id = $1 and p.deleted_at is null`
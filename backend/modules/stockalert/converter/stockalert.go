@@ -0,0 +1,54 @@
+package converter
+
+import (
+	"montelukast/modules/stockalert/dto"
+	"montelukast/modules/stockalert/entity"
+)
+
+type ThresholdConverter struct{}
+
+func (c ThresholdConverter) ToEntity(thresholdReq dto.ThresholdRequest, pharmacyProductID int, pharmacistID int) entity.ThresholdRequest {
+	return entity.ThresholdRequest{
+		PharmacyProductID: pharmacyProductID,
+		PharmacistID:      pharmacistID,
+		Threshold:         *thresholdReq.Threshold,
+	}
+}
+
+type SuggestionFilterConverter struct{}
+
+func (c SuggestionFilterConverter) ToEntity(filterReq dto.SuggestionFilterRequest) entity.SuggestionFilter {
+	return entity.SuggestionFilter{
+		Days:         filterReq.Days,
+		CoverageDays: filterReq.CoverageDays,
+	}
+}
+
+type LowStockProductConverter struct{}
+
+func (c LowStockProductConverter) ToDto(product entity.LowStockProduct) dto.LowStockProductResponse {
+	return dto.LowStockProductResponse{
+		PharmacyProductID: product.PharmacyProductID,
+		ProductID:         product.ProductID,
+		Name:              product.Name,
+		Stock:             product.Stock,
+		AvailableStock:    product.AvailableStock,
+		Threshold:         product.Threshold,
+	}
+}
+
+type ReorderSuggestionConverter struct{}
+
+func (c ReorderSuggestionConverter) ToDto(suggestion entity.ReorderSuggestion) dto.ReorderSuggestionResponse {
+	return dto.ReorderSuggestionResponse{
+		PharmacyProductID: suggestion.PharmacyProductID,
+		ProductID:         suggestion.ProductID,
+		Name:              suggestion.Name,
+		AvailableStock:    suggestion.AvailableStock,
+		Threshold:         suggestion.Threshold,
+		SoldQuantity:      suggestion.SoldQuantity,
+		DailyVelocity:     suggestion.DailyVelocity,
+		DaysOfStock:       suggestion.DaysOfStock,
+		SuggestedQuantity: suggestion.SuggestedQuantity,
+	}
+}
@@ -0,0 +1,31 @@
+package dto
+
+type ThresholdRequest struct {
+	Threshold *int `json:"low_stock_threshold" binding:"required,gte=0"`
+}
+
+type SuggestionFilterRequest struct {
+	Days         int `form:"days" binding:"omitempty,gte=1,lte=365"`
+	CoverageDays int `form:"coverage_days" binding:"omitempty,gte=1,lte=180"`
+}
+
+type LowStockProductResponse struct {
+	PharmacyProductID int    `json:"pharmacy_product_id"`
+	ProductID         int    `json:"product_id"`
+	Name              string `json:"name"`
+	Stock             int    `json:"stock"`
+	AvailableStock    int    `json:"available_stock"`
+	Threshold         int    `json:"low_stock_threshold"`
+}
+
+type ReorderSuggestionResponse struct {
+	PharmacyProductID int      `json:"pharmacy_product_id"`
+	ProductID         int      `json:"product_id"`
+	Name              string   `json:"name"`
+	AvailableStock    int      `json:"available_stock"`
+	Threshold         int      `json:"low_stock_threshold"`
+	SoldQuantity      int      `json:"sold_quantity"`
+	DailyVelocity     float64  `json:"daily_velocity"`
+	DaysOfStock       *float64 `json:"days_of_stock"`
+	SuggestedQuantity int      `json:"suggested_quantity"`
+}
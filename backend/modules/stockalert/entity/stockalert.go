@@ -0,0 +1,44 @@
+package entity
+
+type ThresholdRequest struct {
+	PharmacyProductID int
+	PharmacistID      int
+	Threshold         int
+}
+
+type LowStockProduct struct {
+	PharmacyProductID int
+	ProductID         int
+	Name              string
+	Stock             int
+	AvailableStock    int
+	Threshold         int
+}
+
+type LowStockAlert struct {
+	PharmacyID   int
+	PharmacyName string
+	Products     []LowStockProduct
+}
+
+type SuggestionFilter struct {
+	PharmacyID   int
+	Days         int
+	CoverageDays int
+}
+
+type ProductSales struct {
+	PharmacyProductID int
+	ProductID         int
+	Name              string
+	AvailableStock    int
+	Threshold         int
+	SoldQuantity      int
+}
+
+type ReorderSuggestion struct {
+	ProductSales
+	DailyVelocity     float64
+	DaysOfStock       *float64
+	SuggestedQuantity int
+}
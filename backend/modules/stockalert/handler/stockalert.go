@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"montelukast/modules/stockalert/converter"
+	"montelukast/modules/stockalert/dto"
+	"montelukast/modules/stockalert/usecase"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"montelukast/pkg/wrapper"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StockAlertHandler struct {
+	u usecase.StockAlertUsecase
+}
+
+func NewStockAlertHandler(u usecase.StockAlertUsecase) StockAlertHandler {
+	return StockAlertHandler{
+		u: u,
+	}
+}
+
+func (h StockAlertHandler) UpdateLowStockThresholdHandler(c *gin.Context) {
+	pharmacistID, err := getUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	pharmacyProductID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrUpdateLowStockThreshold, apperror.ErrConvertVariableType, err))
+		return
+	}
+
+	err = apperror.JsonValidator(c)
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrUpdateLowStockThreshold, apperror.ErrInvalidJSON, err))
+		return
+	}
+
+	thresholdReq := dto.ThresholdRequest{}
+	err = c.ShouldBindJSON(&thresholdReq)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = h.u.UpdateLowStockThreshold(c, converter.ThresholdConverter{}.ToEntity(thresholdReq, pharmacyProductID, pharmacistID))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(nil, "update low stock threshold success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h StockAlertHandler) GetLowStockProductsHandler(c *gin.Context) {
+	pharmacistID, err := getUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	products, err := h.u.GetLowStockProducts(c, pharmacistID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	productsRes := []dto.LowStockProductResponse{}
+	for _, product := range products {
+		productsRes = append(productsRes, converter.LowStockProductConverter{}.ToDto(product))
+	}
+	response := wrapper.ResponseData(productsRes, "get low stock products success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h StockAlertHandler) GetReorderSuggestionsHandler(c *gin.Context) {
+	pharmacistID, err := getUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	filterReq := dto.SuggestionFilterRequest{}
+	err = c.ShouldBindQuery(&filterReq)
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrGetReorderSuggestions, apperror.ErrInvalidQuery, err))
+		return
+	}
+
+	suggestions, err := h.u.GetReorderSuggestions(c, pharmacistID, converter.SuggestionFilterConverter{}.ToEntity(filterReq))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	suggestionsRes := []dto.ReorderSuggestionResponse{}
+	for _, suggestion := range suggestions {
+		suggestionsRes = append(suggestionsRes, converter.ReorderSuggestionConverter{}.ToDto(suggestion))
+	}
+	response := wrapper.ResponseData(suggestionsRes, "get reorder suggestions success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func getUserID(c *gin.Context) (int, error) {
+	rawUserID, isExists := c.Get("user_id")
+	if !isExists {
+		return 0, apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, apperror.ErrTokenInvalid)
+	}
+	userID, err := strconv.Atoi(rawUserID.(string))
+	if err != nil {
+		return 0, apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, err)
+	}
+	return userID, nil
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"montelukast/modules/stockalert/entity"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type StockAlertRepo interface {
+	UpdateLowStockThreshold(c context.Context, pharmacyProductID int, pharmacyID int, threshold int) (bool, error)
+	GetLowStockProducts(c context.Context, pharmacyID int) ([]entity.LowStockProduct, error)
+	GetLowStockAlerts(c context.Context) ([]entity.LowStockAlert, error)
+	GetPharmacistEmails(c context.Context, pharmacyID int) ([]string, error)
+	GetProductSales(c context.Context, filter entity.SuggestionFilter) ([]entity.ProductSales, error)
+	SetLowStockAlertSchedule(c context.Context, runDate string, ttl time.Duration) (bool, error)
+	DeleteLowStockAlertSchedule(c context.Context, runDate string) error
+}
+
+type stockAlertRepoImpl struct {
+	db  *sql.DB
+	rdb *redis.Client
+}
+
+func NewStockAlertRepo(dbConn *sql.DB, rdbConn *redis.Client) stockAlertRepoImpl {
+	return stockAlertRepoImpl{
+		db:  dbConn,
+		rdb: rdbConn,
+	}
+}
+
+func (r stockAlertRepoImpl) UpdateLowStockThreshold(c context.Context, pharmacyProductID int, pharmacyID int, threshold int) (bool, error) {
+	query := `UPDATE pharmacy_products
+				SET low_stock_threshold = $3, updated_at = NOW()
+				WHERE id = $1 AND pharmacy_id = $2 AND deleted_at IS NULL`
+
+	res, err := r.db.ExecContext(c, query, pharmacyProductID, pharmacyID, threshold)
+	if err != nil {
+		return false, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return affected > 0, nil
+}
+
+const lowStockQuery = `SELECT ph.id, ph.name, pp.id, p.id, p.name, pp.stock, pas.available_stock, pp.low_stock_threshold
+				FROM pharmacy_products pp
+				JOIN pharmacies ph ON ph.id = pp.pharmacy_id
+				JOIN products p ON p.id = pp.product_id
+				JOIN pharmacy_product_available_stocks pas ON pas.pharmacy_product_id = pp.id
+				WHERE pp.is_active = true AND pp.deleted_at IS NULL AND p.deleted_at IS NULL AND ph.deleted_at IS NULL
+				AND pas.available_stock <= pp.low_stock_threshold`
+
+func (r stockAlertRepoImpl) queryLowStock(c context.Context, query string, args ...any) ([]entity.LowStockAlert, error) {
+	alerts := []entity.LowStockAlert{}
+
+	rows, err := r.db.QueryContext(c, query, args...)
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	indexes := map[int]int{}
+	for rows.Next() {
+		var pharmacyID int
+		var pharmacyName string
+		var product entity.LowStockProduct
+		err := rows.Scan(&pharmacyID, &pharmacyName, &product.PharmacyProductID, &product.ProductID, &product.Name,
+			&product.Stock, &product.AvailableStock, &product.Threshold)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
+		i, ok := indexes[pharmacyID]
+		if !ok {
+			i = len(alerts)
+			indexes[pharmacyID] = i
+			alerts = append(alerts, entity.LowStockAlert{PharmacyID: pharmacyID, PharmacyName: pharmacyName})
+		}
+		alerts[i].Products = append(alerts[i].Products, product)
+	}
+	return alerts, nil
+}
+
+func (r stockAlertRepoImpl) GetLowStockProducts(c context.Context, pharmacyID int) ([]entity.LowStockProduct, error) {
+	alerts, err := r.queryLowStock(c, lowStockQuery+` AND pp.pharmacy_id = $1
+				ORDER BY pas.available_stock, p.name`, pharmacyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(alerts) == 0 {
+		return []entity.LowStockProduct{}, nil
+	}
+	return alerts[0].Products, nil
+}
+
+func (r stockAlertRepoImpl) GetLowStockAlerts(c context.Context) ([]entity.LowStockAlert, error) {
+	return r.queryLowStock(c, lowStockQuery+` AND ph.is_active = true
+				ORDER BY ph.id, pas.available_stock, p.name`)
+}
+
+func (r stockAlertRepoImpl) GetPharmacistEmails(c context.Context, pharmacyID int) ([]string, error) {
+	emails := []string{}
+
+	query := `SELECT u.email
+				FROM pharmacist_details pd
+				JOIN users u ON u.id = pd.pharmacist_id
+				WHERE pd.pharmacy_id = $1 AND pd.deleted_at IS NULL AND u.deleted_at IS NULL`
+
+	rows, err := r.db.QueryContext(c, query, pharmacyID)
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var email string
+		err := rows.Scan(&email)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
+		emails = append(emails, email)
+	}
+	return emails, nil
+}
+
+// GetProductSales sums what each active product of the pharmacy sold in the
+// last filter.Days days. Cancelled orders never left the shelf, so they are
+// left out of the count.
+func (r stockAlertRepoImpl) GetProductSales(c context.Context, filter entity.SuggestionFilter) ([]entity.ProductSales, error) {
+	sales := []entity.ProductSales{}
+
+	query := `SELECT pp.id, p.id, p.name, pas.available_stock, pp.low_stock_threshold, COALESCE(SUM(sold.quantity), 0)
+				FROM pharmacy_products pp
+				JOIN products p ON p.id = pp.product_id
+				JOIN pharmacy_product_available_stocks pas ON pas.pharmacy_product_id = pp.id
+				LEFT JOIN (
+					SELECT opd.pharmacy_product_id, opd.quantity
+					FROM order_product_details opd
+					JOIN order_details od ON od.id = opd.order_detail_id
+					WHERE od.pharmacy_id = $1 AND od.status <> $2 AND od.deleted_at IS NULL AND opd.deleted_at IS NULL
+					AND opd.created_at >= NOW() - make_interval(days => $3)
+				) sold ON sold.pharmacy_product_id = pp.id
+				WHERE pp.pharmacy_id = $1 AND pp.is_active = true AND pp.deleted_at IS NULL AND p.deleted_at IS NULL
+				GROUP BY pp.id, p.id, p.name, pas.available_stock, pp.low_stock_threshold`
+
+	rows, err := r.db.QueryContext(c, query, filter.PharmacyID, appconstant.StatusCancelled, filter.Days)
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productSales entity.ProductSales
+		err := rows.Scan(&productSales.PharmacyProductID, &productSales.ProductID, &productSales.Name,
+			&productSales.AvailableStock, &productSales.Threshold, &productSales.SoldQuantity)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
+		sales = append(sales, productSales)
+	}
+	return sales, nil
+}
+
+// SetLowStockAlertSchedule claims the run of the given date, so restarts and
+// extra instances do not queue the same daily alert twice.
+func (r stockAlertRepoImpl) SetLowStockAlertSchedule(c context.Context, runDate string, ttl time.Duration) (bool, error) {
+	isSet, err := r.rdb.SetNX(c, fmt.Sprintf(appconstant.LowStockAlertRedisKey, runDate), true, ttl).Result()
+	if err != nil {
+		return false, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return isSet, nil
+}
+
+// DeleteLowStockAlertSchedule releases the claim of a run that could not be
+// queued, so the next start-up can queue it again.
+func (r stockAlertRepoImpl) DeleteLowStockAlertSchedule(c context.Context, runDate string) error {
+	err := r.rdb.Del(c, fmt.Sprintf(appconstant.LowStockAlertRedisKey, runDate)).Err()
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"montelukast/pkg/logger"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+type RabbitMQConsumerLowStock struct {
+	usecase  StockAlertUsecase
+	rabbitMQ *amqp.Channel
+}
+
+func NewRabbitMQConsumerLowStock(rabbitMQ *amqp.Channel, u StockAlertUsecase) *RabbitMQConsumerLowStock {
+	return &RabbitMQConsumerLowStock{usecase: u, rabbitMQ: rabbitMQ}
+}
+
+func (r *RabbitMQConsumerLowStock) ConsumeDelayedMessage() {
+	err := r.rabbitMQ.ExchangeDeclare(
+		"low-stock-alert-exchange", //name
+		"x-delayed-message",        //type
+		true,                       // durable
+		false,                      // auto-deleted
+		false,                      // internal
+		false,                      // no-wait
+		amqp.Table{
+			"x-delayed-type": "fanout",
+		},
+	)
+	if err != nil {
+		logger.Log.Error(err)
+	}
+	q, err := r.rabbitMQ.QueueDeclare(
+		"low-stock-alert-queue",
+		true,
+		false,
+		false,
+		false,
+		nil,
+	)
+	if err != nil {
+		logger.Log.Error(err)
+	}
+	err = r.rabbitMQ.QueueBind(
+		q.Name,                     // queue name
+		"",                         // routing key
+		"low-stock-alert-exchange", // exchange
+		false,
+		nil,
+	)
+	if err != nil {
+		logger.Log.Error(err)
+	}
+	msgs, err := r.rabbitMQ.Consume(
+		q.Name,
+		"low_stock_alert",
+		false, //auto ack
+		false, //exclusive
+		false, //no-local
+		false, //no-wait
+		nil,
+	)
+	if err != nil {
+		logger.Log.Error(err)
+	}
+
+	err = r.usecase.ScheduleLowStockAlert(context.Background(), nil)
+	if err != nil {
+		logger.Log.Error(err)
+	}
+	for d := range msgs {
+		var data struct {
+			ScheduledAt *time.Time `json:"scheduled_at"`
+		}
+		err := json.Unmarshal(d.Body, &data)
+		if err != nil {
+			logger.Log.Error(err)
+		}
+		err = r.usecase.SendLowStockAlerts(context.Background())
+		if err != nil {
+			logger.Log.Error(err)
+		}
+		err = r.usecase.ScheduleLowStockAlert(context.Background(), data.ScheduledAt)
+		if err != nil {
+			logger.Log.Error(err)
+		}
+		err = d.Ack(false)
+		if err != nil {
+			logger.Log.Error(err)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"math"
+	pharmacistRepo "montelukast/modules/pharmacist/repository"
+	"montelukast/modules/stockalert/entity"
+	"montelukast/modules/stockalert/repository"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"montelukast/pkg/logger"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/resendlabs/resend-go"
+	"github.com/streadway/amqp"
+)
+
+type StockAlertUsecase interface {
+	UpdateLowStockThreshold(c context.Context, request entity.ThresholdRequest) error
+	GetLowStockProducts(c context.Context, pharmacistID int) ([]entity.LowStockProduct, error)
+	GetReorderSuggestions(c context.Context, pharmacistID int, filter entity.SuggestionFilter) ([]entity.ReorderSuggestion, error)
+	SendLowStockAlerts(c context.Context) error
+	ScheduleLowStockAlert(c context.Context, lastRun *time.Time) error
+}
+
+type stockAlertUsecaseImpl struct {
+	r        repository.StockAlertRepo
+	phr      pharmacistRepo.PharmacistRepo
+	rc       *resend.Client
+	rabbitMQ *amqp.Channel
+}
+
+func NewStockAlertUsecase(rabbitMQ *amqp.Channel, r repository.StockAlertRepo, phr pharmacistRepo.PharmacistRepo, rc *resend.Client) stockAlertUsecaseImpl {
+	return stockAlertUsecaseImpl{
+		r:        r,
+		phr:      phr,
+		rc:       rc,
+		rabbitMQ: rabbitMQ,
+	}
+}
+
+func (u stockAlertUsecaseImpl) UpdateLowStockThreshold(c context.Context, request entity.ThresholdRequest) error {
+	pharmacyID, err := u.getPharmacyID(c, request.PharmacistID, appconstant.FieldErrUpdateLowStockThreshold)
+	if err != nil {
+		return err
+	}
+
+	isUpdated, err := u.r.UpdateLowStockThreshold(c, request.PharmacyProductID, pharmacyID, request.Threshold)
+	if err != nil {
+		return err
+	}
+	if !isUpdated {
+		return apperror.NewErrStatusNotFound(appconstant.FieldErrUpdateLowStockThreshold, apperror.ErrPharmacyProductNotExists, apperror.ErrPharmacyProductNotExists)
+	}
+	return nil
+}
+
+func (u stockAlertUsecaseImpl) GetLowStockProducts(c context.Context, pharmacistID int) ([]entity.LowStockProduct, error) {
+	pharmacyID, err := u.getPharmacyID(c, pharmacistID, appconstant.FieldErrGetLowStockProducts)
+	if err != nil {
+		return nil, err
+	}
+	return u.r.GetLowStockProducts(c, pharmacyID)
+}
+
+// GetReorderSuggestions proposes enough stock to cover the coverage window at
+// the recent sales pace while staying above the low-stock threshold. Products
+// running out soonest come first; products that did not sell come last.
+func (u stockAlertUsecaseImpl) GetReorderSuggestions(c context.Context, pharmacistID int, filter entity.SuggestionFilter) ([]entity.ReorderSuggestion, error) {
+	pharmacyID, err := u.getPharmacyID(c, pharmacistID, appconstant.FieldErrGetReorderSuggestions)
+	if err != nil {
+		return nil, err
+	}
+	filter.PharmacyID = pharmacyID
+	if filter.Days <= 0 {
+		filter.Days = appconstant.DefaultSalesVelocityDays
+	}
+	if filter.CoverageDays <= 0 {
+		filter.CoverageDays = appconstant.DefaultReorderCoverageDays
+	}
+
+	sales, err := u.r.GetProductSales(c, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := []entity.ReorderSuggestion{}
+	for _, productSales := range sales {
+		velocity := float64(productSales.SoldQuantity) / float64(filter.Days)
+		target := int(math.Ceil(velocity*float64(filter.CoverageDays))) + productSales.Threshold
+		if target <= productSales.AvailableStock {
+			continue
+		}
+
+		suggestion := entity.ReorderSuggestion{
+			ProductSales:      productSales,
+			DailyVelocity:     math.Round(velocity*100) / 100,
+			SuggestedQuantity: target - productSales.AvailableStock,
+		}
+		if velocity > 0 {
+			daysOfStock := math.Round(float64(productSales.AvailableStock)/velocity*10) / 10
+			suggestion.DaysOfStock = &daysOfStock
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		a, b := suggestions[i].DaysOfStock, suggestions[j].DaysOfStock
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return *a < *b
+	})
+	return suggestions, nil
+}
+
+func (u stockAlertUsecaseImpl) SendLowStockAlerts(c context.Context) error {
+	alerts, err := u.r.GetLowStockAlerts(c)
+	if err != nil {
+		return err
+	}
+
+	for _, alert := range alerts {
+		emails, err := u.r.GetPharmacistEmails(c, alert.PharmacyID)
+		if err != nil {
+			return err
+		}
+		if len(emails) == 0 {
+			continue
+		}
+
+		err = u.sendLowStockEmail(emails, alert)
+		if err != nil {
+			logger.Log.Error(err)
+		}
+	}
+	return nil
+}
+
+func (u stockAlertUsecaseImpl) sendLowStockEmail(emails []string, alert entity.LowStockAlert) error {
+	var rows strings.Builder
+	for _, product := range alert.Products {
+		fmt.Fprintf(&rows, `<tr><td>%s</td><td>%d</td><td>%d</td></tr>`, html.EscapeString(product.Name), product.AvailableStock, product.Threshold)
+	}
+
+	params := &resend.SendEmailRequest{
+		From:    "mediSEAne <no-reply@mediseane.store>",
+		To:      emails,
+		Subject: "[mediSEAne] Low Stock Alert",
+		Html: fmt.Sprintf(
+			`<p style="font-size:3rem;font-weigth:bold;margin:0px">
+				medi<span style="color:#008081">SEA</span>ne
+			</p>
+			<p style="font-weight:bold">
+				All Your <span style="color:#008081">Healthcare</span> Needs at Your Fingertips
+			</p>
+			<hr>
+			<p style="font-weight:bold">Hello, %d products at %s are running low.</p>
+			<table>
+				<tr><th align="left">Product</th><th>Available</th><th>Threshold</th></tr>
+				%s
+			</table>
+			<p>Check the reorder suggestions in your dashboard to restock them.<p>`,
+			len(alert.Products),
+			html.EscapeString(alert.PharmacyName),
+			rows.String(),
+		),
+	}
+
+	_, err := u.rc.Emails.Send(params)
+	if err != nil {
+		return apperror.NewErrStatusBadRequest(appconstant.FieldErrLowStockAlert, apperror.ErrSendEmail, err)
+	}
+	return nil
+}
+
+// ScheduleLowStockAlert queues the next daily alert run. Each run date can
+// only be claimed once, so calling it on every start-up and after every run
+// keeps exactly one run in flight. After a run the next one is the day after
+// the run was scheduled for, as the message may arrive a little early.
+func (u stockAlertUsecaseImpl) ScheduleLowStockAlert(c context.Context, lastRun *time.Time) error {
+	now := time.Now()
+	nextRun := time.Date(now.Year(), now.Month(), now.Day(), appconstant.LowStockAlertHour, 0, 0, 0, now.Location())
+	if !nextRun.After(now) {
+		nextRun = nextRun.AddDate(0, 0, 1)
+	}
+	if lastRun != nil {
+		last := lastRun.In(now.Location())
+		afterLastRun := time.Date(last.Year(), last.Month(), last.Day()+1, appconstant.LowStockAlertHour, 0, 0, 0, now.Location())
+		if afterLastRun.After(nextRun) {
+			nextRun = afterLastRun
+		}
+	}
+
+	runDate := nextRun.Format("2006-01-02")
+	isClaimed, err := u.r.SetLowStockAlertSchedule(c, runDate, 2*24*time.Hour)
+	if err != nil || !isClaimed {
+		return err
+	}
+	err = u.PublishDelayedMessage(c, int(nextRun.Sub(now).Milliseconds()))
+	if err != nil {
+		errRelease := u.r.DeleteLowStockAlertSchedule(c, runDate)
+		if errRelease != nil {
+			logger.Log.Error(errRelease)
+		}
+		return err
+	}
+	return nil
+}
+
+func (u stockAlertUsecaseImpl) PublishDelayedMessage(c context.Context, delay int) error {
+	err := u.rabbitMQ.ExchangeDeclare(
+		"low-stock-alert-exchange", //name
+		"x-delayed-message",        //type
+		true,                       // durable
+		false,                      // auto-deleted
+		false,                      // internal
+		false,                      // no-wait
+		amqp.Table{
+			"x-delayed-type": "fanout",
+		},
+	)
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrLowStockAlert, apperror.ErrInternalServer, err)
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"scheduled_at": time.Now().Add(time.Duration(delay) * time.Millisecond),
+	})
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrLowStockAlert, apperror.ErrInternalServer, err)
+	}
+	err = u.rabbitMQ.Publish(
+		"low-stock-alert-exchange",
+		"x-delayed-message",
+		false,
+		false,
+		amqp.Publishing{
+			DeliveryMode: amqp.Persistent,
+			ContentType:  "application/json",
+			Body:         body,
+			Headers: amqp.Table{
+				"x-delay": delay,
+			},
+		},
+	)
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrLowStockAlert, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+func (u stockAlertUsecaseImpl) getPharmacyID(c context.Context, pharmacistID int, field string) (int, error) {
+	isExists, err := u.phr.IsPharmacistExistsByID(c, pharmacistID)
+	if err != nil {
+		return 0, err
+	}
+	if !isExists {
+		return 0, apperror.NewErrStatusNotFound(field, apperror.ErrPharmacistNotExists, apperror.ErrPharmacistNotExists)
+	}
+
+	pharmacyID, err := u.phr.GetPharmacyIDByPharmacistID(c, pharmacistID)
+	if err != nil {
+		return 0, err
+	}
+	if pharmacyID == nil {
+		return 0, apperror.NewErrStatusBadRequest(field, apperror.ErrPharmacistNotHasPharmacy, apperror.ErrPharmacistNotHasPharmacy)
+	}
+	return *pharmacyID, nil
+}
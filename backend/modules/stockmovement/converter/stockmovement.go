@@ -0,0 +1,61 @@
+package converter
+
+import (
+	"montelukast/modules/stockmovement/dto"
+	"montelukast/modules/stockmovement/entity"
+	"montelukast/pkg/pagination"
+)
+
+type MovementFilterConverter struct{}
+
+func (c MovementFilterConverter) ToEntity(filterReq dto.MovementFilterRequest, pharmacyProductID int) entity.MovementFilter {
+	return entity.MovementFilter{
+		PharmacyProductID: pharmacyProductID,
+		Reason:            filterReq.Reason,
+		Limit:             filterReq.Limit,
+		Page:              filterReq.Page,
+	}
+}
+
+type PaginatedStockMovementsConverter struct{}
+
+func (c PaginatedStockMovementsConverter) ToDto(paginatedMovements entity.PaginatedStockMovements) dto.PaginatedStockMovementsResponse {
+	movements := []dto.StockMovementResponse{}
+	for _, movement := range paginatedMovements.Movements {
+		movements = append(movements, dto.StockMovementResponse{
+			ID:                movement.ID,
+			PharmacyProductID: movement.PharmacyProductID,
+			Delta:             movement.Delta,
+			Balance:           movement.Balance,
+			Reason:            movement.Reason,
+			ReferenceID:       movement.ReferenceID,
+			CreatedAt:         movement.CreatedAt,
+		})
+	}
+	return dto.PaginatedStockMovementsResponse{
+		Pagination: pagination.PaginationConverter{}.ToDto(paginatedMovements.Pagination),
+		Movements:  movements,
+	}
+}
+
+type ConsistencyReportConverter struct{}
+
+func (c ConsistencyReportConverter) ToDto(report entity.ConsistencyReport) dto.ConsistencyReportResponse {
+	discrepancies := []dto.StockDiscrepancyResponse{}
+	for _, discrepancy := range report.Discrepancies {
+		discrepancies = append(discrepancies, dto.StockDiscrepancyResponse{
+			PharmacyProductID: discrepancy.PharmacyProductID,
+			PharmacyID:        discrepancy.PharmacyID,
+			PharmacyName:      discrepancy.PharmacyName,
+			ProductName:       discrepancy.ProductName,
+			Stock:             discrepancy.Stock,
+			LedgerStock:       discrepancy.LedgerStock,
+			Difference:        discrepancy.Stock - discrepancy.LedgerStock,
+		})
+	}
+	return dto.ConsistencyReportResponse{
+		IsConsistent:    len(discrepancies) == 0,
+		CheckedProducts: report.CheckedProducts,
+		Discrepancies:   discrepancies,
+	}
+}
@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"montelukast/pkg/pagination"
+	"time"
+)
+
+type MovementFilterRequest struct {
+	Reason string `form:"reason"`
+	Limit  int    `form:"limit"`
+	Page   int    `form:"page"`
+}
+
+type ConsistencyFilterRequest struct {
+	PharmacyID *int `form:"pharmacy_id"`
+}
+
+type StockMovementResponse struct {
+	ID                int       `json:"id"`
+	PharmacyProductID int       `json:"pharmacy_product_id"`
+	Delta             int       `json:"delta"`
+	Balance           int       `json:"balance"`
+	Reason            string    `json:"reason"`
+	ReferenceID       *int      `json:"reference_id"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+type PaginatedStockMovementsResponse struct {
+	Pagination pagination.PaginationResponse `json:"pagination"`
+	Movements  []StockMovementResponse       `json:"movements"`
+}
+
+type StockDiscrepancyResponse struct {
+	PharmacyProductID int    `json:"pharmacy_product_id"`
+	PharmacyID        int    `json:"pharmacy_id"`
+	PharmacyName      string `json:"pharmacy_name"`
+	ProductName       string `json:"product_name"`
+	Stock             int    `json:"stock"`
+	LedgerStock       int    `json:"ledger_stock"`
+	Difference        int    `json:"difference"`
+}
+
+type ConsistencyReportResponse struct {
+	IsConsistent    bool                       `json:"is_consistent"`
+	CheckedProducts int                        `json:"checked_products"`
+	Discrepancies   []StockDiscrepancyResponse `json:"discrepancies"`
+}
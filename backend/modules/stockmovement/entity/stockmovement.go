@@ -0,0 +1,61 @@
+package entity
+
+import (
+	"montelukast/pkg/pagination"
+	"time"
+)
+
+type StockMovement struct {
+	ID                int
+	PharmacyProductID int
+	Delta             int
+	Balance           int
+	Reason            string
+	ReferenceID       *int
+	CreatedAt         time.Time
+}
+
+type PaginatedStockMovements struct {
+	Pagination pagination.Pagination
+	Movements  []StockMovement
+}
+
+type MovementFilter struct {
+	PharmacyProductID int
+	Reason            string
+	Limit             int
+	Page              int
+}
+
+func (f *MovementFilter) GetLimit() int {
+	if f.Limit < 1 {
+		return 10
+	}
+	return f.Limit
+}
+
+func (f *MovementFilter) GetOffset() int {
+	if f.Page < 1 {
+		return 0
+	}
+	return (f.Page - 1) * f.GetLimit()
+}
+
+type StockDiscrepancy struct {
+	PharmacyProductID int
+	PharmacyID        int
+	PharmacyName      string
+	ProductName       string
+	Stock             int
+	LedgerStock       int
+}
+
+type ConsistencyReport struct {
+	CheckedProducts int
+	Discrepancies   []StockDiscrepancy
+}
+
+type Viewer struct {
+	ID   int
+	Role string
+}
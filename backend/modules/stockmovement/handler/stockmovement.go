@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"montelukast/modules/stockmovement/converter"
+	"montelukast/modules/stockmovement/dto"
+	"montelukast/modules/stockmovement/entity"
+	"montelukast/modules/stockmovement/usecase"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"montelukast/pkg/wrapper"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StockMovementHandler struct {
+	u usecase.StockMovementUsecase
+}
+
+func NewStockMovementHandler(u usecase.StockMovementUsecase) StockMovementHandler {
+	return StockMovementHandler{
+		u: u,
+	}
+}
+
+func (h StockMovementHandler) GetMovementsHandler(c *gin.Context) {
+	viewer, err := getViewer(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	pharmacyProductID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrGetStockMovements, apperror.ErrConvertVariableType, err))
+		return
+	}
+
+	filterReq := dto.MovementFilterRequest{}
+	err = c.ShouldBindQuery(&filterReq)
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrGetStockMovements, apperror.ErrConvertVariableType, err))
+		return
+	}
+
+	paginatedMovements, err := h.u.GetMovements(c, converter.MovementFilterConverter{}.ToEntity(filterReq, pharmacyProductID), *viewer)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(converter.PaginatedStockMovementsConverter{}.ToDto(*paginatedMovements), "get stock movements success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h StockMovementHandler) CheckConsistencyHandler(c *gin.Context) {
+	viewer, err := getViewer(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	filterReq := dto.ConsistencyFilterRequest{}
+	err = c.ShouldBindQuery(&filterReq)
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrGetStockMovements, apperror.ErrConvertVariableType, err))
+		return
+	}
+
+	report, err := h.u.CheckConsistency(c, filterReq.PharmacyID, *viewer)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(converter.ConsistencyReportConverter{}.ToDto(*report), "check stock consistency success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func getViewer(c *gin.Context) (*entity.Viewer, error) {
+	rawUserID, isExists := c.Get("user_id")
+	if !isExists {
+		return nil, apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, apperror.ErrTokenInvalid)
+	}
+	userID, err := strconv.Atoi(rawUserID.(string))
+	if err != nil {
+		return nil, apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, err)
+	}
+	role, isExists := c.Get("role")
+	if !isExists {
+		return nil, apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrUserUnauthorized, apperror.ErrUserUnauthorized)
+	}
+	return &entity.Viewer{ID: userID, Role: role.(string)}, nil
+}
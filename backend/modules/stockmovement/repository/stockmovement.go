@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"montelukast/modules/stockmovement/entity"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+)
+
+type StockMovementRepo interface {
+	GetPharmacyIDByPharmacyProductID(c context.Context, pharmacyProductID int) (*int, error)
+	GetMovements(c context.Context, filter entity.MovementFilter) ([]entity.StockMovement, error)
+	GetTotalMovement(c context.Context, filter entity.MovementFilter) (int, error)
+	GetTotalPharmacyProduct(c context.Context, pharmacyID *int) (int, error)
+	GetDiscrepancies(c context.Context, pharmacyID *int) ([]entity.StockDiscrepancy, error)
+}
+
+type stockMovementRepoImpl struct {
+	db *sql.DB
+}
+
+func NewStockMovementRepo(dbConn *sql.DB) stockMovementRepoImpl {
+	return stockMovementRepoImpl{
+		db: dbConn,
+	}
+}
+
+func (r stockMovementRepoImpl) GetPharmacyIDByPharmacyProductID(c context.Context, pharmacyProductID int) (*int, error) {
+	query := `SELECT pharmacy_id FROM pharmacy_products WHERE id = $1 AND deleted_at IS NULL`
+
+	var pharmacyID int
+	err := r.db.QueryRowContext(c, query, pharmacyProductID).Scan(&pharmacyID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return &pharmacyID, nil
+}
+
+func (r stockMovementRepoImpl) GetMovements(c context.Context, filter entity.MovementFilter) ([]entity.StockMovement, error) {
+	movements := []entity.StockMovement{}
+
+	query := `SELECT id, pharmacy_product_id, delta, balance, reason, reference_id, created_at
+				FROM stock_movements
+				WHERE pharmacy_product_id = $1 AND deleted_at IS NULL AND ($2 = '' OR reason = $2)
+				ORDER BY id DESC
+				LIMIT $3 OFFSET $4`
+
+	rows, err := r.db.QueryContext(c, query, filter.PharmacyProductID, filter.Reason, filter.GetLimit(), filter.GetOffset())
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var movement entity.StockMovement
+		err := rows.Scan(&movement.ID, &movement.PharmacyProductID, &movement.Delta, &movement.Balance,
+			&movement.Reason, &movement.ReferenceID, &movement.CreatedAt)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
+		movements = append(movements, movement)
+	}
+	return movements, nil
+}
+
+func (r stockMovementRepoImpl) GetTotalMovement(c context.Context, filter entity.MovementFilter) (int, error) {
+	query := `SELECT COUNT(id)
+				FROM stock_movements
+				WHERE pharmacy_product_id = $1 AND deleted_at IS NULL AND ($2 = '' OR reason = $2)`
+
+	var total int
+	err := r.db.QueryRowContext(c, query, filter.PharmacyProductID, filter.Reason).Scan(&total)
+	if err != nil {
+		return 0, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return total, nil
+}
+
+func (r stockMovementRepoImpl) GetTotalPharmacyProduct(c context.Context, pharmacyID *int) (int, error) {
+	query := `SELECT COUNT(id) FROM pharmacy_products WHERE deleted_at IS NULL`
+
+	args := []any{}
+	if pharmacyID != nil {
+		args = append(args, *pharmacyID)
+		query += fmt.Sprintf(` AND pharmacy_id = $%d`, len(args))
+	}
+
+	var total int
+	err := r.db.QueryRowContext(c, query, args...).Scan(&total)
+	if err != nil {
+		return 0, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return total, nil
+}
+
+func (r stockMovementRepoImpl) GetDiscrepancies(c context.Context, pharmacyID *int) ([]entity.StockDiscrepancy, error) {
+	discrepancies := []entity.StockDiscrepancy{}
+
+	query := `SELECT pp.id, ph.id, ph.name, p.name, pp.stock, COALESCE(SUM(sm.delta), 0)
+				FROM pharmacy_products pp
+				JOIN pharmacies ph ON ph.id = pp.pharmacy_id
+				JOIN products p ON p.id = pp.product_id
+				LEFT JOIN stock_movements sm ON sm.pharmacy_product_id = pp.id AND sm.deleted_at IS NULL
+				WHERE pp.deleted_at IS NULL`
+
+	args := []any{}
+	if pharmacyID != nil {
+		args = append(args, *pharmacyID)
+		query += fmt.Sprintf(` AND pp.pharmacy_id = $%d`, len(args))
+	}
+	query += ` GROUP BY pp.id, ph.id, ph.name, p.name, pp.stock
+				HAVING pp.stock <> COALESCE(SUM(sm.delta), 0)
+				ORDER BY pp.id`
+
+	rows, err := r.db.QueryContext(c, query, args...)
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var discrepancy entity.StockDiscrepancy
+		err := rows.Scan(&discrepancy.PharmacyProductID, &discrepancy.PharmacyID, &discrepancy.PharmacyName,
+			&discrepancy.ProductName, &discrepancy.Stock, &discrepancy.LedgerStock)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
+		discrepancies = append(discrepancies, discrepancy)
+	}
+	return discrepancies, nil
+}
@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"context"
+	"math"
+	pharmacistRepo "montelukast/modules/pharmacist/repository"
+	"montelukast/modules/stockmovement/entity"
+	"montelukast/modules/stockmovement/repository"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+)
+
+type StockMovementUsecase interface {
+	GetMovements(c context.Context, filter entity.MovementFilter, viewer entity.Viewer) (*entity.PaginatedStockMovements, error)
+	CheckConsistency(c context.Context, pharmacyID *int, viewer entity.Viewer) (*entity.ConsistencyReport, error)
+}
+
+type stockMovementUsecaseImpl struct {
+	r   repository.StockMovementRepo
+	phr pharmacistRepo.PharmacistRepo
+}
+
+func NewStockMovementUsecase(r repository.StockMovementRepo, phr pharmacistRepo.PharmacistRepo) stockMovementUsecaseImpl {
+	return stockMovementUsecaseImpl{
+		r:   r,
+		phr: phr,
+	}
+}
+
+func (u stockMovementUsecaseImpl) GetMovements(c context.Context, filter entity.MovementFilter, viewer entity.Viewer) (*entity.PaginatedStockMovements, error) {
+	pharmacyID, err := u.r.GetPharmacyIDByPharmacyProductID(c, filter.PharmacyProductID)
+	if err != nil {
+		return nil, err
+	}
+	if pharmacyID == nil {
+		return nil, apperror.NewErrStatusNotFound(appconstant.FieldErrGetStockMovements, apperror.ErrPharmacyProductNotExists, apperror.ErrPharmacyProductNotExists)
+	}
+	if viewer.Role == appconstant.ROLE_PHARMACY {
+		viewerPharmacyID, err := u.getPharmacyID(c, viewer.ID)
+		if err != nil {
+			return nil, err
+		}
+		if *pharmacyID != viewerPharmacyID {
+			return nil, apperror.NewErrStatusNotFound(appconstant.FieldErrGetStockMovements, apperror.ErrPharmacyProductNotExists, apperror.ErrPharmacyProductNotExists)
+		}
+	}
+
+	movements, err := u.r.GetMovements(c, filter)
+	if err != nil {
+		return nil, err
+	}
+	totalItem, err := u.r.GetTotalMovement(c, filter)
+	if err != nil {
+		return nil, err
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+
+	var paginatedMovements entity.PaginatedStockMovements
+	paginatedMovements.Movements = movements
+	paginatedMovements.Pagination.TotalItem = totalItem
+	paginatedMovements.Pagination.CurrentPage = filter.Page
+	paginatedMovements.Pagination.TotalPage = int(math.Ceil(float64(totalItem) / float64(filter.GetLimit())))
+	paginatedMovements.Pagination.Limit = filter.GetLimit()
+	return &paginatedMovements, nil
+}
+
+// CheckConsistency lists the pharmacy products whose stock no longer equals
+// the sum of their ledger entries. Pharmacists can only check their own
+// pharmacy; admins check every pharmacy unless one is given.
+func (u stockMovementUsecaseImpl) CheckConsistency(c context.Context, pharmacyID *int, viewer entity.Viewer) (*entity.ConsistencyReport, error) {
+	if viewer.Role == appconstant.ROLE_PHARMACY {
+		viewerPharmacyID, err := u.getPharmacyID(c, viewer.ID)
+		if err != nil {
+			return nil, err
+		}
+		pharmacyID = &viewerPharmacyID
+	}
+
+	checkedProducts, err := u.r.GetTotalPharmacyProduct(c, pharmacyID)
+	if err != nil {
+		return nil, err
+	}
+	discrepancies, err := u.r.GetDiscrepancies(c, pharmacyID)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.ConsistencyReport{
+		CheckedProducts: checkedProducts,
+		Discrepancies:   discrepancies,
+	}, nil
+}
+
+func (u stockMovementUsecaseImpl) getPharmacyID(c context.Context, pharmacistID int) (int, error) {
+	isExists, err := u.phr.IsPharmacistExistsByID(c, pharmacistID)
+	if err != nil {
+		return 0, err
+	}
+	if !isExists {
+		return 0, apperror.NewErrStatusNotFound(appconstant.FieldErrGetStockMovements, apperror.ErrPharmacistNotExists, apperror.ErrPharmacistNotExists)
+	}
+
+	pharmacyID, err := u.phr.GetPharmacyIDByPharmacistID(c, pharmacistID)
+	if err != nil {
+		return 0, err
+	}
+	if pharmacyID == nil {
+		return 0, apperror.NewErrStatusBadRequest(appconstant.FieldErrGetStockMovements, apperror.ErrPharmacistNotHasPharmacy, apperror.ErrPharmacistNotHasPharmacy)
+	}
+	return *pharmacyID, nil
+}
@@ -0,0 +1,40 @@
+package converter
+
+import (
+	"montelukast/modules/stockmutation/dto"
+	"montelukast/modules/stockmutation/entity"
+)
+
+type MutationRequestConverter struct{}
+
+func (c MutationRequestConverter) ToEntity(mutationReq dto.MutationRequest, pharmacistID int) entity.MutationRequest {
+	return entity.MutationRequest{
+		PharmacistID:     pharmacistID,
+		SourcePharmacyID: mutationReq.SourcePharmacyID,
+		ProductID:        mutationReq.ProductID,
+		Quantity:         mutationReq.Quantity,
+	}
+}
+
+type StockMutationConverter struct{}
+
+func (c StockMutationConverter) ToDto(mutation entity.StockMutation) dto.StockMutationResponse {
+	return dto.StockMutationResponse{
+		ID:                           mutation.ID,
+		SourcePharmacyProductID:      mutation.SourcePharmacyProductID,
+		SourcePharmacyID:             mutation.SourcePharmacyID,
+		SourcePharmacyName:           mutation.SourcePharmacyName,
+		DestinationPharmacyProductID: mutation.DestinationPharmacyProductID,
+		DestinationPharmacyID:        mutation.DestinationPharmacyID,
+		DestinationPharmacyName:      mutation.DestinationPharmacyName,
+		ProductID:                    mutation.ProductID,
+		ProductName:                  mutation.ProductName,
+		Quantity:                     mutation.Quantity,
+		Type:                         mutation.Type,
+		Status:                       mutation.Status,
+		RequestedBy:                  mutation.RequestedBy,
+		ReviewedBy:                   mutation.ReviewedBy,
+		ReviewedAt:                   mutation.ReviewedAt,
+		CreatedAt:                    mutation.CreatedAt,
+	}
+}
@@ -0,0 +1,32 @@
+package dto
+
+import "time"
+
+type MutationRequest struct {
+	SourcePharmacyID int `json:"source_pharmacy_id" binding:"required"`
+	ProductID        int `json:"product_id" binding:"required"`
+	Quantity         int `json:"quantity" binding:"required,min=1"`
+}
+
+type MutationFilterRequest struct {
+	Status string `form:"status"`
+}
+
+type StockMutationResponse struct {
+	ID                           int        `json:"id"`
+	SourcePharmacyProductID      int        `json:"source_pharmacy_product_id"`
+	SourcePharmacyID             int        `json:"source_pharmacy_id"`
+	SourcePharmacyName           string     `json:"source_pharmacy_name"`
+	DestinationPharmacyProductID int        `json:"destination_pharmacy_product_id"`
+	DestinationPharmacyID        int        `json:"destination_pharmacy_id"`
+	DestinationPharmacyName      string     `json:"destination_pharmacy_name"`
+	ProductID                    int        `json:"product_id"`
+	ProductName                  string     `json:"product_name"`
+	Quantity                     int        `json:"quantity"`
+	Type                         string     `json:"type"`
+	Status                       string     `json:"status"`
+	RequestedBy                  *int       `json:"requested_by"`
+	ReviewedBy                   *int       `json:"reviewed_by"`
+	ReviewedAt                   *time.Time `json:"reviewed_at"`
+	CreatedAt                    time.Time  `json:"created_at"`
+}
@@ -0,0 +1,40 @@
+package entity
+
+import "time"
+
+type StockMutation struct {
+	ID                           int
+	SourcePharmacyProductID      int
+	SourcePharmacyID             int
+	SourcePharmacyName           string
+	DestinationPharmacyProductID int
+	DestinationPharmacyID        int
+	DestinationPharmacyName      string
+	ProductID                    int
+	ProductName                  string
+	Quantity                     int
+	Type                         string
+	Status                       string
+	RequestedBy                  *int
+	ReviewedBy                   *int
+	ReviewedAt                   *time.Time
+	CreatedAt                    time.Time
+}
+
+type MutationRequest struct {
+	PharmacistID     int
+	SourcePharmacyID int
+	ProductID        int
+	Quantity         int
+}
+
+type MutationFilter struct {
+	PharmacyID int
+	Status     string
+}
+
+type MutationPharmacyProduct struct {
+	ID         int
+	PharmacyID int
+	PartnerID  int
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"montelukast/modules/stockmutation/converter"
+	"montelukast/modules/stockmutation/dto"
+	"montelukast/modules/stockmutation/usecase"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"montelukast/pkg/wrapper"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StockMutationHandler struct {
+	u usecase.StockMutationUsecase
+}
+
+func NewStockMutationHandler(u usecase.StockMutationUsecase) StockMutationHandler {
+	return StockMutationHandler{
+		u: u,
+	}
+}
+
+func (h StockMutationHandler) RequestMutationHandler(c *gin.Context) {
+	pharmacistID, err := getUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = apperror.JsonValidator(c)
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrRequestStockMutation, apperror.ErrInvalidJSON, err))
+		return
+	}
+
+	mutationReq := dto.MutationRequest{}
+	err = c.ShouldBindJSON(&mutationReq)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	mutation, err := h.u.RequestMutation(c, converter.MutationRequestConverter{}.ToEntity(mutationReq, pharmacistID))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(converter.StockMutationConverter{}.ToDto(*mutation), "request stock mutation success!", nil)
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h StockMutationHandler) GetMutationsHandler(c *gin.Context) {
+	pharmacistID, err := getUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	filterReq := dto.MutationFilterRequest{}
+	err = c.ShouldBindQuery(&filterReq)
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrGetStockMutations, apperror.ErrInvalidJSON, err))
+		return
+	}
+
+	mutations, err := h.u.GetMutations(c, pharmacistID, filterReq.Status)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	mutationsRes := []dto.StockMutationResponse{}
+	for _, mutation := range mutations {
+		mutationsRes = append(mutationsRes, converter.StockMutationConverter{}.ToDto(mutation))
+	}
+	response := wrapper.ResponseData(mutationsRes, "get stock mutations success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h StockMutationHandler) GetMutationHandler(c *gin.Context) {
+	pharmacistID, err := getUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrGetStockMutations, apperror.ErrConvertVariableType, err))
+		return
+	}
+
+	mutation, err := h.u.GetMutation(c, id, pharmacistID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(converter.StockMutationConverter{}.ToDto(*mutation), "get stock mutation success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h StockMutationHandler) ApproveMutationHandler(c *gin.Context) {
+	pharmacistID, err := getUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrReviewStockMutation, apperror.ErrConvertVariableType, err))
+		return
+	}
+
+	err = h.u.ApproveMutation(c, id, pharmacistID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(nil, "approve stock mutation success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h StockMutationHandler) RejectMutationHandler(c *gin.Context) {
+	pharmacistID, err := getUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.NewErrStatusBadRequest(appconstant.FieldErrReviewStockMutation, apperror.ErrConvertVariableType, err))
+		return
+	}
+
+	err = h.u.RejectMutation(c, id, pharmacistID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(nil, "reject stock mutation success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func getUserID(c *gin.Context) (int, error) {
+	rawUserID, isExists := c.Get("user_id")
+	if !isExists {
+		return 0, apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, apperror.ErrTokenInvalid)
+	}
+	userID, err := strconv.Atoi(rawUserID.(string))
+	if err != nil {
+		return 0, apperror.NewErrStatusUnauthorized(appconstant.FieldErrCheckAuthorization, apperror.ErrTokenInvalid, err)
+	}
+	return userID, nil
+}
@@ -0,0 +1,273 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"montelukast/modules/stockmutation/entity"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"montelukast/pkg/transaction"
+)
+
+type StockMutationRepo interface {
+	GetPharmacyProduct(c context.Context, pharmacyID int, productID int) (*entity.MutationPharmacyProduct, error)
+	GetSurplusSources(c context.Context, pharmacyProductID int, quantity int, radius float64) ([]entity.MutationPharmacyProduct, error)
+	TransferStock(c context.Context, sourceID int, destinationID int, quantity int) (bool, error)
+	AddStockMutation(c context.Context, mutation entity.StockMutation) (int, error)
+	AddTransferMovements(c context.Context, mutation entity.StockMutation) error
+	GetStockMutations(c context.Context, filter entity.MutationFilter) ([]entity.StockMutation, error)
+	GetStockMutationByID(c context.Context, id int) (*entity.StockMutation, error)
+	UpdateStockMutationStatus(c context.Context, mutation entity.StockMutation) error
+}
+
+type stockMutationRepoImpl struct {
+	db *sql.DB
+}
+
+func NewStockMutationRepo(dbConn *sql.DB) stockMutationRepoImpl {
+	return stockMutationRepoImpl{
+		db: dbConn,
+	}
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func (r stockMutationRepoImpl) GetPharmacyProduct(c context.Context, pharmacyID int, productID int) (*entity.MutationPharmacyProduct, error) {
+	query := `SELECT pp.id, ph.id, ph.partner_id
+				FROM pharmacy_products pp
+				JOIN pharmacies ph ON ph.id = pp.pharmacy_id
+				WHERE pp.pharmacy_id = $1 AND pp.product_id = $2
+				AND pp.is_active = true AND pp.deleted_at IS NULL AND ph.deleted_at IS NULL`
+
+	var pharmacyProduct entity.MutationPharmacyProduct
+	err := r.db.QueryRowContext(c, query, pharmacyID, productID).Scan(&pharmacyProduct.ID, &pharmacyProduct.PharmacyID, &pharmacyProduct.PartnerID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return &pharmacyProduct, nil
+}
+
+func (r stockMutationRepoImpl) GetSurplusSources(c context.Context, pharmacyProductID int, quantity int, radius float64) ([]entity.MutationPharmacyProduct, error) {
+	tx := transaction.ExtractTx(c)
+	sources := []entity.MutationPharmacyProduct{}
+
+	query := `SELECT spp.id, sph.id, sph.partner_id
+				FROM pharmacy_products dpp
+				JOIN pharmacies dph ON dph.id = dpp.pharmacy_id
+				JOIN pharmacies sph ON sph.partner_id = dph.partner_id AND sph.id <> dph.id
+				JOIN pharmacy_products spp ON spp.pharmacy_id = sph.id AND spp.product_id = dpp.product_id
+				JOIN pharmacy_product_available_stocks pas ON pas.pharmacy_product_id = spp.id
+				WHERE dpp.id = $1 AND dpp.is_active = true AND dpp.deleted_at IS NULL
+				AND spp.is_active = true AND spp.deleted_at IS NULL
+				AND sph.is_active = true AND sph.deleted_at IS NULL
+				AND pas.available_stock - $2 >= spp.low_stock_threshold
+				AND ST_DWithin(sph.location, dph.location, $4)
+				ORDER BY ST_Distance(sph.location, dph.location), spp.id
+				LIMIT $3`
+
+	var rows *sql.Rows
+	var err error
+	if tx != nil {
+		rows, err = tx.QueryContext(c, query, pharmacyProductID, quantity, appconstant.MaxStockMutationSources, radius)
+	} else {
+		rows, err = r.db.QueryContext(c, query, pharmacyProductID, quantity, appconstant.MaxStockMutationSources, radius)
+	}
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var source entity.MutationPharmacyProduct
+		err := rows.Scan(&source.ID, &source.PharmacyID, &source.PartnerID)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+func (r stockMutationRepoImpl) TransferStock(c context.Context, sourceID int, destinationID int, quantity int) (bool, error) {
+	tx := transaction.ExtractTx(c)
+
+	decreaseQuery := `UPDATE pharmacy_products pp
+				SET stock = pp.stock - $2, updated_at = NOW()
+				WHERE pp.id = $1 AND pp.is_active IS TRUE AND pp.deleted_at IS NULL
+				AND pp.stock - COALESCE((
+					SELECT SUM(sh.quantity)
+					FROM stock_holds sh
+					WHERE sh.pharmacy_product_id = pp.id AND sh.expired_at > NOW() AND sh.deleted_at IS NULL
+				), 0) >= $2`
+	increaseQuery := `UPDATE pharmacy_products
+				SET stock = stock + $2, updated_at = NOW()
+				WHERE id = $1`
+
+	res, err := tx.ExecContext(c, decreaseQuery, sourceID, quantity)
+	if err != nil {
+		return false, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	if affected == 0 {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(c, increaseQuery, destinationID, quantity)
+	if err != nil {
+		return false, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return true, nil
+}
+
+func (r stockMutationRepoImpl) AddStockMutation(c context.Context, mutation entity.StockMutation) (int, error) {
+	tx := transaction.ExtractTx(c)
+
+	query := `INSERT INTO stock_mutations (source_pharmacy_product_id, destination_pharmacy_product_id, quantity, type, status, requested_by, reviewed_by, reviewed_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+				RETURNING id`
+
+	args := []any{mutation.SourcePharmacyProductID, mutation.DestinationPharmacyProductID, mutation.Quantity,
+		mutation.Type, mutation.Status, mutation.RequestedBy, mutation.ReviewedBy, mutation.ReviewedAt}
+
+	var id int
+	var err error
+	if tx != nil {
+		err = tx.QueryRowContext(c, query, args...).Scan(&id)
+	} else {
+		err = r.db.QueryRowContext(c, query, args...).Scan(&id)
+	}
+	if err != nil {
+		return 0, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return id, nil
+}
+
+// AddTransferMovements writes both legs of a transfer to the ledger. It runs
+// after TransferStock in the same transaction, so the updated rows are still
+// locked and their current stock is the balance the transfer left behind.
+func (r stockMutationRepoImpl) AddTransferMovements(c context.Context, mutation entity.StockMutation) error {
+	tx := transaction.ExtractTx(c)
+
+	query := `INSERT INTO stock_movements (pharmacy_product_id, delta, balance, reason, reference_id)
+				SELECT pp.id, CASE WHEN pp.id = $1 THEN -$3::int ELSE $3::int END, pp.stock, $4, $5
+				FROM pharmacy_products pp
+				WHERE pp.id IN ($1, $2)`
+
+	_, err := tx.ExecContext(c, query, mutation.SourcePharmacyProductID, mutation.DestinationPharmacyProductID,
+		mutation.Quantity, appconstant.StockMovementTransfer, mutation.ID)
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+const stockMutationColumns = `sm.id, sm.source_pharmacy_product_id, spp.pharmacy_id, sph.name,
+				sm.destination_pharmacy_product_id, dpp.pharmacy_id, dph.name, p.id, p.name,
+				sm.quantity, sm.type, sm.status, sm.requested_by, sm.reviewed_by, sm.reviewed_at, sm.created_at`
+
+const stockMutationJoins = `FROM stock_mutations sm
+				JOIN pharmacy_products spp ON spp.id = sm.source_pharmacy_product_id
+				JOIN pharmacies sph ON sph.id = spp.pharmacy_id
+				JOIN pharmacy_products dpp ON dpp.id = sm.destination_pharmacy_product_id
+				JOIN pharmacies dph ON dph.id = dpp.pharmacy_id
+				JOIN products p ON p.id = spp.product_id`
+
+func scanStockMutation(row rowScanner) (*entity.StockMutation, error) {
+	var mutation entity.StockMutation
+	err := row.Scan(
+		&mutation.ID,
+		&mutation.SourcePharmacyProductID,
+		&mutation.SourcePharmacyID,
+		&mutation.SourcePharmacyName,
+		&mutation.DestinationPharmacyProductID,
+		&mutation.DestinationPharmacyID,
+		&mutation.DestinationPharmacyName,
+		&mutation.ProductID,
+		&mutation.ProductName,
+		&mutation.Quantity,
+		&mutation.Type,
+		&mutation.Status,
+		&mutation.RequestedBy,
+		&mutation.ReviewedBy,
+		&mutation.ReviewedAt,
+		&mutation.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return &mutation, nil
+}
+
+func (r stockMutationRepoImpl) GetStockMutations(c context.Context, filter entity.MutationFilter) ([]entity.StockMutation, error) {
+	mutations := []entity.StockMutation{}
+
+	query := `SELECT ` + stockMutationColumns + `
+				` + stockMutationJoins + `
+				WHERE (spp.pharmacy_id = $1 OR dpp.pharmacy_id = $1) AND sm.deleted_at IS NULL`
+
+	args := []any{filter.PharmacyID}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		query += fmt.Sprintf(` AND sm.status = $%d`, len(args))
+	}
+	query += ` ORDER BY sm.created_at DESC, sm.id DESC`
+
+	rows, err := r.db.QueryContext(c, query, args...)
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		mutation, err := scanStockMutation(rows)
+		if err != nil {
+			return nil, err
+		}
+		mutations = append(mutations, *mutation)
+	}
+	return mutations, nil
+}
+
+func (r stockMutationRepoImpl) GetStockMutationByID(c context.Context, id int) (*entity.StockMutation, error) {
+	tx := transaction.ExtractTx(c)
+
+	query := `SELECT ` + stockMutationColumns + `
+				` + stockMutationJoins + `
+				WHERE sm.id = $1 AND sm.deleted_at IS NULL`
+
+	if tx != nil {
+		return scanStockMutation(tx.QueryRowContext(c, query+` FOR UPDATE OF sm`, id))
+	}
+	return scanStockMutation(r.db.QueryRowContext(c, query, id))
+}
+
+func (r stockMutationRepoImpl) UpdateStockMutationStatus(c context.Context, mutation entity.StockMutation) error {
+	tx := transaction.ExtractTx(c)
+
+	query := `UPDATE stock_mutations
+				SET status = $2, reviewed_by = $3, reviewed_at = NOW(), updated_at = NOW()
+				WHERE id = $1`
+
+	var err error
+	if tx != nil {
+		_, err = tx.ExecContext(c, query, mutation.ID, mutation.Status, mutation.ReviewedBy)
+	} else {
+		_, err = r.db.ExecContext(c, query, mutation.ID, mutation.Status, mutation.ReviewedBy)
+	}
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
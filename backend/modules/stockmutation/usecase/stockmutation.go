@@ -0,0 +1,232 @@
+package usecase
+
+import (
+	"context"
+	pharmacistRepo "montelukast/modules/pharmacist/repository"
+	"montelukast/modules/stockmutation/entity"
+	"montelukast/modules/stockmutation/repository"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"montelukast/pkg/transaction"
+	"os"
+	"strconv"
+	"time"
+)
+
+type StockMutationUsecase interface {
+	CoverShortage(c context.Context, pharmacyProductID int, quantity int) (bool, error)
+	RequestMutation(c context.Context, request entity.MutationRequest) (*entity.StockMutation, error)
+	GetMutations(c context.Context, pharmacistID int, status string) ([]entity.StockMutation, error)
+	GetMutation(c context.Context, id int, pharmacistID int) (*entity.StockMutation, error)
+	ApproveMutation(c context.Context, id int, pharmacistID int) error
+	RejectMutation(c context.Context, id int, pharmacistID int) error
+}
+
+type stockMutationUsecaseImpl struct {
+	r   repository.StockMutationRepo
+	tr  transaction.TransactorRepoImpl
+	phr pharmacistRepo.PharmacistRepo
+}
+
+func NewStockMutationUsecase(r repository.StockMutationRepo, tr transaction.TransactorRepoImpl, phr pharmacistRepo.PharmacistRepo) stockMutationUsecaseImpl {
+	return stockMutationUsecaseImpl{
+		r:   r,
+		tr:  tr,
+		phr: phr,
+	}
+}
+
+// CoverShortage moves stock into the pharmacy product from the nearest
+// pharmacy of the same partner within reach that can spare it without
+// dropping below its own low stock threshold. It must run inside the
+// caller's transaction so the transfer is rolled back with a failed checkout.
+func (u stockMutationUsecaseImpl) CoverShortage(c context.Context, pharmacyProductID int, quantity int) (bool, error) {
+	if quantity <= 0 {
+		return false, nil
+	}
+	sources, err := u.r.GetSurplusSources(c, pharmacyProductID, quantity, getMutationRadius())
+	if err != nil {
+		return false, err
+	}
+	for _, source := range sources {
+		isTransferred, err := u.r.TransferStock(c, source.ID, pharmacyProductID, quantity)
+		if err != nil {
+			return false, err
+		}
+		if !isTransferred {
+			continue
+		}
+		now := time.Now()
+		mutation := entity.StockMutation{
+			SourcePharmacyProductID:      source.ID,
+			DestinationPharmacyProductID: pharmacyProductID,
+			Quantity:                     quantity,
+			Type:                         appconstant.StockMutationTypeAutomatic,
+			Status:                       appconstant.StockMutationStatusApproved,
+			ReviewedAt:                   &now,
+		}
+		mutation.ID, err = u.r.AddStockMutation(c, mutation)
+		if err != nil {
+			return false, err
+		}
+		err = u.r.AddTransferMovements(c, mutation)
+		if err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+func (u stockMutationUsecaseImpl) RequestMutation(c context.Context, request entity.MutationRequest) (*entity.StockMutation, error) {
+	pharmacyID, err := u.getPharmacyID(c, request.PharmacistID, appconstant.FieldErrRequestStockMutation)
+	if err != nil {
+		return nil, err
+	}
+	if request.SourcePharmacyID == pharmacyID {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrRequestStockMutation, apperror.ErrInvalidMutationSource, apperror.ErrInvalidMutationSource)
+	}
+
+	destination, err := u.r.GetPharmacyProduct(c, pharmacyID, request.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	if destination == nil {
+		return nil, apperror.NewErrStatusNotFound(appconstant.FieldErrRequestStockMutation, apperror.ErrPharmacyProductNotExists, apperror.ErrPharmacyProductNotExists)
+	}
+	source, err := u.r.GetPharmacyProduct(c, request.SourcePharmacyID, request.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	if source == nil {
+		return nil, apperror.NewErrStatusNotFound(appconstant.FieldErrRequestStockMutation, apperror.ErrPharmacyProductNotExists, apperror.ErrPharmacyProductNotExists)
+	}
+	if source.PartnerID != destination.PartnerID {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrRequestStockMutation, apperror.ErrInvalidMutationSource, apperror.ErrInvalidMutationSource)
+	}
+
+	id, err := u.r.AddStockMutation(c, entity.StockMutation{
+		SourcePharmacyProductID:      source.ID,
+		DestinationPharmacyProductID: destination.ID,
+		Quantity:                     request.Quantity,
+		Type:                         appconstant.StockMutationTypeManual,
+		Status:                       appconstant.StockMutationStatusPending,
+		RequestedBy:                  &request.PharmacistID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u.r.GetStockMutationByID(c, id)
+}
+
+func (u stockMutationUsecaseImpl) GetMutations(c context.Context, pharmacistID int, status string) ([]entity.StockMutation, error) {
+	pharmacyID, err := u.getPharmacyID(c, pharmacistID, appconstant.FieldErrGetStockMutations)
+	if err != nil {
+		return nil, err
+	}
+	return u.r.GetStockMutations(c, entity.MutationFilter{PharmacyID: pharmacyID, Status: status})
+}
+
+func (u stockMutationUsecaseImpl) GetMutation(c context.Context, id int, pharmacistID int) (*entity.StockMutation, error) {
+	pharmacyID, err := u.getPharmacyID(c, pharmacistID, appconstant.FieldErrGetStockMutations)
+	if err != nil {
+		return nil, err
+	}
+	mutation, err := u.r.GetStockMutationByID(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if mutation == nil || (mutation.SourcePharmacyID != pharmacyID && mutation.DestinationPharmacyID != pharmacyID) {
+		return nil, apperror.NewErrStatusNotFound(appconstant.FieldErrGetStockMutations, apperror.ErrStockMutationNotExists, apperror.ErrStockMutationNotExists)
+	}
+	return mutation, nil
+}
+
+func (u stockMutationUsecaseImpl) ApproveMutation(c context.Context, id int, pharmacistID int) error {
+	pharmacyID, err := u.getPharmacyID(c, pharmacistID, appconstant.FieldErrReviewStockMutation)
+	if err != nil {
+		return err
+	}
+
+	return u.tr.WithinTransaction(c, func(txCtx context.Context) error {
+		mutation, err := u.lockPendingMutation(txCtx, id, pharmacyID)
+		if err != nil {
+			return err
+		}
+		isTransferred, err := u.r.TransferStock(txCtx, mutation.SourcePharmacyProductID, mutation.DestinationPharmacyProductID, mutation.Quantity)
+		if err != nil {
+			return err
+		}
+		if !isTransferred {
+			return apperror.NewErrStatusBadRequest(appconstant.FieldErrReviewStockMutation, apperror.ErrStockUnavailable, apperror.ErrStockUnavailable)
+		}
+		err = u.r.AddTransferMovements(txCtx, *mutation)
+		if err != nil {
+			return err
+		}
+		mutation.Status = appconstant.StockMutationStatusApproved
+		mutation.ReviewedBy = &pharmacistID
+		return u.r.UpdateStockMutationStatus(txCtx, *mutation)
+	})
+}
+
+func (u stockMutationUsecaseImpl) RejectMutation(c context.Context, id int, pharmacistID int) error {
+	pharmacyID, err := u.getPharmacyID(c, pharmacistID, appconstant.FieldErrReviewStockMutation)
+	if err != nil {
+		return err
+	}
+
+	return u.tr.WithinTransaction(c, func(txCtx context.Context) error {
+		mutation, err := u.lockPendingMutation(txCtx, id, pharmacyID)
+		if err != nil {
+			return err
+		}
+		mutation.Status = appconstant.StockMutationStatusRejected
+		mutation.ReviewedBy = &pharmacistID
+		return u.r.UpdateStockMutationStatus(txCtx, *mutation)
+	})
+}
+
+// lockPendingMutation only lets the pharmacy giving away the stock decide
+// on a request.
+func (u stockMutationUsecaseImpl) lockPendingMutation(c context.Context, id int, pharmacyID int) (*entity.StockMutation, error) {
+	mutation, err := u.r.GetStockMutationByID(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if mutation == nil || mutation.SourcePharmacyID != pharmacyID {
+		return nil, apperror.NewErrStatusNotFound(appconstant.FieldErrReviewStockMutation, apperror.ErrStockMutationNotExists, apperror.ErrStockMutationNotExists)
+	}
+	if mutation.Status != appconstant.StockMutationStatusPending {
+		return nil, apperror.NewErrStatusConflict(appconstant.FieldErrReviewStockMutation, apperror.ErrMutationAlreadyReviewed, apperror.ErrMutationAlreadyReviewed)
+	}
+	return mutation, nil
+}
+
+func (u stockMutationUsecaseImpl) getPharmacyID(c context.Context, pharmacistID int, field string) (int, error) {
+	isExists, err := u.phr.IsPharmacistExistsByID(c, pharmacistID)
+	if err != nil {
+		return 0, err
+	}
+	if !isExists {
+		return 0, apperror.NewErrStatusNotFound(field, apperror.ErrPharmacistNotExists, apperror.ErrPharmacistNotExists)
+	}
+
+	pharmacyID, err := u.phr.GetPharmacyIDByPharmacistID(c, pharmacistID)
+	if err != nil {
+		return 0, err
+	}
+	if pharmacyID == nil {
+		return 0, apperror.NewErrStatusBadRequest(field, apperror.ErrPharmacistNotHasPharmacy, apperror.ErrPharmacistNotHasPharmacy)
+	}
+	return *pharmacyID, nil
+}
+
+// getMutationRadius returns the furthest a source pharmacy may be, in meters.
+func getMutationRadius() float64 {
+	km, err := strconv.Atoi(os.Getenv("STOCK_MUTATION_RADIUS_KM"))
+	if err != nil || km <= 0 {
+		km = appconstant.DefaultStockMutationRadiusKm
+	}
+	return float64(km) * 1000
+}
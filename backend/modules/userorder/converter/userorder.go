@@ -14,10 +14,11 @@ func (c GetDetailedOrdersConverter) ToDto(orders []entity.Order) []dto.OrderResp
 	for i := 0; i < total; i++ {
 		order := orders[i]
 		orderResponse := dto.OrderResponse{
-			ID:           order.ID,
-			TotalPrice:   order.TotalPrice.String(),
-			CreatedAt:    order.CreatedAt,
-			OrderDetails: c.toOrderDetails(order.OrderDetails),
+			ID:              order.ID,
+			TotalPrice:      order.TotalPrice.String(),
+			CreatedAt:       order.CreatedAt,
+			PaymentDeadline: order.PaymentDeadline,
+			OrderDetails:    c.toOrderDetails(order.OrderDetails),
 		}
 		orderResponses[i] = orderResponse
 	}
@@ -37,6 +38,7 @@ func (c GetDetailedOrdersConverter) toOrderDetails(orderDetails []entity.OrderDe
 			PharmacyName:       detail.PharmacyName,
 			Status:             detail.Status,
 			LogisticPrice:      detail.LogisticPrice.String(),
+			Tracking:           c.toTracking(detail),
 			OrderProductDetail: c.toOrderProductDetails(detail.OrderProductDetails),
 		}
 		orderDetailResponses[i] = orderDetailResponse
@@ -45,6 +47,28 @@ func (c GetDetailedOrdersConverter) toOrderDetails(orderDetails []entity.OrderDe
 	return orderDetailResponses
 }
 
+func (c GetDetailedOrdersConverter) toTracking(detail entity.OrderDetail) *dto.TrackingResponse {
+	if detail.Courier == nil || detail.TrackingNumber == nil {
+		return nil
+	}
+
+	events := make([]dto.ShipmentEventResponse, len(detail.ShipmentEvents))
+	for i, event := range detail.ShipmentEvents {
+		events[i] = dto.ShipmentEventResponse{
+			Status:      event.Status,
+			Description: event.Description,
+			Location:    event.Location,
+			OccurredAt:  event.OccurredAt,
+		}
+	}
+
+	return &dto.TrackingResponse{
+		Courier:        *detail.Courier,
+		TrackingNumber: *detail.TrackingNumber,
+		Events:         events,
+	}
+}
+
 func (c GetDetailedOrdersConverter) toOrderProductDetails(orderProductDetail []entity.OrderProductDetail) []dto.OrderProductDetailResponse {
 	total := len(orderProductDetail)
 	orderProductDetailResponses := make([]dto.OrderProductDetailResponse, total)
@@ -6,10 +6,11 @@ import (
 )
 
 type OrderResponse struct {
-	ID           int                   `json:"id"`
-	TotalPrice   string                `json:"total_price"`
-	CreatedAt    time.Time             `json:"order_date"`
-	OrderDetails []OrderDetailResponse `json:"order_details"`
+	ID              int                   `json:"id"`
+	TotalPrice      string                `json:"total_price"`
+	CreatedAt       time.Time             `json:"order_date"`
+	PaymentDeadline *time.Time            `json:"payment_deadline"`
+	OrderDetails    []OrderDetailResponse `json:"order_details"`
 }
 
 type OrderDetailResponse struct {
@@ -18,9 +19,23 @@ type OrderDetailResponse struct {
 	PharmacyName       string                       `json:"pharmacy_name"`
 	Status             string                       `json:"status"`
 	LogisticPrice      string                       `json:"logistic_price"`
+	Tracking           *TrackingResponse            `json:"tracking"`
 	OrderProductDetail []OrderProductDetailResponse `json:"order_products"`
 }
 
+type TrackingResponse struct {
+	Courier        string                  `json:"courier"`
+	TrackingNumber string                  `json:"tracking_number"`
+	Events         []ShipmentEventResponse `json:"events"`
+}
+
+type ShipmentEventResponse struct {
+	Status      string    `json:"status"`
+	Description string    `json:"description"`
+	Location    *string   `json:"location"`
+	OccurredAt  time.Time `json:"occurred_at"`
+}
+
 type OrderProductDetailResponse struct {
 	OrderProductID    int    `json:"order_product_id"`
 	PharmacyProductID int    `json:"pharmacy_product_id"`
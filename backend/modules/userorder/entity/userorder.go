@@ -10,9 +10,10 @@ import (
 type Order struct {
 	ID           int
 	UserID       int
-	TotalPrice   decimal.Decimal
-	CreatedAt    time.Time
-	OrderDetails []OrderDetail
+	TotalPrice      decimal.Decimal
+	CreatedAt       time.Time
+	PaymentDeadline *time.Time
+	OrderDetails    []OrderDetail
 }
 
 type OrderDetail struct {
@@ -22,10 +23,21 @@ type OrderDetail struct {
 	PharmacyName        string
 	Status              string
 	LogisticPrice       decimal.Decimal
+	Courier             *string
+	TrackingNumber      *string
+	ShipmentEvents      []ShipmentEvent
 	OrderProductDetails []OrderProductDetail
 	Order
 }
 
+type ShipmentEvent struct {
+	OrderDetailID int
+	Status        string
+	Description   string
+	Location      *string
+	OccurredAt    time.Time
+}
+
 type OrderProductDetail struct {
 	ID                int
 	PharmacyProductID int
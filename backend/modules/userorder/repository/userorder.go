@@ -7,18 +7,13 @@ import (
 	"montelukast/modules/userorder/entity"
 	appconstant "montelukast/pkg/constant"
 	apperror "montelukast/pkg/error"
-	"montelukast/pkg/transaction"
+	"strings"
 )
 
 type UserOrderRepo interface {
-	IsOrderExistsByID(c context.Context, orderID int, userID int) (bool, error)
-	UpdatePaymentStatus(c context.Context, orderDetailID int) error
-	GetOrderDetailIDByID(c context.Context, orderID int) ([]int, error)
-	GetStatusesByID(c context.Context, orderID int) ([]string, error)
 	IsOrderDetailExists(c context.Context, order entity.Order) (bool, error)
-	GetStatusByOrderDetailID(c context.Context, orderDetailID int) (string, error)
-	UpdateDeliveryStatusByOrderDetailID(c context.Context, orderDetailID int) error
 	GetDetailedOrdersByUserID(c context.Context, filter entity.OrderFilter) ([]entity.OrderProductDetail, error)
+	GetShipmentEvents(c context.Context, orderDetailIDs []int) ([]entity.ShipmentEvent, error)
 }
 
 type userOrderRepoImpl struct {
@@ -31,17 +26,6 @@ func NewUserOrderRepo(dbConn *sql.DB) userOrderRepoImpl {
 	}
 }
 
-func (r userOrderRepoImpl) IsOrderExistsByID(c context.Context, orderID int, userID int) (bool, error) {
-	query := `SELECT EXISTS (SELECT 1 FROM orders WHERE id = $2 AND user_id = $1 AND  deleted_at IS NULL) FOR UPDATE `
-
-	var exists bool
-	err := r.db.QueryRow(query, userID, orderID).Scan(&exists)
-	if err != nil && err != sql.ErrNoRows {
-		return exists, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
-	}
-	return exists, nil
-}
-
 func (r userOrderRepoImpl) IsOrderDetailExists(c context.Context, order entity.Order) (bool, error) {
 	query := `SELECT EXISTS (
 				SELECT 1 
@@ -59,128 +43,11 @@ func (r userOrderRepoImpl) IsOrderDetailExists(c context.Context, order entity.O
 	return exists, nil
 }
 
-func (r userOrderRepoImpl) GetOrderDetailIDByID(c context.Context, orderID int) ([]int, error) {
-	tx := transaction.ExtractTx(c)
-
-	orderDetailIDs := []int{}
-
-	query := `SELECT od.id
-				FROM order_details od 
-				WHERE order_id = $1 `
-
-	var err error
-	var rows *sql.Rows
-	if tx != nil {
-		rows, err = tx.QueryContext(c, query, orderID)
-	} else {
-		rows, err = r.db.QueryContext(c, query, orderID)
-	}
-
-	if err != nil {
-		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var orderDetailID int
-		err := rows.Scan(
-			&orderDetailID,
-		)
-		if err != nil {
-			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
-		}
-		orderDetailIDs = append(orderDetailIDs, orderDetailID)
-	}
-
-	return orderDetailIDs, nil
-}
-
-func (r userOrderRepoImpl) GetStatusesByID(c context.Context, orderID int) ([]string, error) {
-	tx := transaction.ExtractTx(c)
-
-	allStatus := []string{}
-
-	query := `SELECT od.status
-				FROM order_details od
-				WHERE order_id = $1 `
-
-	var err error
-	var rows *sql.Rows
-	if tx != nil {
-		rows, err = tx.QueryContext(c, query, orderID)
-	} else {
-		rows, err = r.db.QueryContext(c, query, orderID)
-	}
-
-	if err != nil {
-		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var status string
-		err := rows.Scan(
-			&status,
-		)
-		if err != nil {
-			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
-		}
-		allStatus = append(allStatus, status)
-	}
-
-	return allStatus, nil
-}
-
-func (r userOrderRepoImpl) UpdatePaymentStatus(c context.Context, orderDetailID int) error {
-	tx := transaction.ExtractTx(c)
-
-	query := `UPDATE order_details
-				SET status = $2, updated_at = NOW() 
-				WHERE id = $1 AND deleted_at IS NULL`
-
-	var err error
-	if tx != nil {
-		_, err = tx.ExecContext(c, query, orderDetailID, appconstant.StatusProcessing)
-	} else {
-		_, err = r.db.ExecContext(c, query, orderDetailID, appconstant.StatusProcessing)
-	}
-	if err != nil {
-		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
-	}
-
-	return nil
-}
-
-func (r userOrderRepoImpl) UpdateDeliveryStatusByOrderDetailID(c context.Context, orderDetailID int) error {
-	query := `UPDATE order_details 
-				SET status = $2, updated_at = NOW() 
-				WHERE id = $1 AND deleted_at IS NULL`
-
-	var err error
-	_, err = r.db.ExecContext(c, query, orderDetailID, appconstant.StatusDelivered)
-	if err != nil {
-		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
-	}
-
-	return nil
-}
-
-func (r userOrderRepoImpl) GetStatusByOrderDetailID(c context.Context, orderDetailID int) (string, error) {
-	query := `SELECT status FROM order_details WHERE id = $1 AND deleted_at IS NULL`
-
-	var status string
-	err := r.db.QueryRowContext(c, query, orderDetailID).Scan(&status)
-	if err != nil {
-		return "", apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
-	}
-	return status, nil
-}
-
 func (r userOrderRepoImpl) GetDetailedOrdersByUserID(c context.Context, filter entity.OrderFilter) ([]entity.OrderProductDetail, error) {
 	orderProductDetails := []entity.OrderProductDetail{}
 	query := `SELECT 
-				o.id order_id, o.total_price, o.created_at, 
-				od.id order_detail_id, od.pharmacy_id, od.status, od.logistic_price,
+				o.id order_id, o.total_price, o.created_at, o.payment_deadline,
+				od.id order_detail_id, od.pharmacy_id, od.status, od.logistic_price, od.courier, od.tracking_number,
 				opd.id order_detail_product_id, opd.pharmacy_product_id, opd.quantity, opd.price subtotal,
 				p.name, p.manufacture, p.image[1],
 				pmc.name pharmacy_name
@@ -214,10 +81,13 @@ func (r userOrderRepoImpl) GetDetailedOrdersByUserID(c context.Context, filter e
 			&orderProductDetail.OrderDetail.Order.ID,
 			&orderProductDetail.OrderDetail.Order.TotalPrice,
 			&orderProductDetail.OrderDetail.Order.CreatedAt,
+			&orderProductDetail.OrderDetail.Order.PaymentDeadline,
 			&orderProductDetail.OrderDetail.ID,
 			&orderProductDetail.OrderDetail.PharmacyID,
 			&orderProductDetail.OrderDetail.Status,
 			&orderProductDetail.OrderDetail.LogisticPrice,
+			&orderProductDetail.OrderDetail.Courier,
+			&orderProductDetail.OrderDetail.TrackingNumber,
 			&orderProductDetail.ID,
 			&orderProductDetail.PharmacyProductID,
 			&orderProductDetail.Quantity,
@@ -240,3 +110,43 @@ func (r userOrderRepoImpl) GetDetailedOrdersByUserID(c context.Context, filter e
 
 	return orderProductDetails, nil
 }
+
+func (r userOrderRepoImpl) GetShipmentEvents(c context.Context, orderDetailIDs []int) ([]entity.ShipmentEvent, error) {
+	events := []entity.ShipmentEvent{}
+	if len(orderDetailIDs) == 0 {
+		return events, nil
+	}
+
+	placeholders := make([]string, 0, len(orderDetailIDs))
+	args := make([]any, 0, len(orderDetailIDs))
+	for _, orderDetailID := range orderDetailIDs {
+		args = append(args, orderDetailID)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+	}
+
+	query := fmt.Sprintf(`SELECT order_detail_id, status, description, location, occurred_at
+				FROM shipment_events
+				WHERE order_detail_id IN (%s) AND deleted_at IS NULL
+				ORDER BY occurred_at, id`, strings.Join(placeholders, ","))
+
+	rows, err := r.db.QueryContext(c, query, args...)
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event entity.ShipmentEvent
+		err := rows.Scan(&event.OrderDetailID, &event.Status, &event.Description, &event.Location, &event.OccurredAt)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
+		events = append(events, event)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return events, nil
+}
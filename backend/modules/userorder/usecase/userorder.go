@@ -2,133 +2,47 @@ package usecase
 
 import (
 	"context"
-	"encoding/json"
+	orderStatusEntity "montelukast/modules/orderstatus/entity"
+	orderStatus "montelukast/modules/orderstatus/usecase"
+	paymentEntity "montelukast/modules/payment/entity"
+	paymentUsecase "montelukast/modules/payment/usecase"
 	userRepo "montelukast/modules/user/repository"
 	"montelukast/modules/userorder/entity"
 	"montelukast/modules/userorder/repository"
 	appconstant "montelukast/pkg/constant"
 	apperror "montelukast/pkg/error"
 	"montelukast/pkg/transaction"
-
-	"github.com/go-playground/validator/v10"
-	"github.com/streadway/amqp"
 )
 
 type UserOrderUsecase interface {
 	UpdatePaymentStatus(c context.Context, file entity.File, orderID int, userID int) error
 	UpdateDeliveryStatus(c context.Context, order entity.Order) error
 	GetDetailedOrders(c context.Context, filter entity.OrderFilter) ([]entity.Order, error)
-	UpdatePaymentStatusFromConsumer(ctx context.Context, orderDetailIDs []int) error
 }
 
 type userOrderUsecaseImpl struct {
 	r        repository.UserOrderRepo
 	tr       transaction.TransactorRepoImpl
 	userRepo userRepo.UserRepoImpl
-	rabbitMQ *amqp.Channel
+	os       orderStatus.OrderStatusUsecase
+	payment  paymentUsecase.PaymentUsecase
 }
 
-func NewUserOrderUsecase(rabbitMQ *amqp.Channel, r repository.UserOrderRepo, tr transaction.TransactorRepoImpl, userRepo userRepo.UserRepoImpl) userOrderUsecaseImpl {
+func NewUserOrderUsecase(r repository.UserOrderRepo, tr transaction.TransactorRepoImpl, userRepo userRepo.UserRepoImpl, os orderStatus.OrderStatusUsecase, payment paymentUsecase.PaymentUsecase) userOrderUsecaseImpl {
 	return userOrderUsecaseImpl{
 		r:        r,
 		tr:       tr,
 		userRepo: userRepo,
-		rabbitMQ: rabbitMQ,
+		os:       os,
+		payment:  payment,
 	}
 }
 
 func (u userOrderUsecaseImpl) UpdatePaymentStatus(c context.Context, file entity.File, orderID int, userID int) error {
-	validate := validator.New()
-	err := validate.Struct(file)
-	if err != nil {
-		return apperror.NewErrInternalServerError(appconstant.FieldErrUploadImage, apperror.ErrInternalServer, err)
-	}
-	exists, err := u.r.IsOrderExistsByID(c, orderID, userID)
+	_, err := u.payment.UploadPaymentProof(c, paymentEntity.File{File: file.File}, orderID, userID)
 	if err != nil {
 		return err
 	}
-	if !exists {
-		return apperror.NewErrStatusBadRequest(appconstant.FieldErrUploadPayment, apperror.ErrOrderNotExists, apperror.ErrOrderNotExists)
-	}
-
-	orderDetailIDs, err := u.r.GetOrderDetailIDByID(c, orderID)
-	if err != nil {
-		return nil
-	}
-	if len(orderDetailIDs) == 0 {
-		return apperror.NewErrStatusBadRequest(appconstant.FieldErrUploadPayment, apperror.ErrOrderNotExists, apperror.ErrOrderNotExists)
-	}
-
-	allStatus, err := u.r.GetStatusesByID(c, orderID)
-	if err != nil {
-		return err
-	}
-	for _, status := range allStatus {
-		if status != appconstant.StatusPending {
-			return apperror.NewErrStatusBadRequest(appconstant.FieldErrUploadPayment, apperror.ErrPaymentAlreadyDone, apperror.ErrPaymentAlreadyDone)
-		}
-	}
-	err = u.PublishDelayedMessage(c, orderDetailIDs, appconstant.PaymentCompleteTime)
-	if err != nil {
-		return apperror.NewErrStatusBadRequest(appconstant.FieldErrUploadPayment, apperror.ErrInternalServer, err)
-	}
-	return nil
-}
-
-func (u userOrderUsecaseImpl) PublishDelayedMessage(c context.Context, orderDetailIDs []int, delay int) error {
-	err := u.rabbitMQ.ExchangeDeclare(
-		"update-userorder-exchange", //name
-		"x-delayed-message",         //type
-		true,                        // durable
-		false,                       // auto-deleted
-		false,                       // internal
-		false,                       // no-wait
-		amqp.Table{
-			"x-delayed-type": "fanout",
-		},
-	)
-	if err != nil {
-		return apperror.NewErrInternalServerError(appconstant.FieldErrUpdatePartner, apperror.ErrInternalServer, err)
-	}
-	body, err := json.Marshal(map[string]interface{}{
-		"order_detail_ids": orderDetailIDs,
-	})
-	if err != nil {
-		return apperror.NewErrInternalServerError(appconstant.FieldErrUploadImage, apperror.ErrInternalServer, err)
-	}
-	err = u.rabbitMQ.Publish(
-		"update-userorder-exchange",
-		"x-delayed-message",
-		false,
-		false,
-		amqp.Publishing{
-			DeliveryMode: amqp.Persistent,
-			ContentType:  "application/json",
-			Body:         body,
-			Headers: amqp.Table{
-				"x-delay": delay,
-			},
-		},
-	)
-	if err != nil {
-		return apperror.NewErrInternalServerError(appconstant.FieldErrUploadImage, apperror.ErrInternalServer, err)
-	}
-	return nil
-}
-
-func (u userOrderUsecaseImpl) UpdatePaymentStatusFromConsumer(ctx context.Context, orderDetailIDs []int) error {
-	err := u.tr.WithinTransaction(ctx, func(txCtx context.Context) error {
-		for _, orderDetailID := range orderDetailIDs {
-			err := u.r.UpdatePaymentStatus(txCtx, orderDetailID)
-			if err != nil {
-				return err
-			}
-		}
-		return nil
-	})
-	if err != nil {
-		return apperror.NewErrInternalServerError(appconstant.FieldErrUploadImage, apperror.ErrInternalServer, err)
-	}
 	return nil
 }
 
@@ -141,20 +55,13 @@ func (u userOrderUsecaseImpl) UpdateDeliveryStatus(c context.Context, order enti
 		return apperror.NewErrStatusBadRequest(appconstant.FieldErrConfirmDelivery, apperror.ErrOrderNotExists, apperror.ErrOrderNotExists)
 	}
 
-	status, err := u.r.GetStatusByOrderDetailID(c, order.OrderDetails[0].ID)
-	if err != nil {
-		return err
-	}
-	if status != appconstant.StatusShipped {
-		return apperror.NewErrStatusBadRequest(appconstant.FieldErrConfirmDelivery, apperror.ErrOrderCannotBeCompleted, apperror.ErrOrderCannotBeCompleted)
-	}
-
-	err = u.r.UpdateDeliveryStatusByOrderDetailID(c, order.OrderDetails[0].ID)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	userID := order.UserID
+	return u.os.Transition(c, orderStatusEntity.Transition{
+		OrderDetailID: order.OrderDetails[0].ID,
+		To:            appconstant.StatusDelivered,
+		Actor:         appconstant.ActorUser,
+		ActorID:       &userID,
+	})
 }
 
 func groupByOrderDetail(rows []entity.OrderProductDetail) []entity.OrderDetail {
@@ -162,10 +69,11 @@ func groupByOrderDetail(rows []entity.OrderProductDetail) []entity.OrderDetail {
 	for _, row := range rows {
 		if _, exists := grouped[row.OrderDetail.ID]; !exists {
 			order := &entity.Order{
-				ID:         row.OrderDetail.Order.ID,
-				UserID:     row.OrderDetail.Order.UserID,
-				TotalPrice: row.OrderDetail.Order.TotalPrice,
-				CreatedAt:  row.OrderDetail.Order.CreatedAt,
+				ID:              row.OrderDetail.Order.ID,
+				UserID:          row.OrderDetail.Order.UserID,
+				TotalPrice:      row.OrderDetail.Order.TotalPrice,
+				CreatedAt:       row.OrderDetail.Order.CreatedAt,
+				PaymentDeadline: row.OrderDetail.Order.PaymentDeadline,
 			}
 			grouped[row.OrderDetail.ID] = &entity.OrderDetail{
 				ID:                  row.OrderDetail.ID,
@@ -174,6 +82,8 @@ func groupByOrderDetail(rows []entity.OrderProductDetail) []entity.OrderDetail {
 				PharmacyName:        row.OrderDetail.PharmacyName,
 				Status:              row.OrderDetail.Status,
 				LogisticPrice:       row.OrderDetail.LogisticPrice,
+				Courier:             row.OrderDetail.Courier,
+				TrackingNumber:      row.OrderDetail.TrackingNumber,
 				OrderProductDetails: []entity.OrderProductDetail{},
 				Order:               *order,
 			}
@@ -200,11 +110,12 @@ func groupByOrder(rows []entity.OrderDetail) []entity.Order {
 	for _, row := range rows {
 		if _, exists := grouped[row.Order.ID]; !exists {
 			grouped[row.Order.ID] = &entity.Order{
-				ID:           row.Order.ID,
-				UserID:       row.Order.UserID,
-				TotalPrice:   row.Order.TotalPrice,
-				CreatedAt:    row.Order.CreatedAt,
-				OrderDetails: []entity.OrderDetail{},
+				ID:              row.Order.ID,
+				UserID:          row.Order.UserID,
+				TotalPrice:      row.Order.TotalPrice,
+				CreatedAt:       row.Order.CreatedAt,
+				PaymentDeadline: row.Order.PaymentDeadline,
+				OrderDetails:    []entity.OrderDetail{},
 			}
 		}
 		grouped[row.Order.ID].OrderDetails = append(grouped[row.Order.ID].OrderDetails, row)
@@ -222,6 +133,34 @@ func (u userOrderUsecaseImpl) GetDetailedOrders(c context.Context, filter entity
 		return nil, err
 	}
 	groupedByOrderDetail := groupByOrderDetail(orderProductDetails)
+	err = u.attachShipmentEvents(c, groupedByOrderDetail)
+	if err != nil {
+		return nil, err
+	}
 	groupedByOrder := groupByOrder(groupedByOrderDetail)
 	return groupedByOrder, nil
 }
+
+// attachShipmentEvents loads the tracking timeline of every shipped order
+// detail in a single query.
+func (u userOrderUsecaseImpl) attachShipmentEvents(c context.Context, orderDetails []entity.OrderDetail) error {
+	shippedIDs := []int{}
+	for _, detail := range orderDetails {
+		if detail.TrackingNumber != nil {
+			shippedIDs = append(shippedIDs, detail.ID)
+		}
+	}
+	events, err := u.r.GetShipmentEvents(c, shippedIDs)
+	if err != nil {
+		return err
+	}
+
+	eventsByDetail := make(map[int][]entity.ShipmentEvent)
+	for _, event := range events {
+		eventsByDetail[event.OrderDetailID] = append(eventsByDetail[event.OrderDetailID], event)
+	}
+	for i := range orderDetails {
+		orderDetails[i].ShipmentEvents = eventsByDetail[orderDetails[i].ID]
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package converter
+
+import (
+	"montelukast/modules/voucher/dto"
+	"montelukast/modules/voucher/entity"
+)
+
+type VoucherRequestConverter struct{}
+
+func (c VoucherRequestConverter) ToEntity(voucherReq dto.VoucherRequest) entity.Voucher {
+	return entity.Voucher{
+		Code:              voucherReq.Code,
+		DiscountType:      voucherReq.DiscountType,
+		DiscountValue:     voucherReq.DiscountValue,
+		MaxDiscount:       voucherReq.MaxDiscount,
+		MinSpend:          voucherReq.MinSpend,
+		StartAt:           voucherReq.StartAt,
+		EndAt:             voucherReq.EndAt,
+		UsageLimit:        voucherReq.UsageLimit,
+		UsageLimitPerUser: voucherReq.UsageLimitPerUser,
+		PartnerID:         voucherReq.PartnerID,
+		PharmacyID:        voucherReq.PharmacyID,
+		CategoryID:        voucherReq.CategoryID,
+		IsActive:          voucherReq.IsActive,
+	}
+}
+
+type VoucherResponseConverter struct{}
+
+func (c VoucherResponseConverter) ToDto(voucher entity.Voucher) dto.VoucherResponse {
+	var maxDiscount *string
+	if voucher.MaxDiscount != nil {
+		value := voucher.MaxDiscount.String()
+		maxDiscount = &value
+	}
+	return dto.VoucherResponse{
+		ID:                voucher.ID,
+		Code:              voucher.Code,
+		DiscountType:      voucher.DiscountType,
+		DiscountValue:     voucher.DiscountValue.String(),
+		MaxDiscount:       maxDiscount,
+		MinSpend:          voucher.MinSpend.String(),
+		StartAt:           voucher.StartAt,
+		EndAt:             voucher.EndAt,
+		UsageLimit:        voucher.UsageLimit,
+		UsageLimitPerUser: voucher.UsageLimitPerUser,
+		UsageCount:        voucher.UsageCount,
+		PartnerID:         voucher.PartnerID,
+		PharmacyID:        voucher.PharmacyID,
+		CategoryID:        voucher.CategoryID,
+		IsActive:          voucher.IsActive,
+		IsInPeriod:        voucher.IsInPeriod,
+	}
+}
+
+type VoucherFilterConverter struct{}
+
+func (c VoucherFilterConverter) ToEntity(filterReq dto.VoucherFilterRequest) entity.VoucherFilter {
+	return entity.VoucherFilter{
+		Code:  filterReq.Code,
+		Limit: filterReq.Limit,
+		Page:  filterReq.Page,
+	}
+}
@@ -0,0 +1,54 @@
+package dto
+
+import (
+	"montelukast/pkg/pagination"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type VoucherRequest struct {
+	Code              string           `json:"code" binding:"required,min=3"`
+	DiscountType      string           `json:"discount_type" binding:"required"`
+	DiscountValue     decimal.Decimal  `json:"discount_value"`
+	MaxDiscount       *decimal.Decimal `json:"max_discount"`
+	MinSpend          decimal.Decimal  `json:"min_spend"`
+	StartAt           time.Time        `json:"start_at" binding:"required"`
+	EndAt             time.Time        `json:"end_at" binding:"required"`
+	UsageLimit        *int             `json:"usage_limit" binding:"omitempty,gte=1"`
+	UsageLimitPerUser *int             `json:"usage_limit_per_user" binding:"omitempty,gte=1"`
+	PartnerID         *int             `json:"partner_id"`
+	PharmacyID        *int             `json:"pharmacy_id"`
+	CategoryID        *int             `json:"category_id"`
+	IsActive          bool             `json:"is_active"`
+}
+
+type VoucherResponse struct {
+	ID                int       `json:"id"`
+	Code              string    `json:"code"`
+	DiscountType      string    `json:"discount_type"`
+	DiscountValue     string    `json:"discount_value"`
+	MaxDiscount       *string   `json:"max_discount"`
+	MinSpend          string    `json:"min_spend"`
+	StartAt           time.Time `json:"start_at"`
+	EndAt             time.Time `json:"end_at"`
+	UsageLimit        *int      `json:"usage_limit"`
+	UsageLimitPerUser *int      `json:"usage_limit_per_user"`
+	UsageCount        int       `json:"usage_count"`
+	PartnerID         *int      `json:"partner_id"`
+	PharmacyID        *int      `json:"pharmacy_id"`
+	CategoryID        *int      `json:"category_id"`
+	IsActive          bool      `json:"is_active"`
+	IsInPeriod        bool      `json:"is_in_period"`
+}
+
+type PaginatedVouchersResponse struct {
+	Pagination pagination.PaginationResponse `json:"pagination"`
+	Vouchers   []VoucherResponse             `json:"list_item"`
+}
+
+type VoucherFilterRequest struct {
+	Code  string `form:"code"`
+	Limit int    `form:"limit"`
+	Page  int    `form:"page"`
+}
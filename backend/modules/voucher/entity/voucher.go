@@ -0,0 +1,84 @@
+package entity
+
+import (
+	"montelukast/pkg/pagination"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type Voucher struct {
+	ID                int
+	Code              string
+	DiscountType      string
+	DiscountValue     decimal.Decimal
+	MaxDiscount       *decimal.Decimal
+	MinSpend          decimal.Decimal
+	StartAt           time.Time
+	EndAt             time.Time
+	UsageLimit        *int
+	UsageLimitPerUser *int
+	PartnerID         *int
+	PharmacyID        *int
+	CategoryID        *int
+	IsActive          bool
+	IsInPeriod        bool
+	UsageCount        int
+}
+
+type PaginatedVouchers struct {
+	Pagination pagination.Pagination
+	Vouchers   []Voucher
+}
+
+type VoucherFilter struct {
+	Code  string
+	Limit int
+	Page  int
+}
+
+func (f *VoucherFilter) GetLimit() int {
+	if f.Limit < 1 {
+		return 10
+	}
+	return f.Limit
+}
+
+func (f *VoucherFilter) GetOffset() int {
+	if f.Page < 1 {
+		return 0
+	}
+	return (f.Page - 1) * f.GetLimit()
+}
+
+type CartGroup struct {
+	PharmacyID   int
+	ShippingCost decimal.Decimal
+	Items        []CartItem
+}
+
+type CartItem struct {
+	PharmacyProductID int
+	Subtotal          decimal.Decimal
+}
+
+type VoucherDiscount struct {
+	VoucherID        int
+	Code             string
+	Discount         decimal.Decimal
+	ShippingDiscount decimal.Decimal
+	Groups           []GroupDiscount
+}
+
+type GroupDiscount struct {
+	PharmacyID       int
+	Discount         decimal.Decimal
+	ShippingDiscount decimal.Decimal
+}
+
+type VoucherUsage struct {
+	VoucherID int
+	UserID    int
+	OrderID   int
+	Discount  decimal.Decimal
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"montelukast/modules/voucher/converter"
+	"montelukast/modules/voucher/dto"
+	"montelukast/modules/voucher/usecase"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"montelukast/pkg/pagination"
+	"montelukast/pkg/wrapper"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VoucherHandler struct {
+	u usecase.VoucherUsecase
+}
+
+func NewVoucherHandler(u usecase.VoucherUsecase) VoucherHandler {
+	return VoucherHandler{
+		u: u,
+	}
+}
+
+func (h VoucherHandler) AddVoucherHandler(c *gin.Context) {
+	err := apperror.JsonValidator(c)
+	if err != nil {
+		err := apperror.NewErrStatusBadRequest(appconstant.FieldErrAddVoucher, apperror.ErrInvalidJSON, err)
+		c.Error(err)
+		return
+	}
+	voucherReq := dto.VoucherRequest{}
+
+	err = c.ShouldBindJSON(&voucherReq)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = h.u.AddVoucher(c, converter.VoucherRequestConverter{}.ToEntity(voucherReq))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(nil, "add voucher success!", nil)
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h VoucherHandler) UpdateVoucherHandler(c *gin.Context) {
+	voucherID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		err = apperror.NewErrStatusBadRequest(appconstant.FieldErrUpdateVoucher, apperror.ErrConvertVariableType, err)
+		c.Error(err)
+		return
+	}
+
+	err = apperror.JsonValidator(c)
+	if err != nil {
+		err := apperror.NewErrStatusBadRequest(appconstant.FieldErrUpdateVoucher, apperror.ErrInvalidJSON, err)
+		c.Error(err)
+		return
+	}
+	voucherReq := dto.VoucherRequest{}
+
+	err = c.ShouldBindJSON(&voucherReq)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	voucher := converter.VoucherRequestConverter{}.ToEntity(voucherReq)
+	voucher.ID = voucherID
+	err = h.u.UpdateVoucher(c, voucher)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(nil, "update voucher success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h VoucherHandler) DeleteVoucherHandler(c *gin.Context) {
+	voucherID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		err = apperror.NewErrStatusBadRequest(appconstant.FieldErrDeleteVoucher, apperror.ErrConvertVariableType, err)
+		c.Error(err)
+		return
+	}
+
+	err = h.u.DeleteVoucher(c, voucherID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(nil, "delete voucher success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h VoucherHandler) GetVoucherHandler(c *gin.Context) {
+	voucherID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		err = apperror.NewErrStatusBadRequest(appconstant.FieldErrGetVoucher, apperror.ErrConvertVariableType, err)
+		c.Error(err)
+		return
+	}
+
+	voucher, err := h.u.GetVoucher(c, voucherID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := wrapper.ResponseData(converter.VoucherResponseConverter{}.ToDto(*voucher), "get voucher success!", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h VoucherHandler) GetVouchersHandler(c *gin.Context) {
+	filterReq := dto.VoucherFilterRequest{}
+	err := c.ShouldBindQuery(&filterReq)
+	if err != nil {
+		err := apperror.NewErrStatusBadRequest(appconstant.FieldErrGetVouchers, apperror.ErrConvertVariableType, err)
+		c.Error(err)
+		return
+	}
+
+	paginatedVouchers, err := h.u.GetVouchers(c, converter.VoucherFilterConverter{}.ToEntity(filterReq))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	vouchers := []dto.VoucherResponse{}
+	for _, voucher := range paginatedVouchers.Vouchers {
+		vouchers = append(vouchers, converter.VoucherResponseConverter{}.ToDto(voucher))
+	}
+	result := dto.PaginatedVouchersResponse{
+		Pagination: pagination.PaginationConverter{}.ToDto(paginatedVouchers.Pagination),
+		Vouchers:   vouchers,
+	}
+
+	response := wrapper.ResponseData(result, "get vouchers success!", nil)
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,332 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"montelukast/modules/voucher/entity"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"montelukast/pkg/transaction"
+)
+
+type VoucherRepo interface {
+	AddVoucher(c context.Context, voucher entity.Voucher) error
+	UpdateVoucher(c context.Context, voucher entity.Voucher) error
+	DeleteVoucherByID(c context.Context, id int) error
+	IsVoucherExistsByID(c context.Context, id int) (bool, error)
+	IsVoucherCodeExists(c context.Context, code string, excludeID int) (bool, error)
+	IsPartnerExistsByID(c context.Context, id int) (bool, error)
+	IsPharmacyExistsByID(c context.Context, id int) (bool, error)
+	IsCategoryExistsByID(c context.Context, id int) (bool, error)
+	GetVoucherByID(c context.Context, id int) (*entity.Voucher, error)
+	GetVoucherByCode(c context.Context, code string) (*entity.Voucher, error)
+	GetVouchers(c context.Context, filter entity.VoucherFilter) ([]entity.Voucher, error)
+	GetTotalVoucher(c context.Context, filter entity.VoucherFilter) (int, error)
+	IsProductEligible(c context.Context, voucher entity.Voucher, pharmacyProductID int) (bool, error)
+	GetUsageCount(c context.Context, voucherID int) (int, error)
+	GetUserUsageCount(c context.Context, voucherID int, userID int) (int, error)
+	AddVoucherUsage(c context.Context, usage entity.VoucherUsage) error
+}
+
+type voucherRepoImpl struct {
+	db *sql.DB
+}
+
+func NewVoucherRepo(dbConn *sql.DB) voucherRepoImpl {
+	return voucherRepoImpl{
+		db: dbConn,
+	}
+}
+
+const voucherColumns = `v.id, v.code, v.discount_type, v.discount_value, v.max_discount, v.min_spend,
+				v.start_at, v.end_at, v.usage_limit, v.usage_limit_per_user,
+				v.partner_id, v.pharmacy_id, v.product_category_id, v.is_active,
+				NOW() BETWEEN v.start_at AND v.end_at is_in_period`
+
+const usedOrdersQuery = `SELECT COUNT(DISTINCT vu.order_id)
+				FROM voucher_usages vu
+				WHERE vu.voucher_id = $1 AND vu.deleted_at IS NULL
+				AND EXISTS (
+					SELECT 1 FROM order_details od
+					WHERE od.order_id = vu.order_id AND od.status <> $2 AND od.deleted_at IS NULL
+				)`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanVoucher(row rowScanner) (*entity.Voucher, error) {
+	var voucher entity.Voucher
+	err := row.Scan(
+		&voucher.ID,
+		&voucher.Code,
+		&voucher.DiscountType,
+		&voucher.DiscountValue,
+		&voucher.MaxDiscount,
+		&voucher.MinSpend,
+		&voucher.StartAt,
+		&voucher.EndAt,
+		&voucher.UsageLimit,
+		&voucher.UsageLimitPerUser,
+		&voucher.PartnerID,
+		&voucher.PharmacyID,
+		&voucher.CategoryID,
+		&voucher.IsActive,
+		&voucher.IsInPeriod,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &voucher, nil
+}
+
+func (r voucherRepoImpl) AddVoucher(c context.Context, voucher entity.Voucher) error {
+	query := `INSERT INTO vouchers (code, discount_type, discount_value, max_discount, min_spend, start_at, end_at,
+				usage_limit, usage_limit_per_user, partner_id, pharmacy_id, product_category_id, is_active)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+
+	_, err := r.db.ExecContext(c, query, voucher.Code, voucher.DiscountType, voucher.DiscountValue, voucher.MaxDiscount, voucher.MinSpend,
+		voucher.StartAt, voucher.EndAt, voucher.UsageLimit, voucher.UsageLimitPerUser,
+		voucher.PartnerID, voucher.PharmacyID, voucher.CategoryID, voucher.IsActive)
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+func (r voucherRepoImpl) UpdateVoucher(c context.Context, voucher entity.Voucher) error {
+	query := `UPDATE vouchers
+			SET code = $2, discount_type = $3, discount_value = $4, max_discount = $5, min_spend = $6,
+				start_at = $7, end_at = $8, usage_limit = $9, usage_limit_per_user = $10,
+				partner_id = $11, pharmacy_id = $12, product_category_id = $13, is_active = $14,
+				updated_at = NOW()
+			WHERE id = $1 AND deleted_at IS NULL`
+
+	_, err := r.db.ExecContext(c, query, voucher.ID, voucher.Code, voucher.DiscountType, voucher.DiscountValue, voucher.MaxDiscount, voucher.MinSpend,
+		voucher.StartAt, voucher.EndAt, voucher.UsageLimit, voucher.UsageLimitPerUser,
+		voucher.PartnerID, voucher.PharmacyID, voucher.CategoryID, voucher.IsActive)
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+func (r voucherRepoImpl) DeleteVoucherByID(c context.Context, id int) error {
+	query := `UPDATE vouchers SET deleted_at = NOW() WHERE id = $1`
+
+	_, err := r.db.ExecContext(c, query, id)
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
+
+func (r voucherRepoImpl) IsVoucherExistsByID(c context.Context, id int) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM vouchers WHERE id = $1 AND deleted_at IS NULL)`
+
+	var isExists bool
+	err := r.db.QueryRowContext(c, query, id).Scan(&isExists)
+	if err != nil && err != sql.ErrNoRows {
+		return isExists, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return isExists, nil
+}
+
+func (r voucherRepoImpl) IsVoucherCodeExists(c context.Context, code string, excludeID int) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM vouchers WHERE code = $1 AND id <> $2)`
+
+	var isExists bool
+	err := r.db.QueryRowContext(c, query, code, excludeID).Scan(&isExists)
+	if err != nil && err != sql.ErrNoRows {
+		return isExists, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return isExists, nil
+}
+
+func (r voucherRepoImpl) IsPartnerExistsByID(c context.Context, id int) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM partners WHERE id = $1 AND deleted_at IS NULL)`
+
+	var isExists bool
+	err := r.db.QueryRowContext(c, query, id).Scan(&isExists)
+	if err != nil && err != sql.ErrNoRows {
+		return isExists, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return isExists, nil
+}
+
+func (r voucherRepoImpl) IsPharmacyExistsByID(c context.Context, id int) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM pharmacies WHERE id = $1 AND deleted_at IS NULL)`
+
+	var isExists bool
+	err := r.db.QueryRowContext(c, query, id).Scan(&isExists)
+	if err != nil && err != sql.ErrNoRows {
+		return isExists, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return isExists, nil
+}
+
+func (r voucherRepoImpl) IsCategoryExistsByID(c context.Context, id int) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM product_categories WHERE id = $1 AND deleted_at IS NULL)`
+
+	var isExists bool
+	err := r.db.QueryRowContext(c, query, id).Scan(&isExists)
+	if err != nil && err != sql.ErrNoRows {
+		return isExists, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return isExists, nil
+}
+
+func (r voucherRepoImpl) GetVoucherByID(c context.Context, id int) (*entity.Voucher, error) {
+	query := `SELECT ` + voucherColumns + `
+			FROM vouchers v
+			WHERE v.id = $1 AND v.deleted_at IS NULL`
+
+	voucher, err := scanVoucher(r.db.QueryRowContext(c, query, id))
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+
+	voucher.UsageCount, err = r.GetUsageCount(c, voucher.ID)
+	if err != nil {
+		return nil, err
+	}
+	return voucher, nil
+}
+
+func (r voucherRepoImpl) GetVoucherByCode(c context.Context, code string) (*entity.Voucher, error) {
+	tx := transaction.ExtractTx(c)
+
+	query := `SELECT ` + voucherColumns + `
+			FROM vouchers v
+			WHERE v.code = $1 AND v.deleted_at IS NULL`
+
+	var row *sql.Row
+	if tx != nil {
+		row = tx.QueryRowContext(c, query+` FOR UPDATE`, code)
+	} else {
+		row = r.db.QueryRowContext(c, query, code)
+	}
+	voucher, err := scanVoucher(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return voucher, nil
+}
+
+func (r voucherRepoImpl) GetVouchers(c context.Context, filter entity.VoucherFilter) ([]entity.Voucher, error) {
+	vouchers := []entity.Voucher{}
+
+	query := `SELECT ` + voucherColumns + `
+			FROM vouchers v
+			WHERE v.deleted_at IS NULL AND v.code ILIKE '%' || $1 || '%'
+			ORDER BY v.created_at DESC
+			LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.QueryContext(c, query, filter.Code, filter.GetLimit(), filter.GetOffset())
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		voucher, err := scanVoucher(rows)
+		if err != nil {
+			return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+		}
+		vouchers = append(vouchers, *voucher)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+
+	for i := range vouchers {
+		vouchers[i].UsageCount, err = r.GetUsageCount(c, vouchers[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return vouchers, nil
+}
+
+func (r voucherRepoImpl) GetTotalVoucher(c context.Context, filter entity.VoucherFilter) (int, error) {
+	query := `SELECT COUNT(*) FROM vouchers v WHERE v.deleted_at IS NULL AND v.code ILIKE '%' || $1 || '%'`
+
+	var total int
+	err := r.db.QueryRowContext(c, query, filter.Code).Scan(&total)
+	if err != nil {
+		return 0, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return total, nil
+}
+
+func (r voucherRepoImpl) IsProductEligible(c context.Context, voucher entity.Voucher, pharmacyProductID int) (bool, error) {
+	query := `SELECT EXISTS (
+				SELECT 1
+				FROM pharmacy_products pp
+				JOIN pharmacies ph ON ph.id = pp.pharmacy_id
+				WHERE pp.id = $1
+				AND ($2::bigint IS NULL OR ph.partner_id = $2)
+				AND ($3::bigint IS NULL OR ph.id = $3)
+				AND ($4::bigint IS NULL OR EXISTS (
+					SELECT 1 FROM product_multi_categories pmc
+					WHERE pmc.product_id = pp.product_id AND pmc.product_category_id = $4 AND pmc.deleted_at IS NULL
+				))
+			)`
+
+	var isEligible bool
+	err := r.db.QueryRowContext(c, query, pharmacyProductID, voucher.PartnerID, voucher.PharmacyID, voucher.CategoryID).Scan(&isEligible)
+	if err != nil && err != sql.ErrNoRows {
+		return isEligible, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return isEligible, nil
+}
+
+func (r voucherRepoImpl) GetUsageCount(c context.Context, voucherID int) (int, error) {
+	tx := transaction.ExtractTx(c)
+
+	var count int
+	var err error
+	if tx != nil {
+		err = tx.QueryRowContext(c, usedOrdersQuery, voucherID, appconstant.StatusCancelled).Scan(&count)
+	} else {
+		err = r.db.QueryRowContext(c, usedOrdersQuery, voucherID, appconstant.StatusCancelled).Scan(&count)
+	}
+	if err != nil {
+		return 0, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return count, nil
+}
+
+func (r voucherRepoImpl) GetUserUsageCount(c context.Context, voucherID int, userID int) (int, error) {
+	tx := transaction.ExtractTx(c)
+
+	query := usedOrdersQuery + ` AND vu.user_id = $3`
+
+	var count int
+	var err error
+	if tx != nil {
+		err = tx.QueryRowContext(c, query, voucherID, appconstant.StatusCancelled, userID).Scan(&count)
+	} else {
+		err = r.db.QueryRowContext(c, query, voucherID, appconstant.StatusCancelled, userID).Scan(&count)
+	}
+	if err != nil {
+		return 0, apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return count, nil
+}
+
+func (r voucherRepoImpl) AddVoucherUsage(c context.Context, usage entity.VoucherUsage) error {
+	tx := transaction.ExtractTx(c)
+
+	query := `INSERT INTO voucher_usages (voucher_id, user_id, order_id, discount) VALUES ($1, $2, $3, $4)`
+
+	_, err := tx.ExecContext(c, query, usage.VoucherID, usage.UserID, usage.OrderID, usage.Discount)
+	if err != nil {
+		return apperror.NewErrInternalServerError(appconstant.FieldErrServer, apperror.ErrInternalServer, err)
+	}
+	return nil
+}
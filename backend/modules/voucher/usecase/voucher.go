@@ -0,0 +1,311 @@
+package usecase
+
+import (
+	"context"
+	"math"
+	"montelukast/modules/voucher/entity"
+	"montelukast/modules/voucher/repository"
+	appconstant "montelukast/pkg/constant"
+	apperror "montelukast/pkg/error"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+type VoucherUsecase interface {
+	AddVoucher(c context.Context, voucher entity.Voucher) error
+	UpdateVoucher(c context.Context, voucher entity.Voucher) error
+	DeleteVoucher(c context.Context, id int) error
+	GetVoucher(c context.Context, id int) (*entity.Voucher, error)
+	GetVouchers(c context.Context, filter entity.VoucherFilter) (*entity.PaginatedVouchers, error)
+	CalculateDiscount(c context.Context, code string, userID int, groups []entity.CartGroup) (*entity.VoucherDiscount, error)
+	RecordUsage(c context.Context, discount entity.VoucherDiscount, userID int, orderID int) error
+}
+
+type voucherUsecaseImpl struct {
+	r repository.VoucherRepo
+}
+
+func NewVoucherUsecase(r repository.VoucherRepo) voucherUsecaseImpl {
+	return voucherUsecaseImpl{
+		r: r,
+	}
+}
+
+func (u voucherUsecaseImpl) AddVoucher(c context.Context, voucher entity.Voucher) error {
+	voucher.Code = normalizeCode(voucher.Code)
+	err := u.validateVoucher(c, &voucher, appconstant.FieldErrAddVoucher)
+	if err != nil {
+		return err
+	}
+
+	isCodeExists, err := u.r.IsVoucherCodeExists(c, voucher.Code, 0)
+	if err != nil {
+		return err
+	}
+	if isCodeExists {
+		return apperror.NewErrStatusBadRequest(appconstant.FieldErrAddVoucher, apperror.ErrVoucherAlreadyExists, apperror.ErrVoucherAlreadyExists)
+	}
+
+	return u.r.AddVoucher(c, voucher)
+}
+
+func (u voucherUsecaseImpl) UpdateVoucher(c context.Context, voucher entity.Voucher) error {
+	isExists, err := u.r.IsVoucherExistsByID(c, voucher.ID)
+	if err != nil {
+		return err
+	}
+	if !isExists {
+		return apperror.NewErrStatusNotFound(appconstant.FieldErrUpdateVoucher, apperror.ErrVoucherNotExists, apperror.ErrVoucherNotExists)
+	}
+
+	voucher.Code = normalizeCode(voucher.Code)
+	err = u.validateVoucher(c, &voucher, appconstant.FieldErrUpdateVoucher)
+	if err != nil {
+		return err
+	}
+
+	isCodeExists, err := u.r.IsVoucherCodeExists(c, voucher.Code, voucher.ID)
+	if err != nil {
+		return err
+	}
+	if isCodeExists {
+		return apperror.NewErrStatusBadRequest(appconstant.FieldErrUpdateVoucher, apperror.ErrVoucherAlreadyExists, apperror.ErrVoucherAlreadyExists)
+	}
+
+	return u.r.UpdateVoucher(c, voucher)
+}
+
+func (u voucherUsecaseImpl) DeleteVoucher(c context.Context, id int) error {
+	isExists, err := u.r.IsVoucherExistsByID(c, id)
+	if err != nil {
+		return err
+	}
+	if !isExists {
+		return apperror.NewErrStatusNotFound(appconstant.FieldErrDeleteVoucher, apperror.ErrVoucherNotExists, apperror.ErrVoucherNotExists)
+	}
+	return u.r.DeleteVoucherByID(c, id)
+}
+
+func (u voucherUsecaseImpl) GetVoucher(c context.Context, id int) (*entity.Voucher, error) {
+	isExists, err := u.r.IsVoucherExistsByID(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if !isExists {
+		return nil, apperror.NewErrStatusNotFound(appconstant.FieldErrGetVoucher, apperror.ErrVoucherNotExists, apperror.ErrVoucherNotExists)
+	}
+	return u.r.GetVoucherByID(c, id)
+}
+
+func (u voucherUsecaseImpl) GetVouchers(c context.Context, filter entity.VoucherFilter) (*entity.PaginatedVouchers, error) {
+	filter.Code = normalizeCode(filter.Code)
+	vouchers, err := u.r.GetVouchers(c, filter)
+	if err != nil {
+		return nil, err
+	}
+	totalItem, err := u.r.GetTotalVoucher(c, filter)
+	if err != nil {
+		return nil, err
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+
+	var paginatedVouchers entity.PaginatedVouchers
+	paginatedVouchers.Vouchers = vouchers
+	paginatedVouchers.Pagination.TotalItem = totalItem
+	paginatedVouchers.Pagination.CurrentPage = filter.Page
+	paginatedVouchers.Pagination.TotalPage = int(math.Ceil(float64(totalItem) / float64(filter.GetLimit())))
+	paginatedVouchers.Pagination.Limit = filter.GetLimit()
+	return &paginatedVouchers, nil
+}
+
+func (u voucherUsecaseImpl) CalculateDiscount(c context.Context, code string, userID int, groups []entity.CartGroup) (*entity.VoucherDiscount, error) {
+	voucher, err := u.r.GetVoucherByCode(c, normalizeCode(code))
+	if err != nil {
+		return nil, err
+	}
+	if voucher == nil {
+		return nil, apperror.NewErrStatusNotFound(appconstant.FieldErrApplyVoucher, apperror.ErrVoucherNotExists, apperror.ErrVoucherNotExists)
+	}
+	if !voucher.IsActive || !voucher.IsInPeriod {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrApplyVoucher, apperror.ErrVoucherNotActive, apperror.ErrVoucherNotActive)
+	}
+
+	if voucher.UsageLimit != nil {
+		usageCount, err := u.r.GetUsageCount(c, voucher.ID)
+		if err != nil {
+			return nil, err
+		}
+		if usageCount >= *voucher.UsageLimit {
+			return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrApplyVoucher, apperror.ErrVoucherUsageLimitReached, apperror.ErrVoucherUsageLimitReached)
+		}
+	}
+	if voucher.UsageLimitPerUser != nil {
+		usageCount, err := u.r.GetUserUsageCount(c, voucher.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if usageCount >= *voucher.UsageLimitPerUser {
+			return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrApplyVoucher, apperror.ErrVoucherUsageLimitReached, apperror.ErrVoucherUsageLimitReached)
+		}
+	}
+
+	eligibleSubtotals := make([]decimal.Decimal, len(groups))
+	eligibleShippings := make([]decimal.Decimal, len(groups))
+	var eligibleSubtotal, eligibleShipping decimal.Decimal
+	for i, group := range groups {
+		for _, item := range group.Items {
+			isEligible, err := u.r.IsProductEligible(c, *voucher, item.PharmacyProductID)
+			if err != nil {
+				return nil, err
+			}
+			if isEligible {
+				eligibleSubtotals[i] = eligibleSubtotals[i].Add(item.Subtotal)
+			}
+		}
+		if eligibleSubtotals[i].IsPositive() {
+			eligibleShippings[i] = group.ShippingCost
+		}
+		eligibleSubtotal = eligibleSubtotal.Add(eligibleSubtotals[i])
+		eligibleShipping = eligibleShipping.Add(eligibleShippings[i])
+	}
+	if !eligibleSubtotal.IsPositive() {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrApplyVoucher, apperror.ErrVoucherNotApplicable, apperror.ErrVoucherNotApplicable)
+	}
+	if eligibleSubtotal.LessThan(voucher.MinSpend) {
+		return nil, apperror.NewErrStatusBadRequest(appconstant.FieldErrApplyVoucher, apperror.ErrVoucherMinSpendNotMet, apperror.ErrVoucherMinSpendNotMet)
+	}
+
+	discount := entity.VoucherDiscount{
+		VoucherID: voucher.ID,
+		Code:      voucher.Code,
+		Groups:    make([]entity.GroupDiscount, len(groups)),
+	}
+	for i, group := range groups {
+		discount.Groups[i].PharmacyID = group.PharmacyID
+	}
+
+	switch voucher.DiscountType {
+	case appconstant.VoucherTypePercentage:
+		amount := eligibleSubtotal.Mul(voucher.DiscountValue).Div(decimal.NewFromInt(appconstant.MaxVoucherPercentage)).Round(2)
+		discount.Discount = capDiscount(amount, voucher.MaxDiscount)
+	case appconstant.VoucherTypeFixed:
+		discount.Discount = capDiscount(voucher.DiscountValue, &eligibleSubtotal)
+	case appconstant.VoucherTypeFreeShipping:
+		discount.ShippingDiscount = capDiscount(eligibleShipping, voucher.MaxDiscount)
+	}
+
+	for i, share := range allocate(discount.Discount, eligibleSubtotals) {
+		discount.Groups[i].Discount = share
+	}
+	for i, share := range allocate(discount.ShippingDiscount, eligibleShippings) {
+		discount.Groups[i].ShippingDiscount = share
+	}
+	return &discount, nil
+}
+
+func (u voucherUsecaseImpl) RecordUsage(c context.Context, discount entity.VoucherDiscount, userID int, orderID int) error {
+	return u.r.AddVoucherUsage(c, entity.VoucherUsage{
+		VoucherID: discount.VoucherID,
+		UserID:    userID,
+		OrderID:   orderID,
+		Discount:  discount.Discount.Add(discount.ShippingDiscount),
+	})
+}
+
+func (u voucherUsecaseImpl) validateVoucher(c context.Context, voucher *entity.Voucher, field string) error {
+	switch voucher.DiscountType {
+	case appconstant.VoucherTypePercentage:
+		if !voucher.DiscountValue.IsPositive() || voucher.DiscountValue.GreaterThan(decimal.NewFromInt(appconstant.MaxVoucherPercentage)) {
+			return apperror.NewErrStatusBadRequest(field, apperror.ErrInvalidVoucherValue, apperror.ErrInvalidVoucherValue)
+		}
+	case appconstant.VoucherTypeFixed:
+		if !voucher.DiscountValue.IsPositive() {
+			return apperror.NewErrStatusBadRequest(field, apperror.ErrInvalidVoucherValue, apperror.ErrInvalidVoucherValue)
+		}
+	case appconstant.VoucherTypeFreeShipping:
+		voucher.DiscountValue = decimal.Zero
+	default:
+		return apperror.NewErrStatusBadRequest(field, apperror.ErrInvalidVoucherType, apperror.ErrInvalidVoucherType)
+	}
+	if voucher.MaxDiscount != nil && !voucher.MaxDiscount.IsPositive() {
+		return apperror.NewErrStatusBadRequest(field, apperror.ErrInvalidVoucherValue, apperror.ErrInvalidVoucherValue)
+	}
+	if voucher.MinSpend.IsNegative() {
+		return apperror.NewErrStatusBadRequest(field, apperror.ErrInvalidVoucherValue, apperror.ErrInvalidVoucherValue)
+	}
+	if !voucher.EndAt.After(voucher.StartAt) {
+		return apperror.NewErrStatusBadRequest(field, apperror.ErrInvalidVoucherPeriod, apperror.ErrInvalidVoucherPeriod)
+	}
+
+	if voucher.PartnerID != nil {
+		isExists, err := u.r.IsPartnerExistsByID(c, *voucher.PartnerID)
+		if err != nil {
+			return err
+		}
+		if !isExists {
+			return apperror.NewErrStatusBadRequest(field, apperror.ErrPartnerNotExists, apperror.ErrPartnerNotExists)
+		}
+	}
+	if voucher.PharmacyID != nil {
+		isExists, err := u.r.IsPharmacyExistsByID(c, *voucher.PharmacyID)
+		if err != nil {
+			return err
+		}
+		if !isExists {
+			return apperror.NewErrStatusBadRequest(field, apperror.ErrPharmacyNotExists, apperror.ErrPharmacyNotExists)
+		}
+	}
+	if voucher.CategoryID != nil {
+		isExists, err := u.r.IsCategoryExistsByID(c, *voucher.CategoryID)
+		if err != nil {
+			return err
+		}
+		if !isExists {
+			return apperror.NewErrStatusBadRequest(field, apperror.ErrCategoryNotExists, apperror.ErrCategoryNotExists)
+		}
+	}
+	return nil
+}
+
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func capDiscount(amount decimal.Decimal, limit *decimal.Decimal) decimal.Decimal {
+	if limit != nil && amount.GreaterThan(*limit) {
+		return *limit
+	}
+	return amount
+}
+
+func allocate(amount decimal.Decimal, weights []decimal.Decimal) []decimal.Decimal {
+	shares := make([]decimal.Decimal, len(weights))
+	var total decimal.Decimal
+	last := -1
+	for i, weight := range weights {
+		if weight.IsPositive() {
+			total = total.Add(weight)
+			last = i
+		}
+	}
+	if last < 0 {
+		return shares
+	}
+
+	remaining := amount
+	for i, weight := range weights {
+		if !weight.IsPositive() {
+			continue
+		}
+		if i == last {
+			shares[i] = remaining
+			break
+		}
+		shares[i] = amount.Mul(weight).Div(total).Round(2)
+		remaining = remaining.Sub(shares[i])
+	}
+	return shares
+}
@@ -87,6 +87,46 @@ const (
 	FieldErrGetPharmacyProducts       = "get pharmacy products"
 	FieldUpdateProductPhoto           = "update product photo"
 	FieldErrChangeStatus              = "error change status"
+	FieldErrUploadPrescription        = "upload prescription"
+	FieldErrAttachPrescription        = "attach prescription"
+	FieldErrGetPrescriptions          = "get prescriptions"
+	FieldErrReviewPrescription        = "review prescription"
+	FieldErrOrderStatus               = "order status"
+	FieldErrCreatePayment             = "create payment"
+	FieldErrGetPayment                = "get payment"
+	FieldErrPaymentWebhook            = "payment webhook"
+	FieldErrGetPaymentProofs          = "get payment proofs"
+	FieldErrReviewPaymentProof        = "review payment proof"
+	FieldErrAddVoucher                = "add voucher"
+	FieldErrUpdateVoucher             = "update voucher"
+	FieldErrDeleteVoucher             = "delete voucher"
+	FieldErrGetVoucher                = "get voucher"
+	FieldErrGetVouchers               = "get vouchers"
+	FieldErrApplyVoucher              = "apply voucher"
+	FieldErrRequestReturn             = "request return"
+	FieldErrGetReturns                = "get returns"
+	FieldErrReviewReturn              = "review return"
+	FieldErrGetInvoice                = "get invoice"
+	FieldErrBuyAgain                  = "buy again"
+	FieldErrOptimizeCart              = "optimize cart"
+	FieldErrRequestStockMutation      = "request stock mutation"
+	FieldErrGetStockMutations         = "get stock mutations"
+	FieldErrReviewStockMutation       = "review stock mutation"
+	FieldErrGetStockMovements         = "get stock movements"
+	FieldErrResetStockUpdateLimit     = "reset stock update limit"
+	FieldErrImportInventory           = "import inventory"
+	FieldErrExportInventory           = "export inventory"
+	FieldErrUpdateLowStockThreshold   = "update low stock threshold"
+	FieldErrGetLowStockProducts       = "get low stock products"
+	FieldErrGetReorderSuggestions     = "get reorder suggestions"
+	FieldErrLowStockAlert             = "low stock alert"
+	FieldErrAddLogisticPartner        = "add logistic partner"
+	FieldErrUpdateLogisticPartner     = "update logistic partner"
+	FieldErrDeleteLogisticPartner     = "delete logistic partner"
+	FieldErrGetLogisticPartners       = "get logistic partners"
+	FieldErrSetPharmacyLogistics      = "set pharmacy logistic partners"
+	FieldErrUpdateServiceArea         = "update pharmacy service area"
+	FieldErrTrackShipment             = "track shipment"
 )
 
 const (
@@ -101,11 +141,6 @@ const (
 )
 
 const (
-	IDLogisticPartnerSameDay     = 2
-	IDLogisticPartnerInstantDay  = 1
-	IDLogisticNextDay            = 3
-	SameDay                      = "Same Day"
-	Instant                      = "Instant"
 	EtdSameDay                   = "1 day"
 	EtdInstant                   = "1 day"
 	SameDayPrice                 = 1000000
@@ -116,24 +151,190 @@ const (
 	OrderInitialvaluePharmacist  = "desc"
 	OngkirTimeExpiration         = 5 * time.Minute
 	CartRedisExpiration          = 5 * time.Minute
-	OngkirRedisKey               = "shipping:%d:%d"
-	MedicineWeight               = 1000
+	CartOptimizationRedisKey     = "cart:%d:optimization:%s"
+	MaxCartOptimizerPharmacies   = 10
+	MaxCartOptimizerQuotes       = 3
+	CartOptimizerBaseShipping    = 10000
+	CartOptimizerShippingPerKm   = 2500
+	CartOptimizerShippingPerKg   = 5000
+	CheckoutLockExpiration       = 1 * time.Minute
+	CheckoutResultExpiration     = 24 * time.Hour
+	CheckoutIdempotencyRedisKey  = "checkout:%d:idempotency:%s"
+	CheckoutProcessingMarker     = "processing"
+	IdempotencyKeyHeader         = "Idempotency-Key"
+	OngkirRedisKey               = "shipping:%d:%d:%s"
+	VolumetricDivisor            = 6000
+	MinParcelWeight              = 1
 	DefaultStatusOrder           = "Waiting for Payment"
-	PaymentCompleteTime          = 60000
-	OrderCompleteTime            = 10000
+	DefaultPaymentDeadline       = 24 * time.Hour
+	DefaultAutoConfirmDays       = 7
+	AutoConfirmReminderTime      = 24 * time.Hour
+	MaxMessageDelay              = (1<<32 - 1) * time.Millisecond
+	DefaultReturnWindowDays      = 7
+	MaxReturnImages              = 5
+	MaxStockMutationSources      = 5
+	DefaultStockMutationRadiusKm = 25
+	StockUpdateRedisKey          = "stock-update:%s:%d"
+	MaxInventoryImportRows       = 1000
+	MaxInventoryImportSize       = 2 << 20
+	DefaultSalesVelocityDays     = 30
+	DefaultReorderCoverageDays   = 14
+	LowStockAlertHour            = 7
+	LowStockAlertRedisKey        = "low-stock-alert:%s"
 )
 
 const (
-	StatusCancelled  = "Cancelled"
-	StatusDelivered  = "Delivered"
-	StatusShipped    = "Shipped"
-	StatusProcessing = "Processing"
-	StatusPending    = "Pending"
+	StatusCancelled     = "Cancelled"
+	StatusDelivered     = "Delivered"
+	StatusShipped       = "Shipped"
+	StatusProcessing    = "Processing"
+	StatusPending       = "Pending"
+	StatusPaymentReview = "Payment Review"
+)
+
+const (
+	VoucherTypePercentage   = "percentage"
+	VoucherTypeFixed        = "fixed"
+	VoucherTypeFreeShipping = "free_shipping"
+	MaxVoucherPercentage    = 100
+)
+
+const (
+	DeliveryActionReminder    = "reminder"
+	DeliveryActionAutoConfirm = "auto_confirm"
+	DeliveryActionTrack       = "track"
+)
+
+const (
+	ActorUser       = "user"
+	ActorPharmacist = "pharmacist"
+	ActorAdmin      = "admin"
+	ActorSystem     = "system"
+)
+
+const (
+	PaymentProviderSimulated      = "simulated"
+	PaymentProviderManualTransfer = "manual_transfer"
+	PaymentStatusPending          = "Pending"
+	PaymentStatusPaid             = "Paid"
+	PaymentStatusFailed           = "Failed"
+	PaymentStatusRefundRequired   = "Refund Required"
+	PaymentReferencePrefix        = "PAY-"
+	SimulatorSignatureHeader      = "X-Simulator-Signature"
+)
+
+const (
+	PaymentProofStatusPending  = "Pending"
+	PaymentProofStatusApproved = "Approved"
+	PaymentProofStatusRejected = "Rejected"
+)
+
+const (
+	BuyAgainStatusAdded      = "added"
+	BuyAgainStatusInactive   = "inactive"
+	BuyAgainStatusOutOfStock = "out_of_stock"
+)
+
+const (
+	InvoiceNumberFormat         = "INV/%s/%06d"
+	InvoiceDetailNumberFormat   = "INV/%s/%06d/%d"
+	InvoiceFileNameFormat       = "invoice-%06d.pdf"
+	InvoiceDetailFileNameFormat = "invoice-%06d-%d.pdf"
+)
+
+const (
+	ReturnStatusPending   = "Pending"
+	ReturnStatusApproved  = "Approved"
+	ReturnStatusRejected  = "Rejected"
+	RefundStatusPending   = "Pending"
+	RefundStatusCancelled = "Cancelled"
+)
+
+const (
+	StockMutationTypeAutomatic  = "automatic"
+	StockMutationTypeManual     = "manual"
+	StockMutationStatusPending  = "Pending"
+	StockMutationStatusApproved = "Approved"
+	StockMutationStatusRejected = "Rejected"
+)
+
+const (
+	StockMovementSale             = "sale"
+	StockMovementCancel           = "cancel"
+	StockMovementManualAdjustment = "manual_adjustment"
+	StockMovementTransfer         = "transfer"
+	StockMovementReturn           = "return"
+	StockMovementReturnReversal   = "return_reversal"
+)
+
+const (
+	StockUpdateScopePharmacyProduct = "pharmacy_product"
+	StockUpdateScopePharmacy        = "pharmacy"
+)
+
+const (
+	InventoryActionCreate    = "create"
+	InventoryActionUpdate    = "update"
+	InventoryActionUnchanged = "unchanged"
+)
+
+const (
+	ReasonCancelledByPharmacist = "cancelled by pharmacist"
+	ReasonPaymentDeadlinePassed = "payment deadline passed"
+	ReasonDeliveryAutoConfirmed = "delivery auto-confirmed"
+	ReasonDeliveredByCourier    = "delivered by courier"
+)
+
+const (
+	PrescriptionStatusPending  = "Pending"
+	PrescriptionStatusApproved = "Approved"
+	PrescriptionStatusRejected = "Rejected"
+	PrescriptionClassification = "Prescription Drugs"
 )
 
 const (
 	URLOngkirLocationID = "https://rajaongkir.komerce.id/api/v1/destination/domestic-destination"
 	URLOngkirCost       = "https://rajaongkir.komerce.id/api/v1/calculate/domestic-cost"
-	DefaultCourier      = "jne"
 	LowestPrice         = "lowest"
 )
+
+const (
+	LogisticNameInstant = "instant"
+	LogisticNameSameDay = "same day"
+)
+
+const (
+	CourierInstant = "instant"
+	CourierSameDay = "same_day"
+	CourierJNE     = "jne"
+	CourierPOS     = "pos"
+	CourierTIKI    = "tiki"
+)
+
+const (
+	ShippingProviderRajaOngkir = "rajaongkir"
+	ShippingProviderFake       = "fake"
+	ShippingRequestTimeout     = 5 * time.Second
+	ShippingMaxRetries         = 2
+	ShippingRetryBackoff       = 200 * time.Millisecond
+	ShippingBreakerThreshold   = 5
+	ShippingBreakerCooldown    = 30 * time.Second
+	FakeShippingBaseCost       = 9000
+)
+
+const (
+	ServiceAreaCondition = "(CASE WHEN ph.service_zone IS NOT NULL THEN ST_Covers(ph.service_zone, %[1]s) ELSE ST_DWithin(ph.location, %[1]s, ph.service_radius) END)"
+	MinServiceZonePoints = 3
+	ServiceZoneWKTFormat = "SRID=4326;POLYGON((%s))"
+)
+
+const (
+	URLTrackWaybill            = "https://rajaongkir.komerce.id/api/v1/track/waybill"
+	TrackingTimeLayout         = "2006-01-02 15:04:05"
+	TrackingPollInterval       = 1 * time.Hour
+	ShipmentEventShipped       = "shipped"
+	ShipmentEventInTransit     = "in_transit"
+	ShipmentEventDelivered     = "delivered"
+	FakeTrackingTransitAfter   = 4 * time.Hour
+	FakeTrackingDeliveredAfter = 24 * time.Hour
+)
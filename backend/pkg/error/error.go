@@ -3,6 +3,7 @@ package apperror
 import (
 	"errors"
 	"net/http"
+	"time"
 )
 
 type Error struct {
@@ -15,6 +16,18 @@ type ErrorStruct struct {
 	Message       string
 	Status        int
 	SpecificError error
+	Data          interface{}
+}
+
+type StockConflict struct {
+	PharmacyProductID int    `json:"pharmacy_product_id"`
+	Name              string `json:"name"`
+	Requested         int    `json:"requested"`
+	Available         int    `json:"available"`
+}
+
+type StockUpdateLimit struct {
+	NextUpdateAt time.Time `json:"next_update_at"`
 }
 
 func (es ErrorStruct) Error() string {
@@ -57,6 +70,45 @@ func NewErrInternalServerError(field string, sentinel, err error) *ErrorStruct {
 	}
 }
 
+func NewErrStatusConflict(field string, sentinel, err error) *ErrorStruct {
+	return &ErrorStruct{
+		Field:         field,
+		Message:       sentinel.Error(),
+		Status:        http.StatusConflict,
+		SpecificError: err,
+	}
+}
+
+func NewErrStockConflict(field string, conflicts []StockConflict) *ErrorStruct {
+	return &ErrorStruct{
+		Field:         field,
+		Message:       ErrStockUnavailable.Error(),
+		Status:        http.StatusConflict,
+		SpecificError: ErrStockUnavailable,
+		Data:          conflicts,
+	}
+}
+
+func NewErrStockUpdateLimited(field string, nextUpdateAt time.Time) *ErrorStruct {
+	return &ErrorStruct{
+		Field:         field,
+		Message:       ErrStockAlreadyUpdated.Error() + ", next update allowed at " + nextUpdateAt.Format(time.RFC3339),
+		Status:        http.StatusTooManyRequests,
+		SpecificError: ErrStockAlreadyUpdated,
+		Data:          StockUpdateLimit{NextUpdateAt: nextUpdateAt},
+	}
+}
+
+func NewErrInvalidImport(field string, report interface{}) *ErrorStruct {
+	return &ErrorStruct{
+		Field:         field,
+		Message:       ErrInvalidImportRows.Error(),
+		Status:        http.StatusBadRequest,
+		SpecificError: ErrInvalidImportRows,
+		Data:          report,
+	}
+}
+
 var (
 	ErrDataNotExists               = errors.New("data not found")
 	ErrIdEmpty                     = errors.New("id required ")
@@ -104,6 +156,7 @@ var (
 	ErrCartNotAvailable            = errors.New("cart not exist")
 	ErrPharmacyProductNotExists    = errors.New("pharmacy product does not exists")
 	ErrCartItemNotExists           = errors.New("product not exists in cart")
+	ErrCartOptimizationExpired     = errors.New("cart optimization expired or cart has changed")
 	ErrInternalServer              = errors.New("internal server error")
 	ErrUploadImage                 = errors.New("error uploading image")
 	ErrUploadImageSize             = errors.New("image must smaller than 1MB")
@@ -141,9 +194,13 @@ var (
 	ErrUnitInPackMandatory         = errors.New("unit in pack required")
 	ErrOrderNotExists              = errors.New("order not exists")
 	ErrRajaOngkirNoResult          = errors.New("error raja ongkir no result")
+	ErrShippingUnavailable         = errors.New("shipping provider unavailable")
 	ErrPaymentAlreadyDone          = errors.New("payment already done")
 	ErrOrderCannotBeCompleted      = errors.New("order cannot be completed yet")
 	ErrAddressTooFarr              = errors.New("location too far")
+	ErrAddressOutsideServiceArea   = errors.New("address is outside the pharmacy service area")
+	ErrInvalidServiceZone          = errors.New("service zone must be a closed polygon of valid coordinates")
+	ErrCourierMismatch             = errors.New("courier does not match the logistic partner of the order")
 	ErrInvalidOrderCancelation     = errors.New("invalid order")
 	ErrInvalidDay                  = errors.New("invalid day")
 	ErrDuplicateDay                = errors.New("duplicate day")
@@ -160,4 +217,56 @@ var (
 	ErrPharmacyCannotBeActivated   = errors.New("pharmacy cannot be activated when there is no pharmacist yet")
 	ErrMessageQueue                = errors.New("error publishing to message queue")
 	ErrPharmacistExist             = errors.New("pharmacist still exists")
+	ErrPrescriptionNotExists       = errors.New("prescription not exists")
+	ErrPrescriptionNotApproved     = errors.New("prescription has not been approved by pharmacist")
+	ErrPrescriptionAlreadyReviewed = errors.New("prescription already reviewed")
+	ErrPrescriptionAttachClosed    = errors.New("prescription can only be attached while the order is awaiting payment")
+	ErrInvalidStatusTransition     = errors.New("order status cannot be changed to the requested status")
+	ErrActorNotAllowed             = errors.New("not allowed to make this order status change")
+	ErrPaymentProviderNotExists    = errors.New("payment provider not exists")
+	ErrPaymentNotExists            = errors.New("payment not exists")
+	ErrInvalidWebhookSignature     = errors.New("invalid webhook signature")
+	ErrInvalidWebhookPayload       = errors.New("invalid webhook payload")
+	ErrWebhookNotSupported         = errors.New("payment provider does not support webhooks")
+	ErrPaymentProofNotExists       = errors.New("payment proof not exists")
+	ErrPaymentProofAlreadyReviewed = errors.New("payment proof already reviewed")
+	ErrProofNeedsAdminReview       = errors.New("payment proof of an order from several pharmacies can only be reviewed by an admin")
+	ErrPaymentDeadlinePassed       = errors.New("payment deadline has passed")
+	ErrCheckoutInProgress          = errors.New("checkout is still being processed")
+	ErrVoucherNotExists            = errors.New("voucher not exists")
+	ErrVoucherAlreadyExists        = errors.New("voucher code already exists")
+	ErrInvalidVoucherType          = errors.New("invalid voucher discount type")
+	ErrInvalidVoucherValue         = errors.New("invalid voucher discount value")
+	ErrInvalidVoucherPeriod        = errors.New("voucher end time must be after start time")
+	ErrVoucherNotActive            = errors.New("voucher is not active")
+	ErrVoucherUsageLimitReached    = errors.New("voucher usage limit reached")
+	ErrVoucherNotApplicable        = errors.New("voucher is not applicable to the cart")
+	ErrVoucherMinSpendNotMet       = errors.New("minimum spend for voucher is not met")
+	ErrOrderCancelled              = errors.New("order has been cancelled")
+	ErrCancellationReasonRequired  = errors.New("cancellation reason is required for processed orders")
+	ErrOrderNotDelivered           = errors.New("order has not been delivered")
+	ErrReturnWindowPassed          = errors.New("return window has passed")
+	ErrReturnNotExists             = errors.New("return request not exists")
+	ErrReturnAlreadyReviewed       = errors.New("return request already reviewed")
+	ErrReturnReasonRequired        = errors.New("return reason is required")
+	ErrInvalidReturnItem           = errors.New("invalid return item")
+	ErrReturnQuantityExceeded      = errors.New("return quantity exceeds purchased quantity")
+	ErrReturnImagesRequired        = errors.New("return request requires at least one photo")
+	ErrReturnRestockSold           = errors.New("restocked return items have already been sold")
+	ErrTooManyReturnImages         = errors.New("too many photos in return request")
+	ErrStockMutationNotExists      = errors.New("stock mutation not exists")
+	ErrMutationAlreadyReviewed     = errors.New("stock mutation already reviewed")
+	ErrInvalidMutationSource       = errors.New("source must be another pharmacy of the same partner")
+	ErrInvalidImportFile           = errors.New("import file must be a csv or xlsx file")
+	ErrInvalidImportHeader         = errors.New("import file must have product_id or name, stock and price columns")
+	ErrTooManyImportRows           = errors.New("import file has too many rows")
+	ErrInvalidImportRows           = errors.New("import file has invalid rows")
+	ErrDuplicateImportRow          = errors.New("product appears more than once in the file")
+	ErrInvalidImportValue          = errors.New("invalid value")
+	ErrImportFileTooLarge          = errors.New("import file is too large")
+	ErrAmbiguousImportProduct      = errors.New("name matches more than one product, use product_id")
+	ErrLogisticPartnerNotExists    = errors.New("logistic partner not exists")
+	ErrLogisticPartnerExists       = errors.New("logistic partner already exists")
+	ErrInvalidCourierService       = errors.New("service can only be set for rajaongkir couriers")
+	ErrShippingQuoteExpired        = errors.New("shipping quote expired, please reload delivery options")
 )
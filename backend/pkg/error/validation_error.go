@@ -174,15 +174,3 @@ func CheckCurrentPage(currPage, totalPage, limit int) int {
 	}
 	return currPage
 }
-
-func IsOrderCanBeCanceled(orderStatus string) bool {
-	StatusThatCanBeCanceled := []string{"Pending", "Processing"}
-
-	for _, status := range StatusThatCanBeCanceled {
-		if orderStatus == status {
-			return true
-		}
-	}
-
-	return false
-}
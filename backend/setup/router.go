@@ -3,6 +3,7 @@ package setup
 import (
 	"database/sql"
 	"montelukast/middleware"
+	"os"
 	"time"
 
 	categoryHandler "montelukast/modules/category/handler"
@@ -44,8 +45,11 @@ import (
 	orderHandler "montelukast/modules/order/handler"
 	orderRepo "montelukast/modules/order/repository"
 	orderUsecase "montelukast/modules/order/usecase"
+	orderStatusRepo "montelukast/modules/orderstatus/repository"
+	orderStatusUsecase "montelukast/modules/orderstatus/usecase"
 
 	deliveryHandler "montelukast/modules/delivery/handler"
+	deliveryProvider "montelukast/modules/delivery/provider"
 	deliveryRepo "montelukast/modules/delivery/repository"
 	deliveryUsecase "montelukast/modules/delivery/usecase"
 
@@ -57,6 +61,41 @@ import (
 	checkoutRepo "montelukast/modules/checkout/repository"
 	checkoutUsecase "montelukast/modules/checkout/usecase"
 
+	prescriptionHandler "montelukast/modules/prescription/handler"
+	prescriptionRepo "montelukast/modules/prescription/repository"
+	prescriptionUsecase "montelukast/modules/prescription/usecase"
+
+	paymentHandler "montelukast/modules/payment/handler"
+	paymentProvider "montelukast/modules/payment/provider"
+	paymentRepo "montelukast/modules/payment/repository"
+	paymentUsecase "montelukast/modules/payment/usecase"
+	voucherHandler "montelukast/modules/voucher/handler"
+	voucherRepo "montelukast/modules/voucher/repository"
+	voucherUsecase "montelukast/modules/voucher/usecase"
+
+	orderReturnHandler "montelukast/modules/orderreturn/handler"
+	orderReturnRepo "montelukast/modules/orderreturn/repository"
+	orderReturnUsecase "montelukast/modules/orderreturn/usecase"
+
+	invoiceHandler "montelukast/modules/invoice/handler"
+	invoiceRepo "montelukast/modules/invoice/repository"
+	invoiceUsecase "montelukast/modules/invoice/usecase"
+
+	stockMutationHandler "montelukast/modules/stockmutation/handler"
+	stockMutationRepo "montelukast/modules/stockmutation/repository"
+	stockMutationUsecase "montelukast/modules/stockmutation/usecase"
+
+	stockMovementHandler "montelukast/modules/stockmovement/handler"
+	stockMovementRepo "montelukast/modules/stockmovement/repository"
+	stockMovementUsecase "montelukast/modules/stockmovement/usecase"
+
+	stockAlertHandler "montelukast/modules/stockalert/handler"
+	stockAlertRepo "montelukast/modules/stockalert/repository"
+	stockAlertUsecase "montelukast/modules/stockalert/usecase"
+	logisticPartnerHandler "montelukast/modules/logisticpartner/handler"
+	logisticPartnerRepo "montelukast/modules/logisticpartner/repository"
+	logisticPartnerUsecase "montelukast/modules/logisticpartner/usecase"
+
 	"montelukast/modules/user/handler"
 	"montelukast/modules/user/repository"
 	"montelukast/modules/user/usecase"
@@ -89,6 +128,15 @@ type Handler struct {
 	UserOrderHandler       userOrderHandler.UserOrderHandler
 	CheckoutHandler        checkoutHandler.CheckoutHandler
 	PharmacyProductHandler pharmacyProductHandler.PharmacyProductHandler
+	PrescriptionHandler    prescriptionHandler.PrescriptionHandler
+	PaymentHandler         paymentHandler.PaymentHandler
+	VoucherHandler         voucherHandler.VoucherHandler
+	OrderReturnHandler     orderReturnHandler.OrderReturnHandler
+	InvoiceHandler         invoiceHandler.InvoiceHandler
+	StockMutationHandler   stockMutationHandler.StockMutationHandler
+	StockMovementHandler   stockMovementHandler.StockMovementHandler
+	StockAlertHandler      stockAlertHandler.StockAlertHandler
+	LogisticPartnerHandler logisticPartnerHandler.LogisticPartnerHandler
 }
 
 func SetUp(db *sql.DB, redisDB *redis.Client, resendClient *resend.Client, rabbitMQ *amqp.Channel) *gin.Engine {
@@ -113,8 +161,13 @@ func SetUp(db *sql.DB, redisDB *redis.Client, resendClient *resend.Client, rabbi
 	pharmacyProductUsecase := pharmacyProductUsecase.NewPharmacyProductUsecase(pharmacyProductRepository, transaction, pharmacyRepository, productRepository, pharmacistRepository)
 	pharmacyProductHandler := pharmacyProductHandler.NewPharmacyProductHandler(pharmacyProductUsecase)
 
+	deliveryRepostiory := deliveryRepo.NewDeliveryRepository(db, redisDB)
+	checkoutRepo := checkoutRepo.NewCheckoutRepo(db, redisDB)
+	deliveryUsecase := deliveryUsecase.NewDeliveryUsecase(deliveryRepostiory, &checkoutRepo, deliveryProvider.NewShippingProvider(os.Getenv("SHIPPING_PROVIDER"), os.Getenv("RAJA_ONGKIR_API_KEY")))
+	deliveryHandler := deliveryHandler.NewDeliveryHandler(&deliveryUsecase)
+
 	cartRepository := cartRepo.NewCartRepo(db, redisDB)
-	cartUsecase := cartUsecase.NewCartUsecase(cartRepository, pharmacyProductRepository)
+	cartUsecase := cartUsecase.NewCartUsecase(cartRepository, pharmacyProductRepository, &deliveryUsecase, transaction)
 	cartHandler := cartHandler.NewCartHandler(cartUsecase)
 
 	adminRepository := adminRepo.NewAdminRepository(db)
@@ -129,29 +182,67 @@ func SetUp(db *sql.DB, redisDB *redis.Client, resendClient *resend.Client, rabbi
 	addressUsecase := addressUsecase.NewAddressUsecase(addressRepository, transaction)
 	addressHandler := addressHandler.NewAddressHandler(addressUsecase)
 
+	orderStatusRepository := orderStatusRepo.NewOrderStatusRepo(db)
+	orderStatusUsecase := orderStatusUsecase.NewOrderStatusUsecase(orderStatusRepository, transaction)
+
 	orderRepository := orderRepo.NewOrderRepo(db)
-	orderusecase := orderUsecase.NewOrderUsecase(rabbitMQ, orderRepository, transaction)
+	orderusecase := orderUsecase.NewOrderUsecase(rabbitMQ, orderRepository, transaction, orderStatusUsecase, resendClient, deliveryProvider.NewTrackingProvider(os.Getenv("SHIPPING_PROVIDER"), os.Getenv("RAJA_ONGKIR_API_KEY")))
 	orderHandler := orderHandler.NewOrderHandler(orderusecase)
 
 	categoryRepository := categoryRepo.NewCategoryRepo(db)
 	categoryUsecase := categoryUsecase.NewCategoryUsecase(categoryRepository)
 	categoryHandler := categoryHandler.NewCategoryHandler(categoryUsecase)
 
-	deliveryRepostiory := deliveryRepo.NewDeliveryRepository(db, redisDB)
-	checkoutRepo := checkoutRepo.NewCheckoutRepo(db, redisDB)
+	voucherRepository := voucherRepo.NewVoucherRepo(db)
+	voucherUsecase := voucherUsecase.NewVoucherUsecase(voucherRepository)
+	voucherHandler := voucherHandler.NewVoucherHandler(voucherUsecase)
 
-	checkoutUsecase := checkoutUsecase.NewCheckoutUsecase(&checkoutRepo, deliveryRepostiory, transaction)
-	checkoutHandler := checkoutHandler.NewCheckoutHandler(checkoutUsecase)
+	stockMutationRepository := stockMutationRepo.NewStockMutationRepo(db)
+	stockMutationUsecase := stockMutationUsecase.NewStockMutationUsecase(stockMutationRepository, transaction, pharmacistRepository)
+	stockMutationHandler := stockMutationHandler.NewStockMutationHandler(stockMutationUsecase)
 
-	deliveryUsecase := deliveryUsecase.NewDeliveryUsecase(deliveryRepostiory, &checkoutRepo)
-	deliveryHandler := deliveryHandler.NewDeliveryHandler(&deliveryUsecase)
+	checkoutusecase := checkoutUsecase.NewCheckoutUsecase(rabbitMQ, &checkoutRepo, deliveryRepostiory, transaction, orderStatusUsecase, voucherUsecase, stockMutationUsecase, resendClient)
+	checkoutHandler := checkoutHandler.NewCheckoutHandler(checkoutusecase)
 
 	userOrderRepostiory := userOrderRepo.NewUserOrderRepo(db)
-	userOrderUsecase := userorderUsecase.NewUserOrderUsecase(rabbitMQ, userOrderRepostiory, transaction, userRepository)
+	paymentRepository := paymentRepo.NewPaymentRepo(db)
+	paymentProviders := []paymentProvider.PaymentProvider{
+		paymentProvider.NewManualTransferProvider(os.Getenv("MANUAL_TRANSFER_BANK"), os.Getenv("MANUAL_TRANSFER_ACCOUNT_NUMBER"), os.Getenv("MANUAL_TRANSFER_ACCOUNT_NAME")),
+	}
+	// The simulator lets anyone holding its secret mark orders paid, so it is
+	// only registered on development machines that opt in.
+	if os.Getenv("PAYMENT_SIMULATOR_ENABLED") == "true" && os.Getenv("PAYMENT_SIMULATOR_SECRET") != "" {
+		paymentProviders = append(paymentProviders, paymentProvider.NewSimulatedProvider(os.Getenv("PAYMENT_SIMULATOR_SECRET")))
+	}
+	paymentUsecase := paymentUsecase.NewPaymentUsecase(paymentRepository, transaction, orderStatusUsecase, pharmacistRepository, paymentProviders...)
+	paymentHandler := paymentHandler.NewPaymentHandler(paymentUsecase)
+
+	userOrderUsecase := userorderUsecase.NewUserOrderUsecase(userOrderRepostiory, transaction, userRepository, orderStatusUsecase, paymentUsecase)
 	userOrderHandler := userOrderHandler.NewUserOrderHandler(userOrderUsecase)
 
-	consumer := userorderUsecase.NewRabbitMQConsumer(rabbitMQ, userOrderUsecase)
-	go consumer.ConsumeDelayedMessage()
+	prescriptionRepository := prescriptionRepo.NewPrescriptionRepo(db)
+	prescriptionUsecase := prescriptionUsecase.NewPrescriptionUsecase(prescriptionRepository, transaction, pharmacistRepository, orderStatusUsecase)
+	prescriptionHandler := prescriptionHandler.NewPrescriptionHandler(prescriptionUsecase)
+
+	orderReturnRepository := orderReturnRepo.NewOrderReturnRepo(db)
+	orderReturnUsecase := orderReturnUsecase.NewOrderReturnUsecase(orderReturnRepository, transaction, pharmacistRepository)
+	orderReturnHandler := orderReturnHandler.NewOrderReturnHandler(orderReturnUsecase)
+
+	invoiceRepository := invoiceRepo.NewInvoiceRepo(db)
+	invoiceUsecase := invoiceUsecase.NewInvoiceUsecase(invoiceRepository, pharmacistRepository)
+	invoiceHandler := invoiceHandler.NewInvoiceHandler(invoiceUsecase)
+
+	stockMovementRepository := stockMovementRepo.NewStockMovementRepo(db)
+	stockMovementUsecase := stockMovementUsecase.NewStockMovementUsecase(stockMovementRepository, pharmacistRepository)
+	stockMovementHandler := stockMovementHandler.NewStockMovementHandler(stockMovementUsecase)
+
+	stockAlertRepository := stockAlertRepo.NewStockAlertRepo(db, redisDB)
+	stockalertusecase := stockAlertUsecase.NewStockAlertUsecase(rabbitMQ, stockAlertRepository, pharmacistRepository, resendClient)
+	stockAlertHandler := stockAlertHandler.NewStockAlertHandler(stockalertusecase)
+
+	logisticPartnerRepository := logisticPartnerRepo.NewLogisticPartnerRepo(db)
+	logisticpartnerusecase := logisticPartnerUsecase.NewLogisticPartnerUsecase(logisticPartnerRepository, transaction)
+	logisticPartnerHandler := logisticPartnerHandler.NewLogisticPartnerHandler(logisticpartnerusecase)
 
 	partnerConsumer := partUsecase.NewRabbitMQConsumerPartner(rabbitMQ, partnerUsecase)
 	go partnerConsumer.ConsumeDelayedMessage()
@@ -159,6 +250,12 @@ func SetUp(db *sql.DB, redisDB *redis.Client, resendClient *resend.Client, rabbi
 	updateStatusConsumer := orderUsecase.NewRabbitMQConsumerStatus(rabbitMQ, orderusecase)
 	go updateStatusConsumer.ConsumeDelayedMessage()
 
+	paymentDeadlineConsumer := checkoutUsecase.NewRabbitMQConsumerPaymentDeadline(rabbitMQ, checkoutusecase)
+	go paymentDeadlineConsumer.ConsumeDelayedMessage()
+
+	lowStockConsumer := stockAlertUsecase.NewRabbitMQConsumerLowStock(rabbitMQ, stockalertusecase)
+	go lowStockConsumer.ConsumeDelayedMessage()
+
 	router := SetRouter(Handler{
 		UserHandler:            userHandler,
 		CategoryHandler:        categoryHandler,
@@ -174,6 +271,15 @@ func SetUp(db *sql.DB, redisDB *redis.Client, resendClient *resend.Client, rabbi
 		UserOrderHandler:       userOrderHandler,
 		CheckoutHandler:        checkoutHandler,
 		PharmacyProductHandler: pharmacyProductHandler,
+		PrescriptionHandler:    prescriptionHandler,
+		PaymentHandler:         paymentHandler,
+		VoucherHandler:         voucherHandler,
+		OrderReturnHandler:     orderReturnHandler,
+		InvoiceHandler:         invoiceHandler,
+		StockMutationHandler:   stockMutationHandler,
+		StockMovementHandler:   stockMovementHandler,
+		StockAlertHandler:      stockAlertHandler,
+		LogisticPartnerHandler: logisticPartnerHandler,
 	})
 
 	return router
@@ -188,7 +294,7 @@ func SetRouter(h Handler) *gin.Engine {
 	r.Use(cors.Middleware(cors.Config{
 		Origins:        "*",
 		Methods:        "GET, PUT, POST, PATCH, DELETE",
-		RequestHeaders: "Origin, Authorization, Content-Type",
+		RequestHeaders: "Origin, Authorization, Content-Type, Idempotency-Key",
 		ExposedHeaders: "",
 		MaxAge:         50 * time.Second,
 		Credentials:    false,
@@ -217,6 +323,9 @@ func SetRouter(h Handler) *gin.Engine {
 	pharmacistAuth := baseEndpoint.Group("/pharmacists/auth")
 	pharmacistAuth.POST("/login", h.PharmacistHandler.Login)
 
+	paymentGeneral := baseEndpoint.Group("/payments")
+	paymentGeneral.POST("/webhook/:provider", h.PaymentHandler.WebhookHandler)
+
 	pharmacistGeneral := baseEndpoint.Group("/pharmacist")
 	pharmacistGeneral.GET("products/master", h.ProductHandler.GetMasterProductsHandler)
 
@@ -236,8 +345,16 @@ func SetRouter(h Handler) *gin.Engine {
 	userProtected.DELETE("/carts/:id", h.CartHandler.DeleteFromCartHandler)
 	userProtected.GET("/carts", h.CartHandler.GetGroupedCartItemsHandler)
 	userProtected.GET("/carts/overview", h.CartHandler.GetCartItemsHandler)
+	userProtected.GET("/carts/optimization", h.CartHandler.OptimizeCartHandler)
+	userProtected.POST("/carts/optimization", h.CartHandler.ApplyCartOptimizationHandler)
 	userProtected.POST("/carts/checkout", h.CartHandler.GetSelectedCartItemsHandler)
+	userProtected.DELETE("/carts/checkout/:id", h.CartHandler.AbandonCheckoutHandler)
 	userProtected.PATCH("/order-details/:order_id/payment", h.UserOrderHandler.UpdatePaymentHandler)
+	userProtected.POST("/orders/:order_id/payment", h.PaymentHandler.CreatePaymentHandler)
+	userProtected.GET("/orders/:order_id/payment", h.PaymentHandler.GetPaymentHandler)
+	userProtected.GET("/orders/:order_id/invoice", h.InvoiceHandler.GetUserInvoiceHandler)
+	userProtected.POST("/orders/:order_id/buy-again", h.CartHandler.BuyAgainOrderHandler)
+	userProtected.POST("/order-details/:order_id/buy-again", h.CartHandler.BuyAgainOrderDetailHandler)
 
 	/* ADMIN PROTECTED */
 	adminProtected := protected.Group("/admin")
@@ -251,6 +368,8 @@ func SetRouter(h Handler) *gin.Engine {
 	adminProtected.PUT("/pharmacies", h.PharmacyHandler.UpdatePharmacyHandler)
 	adminProtected.PATCH("/pharmacies/logo", h.PharmacyHandler.AddLogoHandler)
 	adminProtected.DELETE("/pharmacies/:id", h.PharmacyHandler.DeletePharmacyHandler)
+	adminProtected.GET("/pharmacies/:id/service-area", h.PharmacyHandler.GetServiceAreaHandler)
+	adminProtected.PUT("/pharmacies/:id/service-area", h.PharmacyHandler.UpdateServiceAreaHandler)
 
 	adminProtected.GET("/partners", h.PartnerHandler.GetPartnersHandler)
 	adminProtected.GET("/partners/:id", h.PartnerHandler.GetPartnerHandler)
@@ -277,6 +396,34 @@ func SetRouter(h Handler) *gin.Engine {
 	adminProtected.DELETE("/products/:id", h.ProductHandler.DeleteProductHandler)
 	adminProtected.GET("/products", h.ProductHandler.GetProductsAdminHandler)
 
+	adminProtected.GET("/vouchers", h.VoucherHandler.GetVouchersHandler)
+	adminProtected.GET("/vouchers/:id", h.VoucherHandler.GetVoucherHandler)
+	adminProtected.POST("/vouchers", h.VoucherHandler.AddVoucherHandler)
+	adminProtected.PATCH("/vouchers/:id", h.VoucherHandler.UpdateVoucherHandler)
+	adminProtected.DELETE("/vouchers/:id", h.VoucherHandler.DeleteVoucherHandler)
+
+	adminProtected.GET("/payment-proofs", h.PaymentHandler.GetPaymentProofsHandler)
+	adminProtected.GET("/payment-proofs/:id", h.PaymentHandler.GetPaymentProofHandler)
+	adminProtected.PATCH("/payment-proofs/:id/approval", h.PaymentHandler.ApprovePaymentProofHandler)
+	adminProtected.PATCH("/payment-proofs/:id/rejection", h.PaymentHandler.RejectPaymentProofHandler)
+
+	adminProtected.GET("/pharmacy-products/:id/stock-movements", h.StockMovementHandler.GetMovementsHandler)
+	adminProtected.DELETE("/pharmacy-products/:id/stock-update-limit", h.PharmacyProductHandler.ResetStockUpdateLimitHandler)
+	adminProtected.GET("/stock-movements/consistency", h.StockMovementHandler.CheckConsistencyHandler)
+
+	adminProtected.GET("/logistic-partners", h.LogisticPartnerHandler.GetLogisticPartnersHandler)
+	adminProtected.GET("/logistic-partners/:id", h.LogisticPartnerHandler.GetLogisticPartnerHandler)
+	adminProtected.POST("/logistic-partners", h.LogisticPartnerHandler.AddLogisticPartnerHandler)
+	adminProtected.PATCH("/logistic-partners/:id", h.LogisticPartnerHandler.UpdateLogisticPartnerHandler)
+	adminProtected.DELETE("/logistic-partners/:id", h.LogisticPartnerHandler.DeleteLogisticPartnerHandler)
+	adminProtected.GET("/pharmacies/:id/logistic-partners", h.LogisticPartnerHandler.GetPharmacyLogisticPartnersHandler)
+	adminProtected.PUT("/pharmacies/:id/logistic-partners", h.LogisticPartnerHandler.UpdatePharmacyLogisticPartnersHandler)
+
+	adminProtected.GET("/returns", h.OrderReturnHandler.GetReturnsHandler)
+	adminProtected.GET("/returns/:id", h.OrderReturnHandler.GetReturnHandler)
+	adminProtected.PATCH("/returns/:id/approval", h.OrderReturnHandler.ApproveReturnHandler)
+	adminProtected.PATCH("/returns/:id/rejection", h.OrderReturnHandler.RejectReturnHandler)
+
 	/* USER PROTECTED */
 
 	addressAuth := protected.Group("/addresses")
@@ -306,7 +453,14 @@ func SetRouter(h Handler) *gin.Engine {
 	userProtected.PATCH("/orders/:order-detail-id/completion", h.UserOrderHandler.ConfirmDeliveryHandler)
 	userProtected.GET("/carts/checkout/delivery", h.DeliveryHandler.GetOngkirCost)
 	userProtected.POST("/carts/checkout/order", h.CheckoutHandler.CheckoutCartHandler)
+	userProtected.POST("/carts/checkout/voucher", h.CheckoutHandler.ValidateVoucherHandler)
 	userProtected.PATCH("/carts/checkout/cancel/:order-id", h.CheckoutHandler.CancelOrder)
+	userProtected.POST("/prescriptions", h.PrescriptionHandler.UploadPrescriptionHandler)
+	userProtected.PATCH("/order-details/:order_id/prescription", h.PrescriptionHandler.AttachPrescriptionHandler)
+	userProtected.PATCH("/order-details/:order_id/cancellation", h.CheckoutHandler.CancelOrderDetailHandler)
+	userProtected.POST("/order-details/:order_id/returns", h.OrderReturnHandler.RequestReturnHandler)
+	userProtected.GET("/returns", h.OrderReturnHandler.GetUserReturnsHandler)
+	userProtected.GET("/returns/:id", h.OrderReturnHandler.GetUserReturnHandler)
 
 	/* PHARMACIST PROTECTED */
 
@@ -314,14 +468,42 @@ func SetRouter(h Handler) *gin.Engine {
 	pharmacistProtected.Use(middleware.AuthPharmacistMiddleware)
 	pharmacistProtected.GET("/orders", h.OrderHandler.GetOrdersHandler)
 	pharmacistProtected.GET("/orders/:id", h.OrderHandler.GetOrderedProductsHandler)
+	pharmacistProtected.GET("/orders/:id/invoice", h.InvoiceHandler.GetPharmacistInvoiceHandler)
 	pharmacistProtected.PATCH("/orders/:id", h.OrderHandler.UpdateOrderStatusHandler)
 	pharmacistProtected.DELETE("/orders/:id", h.OrderHandler.DeleteOrderHandler)
 
+	pharmacistProtected.GET("/prescriptions", h.PrescriptionHandler.GetPrescriptionsHandler)
+	pharmacistProtected.PATCH("/prescriptions/:id/approval", h.PrescriptionHandler.ApprovePrescriptionHandler)
+	pharmacistProtected.PATCH("/prescriptions/:id/rejection", h.PrescriptionHandler.RejectPrescriptionHandler)
+
+	pharmacistProtected.GET("/payment-proofs", h.PaymentHandler.GetPaymentProofsHandler)
+	pharmacistProtected.GET("/payment-proofs/:id", h.PaymentHandler.GetPaymentProofHandler)
+	pharmacistProtected.PATCH("/payment-proofs/:id/approval", h.PaymentHandler.ApprovePaymentProofHandler)
+	pharmacistProtected.PATCH("/payment-proofs/:id/rejection", h.PaymentHandler.RejectPaymentProofHandler)
+
+	pharmacistProtected.GET("/returns", h.OrderReturnHandler.GetReturnsHandler)
+	pharmacistProtected.GET("/returns/:id", h.OrderReturnHandler.GetReturnHandler)
+	pharmacistProtected.PATCH("/returns/:id/approval", h.OrderReturnHandler.ApproveReturnHandler)
+	pharmacistProtected.PATCH("/returns/:id/rejection", h.OrderReturnHandler.RejectReturnHandler)
+
+	pharmacistProtected.POST("/stock-mutations", h.StockMutationHandler.RequestMutationHandler)
+	pharmacistProtected.GET("/stock-mutations", h.StockMutationHandler.GetMutationsHandler)
+	pharmacistProtected.GET("/stock-mutations/:id", h.StockMutationHandler.GetMutationHandler)
+	pharmacistProtected.PATCH("/stock-mutations/:id/approval", h.StockMutationHandler.ApproveMutationHandler)
+	pharmacistProtected.PATCH("/stock-mutations/:id/rejection", h.StockMutationHandler.RejectMutationHandler)
+
 	pharmacistProtected.POST("/products", h.PharmacyProductHandler.AddPharmacyProductHandler)
 	pharmacistProtected.PATCH("/products/:id", h.PharmacyProductHandler.UpdatePharmacyProductHandler)
 	pharmacistProtected.DELETE("/products/:id", h.PharmacyProductHandler.DeletePharmacyProductHandler)
 	pharmacistProtected.GET("/products", h.PharmacyProductHandler.GetPharmacyProductsHandler)
+	pharmacistProtected.POST("/products/import", h.PharmacyProductHandler.ImportInventoryHandler)
+	pharmacistProtected.GET("/products/export", h.PharmacyProductHandler.ExportInventoryHandler)
 	pharmacistProtected.GET("/products/:id", h.PharmacyProductHandler.GetPharmacyProductDetailHandler)
+	pharmacistProtected.GET("/products/:id/stock-movements", h.StockMovementHandler.GetMovementsHandler)
+	pharmacistProtected.GET("/stock-movements/consistency", h.StockMovementHandler.CheckConsistencyHandler)
+	pharmacistProtected.PATCH("/products/:id/low-stock-threshold", h.StockAlertHandler.UpdateLowStockThresholdHandler)
+	pharmacistProtected.GET("/low-stock-products", h.StockAlertHandler.GetLowStockProductsHandler)
+	pharmacistProtected.GET("/reorder-suggestions", h.StockAlertHandler.GetReorderSuggestionsHandler)
 
 	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
@@ -2,6 +2,7 @@ create table logistics (
    id bigserial primary key,
    name varchar not null,
    price decimal(14, 2) not null,
+   price_per_kg decimal(14, 2) not null default 0,
    created_at timestamp not null default current_timestamp,
    updated_at timestamp not null default current_timestamp,
    deleted_at timestamp null
@@ -26,6 +27,9 @@ create table partners (
    start_hour varchar not null,
    end_hour varchar not null,
    is_active boolean not null,
+   stock_update_scope varchar not null default 'pharmacy_product',
+   stock_update_limit int not null default 1,
+   stock_update_window_hours int not null default 24,
    created_at timestamp not null default current_timestamp,
    updated_at timestamp not null default current_timestamp,
    deleted_at timestamp null
@@ -79,6 +83,16 @@ create table users (
 );
 
 
+create table prescriptions (
+   id bigserial primary key,
+   user_id bigint not null references users(id),
+   image varchar not null,
+   created_at timestamp not null default current_timestamp,
+   updated_at timestamp not null default current_timestamp,
+   deleted_at timestamp null
+);
+
+
 CREATE TABLE provinces (
    id BIGSERIAL PRIMARY KEY,
    name VARCHAR NOT NULL,
@@ -159,6 +173,8 @@ create table pharmacies (
    sub_district varchar NOT NULL,
    postal_code bigint NOT NULL,
    location GEOGRAPHY(Point, 4326) NOT NULL,
+   service_radius int NOT NULL DEFAULT 25000 CHECK (service_radius > 0),
+   service_zone GEOGRAPHY(Polygon, 4326) NULL,
    is_active bool NOT NULL DEFAULT false,
    created_at timestamp not null default current_timestamp,
    updated_at timestamp not null default current_timestamp,
@@ -168,6 +184,9 @@ create table pharmacies (
 CREATE TABLE logistic_partners (
    id bigserial primary key,
    name VARCHAR NOT NULL,
+   courier VARCHAR NOT NULL,
+   service VARCHAR NULL,
+   is_active BOOLEAN NOT NULL DEFAULT TRUE,
    created_at timestamp not null default current_timestamp,
    updated_at timestamp not null default current_timestamp,
    deleted_at timestamp null
@@ -183,6 +202,8 @@ CREATE TABLE pharmacies_logistic_partners(
    deleted_at timestamp null
 );
 
+CREATE UNIQUE INDEX pharmacies_logistic_partners_unique_idx ON pharmacies_logistic_partners (pharmacy_id, logistic_partner_id) WHERE deleted_at IS NULL;
+
 
 create table pharmacist_details (
    id bigserial primary key,
@@ -197,22 +218,72 @@ create table pharmacist_details (
 );
 
 
+create table vouchers (
+   id bigserial primary key,
+   code varchar not null unique,
+   discount_type varchar not null,
+   discount_value decimal(14,2) not null default 0,
+   max_discount decimal(14,2) null,
+   min_spend decimal(14,2) not null default 0,
+   start_at timestamp not null,
+   end_at timestamp not null,
+   usage_limit int null,
+   usage_limit_per_user int null,
+   partner_id bigint null references partners(id),
+   pharmacy_id bigint null references pharmacies(id),
+   product_category_id bigint null references product_categories(id),
+   is_active boolean not null default true,
+   created_at timestamp not null default current_timestamp,
+   updated_at timestamp not null default current_timestamp,
+   deleted_at timestamp null
+);
+
+
 create table orders (
    id bigserial primary key,
    user_id bigint not null references users(id),
    total_price decimal(14,2) not null,
+   payment_deadline timestamp null,
+   idempotency_key varchar null,
+   checkout_result jsonb null,
    created_at timestamp not null default current_timestamp,
    updated_at timestamp not null default current_timestamp,
    deleted_at timestamp null
 );
 
+CREATE UNIQUE INDEX orders_idempotency_key_unique_idx ON orders (user_id, idempotency_key) WHERE idempotency_key IS NOT NULL;
+
 
 create table order_details (
    id bigserial primary key,
    order_id bigint not null references orders(id),
    pharmacy_id bigint not null references pharmacies(id),
    logistic_price decimal(14,2) not null,
+   logistic_partner_id bigint null references logistic_partners(id),
+   courier varchar null,
+   tracking_number varchar null,
+   shipped_at timestamp null,
    status varchar not null,
+   prescription_id bigint null references prescriptions(id),
+   prescription_status varchar null,
+   prescription_reviewed_by bigint null references users(id),
+   prescription_rejection_reason varchar null,
+   prescription_reviewed_at timestamp null,
+   voucher_id bigint null references vouchers(id),
+   discount decimal(14,2) not null default 0,
+   shipping_discount decimal(14,2) not null default 0,
+   created_at timestamp not null default current_timestamp,
+   updated_at timestamp not null default current_timestamp,
+   deleted_at timestamp null
+);
+
+
+create table voucher_usages (
+   id bigserial primary key,
+   voucher_id bigint not null references vouchers(id),
+   user_id bigint not null references users(id),
+   order_id bigint not null references orders(id),
+   discount decimal(14,2) not null,
    created_at timestamp not null default current_timestamp,
    updated_at timestamp not null default current_timestamp,
    deleted_at timestamp null
@@ -254,9 +325,10 @@ create table pharmacy_products (
    id bigserial primary key,
    pharmacy_id bigint not null references pharmacies(id),
    product_id bigint not null references products(id),
-   stock int not null,
+   stock int not null check (stock >= 0),
    price decimal(14,2) not null,
    is_active boolean not null,
+   low_stock_threshold int not null default 10 check (low_stock_threshold >= 0),
    created_at timestamp not null default current_timestamp,
    updated_at timestamp not null default current_timestamp,
    deleted_at timestamp null
@@ -275,6 +347,144 @@ create table order_product_details (
 );
 
 
+create table payments (
+   id bigserial primary key,
+   order_id bigint not null references orders(id),
+   provider varchar not null,
+   reference varchar not null unique,
+   amount decimal(14,2) not null,
+   status varchar not null,
+   instructions varchar null,
+   paid_at timestamp null,
+   created_at timestamp not null default current_timestamp,
+   updated_at timestamp not null default current_timestamp,
+   deleted_at timestamp null
+);
+
+
+create table payment_proofs (
+   id bigserial primary key,
+   payment_id bigint not null references payments(id),
+   order_id bigint not null references orders(id),
+   image varchar not null,
+   status varchar not null,
+   reviewer_id bigint null references users(id),
+   rejection_reason varchar null,
+   reviewed_at timestamp null,
+   created_at timestamp not null default current_timestamp,
+   updated_at timestamp not null default current_timestamp,
+   deleted_at timestamp null
+);
+
+
+create table order_status_histories (
+   id bigserial primary key,
+   order_detail_id bigint not null references order_details(id),
+   from_status varchar null,
+   to_status varchar not null,
+   actor varchar not null,
+   actor_id bigint null references users(id),
+   reason varchar null,
+   created_at timestamp not null default current_timestamp,
+   updated_at timestamp not null default current_timestamp,
+   deleted_at timestamp null
+);
+
+create table shipment_events (
+   id bigserial primary key,
+   order_detail_id bigint not null references order_details(id),
+   status varchar not null,
+   description varchar not null,
+   location varchar null,
+   occurred_at timestamp not null,
+   created_at timestamp not null default current_timestamp,
+   updated_at timestamp not null default current_timestamp,
+   deleted_at timestamp null
+);
+
+CREATE UNIQUE INDEX shipment_events_unique_idx ON shipment_events (order_detail_id, status, occurred_at, description) WHERE deleted_at IS NULL;
+
+
+create table order_returns (
+   id bigserial primary key,
+   order_detail_id bigint not null references order_details(id),
+   user_id bigint not null references users(id),
+   reason varchar not null,
+   status varchar not null,
+   reviewer_id bigint null references users(id),
+   rejection_reason varchar null,
+   is_restocked boolean not null default false,
+   reviewed_at timestamp null,
+   created_at timestamp not null default current_timestamp,
+   updated_at timestamp not null default current_timestamp,
+   deleted_at timestamp null
+);
+
+
+create table order_return_items (
+   id bigserial primary key,
+   order_return_id bigint not null references order_returns(id),
+   order_product_detail_id bigint not null references order_product_details(id),
+   quantity int not null check (quantity > 0),
+   amount decimal(14,2) not null,
+   created_at timestamp not null default current_timestamp,
+   updated_at timestamp not null default current_timestamp,
+   deleted_at timestamp null
+);
+
+
+create table order_return_images (
+   id bigserial primary key,
+   order_return_id bigint not null references order_returns(id),
+   image varchar not null,
+   created_at timestamp not null default current_timestamp,
+   updated_at timestamp not null default current_timestamp,
+   deleted_at timestamp null
+);
+
+
+create table order_return_histories (
+   id bigserial primary key,
+   order_return_id bigint not null references order_returns(id),
+   from_status varchar null,
+   to_status varchar not null,
+   actor varchar not null,
+   actor_id bigint null references users(id),
+   reason varchar null,
+   created_at timestamp not null default current_timestamp,
+   updated_at timestamp not null default current_timestamp,
+   deleted_at timestamp null
+);
+
+
+create table refunds (
+   id bigserial primary key,
+   order_return_id bigint not null unique references order_returns(id),
+   order_id bigint not null references orders(id),
+   amount decimal(14,2) not null,
+   status varchar not null,
+   created_at timestamp not null default current_timestamp,
+   updated_at timestamp not null default current_timestamp,
+   deleted_at timestamp null
+);
+
+
+create table stock_mutations (
+   id bigserial primary key,
+   source_pharmacy_product_id bigint not null references pharmacy_products(id),
+   destination_pharmacy_product_id bigint not null references pharmacy_products(id),
+   quantity int not null check (quantity > 0),
+   type varchar not null,
+   status varchar not null,
+   requested_by bigint null references users(id),
+   reviewed_by bigint null references users(id),
+   reviewed_at timestamp null,
+   created_at timestamp not null default current_timestamp,
+   updated_at timestamp not null default current_timestamp,
+   deleted_at timestamp null
+);
+
+
 create table reset_password_tokens (
    id bigserial primary key,
    user_id bigint not null references users(id),
@@ -296,6 +506,42 @@ create table carts (
 );
 
 
+create table stock_holds (
+   id bigserial primary key,
+   user_id bigint not null references users(id),
+   cart_id varchar not null,
+   pharmacy_product_id bigint not null references pharmacy_products(id),
+   quantity int not null,
+   expired_at timestamp not null,
+   created_at timestamp not null default current_timestamp,
+   updated_at timestamp not null default current_timestamp,
+   deleted_at timestamp null
+);
+
+
+create view pharmacy_product_available_stocks as
+   select pp.id as pharmacy_product_id,
+      pp.stock - coalesce(sum(sh.quantity), 0) as available_stock
+   from pharmacy_products pp
+   left join stock_holds sh on sh.pharmacy_product_id = pp.id and sh.expired_at > now() and sh.deleted_at is null
+   group by pp.id, pp.stock;
+
+
+create table stock_movements (
+   id bigserial primary key,
+   pharmacy_product_id bigint not null references pharmacy_products(id),
+   delta int not null,
+   balance int not null,
+   reason varchar not null,
+   reference_id bigint null,
+   created_at timestamp not null default current_timestamp,
+   updated_at timestamp not null default current_timestamp,
+   deleted_at timestamp null
+);
+
+create index stock_movements_pharmacy_product_id_idx on stock_movements (pharmacy_product_id, id);
+
+
 create table verify_email_tokens (
    id bigserial primary key,
    user_id bigint not null references users(id),
@@ -409,6 +655,9 @@ FROM '/data/pharmacies/pharmacies_jabodetabek.csv' CSV HEADER;
 COPY pharmacy_products (pharmacy_id, product_id, stock, price, is_active)
 FROM '/data/pharmacy_products/pharmacy_products.csv' CSV HEADER;
 
+insert into stock_movements (pharmacy_product_id, delta, balance, reason)
+select id, stock, stock, 'manual_adjustment' from pharmacy_products where stock <> 0;
+
 insert into carts (user_id, pharmacy_product_id, quantity) values
 	(2, 1, 1),
 	(2, 4, 5),
@@ -454,6 +703,18 @@ VALUES
 ('same day',1000),
 ('instant',2500);
 
+INSERT INTO logistic_partners (name, courier, service)
+VALUES
+('Instant', 'instant', NULL),
+('Same Day', 'same_day', NULL),
+('JNE', 'jne', NULL),
+('POS Indonesia', 'pos', NULL),
+('TIKI', 'tiki', NULL);
+
+INSERT INTO pharmacies_logistic_partners (pharmacy_id, logistic_partner_id)
+SELECT ph.id, lp.id FROM pharmacies ph CROSS JOIN logistic_partners lp
+WHERE lp.courier IN ('instant', 'same_day', 'jne');
+
 INSERT INTO public.user_addresses (user_id, "name", phone_number, address, province_id, province, city_id, city, district_id, district, sub_district_id, sub_district, postal_code, "location", is_active, created_at, updated_at, deleted_at)
 VALUES
 (2, 'Jonathan', '089505123456', 'Jalan Pos', 31, 'DKI JAKARTA', 3174, 'JAKARTA BARAT', 3174010, 'KEMBANGAN', 3174010002, 'SRENGSENG', '11630', 'SRID=4326;POINT (106.74130492346 -6.191140471555)'::public.geography, true, '2025-01-27 18:26:57.025', '2025-01-27 18:27:02.874', NULL);